@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Archiit19/customer-service-go/internal/config"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// overrides collects repeated -set KEY=VALUE flags.
+type overrides map[string]string
+
+func (o overrides) String() string { return "" }
+
+func (o overrides) Set(v string) error {
+	k, val, ok := strings.Cut(v, "=")
+	if !ok || strings.TrimSpace(k) == "" {
+		return fmt.Errorf("expected KEY=VALUE")
+	}
+	o[strings.ToUpper(strings.TrimSpace(k))] = val
+	return nil
+}
+
+// configFlags are accepted by every command and form the highest-precedence
+// configuration layer.
+type configFlags struct {
+	file      string
+	overrides overrides
+	port      string
+	logLevel  string
+}
+
+func addConfigFlags(fs *flag.FlagSet) *configFlags {
+	cf := &configFlags{overrides: overrides{}}
+	fs.StringVar(&cf.file, "config", "", "YAML or TOML config file (default $CONFIG_FILE)")
+	fs.Var(cf.overrides, "set", "override a setting, e.g. -set DB_MAX_CONNS=20 (repeatable)")
+	fs.StringVar(&cf.port, "port", "", "shorthand for -set APP_PORT=<port>")
+	fs.StringVar(&cf.logLevel, "log-level", "", "shorthand for -set LOG_LEVEL=<level>")
+	return cf
+}
+
+func (cf *configFlags) options() config.LoadOptions {
+	if cf.port != "" {
+		cf.overrides["APP_PORT"] = cf.port
+	}
+	if cf.logLevel != "" {
+		cf.overrides["LOG_LEVEL"] = cf.logLevel
+	}
+	return config.LoadOptions{File: cf.file, Overrides: cf.overrides}
+}
+
+// bootstrap loads configuration and builds the logger every command needs,
+// returning the logger's level so it can be changed at runtime.
+// Configuration errors are logged with a default logger, since the
+// configured one cannot be built.
+func bootstrap(ctx context.Context, cf *configFlags) (*config.Config, logger.Logger, *logger.AtomicLevel, bool) {
+	cfg, warnings, err := config.Load(cf.options())
+	if err != nil {
+		fallback, lerr := logger.New(logger.NewAtomicLevel("INFO"), "PARTIAL")
+		if lerr != nil {
+			panic(lerr)
+		}
+		fallback.Error(ctx, "configuration invalid", logger.Err(err))
+		return nil, nil, nil, false
+	}
+	level := logger.NewAtomicLevel(cfg.LogLevel)
+	logg, err := logger.New(level, cfg.LogRedaction)
+	if err != nil {
+		panic(err)
+	}
+	for _, msg := range warnings {
+		logg.Warn(ctx, "configuration warning", logger.String("detail", msg))
+	}
+	logg.Info(ctx, "configuration loaded", logger.String("environment", cfg.Environment), logger.String("port", cfg.AppPort), logger.String("log_level", cfg.LogLevel), logger.String("log_redaction", cfg.LogRedaction))
+	return cfg, logg, level, true
+}
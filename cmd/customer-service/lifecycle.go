@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"google.golang.org/grpc"
+)
+
+// component is a unit of the process that must be stopped on shutdown. start
+// returns once the component is running; long-running work continues in the
+// background and reports fatal errors through lifecycle.fail. stop must return
+// by the deadline on its context.
+type component struct {
+	name  string
+	start func(ctx context.Context) error
+	stop  func(ctx context.Context) error
+}
+
+// lifecycle starts components in dependency order and stops them in reverse,
+// so the HTTP server stops accepting work before the workers and pool it
+// relies on go away.
+type lifecycle struct {
+	log     logger.Logger
+	started []component
+	errc    chan error
+}
+
+func newLifecycle(log logger.Logger) *lifecycle {
+	return &lifecycle{log: log, errc: make(chan error, 1)}
+}
+
+// start starts c and registers it for shutdown. Components whose resources
+// were created by their constructor may leave start nil.
+func (l *lifecycle) start(ctx context.Context, c component) error {
+	if c.start != nil {
+		if err := c.start(ctx); err != nil {
+			return fmt.Errorf("start %s: %w", c.name, err)
+		}
+	}
+	l.started = append(l.started, c)
+	l.log.Info(ctx, "component started", logger.String("component", c.name))
+	return nil
+}
+
+// fail reports that a running component can no longer do its job, which ends
+// wait and shuts the process down.
+func (l *lifecycle) fail(name string, err error) {
+	select {
+	case l.errc <- fmt.Errorf("%s: %w", name, err):
+	default:
+	}
+}
+
+// wait blocks until SIGINT/SIGTERM arrives or a component fails, returning
+// the failure if there was one.
+func (l *lifecycle) wait(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	select {
+	case <-ctx.Done():
+		l.log.Info(context.Background(), "shutdown signal received")
+		return nil
+	case err := <-l.errc:
+		l.log.Error(context.Background(), "component failed", logger.Err(err))
+		return err
+	}
+}
+
+// stop stops started components in reverse order. Every component is given a
+// chance to stop even if an earlier one fails or the deadline passes.
+func (l *lifecycle) stop(ctx context.Context) error {
+	var errs []error
+	for i := len(l.started) - 1; i >= 0; i-- {
+		c := l.started[i]
+		if c.stop == nil {
+			continue
+		}
+		start := time.Now()
+		if err := c.stop(ctx); err != nil {
+			l.log.Error(ctx, "component stop failed", logger.String("component", c.name), logger.Err(err))
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.name, err))
+			continue
+		}
+		l.log.Info(ctx, "component stopped", logger.String("component", c.name), logger.Duration("duration", time.Since(start)))
+	}
+	l.started = nil
+	return errors.Join(errs...)
+}
+
+// httpServer binds the listener synchronously so a port conflict fails
+// startup instead of being logged from a goroutine, then serves in the
+// background. Stopping drains in-flight requests.
+func (l *lifecycle) httpServer(srv *http.Server) component {
+	return component{
+		name: "http server",
+		start: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			l.log.Info(ctx, "server listening", logger.String("address", ln.Addr().String()))
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					l.fail("http server", err)
+				}
+			}()
+			return nil
+		},
+		stop: srv.Shutdown,
+	}
+}
+
+// grpcServer binds addr synchronously, as httpServer does, then serves srv in
+// the background. Stopping waits for in-flight calls and closes any that are
+// still running when the deadline passes.
+func (l *lifecycle) grpcServer(srv *grpc.Server, addr string) component {
+	return component{
+		name: "grpc server",
+		start: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			l.log.Info(ctx, "grpc server listening", logger.String("address", ln.Addr().String()))
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+					l.fail("grpc server", err)
+				}
+			}()
+			return nil
+		},
+		stop: func(ctx context.Context) error {
+			done := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				srv.Stop()
+				<-done
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// worker runs fn in the background until shutdown. fn must return when its
+// context is cancelled; stopping cancels it and waits for it to finish. An
+// error returned before shutdown is treated as fatal.
+func (l *lifecycle) worker(name string, fn func(ctx context.Context) error) component {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	return component{
+		name: name,
+		start: func(context.Context) error {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := fn(ctx); err != nil && ctx.Err() == nil {
+					l.fail(name, err)
+				}
+			}()
+			return nil
+		},
+		stop: func(stopCtx context.Context) error {
+			cancel()
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	}
+}
@@ -2,43 +2,180 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
 	"time"
 
+	customerservice "github.com/Archiit19/customer-service-go"
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/Archiit19/customer-service-go/internal/auth"
 	"github.com/Archiit19/customer-service-go/internal/config"
 	"github.com/Archiit19/customer-service-go/internal/customer"
 	dbpkg "github.com/Archiit19/customer-service-go/internal/db"
+	"github.com/Archiit19/customer-service-go/internal/flags"
+	grpch "github.com/Archiit19/customer-service-go/internal/grpc"
+	"github.com/Archiit19/customer-service-go/internal/health"
 	httph "github.com/Archiit19/customer-service-go/internal/http"
+	"github.com/Archiit19/customer-service-go/internal/jobs"
 	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/metrics"
+	"github.com/Archiit19/customer-service-go/internal/notify"
+	"github.com/Archiit19/customer-service-go/internal/openapi"
+	"github.com/Archiit19/customer-service-go/internal/pii"
+	"github.com/Archiit19/customer-service-go/internal/ratelimit"
+	"github.com/Archiit19/customer-service-go/internal/settings"
+	"github.com/Archiit19/customer-service-go/internal/tracing"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run dispatches to a subcommand, defaulting to serving HTTP, and returns the
+// process exit code. Keeping this out of main lets deferred cleanup run on
+// every path.
+func run(args []string) int {
+	cmd := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+	switch cmd {
+	case "serve":
+		return runServe(args)
+	case "reencrypt":
+		return runReencrypt(args)
+	case "config":
+		return runConfig(args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q; expected serve, reencrypt or config\n", cmd)
+		return 2
+	}
+}
+
+// runServe runs the HTTP, and optionally gRPC, service until it is signalled to stop.
+func runServe(args []string) int {
 	ctx := context.Background()
-	cfg, warnings := config.Load()
-	logg, err := logger.New(cfg.LogLevel)
-	if err != nil {
-		panic(err)
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	cf := addConfigFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, logg, logLevel, ok := bootstrap(ctx, cf)
+	if !ok {
+		return 1
 	}
 	defer func() {
 		_ = logg.Sync()
 	}()
-	for _, msg := range warnings {
-		logg.Warn(ctx, "configuration warning", logger.String("detail", msg))
+	keys, err := newKeyring(cfg)
+	if err != nil {
+		logg.Error(ctx, "pii keyring initialization failed", logger.Err(err))
+		return 1
 	}
-	logg.Info(ctx, "configuration loaded", logger.String("port", cfg.AppPort), logger.String("log_level", cfg.LogLevel))
-	pool, err := dbpkg.NewPool(ctx, cfg, logg)
+
+	lc := newLifecycle(logg)
+	// Whatever was started is stopped on every exit path; after a normal
+	// shutdown this is a no-op.
+	defer func() {
+		ctxStop, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		_ = lc.stop(ctxStop)
+	}()
+
+	healthReg := health.NewRegistry()
+	tracer, err := newTracer(cfg, logg, healthReg)
+	if err != nil {
+		logg.Error(ctx, "tracing initialization failed", logger.Err(err))
+		return 1
+	}
+	if err := lc.start(ctx, component{name: "tracer", stop: tracer.Shutdown}); err != nil {
+		logg.Error(ctx, "tracer start failed", logger.Err(err))
+		return 1
+	}
+	pool, err := dbpkg.NewPool(ctx, cfg, logg, tracer)
 	if err != nil {
 		logg.Error(ctx, "database pool initialization failed", logger.Err(err))
-		os.Exit(1)
+		return 1
+	}
+	if err := lc.start(ctx, component{name: "database pool", stop: func(context.Context) error {
+		pool.Close()
+		return nil
+	}}); err != nil {
+		logg.Error(ctx, "database pool start failed", logger.Err(err))
+		return 1
+	}
+	dbpkg.RegisterHealthChecks(healthReg, pool)
+	var registry metrics.Registry
+	if cfg.MetricsEnabled {
+		registry = metrics.NewRegistry()
+		dbpkg.RegisterPoolMetrics(registry, pool)
+	}
+	repo := customer.NewPGRepository(pool, logg, keys, cfg.PIIEncryptContacts)
+	auditStore := audit.NewPGStore(pool, logg)
+	features, staticFlags, err := newFlags(ctx, cfg, pool, logg, lc)
+	if err != nil {
+		logg.Error(ctx, "feature flag initialization failed", logger.Err(err))
+		return 1
+	}
+	svc := customer.NewService(repo, logg, auditStore, registry, tracer, features, customer.ContactVerification{
+		Notifier: newNotifier(cfg, logg, healthReg),
+		ContactLimits: customer.ContactLimits{
+			TTL:            cfg.OTPTTL,
+			MaxAttempts:    int(cfg.OTPMaxAttempts),
+			ResendInterval: cfg.OTPResendInterval,
+			MaxSends:       int(cfg.OTPMaxSends),
+		},
+	})
+	jobStore := jobs.NewPGStore(pool, logg, keys)
+	if cfg.JobsWorkerEnabled {
+		// Started before the servers so it stops after them.
+		runner := jobs.NewRunner(jobStore, svc, logg, int(cfg.JobsChunkSize), cfg.JobsStaleAfter)
+		if err := lc.start(ctx, lc.worker("job runner", func(ctx context.Context) error {
+			return runner.Run(ctx, cfg.JobsPollInterval)
+		})); err != nil {
+			logg.Error(ctx, "job runner start failed", logger.Err(err))
+			return 1
+		}
+	}
+	limiter, err := newLimiter(cfg, pool, logg)
+	if err != nil {
+		logg.Error(ctx, "rate limiter initialization failed", logger.Err(err))
+		return 1
+	}
+	authn, err := auth.ParseAPIKeys(cfg.AuthAPIKeys)
+	if err != nil {
+		logg.Error(ctx, "api key configuration invalid", logger.Err(err))
+		return 1
+	}
+	if !authn.Enabled() {
+		logg.Warn(ctx, "AUTH_API_KEYS not set; all requests run as anonymous")
+	}
+	spec, err := openapi.Load(customerservice.OpenAPI)
+	if err != nil {
+		logg.Error(ctx, "openapi description invalid", logger.Err(err))
+		return 1
 	}
-	defer pool.Close()
-	logg.Info(ctx, "database pool initialized")
-	repo := customer.NewPGRepository(pool, logg)
-	svc := customer.NewService(repo, logg)
-	router := httph.NewRouter(svc, logg)
+	router := httph.NewRouter(svc, logg, httph.Options{
+		Limiter:           limiter,
+		Authn:             authn,
+		AuthRequired:      cfg.AuthRequired,
+		Audit:             auditStore,
+		Metrics:           registry,
+		Tracer:            tracer,
+		Health:            healthReg,
+		LogLevel:          logLevel,
+		Flags:             features,
+		Jobs:              jobStore,
+		MaxUploadBytes:    cfg.JobsMaxUploadBytes,
+		ExportMaxDuration: cfg.ExportMaxDuration,
+		Spec:              spec,
+		ValidateRequests:  cfg.OpenAPIValidation != config.OpenAPIValidationOff,
+		ValidateResponses: cfg.OpenAPIValidation == config.OpenAPIValidationAll,
+	})
 	srv := &http.Server{
 		Addr:              ":" + cfg.AppPort,
 		Handler:           router,
@@ -47,21 +184,210 @@ func main() {
 		ReadHeaderTimeout: 5 * time.Second,
 		IdleTimeout:       60 * time.Second,
 	}
-	go func() {
-		logg.Info(ctx, "server listening", logger.String("address", srv.Addr))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logg.Error(ctx, "server listen failed", logger.Err(err))
+	if err := lc.start(ctx, lc.httpServer(srv)); err != nil {
+		logg.Error(ctx, "server listen failed", logger.Err(err))
+		return 1
+	}
+	if cfg.GRPCEnabled {
+		grpcSrv := grpch.NewServer(svc, logg, grpch.Options{
+			Limiter:      limiter,
+			Authn:        authn,
+			AuthRequired: cfg.AuthRequired,
+			Tracer:       tracer,
+		})
+		if err := lc.start(ctx, lc.grpcServer(grpcSrv, ":"+cfg.GRPCPort)); err != nil {
+			logg.Error(ctx, "grpc server listen failed", logger.Err(err))
+			return 1
 		}
-	}()
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
-	<-stop
-	logg.Info(ctx, "shutdown signal received")
-	ctxShutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	}
+	sm := newSettingsManager(cfg, cf, logg, logLevel, limiter, staticFlags)
+	if err := lc.start(ctx, lc.worker("settings watcher", func(ctx context.Context) error {
+		return sm.Run(ctx, cfg.File, cfg.SettingsWatchInterval)
+	})); err != nil {
+		logg.Error(ctx, "settings watcher start failed", logger.Err(err))
+		return 1
+	}
+
+	failure := lc.wait(ctx)
+	healthReg.SetShuttingDown()
+	if failure == nil && cfg.ShutdownPreStopDelay > 0 {
+		// Keep serving while the gateway notices /readyz failing and stops
+		// sending new requests.
+		logg.Info(ctx, "waiting for traffic to drain", logger.Duration("delay", cfg.ShutdownPreStopDelay))
+		time.Sleep(cfg.ShutdownPreStopDelay)
+	}
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
-	if err := srv.Shutdown(ctxShutdown); err != nil {
-		logg.Error(ctxShutdown, "server shutdown error", logger.Err(err))
-	} else {
-		logg.Info(ctxShutdown, "server shutdown complete")
+	if err := lc.stop(ctxShutdown); err != nil {
+		logg.Error(ctxShutdown, "shutdown incomplete", logger.Err(err))
+		return 1
+	}
+	if failure != nil {
+		return 1
+	}
+	logg.Info(ctxShutdown, "shutdown complete")
+	return 0
+}
+
+// newLimiter builds the rate limiter from configuration, returning nil when
+// rate limiting is disabled.
+func newLimiter(cfg *config.Config, pool *pgxpool.Pool, log logger.Logger) (*ratelimit.Limiter, error) {
+	if !cfg.RateLimitEnabled {
+		return nil, nil
+	}
+	def, err := ratelimit.ParseRule(cfg.RateLimitDefault)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := ratelimit.ParseRoutes(cfg.RateLimitRoutes)
+	if err != nil {
+		return nil, err
+	}
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimitStore == "postgres" {
+		store = ratelimit.NewPGStore(pool, log)
+	}
+	log.Info(context.Background(), "rate limiting enabled", logger.String("store", cfg.RateLimitStore), logger.String("default", def.String()), logger.Int("routes", len(routes)))
+	return ratelimit.NewLimiter(store, def, routes), nil
+}
+
+// newSettingsManager registers the settings that apply without a restart:
+// the log level, the rate limit rules when rate limiting is enabled, and
+// FEATURE_FLAGS when flags are configured statically. Whether rate limiting is
+// enabled and which store it uses still require a restart.
+func newSettingsManager(cfg *config.Config, cf *configFlags, log logger.Logger, level *logger.AtomicLevel, limiter *ratelimit.Limiter, staticFlags *flags.Static) *settings.Manager {
+	opts := cf.options()
+	sm := settings.NewManager(cfg, func() (*config.Config, []string, error) {
+		return config.Load(opts)
+	}, log)
+	sm.Watch("LOG_LEVEL", func(_ context.Context, v string) error {
+		return level.Set(v)
+	})
+	if limiter != nil {
+		// The default and route rules are parsed together, so each watcher
+		// needs the other's last applied value.
+		def, routes := cfg.RateLimitDefault, cfg.RateLimitRoutes
+		applyRules := func(newDef, newRoutes string) error {
+			d, err := ratelimit.ParseRule(newDef)
+			if err != nil {
+				return err
+			}
+			r, err := ratelimit.ParseRoutes(newRoutes)
+			if err != nil {
+				return err
+			}
+			limiter.SetRules(d, r)
+			def, routes = newDef, newRoutes
+			return nil
+		}
+		sm.Watch("RATE_LIMIT_DEFAULT", func(_ context.Context, v string) error {
+			return applyRules(v, routes)
+		})
+		sm.Watch("RATE_LIMIT_ROUTES", func(_ context.Context, v string) error {
+			return applyRules(def, v)
+		})
+	}
+	if staticFlags != nil {
+		sm.Watch("FEATURE_FLAGS", func(_ context.Context, v string) error {
+			parsed, err := flags.ParseSpec(v)
+			if err != nil {
+				return err
+			}
+			staticFlags.Replace(parsed)
+			return nil
+		})
+	}
+	return sm
+}
+
+// newFlags builds the feature flag client. Static flags are returned as well
+// so FEATURE_FLAGS can be reloaded; Postgres-backed flags are loaded before
+// serving and then refreshed in the background.
+func newFlags(ctx context.Context, cfg *config.Config, pool *pgxpool.Pool, log logger.Logger, lc *lifecycle) (*flags.Client, *flags.Static, error) {
+	if cfg.FlagsProvider == "postgres" {
+		p := flags.NewPGProvider(pool, log)
+		if err := p.Refresh(ctx); err != nil {
+			return nil, nil, err
+		}
+		if err := lc.start(ctx, lc.worker("feature flag refresh", func(ctx context.Context) error {
+			return p.Run(ctx, cfg.FlagsRefreshInterval)
+		})); err != nil {
+			return nil, nil, err
+		}
+		return flags.NewClient(p, log), nil, nil
+	}
+	s, err := flags.NewStatic(cfg.FeatureFlags)
+	if err != nil {
+		return nil, nil, fmt.Errorf("FEATURE_FLAGS: %w", err)
+	}
+	return flags.NewClient(s, log), s, nil
+}
+
+// newTracer builds the tracer from configuration. With TRACING_EXPORTER=none
+// spans are not exported, but trace IDs still propagate into logs. The OTLP
+// exporter reports its last export as a non-critical health check.
+func newTracer(cfg *config.Config, log logger.Logger, reg *health.Registry) (*tracing.Tracer, error) {
+	var exporter tracing.Exporter = tracing.NopExporter{}
+	if cfg.TracingExporter == "otlp" {
+		headers, err := tracing.ParseHeaders(cfg.OTLPHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("OTEL_EXPORTER_OTLP_HEADERS: %w", err)
+		}
+		endpoint := strings.TrimSuffix(cfg.OTLPEndpoint, "/") + "/v1/traces"
+		otlp := tracing.NewOTLPExporter(endpoint, headers, cfg.ServiceName, func(err error) {
+			log.Warn(context.Background(), "trace export failed", logger.Err(err))
+		})
+		reg.RegisterNonCritical("otlp_exporter", 0, otlp.Check)
+		exporter = otlp
+		log.Info(context.Background(), "tracing enabled", logger.String("endpoint", endpoint), logger.String("service", cfg.ServiceName))
+	}
+	return tracing.NewTracer(exporter, cfg.TracingSampleRatio), nil
+}
+
+// newNotifier builds the notifier delivering contact verification codes,
+// routing email and SMS to the providers chosen by NOTIFY_EMAIL and
+// NOTIFY_SMS. Remote providers are registered as non-critical health checks.
+func newNotifier(cfg *config.Config, log logger.Logger, reg *health.Registry) notify.Notifier {
+	stub := func(kind string) notify.Notifier {
+		if kind == "file" {
+			return notify.NewFile(cfg.NotifyFile)
+		}
+		return notify.NewConsole()
+	}
+	email, sms := stub(cfg.NotifyEmail), stub(cfg.NotifySMS)
+	if cfg.NotifyEmail == "smtp" {
+		smtp := notify.NewSMTP(cfg.SMTPHost, int(cfg.SMTPPort), cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+		reg.RegisterNonCritical("smtp", 0, smtp.Check)
+		email = smtp
+	}
+	if cfg.NotifySMS == "webhook" {
+		webhook := notify.NewWebhook(cfg.SMSWebhookURL, cfg.SMSWebhookToken)
+		reg.RegisterNonCritical("sms_webhook", 0, webhook.Check)
+		sms = notify.NewSMS(webhook)
+	}
+	log.Info(context.Background(), "contact verification notifiers configured", logger.String("email", cfg.NotifyEmail), logger.String("sms", cfg.NotifySMS))
+	return notify.Channels{notify.ChannelEmail: email, notify.ChannelSMS: sms}
+}
+
+// newKeyring builds the PII keyring from configuration, returning nil when no
+// keys are configured so PII keeps being stored in plaintext.
+func newKeyring(cfg *config.Config) (*pii.Keyring, error) {
+	if cfg.PIIKeys == "" {
+		return nil, nil
+	}
+	keys, err := pii.ParseKeys(cfg.PIIKeys)
+	if err != nil {
+		return nil, err
+	}
+	activeID := cfg.PIIActiveKeyID
+	if activeID == "" && len(keys) == 1 {
+		for id := range keys {
+			activeID = id
+		}
+	}
+	indexKey, err := pii.DecodeKey(cfg.PIIIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("PII_INDEX_KEY: %w", err)
 	}
+	return pii.NewKeyring(keys, activeID, indexKey)
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	dbpkg "github.com/Archiit19/customer-service-go/internal/db"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// runReencrypt implements `customer-service reencrypt`, which rewrites stored
+// PII with the active key after a key rotation or when enabling encryption
+// on an existing database. It returns the process exit code.
+func runReencrypt(args []string) int {
+	ctx := context.Background()
+	fs := flag.NewFlagSet("reencrypt", flag.ContinueOnError)
+	cf := addConfigFlags(fs)
+	batchSize := fs.Int("batch-size", 500, "rows to process per batch")
+	dryRun := fs.Bool("dry-run", false, "report rows that would change without writing them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, logg, _, ok := bootstrap(ctx, cf)
+	if !ok {
+		return 1
+	}
+	defer func() {
+		_ = logg.Sync()
+	}()
+	keys, err := newKeyring(cfg)
+	if err != nil {
+		logg.Error(ctx, "pii keyring initialization failed", logger.Err(err))
+		return 1
+	}
+	if keys == nil {
+		logg.Error(ctx, "reencrypt requires PII_KEYS and PII_INDEX_KEY to be set")
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := dbpkg.NewPool(ctx, cfg, logg, nil)
+	if err != nil {
+		logg.Error(ctx, "database pool initialization failed", logger.Err(err))
+		return 1
+	}
+	defer pool.Close()
+
+	repo := customer.NewPGRepository(pool, logg, keys, cfg.PIIEncryptContacts)
+	logg.Info(ctx, "pii re-encryption started", logger.String("active_key", keys.ActiveKeyID()), logger.Bool("encrypt_contacts", cfg.PIIEncryptContacts), logger.Int("batch_size", *batchSize), logger.Bool("dry_run", *dryRun))
+	if _, err := repo.ReencryptPII(ctx, *batchSize, *dryRun); err != nil {
+		logg.Error(ctx, "pii re-encryption failed", logger.Err(err))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/google/uuid"
+)
+
+// Outcomes of an audited action.
+const (
+	OutcomeSuccess  = "SUCCESS"
+	OutcomeFailure  = "FAILURE"
+	OutcomeDenied   = "DENIED"
+	OutcomeNotFound = "NOT_FOUND"
+	OutcomeInvalid  = "INVALID"
+)
+
+// MetadataCustomerIDs is the metadata key listing the customers an action
+// returned, for actions on many customers such as listings and exports.
+// Queries by resource ID also match entries listing it here.
+const MetadataCustomerIDs = "customer_ids"
+
+// Change is the before/after value of one field. PII must be masked before it
+// is placed in a Change.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Entry is one audited action against a resource.
+type Entry struct {
+	ID           uuid.UUID         `json:"id"`
+	OccurredAt   time.Time         `json:"occurred_at"`
+	Tenant       string            `json:"tenant_id"`
+	Actor        string            `json:"actor"`
+	Action       string            `json:"action"`
+	ResourceType string            `json:"resource_type"`
+	ResourceID   string            `json:"resource_id,omitempty"`
+	RequestID    string            `json:"request_id,omitempty"`
+	ClientIP     string            `json:"client_ip,omitempty"`
+	Outcome      string            `json:"outcome"`
+	Changes      map[string]Change `json:"changes,omitempty"`
+	Metadata     map[string]any    `json:"metadata,omitempty"`
+}
+
+// NewEntry starts an entry for action on a resource, filling in the actor,
+// request ID and client IP from ctx.
+func NewEntry(ctx context.Context, action, resourceType, resourceID string) Entry {
+	return Entry{
+		Tenant:       auth.TenantFromContext(ctx),
+		Actor:        auth.FromContext(ctx).ID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		RequestID:    logger.RequestIDFromContext(ctx),
+		ClientIP:     auth.ClientIPFromContext(ctx),
+	}
+}
+
+// Filter narrows an audit query. Zero values are ignored.
+type Filter struct {
+	Tenant string
+	// ResourceID also matches entries listing it under MetadataCustomerIDs.
+	ResourceID string
+	Actor      string
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Offset     int
+	// IncludeMerged also matches entries of customers merged into the
+	// customer ResourceID names, which must then be a UUID.
+	IncludeMerged bool
+}
+
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+// PageLimit returns how many entries a query with f returns at most: Limit,
+// or 100 when it is unset or above 1000.
+func (f Filter) PageLimit() int {
+	if f.Limit <= 0 || f.Limit > maxQueryLimit {
+		return defaultQueryLimit
+	}
+	return f.Limit
+}
+
+// Recorder persists audit entries.
+type Recorder interface {
+	Record(ctx context.Context, e Entry) error
+}
+
+// Store records entries and answers audit queries.
+type Store interface {
+	Recorder
+	Query(ctx context.Context, f Filter) ([]Entry, int, error)
+}
+
+// Nop discards entries. It is used when auditing is not wired, e.g. in tools
+// that construct a Service without a database.
+type Nop struct{}
+
+func (Nop) Record(context.Context, Entry) error { return nil }
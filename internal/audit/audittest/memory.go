@@ -0,0 +1,95 @@
+// Package audittest provides an in-memory audit store for tests. Nothing in
+// it is meant for production use.
+package audittest
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/google/uuid"
+)
+
+// MemoryStore keeps entries in process memory. Changes and metadata go
+// through JSON as they do in audit_log, and customers merged into another are
+// found through the merged_into metadata of their merge entries rather than
+// the customers table.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries []audit.Entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Record(_ context.Context, e audit.Entry) error {
+	if err := roundTrip(&e.Changes); err != nil {
+		return err
+	}
+	if err := roundTrip(&e.Metadata); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.ID, e.OccurredAt = uuid.New(), time.Now().UTC()
+	s.entries = append(s.entries, e)
+	return nil
+}
+
+func roundTrip[T any](v *T) error {
+	data, err := json.Marshal(*v)
+	if err != nil {
+		return err
+	}
+	var zero T
+	*v = zero
+	return json.Unmarshal(data, v)
+}
+
+func (s *MemoryStore) Query(_ context.Context, f audit.Filter) ([]audit.Entry, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resources := []string{f.ResourceID}
+	if f.ResourceID != "" && f.IncludeMerged {
+		for _, e := range s.entries {
+			if e.Metadata["merged_into"] == f.ResourceID && !slices.Contains(resources, e.ResourceID) {
+				resources = append(resources, e.ResourceID)
+			}
+		}
+	}
+	var out []audit.Entry
+	for _, e := range s.entries {
+		if f.Tenant != "" && e.Tenant != f.Tenant || f.Actor != "" && e.Actor != f.Actor ||
+			!f.From.IsZero() && e.OccurredAt.Before(f.From) || !f.To.IsZero() && !e.OccurredAt.Before(f.To) {
+			continue
+		}
+		if f.ResourceID != "" && !concerns(e, resources) {
+			continue
+		}
+		out = append(out, e)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].OccurredAt.After(out[j].OccurredAt) })
+	total := len(out)
+	out = out[min(f.Offset, total):min(f.Offset+f.PageLimit(), total)]
+	return out, total, nil
+}
+
+// concerns reports whether e is about one of the resources, directly or
+// through audit.MetadataCustomerIDs.
+func concerns(e audit.Entry, resources []string) bool {
+	if slices.Contains(resources, e.ResourceID) {
+		return true
+	}
+	ids, _ := e.Metadata[audit.MetadataCustomerIDs].([]any)
+	for _, id := range ids {
+		if s, ok := id.(string); ok && slices.Contains(resources, s) {
+			return true
+		}
+	}
+	return false
+}
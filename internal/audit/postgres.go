@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PGStore stores entries in the append-only audit_log table.
+type PGStore struct {
+	pool   *pgxpool.Pool
+	logger logger.Logger
+}
+
+func NewPGStore(pool *pgxpool.Pool, log logger.Logger) *PGStore {
+	return &PGStore{pool: pool, logger: log}
+}
+
+// Record inserts e. Failures are logged and returned; callers decide whether
+// an unrecorded action should still succeed.
+func (s *PGStore) Record(ctx context.Context, e Entry) error {
+	changes, err := marshalNullable(len(e.Changes) > 0, e.Changes)
+	if err != nil {
+		return err
+	}
+	metadata, err := marshalNullable(len(e.Metadata) > 0, e.Metadata)
+	if err != nil {
+		return err
+	}
+	q := `
+INSERT INTO audit_log (tenant_id, actor, action, resource_type, resource_id, request_id, client_ip, outcome, changes, metadata)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+`
+	_, err = s.pool.Exec(ctx, q, e.Tenant, e.Actor, e.Action, e.ResourceType, e.ResourceID, e.RequestID, e.ClientIP, e.Outcome, changes, metadata)
+	if err != nil {
+		s.logger.Error(ctx, "audit log insert failed", logger.Err(err), logger.String("action", e.Action), logger.String("resource_id", e.ResourceID))
+		return err
+	}
+	s.logger.Debug(ctx, "audit entry recorded", logger.String("action", e.Action), logger.String("resource_id", e.ResourceID), logger.String("outcome", e.Outcome))
+	return nil
+}
+
+// Query returns entries matching f, newest first, and the total number of
+// matches.
+func (s *PGStore) Query(ctx context.Context, f Filter) ([]Entry, int, error) {
+	where := []string{}
+	args := []any{}
+	argi := 1
+	if f.Tenant != "" {
+		where = append(where, fmt.Sprintf("tenant_id = $%d", argi))
+		args = append(args, f.Tenant)
+		argi++
+	}
+	if f.ResourceID != "" && f.IncludeMerged {
+		where = append(where, fmt.Sprintf(`(resource_id = $%d OR metadata->'customer_ids' ? $%[1]d
+    OR resource_id IN (SELECT id::text FROM customers WHERE merged_into = $%[1]d::text::uuid)
+    OR metadata->'customer_ids' ?| ARRAY(SELECT id::text FROM customers WHERE merged_into = $%[1]d::text::uuid))`, argi))
+		args = append(args, f.ResourceID)
+		argi++
+	} else if f.ResourceID != "" {
+		where = append(where, fmt.Sprintf("(resource_id = $%d OR metadata->'customer_ids' ? $%[1]d)", argi))
+		args = append(args, f.ResourceID)
+		argi++
+	}
+	if f.Actor != "" {
+		where = append(where, fmt.Sprintf("actor = $%d", argi))
+		args = append(args, f.Actor)
+		argi++
+	}
+	if !f.From.IsZero() {
+		where = append(where, fmt.Sprintf("occurred_at >= $%d", argi))
+		args = append(args, f.From)
+		argi++
+	}
+	if !f.To.IsZero() {
+		where = append(where, fmt.Sprintf("occurred_at < $%d", argi))
+		args = append(args, f.To)
+		argi++
+	}
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.pool.QueryRow(ctx, "SELECT COUNT(*) FROM audit_log "+whereSQL+";", args...).Scan(&total); err != nil {
+		s.logger.Error(ctx, "audit count query failed", logger.Err(err))
+		return nil, 0, err
+	}
+
+	limit := f.PageLimit()
+	q := fmt.Sprintf(`
+SELECT id, occurred_at, tenant_id, actor, action, resource_type, resource_id, request_id, client_ip, outcome, changes, metadata
+FROM audit_log
+%s
+ORDER BY occurred_at DESC, id
+LIMIT $%d OFFSET $%d;
+`, whereSQL, argi, argi+1)
+	args = append(args, limit, f.Offset)
+	rows, err := s.pool.Query(ctx, q, args...)
+	if err != nil {
+		s.logger.Error(ctx, "audit query failed", logger.Err(err))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var (
+			e                 Entry
+			changes, metadata []byte
+		)
+		if err := rows.Scan(&e.ID, &e.OccurredAt, &e.Tenant, &e.Actor, &e.Action, &e.ResourceType, &e.ResourceID, &e.RequestID, &e.ClientIP, &e.Outcome, &changes, &metadata); err != nil {
+			s.logger.Error(ctx, "audit row scan failed", logger.Err(err))
+			return nil, 0, err
+		}
+		if err := unmarshalNullable(changes, &e.Changes); err != nil {
+			return nil, 0, err
+		}
+		if err := unmarshalNullable(metadata, &e.Metadata); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error(ctx, "audit rows iteration failed", logger.Err(err))
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func marshalNullable(present bool, v any) ([]byte, error) {
+	if !present {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal audit details: %w", err)
+	}
+	return data, nil
+}
+
+func unmarshalNullable(data []byte, v any) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unmarshal audit details: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Scopes granted to API keys.
+const (
+	// ScopeRevealPAN allows reading unmasked PAN numbers.
+	ScopeRevealPAN = "pan:reveal"
+	// ScopeAuditRead allows querying the access audit log.
+	ScopeAuditRead = "audit:read"
+	// ScopeAdmin allows changing runtime settings such as the log level.
+	ScopeAdmin = "admin:write"
+	// ScopeExportPII allows exporting unmasked email addresses and phone
+	// numbers.
+	ScopeExportPII = "pii:export"
+	// ScopeAnyTenant allows a principal not bound to a tenant to act in the
+	// tenant it names per request.
+	ScopeAnyTenant = "tenant:any"
+)
+
+// AnonymousID identifies callers that did not present credentials.
+const AnonymousID = "anonymous"
+
+// DefaultTenant owns requests that name no tenant, and every row created
+// before tenants were introduced.
+const DefaultTenant = "default"
+
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidTenant reports whether id can name a tenant: lower-case letters,
+// digits, '-' and '_', at most 63 characters.
+func ValidTenant(id string) bool {
+	return tenantPattern.MatchString(id)
+}
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidTenant      = errors.New("invalid tenant")
+	ErrTenantNotPermitted = errors.New("tenant not permitted")
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	ID     string
+	Scopes []string
+	// Tenant, when set, is the only tenant the principal may act in. Principals
+	// without one act in DefaultTenant unless granted ScopeAnyTenant.
+	Tenant string
+}
+
+// Anonymous is the principal used when a request carries no credentials.
+var Anonymous = Principal{ID: AnonymousID}
+
+// IsAnonymous reports whether the principal did not authenticate.
+func (p Principal) IsAnonymous() bool {
+	return p.ID == AnonymousID
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// ResolveTenant returns the tenant p acts in when the caller asks for
+// requested, which may be empty. Principals bound to a tenant always act in it
+// and are refused any other. Unbound principals granted ScopeAnyTenant act in
+// requested, or DefaultTenant; all others, including Anonymous, act only in
+// DefaultTenant.
+func ResolveTenant(p Principal, requested string) (string, error) {
+	switch {
+	case p.Tenant != "" && requested != "" && requested != p.Tenant:
+		return "", ErrTenantNotPermitted
+	case p.Tenant != "":
+		return p.Tenant, nil
+	case requested == "":
+		return DefaultTenant, nil
+	case !ValidTenant(requested):
+		return "", ErrInvalidTenant
+	case requested != DefaultTenant && (p.IsAnonymous() || !p.HasScope(ScopeAnyTenant)):
+		return "", ErrTenantNotPermitted
+	default:
+		return requested, nil
+	}
+}
+
+type contextKey string
+
+const (
+	principalKey contextKey = "principal"
+	clientIPKey  contextKey = "client_ip"
+	tenantKey    contextKey = "tenant"
+)
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// FromContext returns the request principal, or Anonymous when none is set.
+func FromContext(ctx context.Context) Principal {
+	if ctx == nil {
+		return Anonymous
+	}
+	p, ok := ctx.Value(principalKey).(Principal)
+	if !ok {
+		return Anonymous
+	}
+	return p
+}
+
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+func ClientIPFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
+
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// TenantFromContext returns the tenant a request acts in, or DefaultTenant
+// when none is set.
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return DefaultTenant
+	}
+	tenant, ok := ctx.Value(tenantKey).(string)
+	if !ok || tenant == "" {
+		return DefaultTenant
+	}
+	return tenant
+}
+
+type apiKey struct {
+	hash      [sha256.Size]byte
+	principal Principal
+}
+
+// Authenticator resolves API keys to principals. Keys are kept only as
+// SHA-256 digests and compared in constant time.
+type Authenticator struct {
+	keys []apiKey
+}
+
+// ParseAPIKeys parses a ","-separated list of "<principal>:<key>:<scopes>"
+// entries, where scopes are "|"-separated and may be empty, e.g.
+// "ops-console:s3cret:pan:reveal|audit:read,crm:abc123:".
+// Scopes may themselves contain ':' so only the first two separators count.
+// A principal written "<principal>@<tenant>" is bound to that tenant.
+func ParseAPIKeys(s string) (*Authenticator, error) {
+	a := &Authenticator{}
+	for i, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			// Do not echo the entry: it contains a secret.
+			return nil, fmt.Errorf("api key entry %d: expected <principal>:<key>:<scopes>", i+1)
+		}
+		id, tenant, bound := strings.Cut(parts[0], "@")
+		if bound && (id == "" || !ValidTenant(tenant)) {
+			return nil, fmt.Errorf("api key entry %d: invalid tenant in <principal>@<tenant>", i+1)
+		}
+		p := Principal{ID: id, Tenant: tenant}
+		if len(parts) == 3 {
+			for _, scope := range strings.Split(parts[2], "|") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					p.Scopes = append(p.Scopes, scope)
+				}
+			}
+		}
+		a.keys = append(a.keys, apiKey{hash: sha256.Sum256([]byte(parts[1])), principal: p})
+	}
+	return a, nil
+}
+
+// Enabled reports whether any API keys are configured.
+func (a *Authenticator) Enabled() bool {
+	return a != nil && len(a.keys) > 0
+}
+
+// Authenticate returns the principal owning key.
+func (a *Authenticator) Authenticate(key string) (Principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(sum[:], k.hash[:]) == 1 {
+			return k.principal, nil
+		}
+	}
+	return Principal{}, ErrInvalidCredentials
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolveTenant(t *testing.T) {
+	bound := Principal{ID: "crm", Tenant: "retail"}
+	unbound := Principal{ID: "crm"}
+	operator := Principal{ID: "console", Scopes: []string{ScopeAnyTenant}}
+	// A bound principal's own scopes cannot widen its tenant.
+	boundOperator := Principal{ID: "console", Tenant: "retail", Scopes: []string{ScopeAnyTenant}}
+	for _, tc := range []struct {
+		name      string
+		p         Principal
+		requested string
+		want      string
+		err       error
+	}{
+		{"bound, none requested", bound, "", "retail", nil},
+		{"bound, own tenant", bound, "retail", "retail", nil},
+		{"bound, other tenant", bound, "wholesale", "", ErrTenantNotPermitted},
+		{"bound with tenant:any, other tenant", boundOperator, "wholesale", "", ErrTenantNotPermitted},
+		{"anonymous, none requested", Anonymous, "", DefaultTenant, nil},
+		{"anonymous, default requested", Anonymous, DefaultTenant, DefaultTenant, nil},
+		{"anonymous, other tenant", Anonymous, "retail", "", ErrTenantNotPermitted},
+		{"anonymous with tenant:any, other tenant", Principal{ID: AnonymousID, Scopes: []string{ScopeAnyTenant}}, "retail", "", ErrTenantNotPermitted},
+		{"unbound, none requested", unbound, "", DefaultTenant, nil},
+		{"unbound, other tenant", unbound, "retail", "", ErrTenantNotPermitted},
+		{"unbound, invalid tenant", unbound, "Retail!", "", ErrInvalidTenant},
+		{"tenant:any, none requested", operator, "", DefaultTenant, nil},
+		{"tenant:any, other tenant", operator, "retail", "retail", nil},
+		{"tenant:any, invalid tenant", operator, "Retail!", "", ErrInvalidTenant},
+	} {
+		got, err := ResolveTenant(tc.p, tc.requested)
+		if got != tc.want || !errors.Is(err, tc.err) {
+			t.Errorf("%s: got %q, %v; want %q, %v", tc.name, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	a, err := ParseAPIKeys(" ops@retail:s3cret:pan:reveal|audit:read , crm:abc123: ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Enabled() {
+		t.Fatal("keys parsed but authenticator disabled")
+	}
+	p, err := a.Authenticate("s3cret")
+	if err != nil || p.ID != "ops" || p.Tenant != "retail" || !p.HasScope(ScopeRevealPAN) || !p.HasScope(ScopeAuditRead) {
+		t.Errorf("s3cret: %+v, %v", p, err)
+	}
+	if p, err := a.Authenticate("abc123"); err != nil || p.ID != "crm" || p.Tenant != "" || len(p.Scopes) != 0 {
+		t.Errorf("abc123: %+v, %v", p, err)
+	}
+	if _, err := a.Authenticate("nope"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown key: %v", err)
+	}
+	for _, bad := range []string{"no-key", ":key:", "p:", "@retail:key:", "ops@Bad Tenant:key:"} {
+		if _, err := ParseAPIKeys(bad); err == nil {
+			t.Errorf("ParseAPIKeys(%q) succeeded", bad)
+		}
+	}
+	var none *Authenticator
+	if none.Enabled() {
+		t.Error("nil authenticator enabled")
+	}
+}
+
+func TestContextDefaults(t *testing.T) {
+	ctx := context.Background()
+	if p := FromContext(ctx); !p.IsAnonymous() {
+		t.Errorf("principal without one set: %+v, want anonymous", p)
+	}
+	if tenant := TenantFromContext(ctx); tenant != DefaultTenant {
+		t.Errorf("tenant without one set: %q, want %q", tenant, DefaultTenant)
+	}
+	if tenant := TenantFromContext(WithTenant(ctx, "")); tenant != DefaultTenant {
+		t.Errorf("empty tenant: %q, want %q", tenant, DefaultTenant)
+	}
+	ctx = WithClientIP(WithTenant(WithPrincipal(ctx, Principal{ID: "crm"}), "retail"), "10.0.0.1")
+	if p := FromContext(ctx); p.ID != "crm" || p.IsAnonymous() {
+		t.Errorf("principal %+v, want crm", p)
+	}
+	if tenant := TenantFromContext(ctx); tenant != "retail" {
+		t.Errorf("tenant %q, want retail", tenant)
+	}
+	if ip := ClientIPFromContext(ctx); ip != "10.0.0.1" {
+		t.Errorf("client IP %q", ip)
+	}
+}
+
+func TestValidTenant(t *testing.T) {
+	for _, id := range []string{"default", "acme", "a", "retail-in_2", strings.Repeat("a", 63)} {
+		if !ValidTenant(id) {
+			t.Errorf("ValidTenant(%q) = false", id)
+		}
+	}
+	for _, id := range []string{"", "Acme", "-acme", "_acme", "acme corp", "acme/x", strings.Repeat("a", 64)} {
+		if ValidTenant(id) {
+			t.Errorf("ValidTenant(%q) = true", id)
+		}
+	}
+}
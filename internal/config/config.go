@@ -1,89 +1,427 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// Environments selected by APP_ENV.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Modes of OPENAPI_VALIDATION.
+const (
+	OpenAPIValidationOff      = "off"
+	OpenAPIValidationRequests = "requests"
+	OpenAPIValidationAll      = "all"
+)
+
+// maxUploadBytes caps JOBS_MAX_UPLOAD_MB. An import is held in memory, and
+// copied once more when it is encrypted, so a larger file could exhaust the
+// pod's memory.
+const maxUploadBytes = 32 << 20
+
 type Config struct {
-	AppPort  string
-	LogLevel string
+	Environment  string
+	AppPort      string
+	LogLevel     string
+	LogRedaction string
 
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBSSLMode  string
+	// GRPCEnabled serves the customer API over gRPC on GRPCPort alongside
+	// HTTP.
+	GRPCEnabled bool
+	GRPCPort    string
+
+	// OpenAPIValidation checks requests, or requests and responses, against
+	// the embedded API description.
+	OpenAPIValidation string
+
+	// DatabaseURL, when set, is used verbatim and the DB_* connection fields
+	// are ignored.
+	DatabaseURL string
+	DBHost      string
+	DBPort      string
+	DBUser      string
+	DBPassword  string
+	DBName      string
+	DBSSLMode   string
 
 	DBMaxConns    int32
 	DBMinConns    int32
 	DBMaxIdleTime time.Duration
+	// DBTenantRLS sets app.tenant_id on every connection for the row-level
+	// security policies in migrations/optional/tenant_rls.sql.
+	DBTenantRLS bool
+
+	RateLimitEnabled bool
+	RateLimitStore   string
+	RateLimitDefault string
+	RateLimitRoutes  string
+
+	PIIKeys            string
+	PIIActiveKeyID     string
+	PIIIndexKey        string
+	PIIEncryptContacts bool
+
+	AuthAPIKeys  string
+	AuthRequired bool
+
+	MetricsEnabled bool
+
+	FlagsProvider        string
+	FeatureFlags         string
+	FlagsRefreshInterval time.Duration
+
+	ShutdownPreStopDelay time.Duration
+	ShutdownTimeout      time.Duration
+
+	// JobsWorkerEnabled runs queued background jobs, such as customer
+	// imports, in this process. Jobs can be submitted either way.
+	JobsWorkerEnabled  bool
+	JobsPollInterval   time.Duration
+	JobsChunkSize      int32
+	JobsStaleAfter     time.Duration
+	JobsMaxUploadBytes int64
+
+	// ExportMaxDuration bounds one customer export, which runs without the
+	// request timeout.
+	ExportMaxDuration time.Duration
+
+	// OTPTTL and OTPMaxAttempts bound the one-time codes that verify a
+	// customer's email and phone, and OTPResendInterval and OTPMaxSends how
+	// often they are sent. Attempts and sends are counted per contact over
+	// 24 hours.
+	OTPTTL            time.Duration
+	OTPMaxAttempts    int32
+	OTPResendInterval time.Duration
+	OTPMaxSends       int32
+	// NotifyEmail and NotifySMS choose how codes are delivered: console and
+	// file are development stand-ins that write them to standard error or
+	// NotifyFile.
+	NotifyEmail     string
+	NotifySMS       string
+	NotifyFile      string
+	SMTPHost        string
+	SMTPPort        int32
+	SMTPUsername    string
+	SMTPPassword    string
+	SMTPFrom        string
+	SMSWebhookURL   string
+	SMSWebhookToken string
+
+	ServiceName        string
+	TracingExporter    string
+	TracingSampleRatio float64
+	OTLPEndpoint       string
+	OTLPHeaders        string
+
+	// SettingsWatchInterval is how often File is checked for changes to the
+	// settings that apply without a restart; zero disables polling.
+	SettingsWatchInterval time.Duration
+
+	// File is the config file the settings were read from, if any.
+	File     string
+	settings []Setting
 }
 
-func getenv(key, def string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
-	}
-	return def
+// LoadOptions supplies the layers above the environment.
+type LoadOptions struct {
+	// File is a YAML or TOML config file; empty falls back to CONFIG_FILE.
+	File string
+	// Overrides are KEY=value settings from command-line flags and take
+	// precedence over everything else.
+	Overrides map[string]string
 }
 
-func parseInt32(key string, def int32) (int32, string) {
-	v := getenv(key, "")
-	if v == "" {
-		return def, ""
+// DSN returns the PostgreSQL connection string.
+func (c *Config) DSN() string {
+	if c.DatabaseURL != "" {
+		return c.DatabaseURL
 	}
-	i, err := strconv.Atoi(v)
-	if err != nil {
-		return def, fmt.Sprintf("invalid %s=%q; using default %d", key, v, def)
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.DBUser, c.DBPassword),
+		Host:     c.DBHost + ":" + c.DBPort,
+		Path:     "/" + c.DBName,
+		RawQuery: url.Values{"sslmode": {c.DBSSLMode}}.Encode(),
 	}
-	return int32(i), ""
+	return u.String()
 }
 
-func parseDuration(key string, def time.Duration) (time.Duration, string) {
-	v := getenv(key, "")
-	if v == "" {
-		return def, ""
-	}
-	d, err := time.ParseDuration(v)
-	if err != nil {
-		return def, fmt.Sprintf("invalid %s=%q; using default %s", key, v, def)
+// Settings returns every resolved key in load order with its source.
+func (c *Config) Settings() []Setting {
+	return append([]Setting(nil), c.settings...)
+}
+
+// Value returns the resolved value of key, or "" if no setting has that key.
+func (c *Config) Value(key string) string {
+	for _, s := range c.settings {
+		if s.Key == key {
+			return s.Value
+		}
 	}
-	return d, ""
+	return ""
 }
 
-func Load() (*Config, []string) {
-	warnings := make([]string, 0)
-	maxConns, warn := parseInt32("DB_MAX_CONNS", 10)
-	if warn != "" {
-		warnings = append(warnings, warn)
+// Load resolves configuration from defaults, the config file, the
+// environment and opts.Overrides, in increasing precedence. Problems with
+// individual values are returned as warnings in development, where the
+// default is used instead, and as an error in production. Values that cannot
+// work in any environment, such as DB_MIN_CONNS above DB_MAX_CONNS, are
+// always an error.
+func Load(opts LoadOptions) (*Config, []string, error) {
+	l := &loader{
+		overrides: opts.Overrides,
+		lookupEnv: os.LookupEnv,
+		used:      map[string]bool{"CONFIG_FILE": true},
 	}
-	minConns, warn := parseInt32("DB_MIN_CONNS", 2)
-	if warn != "" {
-		warnings = append(warnings, warn)
+	path := opts.File
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
 	}
-	maxIdle, warn := parseDuration("DB_MAX_IDLE_TIME", 30*time.Minute)
-	if warn != "" {
-		warnings = append(warnings, warn)
+	if path != "" {
+		file, err := readFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("config file: %w", err)
+		}
+		l.file = file
 	}
 
+	upper, lower := strings.ToUpper, strings.ToLower
 	cfg := &Config{
-		AppPort:  getenv("APP_PORT", "8080"),
-		LogLevel: strings.ToUpper(getenv("LOG_LEVEL", "INFO")),
+		Environment:  l.oneOf("APP_ENV", EnvDevelopment, lower, EnvDevelopment, EnvProduction),
+		AppPort:      l.str("APP_PORT", "8080"),
+		LogLevel:     l.oneOf("LOG_LEVEL", "INFO", upper, "DEBUG", "INFO", "WARN", "ERROR"),
+		LogRedaction: l.oneOf("LOG_REDACTION", "PARTIAL", upper, "PARTIAL", "FULL", "HASH", "NONE"),
+
+		GRPCEnabled: l.bool("GRPC_ENABLED", false),
+		GRPCPort:    l.str("GRPC_PORT", "9090"),
+
+		OpenAPIValidation: l.oneOf("OPENAPI_VALIDATION", OpenAPIValidationOff, lower, OpenAPIValidationOff, OpenAPIValidationRequests, OpenAPIValidationAll),
+
+		DatabaseURL: l.str("DATABASE_URL", ""),
+		DBHost:      l.str("DB_HOST", "localhost"),
+		DBPort:      l.str("DB_PORT", "5432"),
+		DBUser:      l.str("DB_USER", "postgres"),
+		DBPassword:  l.str("DB_PASSWORD", "postgres"),
+		DBName:      l.str("DB_NAME", "postgres"),
+		DBSSLMode:   l.oneOf("DB_SSLMODE", "disable", lower, "disable", "allow", "prefer", "require", "verify-ca", "verify-full"),
+
+		DBMaxConns:    l.int32("DB_MAX_CONNS", 10),
+		DBMinConns:    l.int32("DB_MIN_CONNS", 2),
+		DBMaxIdleTime: l.duration("DB_MAX_IDLE_TIME", 30*time.Minute),
+		DBTenantRLS:   l.bool("DB_TENANT_RLS", false),
+
+		RateLimitEnabled: l.bool("RATE_LIMIT_ENABLED", true),
+		RateLimitStore:   l.oneOf("RATE_LIMIT_STORE", "memory", lower, "memory", "postgres"),
+		RateLimitDefault: l.str("RATE_LIMIT_DEFAULT", "120/1m"),
+		RateLimitRoutes:  l.str("RATE_LIMIT_ROUTES", "GET /v1/customers=30/1m;GET /v1/customers/{id}/status=30/1m;PATCH /v1/customers/{id}/verification=10/1m;POST /v1/customers/{id}/contact-verification=5/1m;auth-failures=10/1m"),
+
+		PIIKeys:            l.str("PII_KEYS", ""),
+		PIIActiveKeyID:     l.str("PII_ACTIVE_KEY_ID", ""),
+		PIIIndexKey:        l.str("PII_INDEX_KEY", ""),
+		PIIEncryptContacts: l.bool("PII_ENCRYPT_CONTACTS", false),
+
+		AuthAPIKeys:  l.str("AUTH_API_KEYS", ""),
+		AuthRequired: l.bool("AUTH_REQUIRED", false),
+
+		MetricsEnabled: l.bool("METRICS_ENABLED", true),
+
+		FlagsProvider:        l.oneOf("FLAGS_PROVIDER", "static", lower, "static", "postgres"),
+		FeatureFlags:         l.str("FEATURE_FLAGS", ""),
+		FlagsRefreshInterval: l.duration("FLAGS_REFRESH_INTERVAL", 30*time.Second),
+
+		ShutdownPreStopDelay: l.duration("SHUTDOWN_PRE_STOP_DELAY", 5*time.Second),
+		ShutdownTimeout:      l.duration("SHUTDOWN_TIMEOUT", 20*time.Second),
+
+		JobsWorkerEnabled:  l.bool("JOBS_WORKER_ENABLED", true),
+		JobsPollInterval:   l.duration("JOBS_POLL_INTERVAL", 2*time.Second),
+		JobsChunkSize:      l.int32("JOBS_CHUNK_SIZE", 500),
+		JobsStaleAfter:     l.duration("JOBS_STALE_AFTER", 2*time.Minute),
+		JobsMaxUploadBytes: int64(l.int32("JOBS_MAX_UPLOAD_MB", 16)) << 20,
+
+		ExportMaxDuration: l.duration("EXPORT_MAX_DURATION", 30*time.Minute),
+
+		OTPTTL:            l.duration("OTP_TTL", 10*time.Minute),
+		OTPMaxAttempts:    l.int32("OTP_MAX_ATTEMPTS", 5),
+		OTPResendInterval: l.duration("OTP_RESEND_INTERVAL", time.Minute),
+		OTPMaxSends:       l.int32("OTP_MAX_SENDS", 5),
+		NotifyEmail:       l.oneOf("NOTIFY_EMAIL", "console", lower, "console", "file", "smtp"),
+		NotifySMS:         l.oneOf("NOTIFY_SMS", "console", lower, "console", "file", "webhook"),
+		NotifyFile:        l.str("NOTIFY_FILE", "notifications.log"),
+		SMTPHost:          l.str("SMTP_HOST", ""),
+		SMTPPort:          l.int32("SMTP_PORT", 587),
+		SMTPUsername:      l.str("SMTP_USERNAME", ""),
+		SMTPPassword:      l.str("SMTP_PASSWORD", ""),
+		SMTPFrom:          l.str("SMTP_FROM", ""),
+		SMSWebhookURL:     l.str("SMS_WEBHOOK_URL", ""),
+		SMSWebhookToken:   l.str("SMS_WEBHOOK_TOKEN", ""),
+
+		ServiceName:        l.str("OTEL_SERVICE_NAME", "customer-service"),
+		TracingExporter:    l.oneOf("TRACING_EXPORTER", "none", lower, "none", "otlp"),
+		TracingSampleRatio: l.float("TRACING_SAMPLE_RATIO", 1),
+		OTLPEndpoint:       l.str("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+		OTLPHeaders:        l.str("OTEL_EXPORTER_OTLP_HEADERS", ""),
+
+		SettingsWatchInterval: l.duration("SETTINGS_WATCH_INTERVAL", 10*time.Second),
+
+		File: path,
+	}
+	cfg.settings = l.settings
+
+	for _, k := range l.unusedFileKeys() {
+		l.problemf("unknown key %s in config file", k)
+	}
+	cfg.checkProduction(l)
+	if err := errors.Join(append(l.errs, cfg.validate()...)...); err != nil {
+		return nil, nil, err
+	}
+	if cfg.Environment == EnvProduction && len(l.problems) > 0 {
+		return nil, nil, fmt.Errorf("invalid production configuration: %s", strings.Join(l.problems, "; "))
+	}
+	return cfg, l.problems, nil
+}
 
-		DBHost:     getenv("DB_HOST", "localhost"),
-		DBPort:     getenv("DB_PORT", "5432"),
-		DBUser:     getenv("DB_USER", "postgres"),
-		DBPassword: getenv("DB_PASSWORD", "postgres"),
-		DBName:     getenv("DB_NAME", "postgres"),
-		DBSSLMode:  getenv("DB_SSLMODE", "disable"),
+// checkProduction flags development conveniences that must not reach
+// production.
+func (c *Config) checkProduction(l *loader) {
+	if c.DatabaseURL == "" {
+		if c.source("DB_PASSWORD") == SourceDefault {
+			l.problemf("DB_PASSWORD not set; using the development default")
+		}
+		if c.DBSSLMode == "disable" && c.Environment == EnvProduction {
+			l.problemf("DB_SSLMODE=disable sends credentials and PII in cleartext")
+		}
+	}
+	if c.PIIKeys == "" {
+		l.problemf("PII_KEYS not set; PAN, email and phone are stored in plaintext")
+	}
+	if c.Environment == EnvProduction {
+		if c.NotifyEmail != "smtp" {
+			l.problemf("NOTIFY_EMAIL=%s does not deliver verification codes to customers", c.NotifyEmail)
+		}
+		if c.NotifySMS != "webhook" {
+			l.problemf("NOTIFY_SMS=%s does not deliver verification codes to customers", c.NotifySMS)
+		}
+	}
+}
 
-		DBMaxConns:    maxConns,
-		DBMinConns:    minConns,
-		DBMaxIdleTime: maxIdle,
+// validate rejects values that cannot work in any environment.
+func (c *Config) validate() []error {
+	var errs []error
+	if port, err := strconv.Atoi(c.AppPort); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("APP_PORT=%q is not a valid port", c.AppPort))
+	}
+	if c.GRPCEnabled {
+		if port, err := strconv.Atoi(c.GRPCPort); err != nil || port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("GRPC_PORT=%q is not a valid port", c.GRPCPort))
+		} else if c.GRPCPort == c.AppPort {
+			errs = append(errs, fmt.Errorf("GRPC_PORT=%s must differ from APP_PORT", c.GRPCPort))
+		}
+	}
+	if c.DatabaseURL != "" {
+		u, err := url.Parse(c.DatabaseURL)
+		if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			// The URL may embed a password, so it is not echoed.
+			errs = append(errs, errors.New("DATABASE_URL must be a postgres:// URL"))
+		}
+	}
+	if c.DBMaxConns < 1 {
+		errs = append(errs, fmt.Errorf("DB_MAX_CONNS=%d must be at least 1", c.DBMaxConns))
+	}
+	if c.DBMinConns < 0 || c.DBMinConns > c.DBMaxConns {
+		errs = append(errs, fmt.Errorf("DB_MIN_CONNS=%d must be between 0 and DB_MAX_CONNS=%d", c.DBMinConns, c.DBMaxConns))
+	}
+	if c.AuthRequired && strings.TrimSpace(c.AuthAPIKeys) == "" {
+		errs = append(errs, errors.New("AUTH_REQUIRED=true needs AUTH_API_KEYS; without keys every request would be refused"))
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO=%g must be between 0 and 1", c.TracingSampleRatio))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("SHUTDOWN_TIMEOUT=%s must be positive", c.ShutdownTimeout))
+	}
+	if c.FlagsRefreshInterval <= 0 {
+		errs = append(errs, fmt.Errorf("FLAGS_REFRESH_INTERVAL=%s must be positive", c.FlagsRefreshInterval))
+	}
+	if c.JobsPollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("JOBS_POLL_INTERVAL=%s must be positive", c.JobsPollInterval))
+	}
+	if c.JobsChunkSize < 1 || c.JobsChunkSize > 5000 {
+		errs = append(errs, fmt.Errorf("JOBS_CHUNK_SIZE=%d must be between 1 and 5000", c.JobsChunkSize))
+	}
+	if c.JobsStaleAfter <= 0 {
+		errs = append(errs, fmt.Errorf("JOBS_STALE_AFTER=%s must be positive", c.JobsStaleAfter))
+	}
+	if c.JobsMaxUploadBytes < 1<<20 || c.JobsMaxUploadBytes > maxUploadBytes {
+		errs = append(errs, fmt.Errorf("JOBS_MAX_UPLOAD_MB=%d must be between 1 and %d", c.JobsMaxUploadBytes>>20, maxUploadBytes>>20))
+	}
+	if c.ExportMaxDuration <= 0 {
+		errs = append(errs, fmt.Errorf("EXPORT_MAX_DURATION=%s must be positive", c.ExportMaxDuration))
+	}
+	if c.OTPTTL < time.Minute {
+		errs = append(errs, fmt.Errorf("OTP_TTL=%s must be at least 1m", c.OTPTTL))
+	}
+	if c.OTPMaxAttempts < 1 || c.OTPMaxAttempts > 10 {
+		errs = append(errs, fmt.Errorf("OTP_MAX_ATTEMPTS=%d must be between 1 and 10", c.OTPMaxAttempts))
+	}
+	if c.OTPResendInterval < 0 {
+		errs = append(errs, fmt.Errorf("OTP_RESEND_INTERVAL=%s must not be negative", c.OTPResendInterval))
+	}
+	if c.OTPMaxSends < 1 || c.OTPMaxSends > 20 {
+		errs = append(errs, fmt.Errorf("OTP_MAX_SENDS=%d must be between 1 and 20", c.OTPMaxSends))
+	}
+	if (c.NotifyEmail == "file" || c.NotifySMS == "file") && c.NotifyFile == "" {
+		errs = append(errs, errors.New("NOTIFY_FILE is required when NOTIFY_EMAIL or NOTIFY_SMS is file"))
+	}
+	if c.NotifyEmail == "smtp" {
+		if c.SMTPHost == "" || c.SMTPFrom == "" {
+			errs = append(errs, errors.New("SMTP_HOST and SMTP_FROM are required when NOTIFY_EMAIL=smtp"))
+		}
+		if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			errs = append(errs, fmt.Errorf("SMTP_PORT=%d is not a valid port", c.SMTPPort))
+		}
+	}
+	if c.NotifySMS == "webhook" {
+		if u, err := url.Parse(c.SMSWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, errors.New("SMS_WEBHOOK_URL must be an http:// or https:// URL when NOTIFY_SMS=webhook"))
+		}
+	}
+	if c.SettingsWatchInterval < 0 {
+		errs = append(errs, fmt.Errorf("SETTINGS_WATCH_INTERVAL=%s must not be negative", c.SettingsWatchInterval))
+	}
+	if c.ShutdownPreStopDelay < 0 {
+		errs = append(errs, fmt.Errorf("SHUTDOWN_PRE_STOP_DELAY=%s must not be negative", c.ShutdownPreStopDelay))
+	}
+	return errs
+}
+
+func (c *Config) source(key string) string {
+	for _, s := range c.settings {
+		if s.Key == key {
+			return s.Source
+		}
+	}
+	return SourceDefault
+}
+
+// Redacted returns the value for display. Secrets are replaced, except that
+// a database URL keeps everything but its password.
+func (s Setting) Redacted() string {
+	if !s.Secret || s.Value == "" {
+		return s.Value
+	}
+	if s.Key == "DATABASE_URL" {
+		if u, err := url.Parse(s.Value); err == nil {
+			return u.Redacted()
+		}
 	}
-	return cfg, warnings
+	return "[REDACTED]"
 }
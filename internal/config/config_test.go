@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAuthRequiredNeedsAPIKeys(t *testing.T) {
+	t.Setenv("AUTH_API_KEYS", "")
+	t.Setenv("AUTH_REQUIRED", "")
+	_, _, err := Load(LoadOptions{Overrides: map[string]string{"AUTH_REQUIRED": "true"}})
+	if err == nil || !strings.Contains(err.Error(), "AUTH_REQUIRED=true needs AUTH_API_KEYS") {
+		t.Fatalf("AUTH_REQUIRED without keys: got %v, want a startup error", err)
+	}
+	cfg, _, err := Load(LoadOptions{Overrides: map[string]string{"AUTH_REQUIRED": "true", "AUTH_API_KEYS": "crm:key:"}})
+	if err != nil {
+		t.Fatalf("AUTH_REQUIRED with keys: %v", err)
+	}
+	if !cfg.AuthRequired {
+		t.Error("AuthRequired not set")
+	}
+}
+
+func TestJobsMaxUploadIsCapped(t *testing.T) {
+	t.Setenv("JOBS_MAX_UPLOAD_MB", "")
+	_, _, err := Load(LoadOptions{Overrides: map[string]string{"JOBS_MAX_UPLOAD_MB": "100"}})
+	if err == nil || !strings.Contains(err.Error(), "JOBS_MAX_UPLOAD_MB=100 must be between 1 and 32") {
+		t.Fatalf("JOBS_MAX_UPLOAD_MB=100: got %v, want a startup error", err)
+	}
+	cfg, _, err := Load(LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.JobsMaxUploadBytes != 16<<20 {
+		t.Errorf("default JobsMaxUploadBytes %d, want 16 MiB", cfg.JobsMaxUploadBytes)
+	}
+}
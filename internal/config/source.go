@@ -0,0 +1,266 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources a setting can come from, lowest precedence first.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Setting is the effective value of one configuration key and where it came
+// from.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	Secret bool
+}
+
+// secretKeys are never printed unredacted by tooling and may be supplied
+// through a <KEY>_FILE variable pointing at a mounted secret.
+var secretKeys = map[string]bool{
+	"DATABASE_URL":               true,
+	"DB_PASSWORD":                true,
+	"PII_KEYS":                   true,
+	"PII_INDEX_KEY":              true,
+	"AUTH_API_KEYS":              true,
+	"OTEL_EXPORTER_OTLP_HEADERS": true,
+	"SMTP_PASSWORD":              true,
+	"SMS_WEBHOOK_TOKEN":          true,
+}
+
+// loader resolves keys through the layers defaults < file < env < flags and
+// collects problems with the values it reads.
+type loader struct {
+	file      map[string]string
+	overrides map[string]string
+	lookupEnv func(string) (string, bool)
+
+	used     map[string]bool
+	settings []Setting
+	// problems are tolerated outside production; errs never are.
+	problems []string
+	errs     []error
+}
+
+func (l *loader) problemf(format string, args ...any) {
+	l.problems = append(l.problems, fmt.Sprintf(format, args...))
+}
+
+// raw returns the highest-precedence value for key. At each layer a
+// <KEY>_FILE entry is read as the value when KEY itself is not set.
+func (l *loader) raw(key string) (string, string, bool) {
+	l.used[key] = true
+	l.used[key+"_FILE"] = true
+	if v, ok := l.overrides[key]; ok {
+		return v, SourceFlag, true
+	}
+	if v, ok := l.lookupEnv(key); ok && v != "" {
+		return v, SourceEnv, true
+	}
+	if path, ok := l.lookupEnv(key + "_FILE"); ok && path != "" {
+		return l.readSecret(key, path, SourceEnv)
+	}
+	if v, ok := l.file[key]; ok {
+		return v, SourceFile, true
+	}
+	if path, ok := l.file[key+"_FILE"]; ok && path != "" {
+		return l.readSecret(key, path, SourceFile)
+	}
+	return "", SourceDefault, false
+}
+
+func (l *loader) readSecret(key, path, source string) (string, string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s_FILE: %w", key, err))
+		return "", source, false
+	}
+	return strings.TrimRight(string(data), "\r\n"), source, true
+}
+
+func (l *loader) record(key, value, source string) {
+	l.settings = append(l.settings, Setting{Key: key, Value: value, Source: source, Secret: secretKeys[key]})
+}
+
+func (l *loader) str(key, def string) string {
+	v, src, ok := l.raw(key)
+	if !ok {
+		v, src = def, SourceDefault
+	}
+	l.record(key, v, src)
+	return v
+}
+
+// oneOf reads a case-insensitive enumerated value, normalised with normalize.
+func (l *loader) oneOf(key, def string, normalize func(string) string, allowed ...string) string {
+	v := normalize(l.str(key, def))
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	l.problemf("invalid %s=%q; expected one of %s", key, v, strings.Join(allowed, ", "))
+	return def
+}
+
+func (l *loader) int32(key string, def int32) int32 {
+	v := l.str(key, strconv.Itoa(int(def)))
+	i, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		l.problemf("invalid %s=%q; expected an integer", key, v)
+		return def
+	}
+	return int32(i)
+}
+
+func (l *loader) duration(key string, def time.Duration) time.Duration {
+	v := l.str(key, def.String())
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		l.problemf("invalid %s=%q; expected a duration such as 30s", key, v)
+		return def
+	}
+	return d
+}
+
+func (l *loader) float(key string, def float64) float64 {
+	v := l.str(key, strconv.FormatFloat(def, 'g', -1, 64))
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		l.problemf("invalid %s=%q; expected a number", key, v)
+		return def
+	}
+	return f
+}
+
+func (l *loader) bool(key string, def bool) bool {
+	v := l.str(key, strconv.FormatBool(def))
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.problemf("invalid %s=%q; expected true or false", key, v)
+		return def
+	}
+	return b
+}
+
+// unusedFileKeys reports keys in the config file that no setting read,
+// which are almost always typos.
+func (l *loader) unusedFileKeys() []string {
+	var unused []string
+	for k := range l.file {
+		if !l.used[k] {
+			unused = append(unused, k)
+		}
+	}
+	return unused
+}
+
+// readFile parses a YAML or TOML config file, chosen by extension, into
+// environment-style keys. Only the subset needed for flat settings is
+// supported: scalar values, one level of nesting (YAML mappings or TOML
+// tables) whose names prefix the key, and # comments. A YAML "db:" mapping
+// containing "host: x" and a TOML "[db]" table containing "host = x" both set
+// DB_HOST.
+func readFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sep string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		sep = ":"
+	case ".toml":
+		sep = "="
+	default:
+		return nil, fmt.Errorf("%s: unsupported config format; use .yaml, .yml or .toml", path)
+	}
+
+	values := map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := stripComment(scanner.Text())
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if sep == "=" && strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			continue
+		}
+		k, v, ok := strings.Cut(trimmed, sep)
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("%s:%d: expected <key>%s <value>", path, n, sep)
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if sep == ":" {
+			indented := line != strings.TrimLeft(line, " \t")
+			switch {
+			case !indented && v == "":
+				section = k
+				continue
+			case !indented:
+				section = ""
+			case section == "":
+				return nil, fmt.Errorf("%s:%d: unexpected indentation", path, n)
+			}
+		}
+		if section != "" {
+			k = section + "_" + k
+		}
+		values[envKey(k)] = unquote(v)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func envKey(k string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(k))
+}
+
+// stripComment drops a trailing # comment that is not inside quotes.
+func stripComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquote(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		if v[0] == '"' {
+			if s, err := strconv.Unquote(v); err == nil {
+				return s
+			}
+		}
+		return v[1 : len(v)-1]
+	}
+	return v
+}
@@ -0,0 +1,147 @@
+package customer
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// AddressType is the purpose of an address, as required for KYC.
+type AddressType string
+
+const (
+	AddressResidential    AddressType = "residential"
+	AddressCorrespondence AddressType = "correspondence"
+	AddressPermanent      AddressType = "permanent"
+)
+
+// IsValidAddressType returns true only for known AddressType values.
+func IsValidAddressType(t AddressType) bool {
+	switch t {
+	case AddressResidential, AddressCorrespondence, AddressPermanent:
+		return true
+	default:
+		return false
+	}
+}
+
+var (
+	ErrAddressNotFound     = errors.New("address not found")
+	ErrInvalidAddressType  = errors.New("invalid address type: expected residential, correspondence or permanent")
+	ErrInvalidAddress      = errors.New("invalid address: line1, city and state are required; lines are limited to 200 characters, city and state to 100")
+	ErrInvalidPINCode      = errors.New("invalid PIN code: expected six digits, not starting with 0")
+	ErrPrimaryAddressTaken = errors.New("conflict: the customer already has a primary address")
+)
+
+// Address is a postal address in India of a customer. At most one address
+// of a customer is primary.
+type Address struct {
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	Type       AddressType
+	Line1      string
+	Line2      string
+	City       string
+	State      string
+	PINCode    string
+	Primary    bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// UpdateAddress changes the fields of an address that are non-nil.
+type UpdateAddress struct {
+	Type    *AddressType
+	Line1   *string
+	Line2   *string
+	City    *string
+	State   *string
+	PINCode *string
+	Primary *bool
+}
+
+// Apply returns a copy of a with the changes in upd.
+func (upd UpdateAddress) Apply(a Address) Address {
+	if upd.Type != nil {
+		a.Type = *upd.Type
+	}
+	if upd.Line1 != nil {
+		a.Line1 = *upd.Line1
+	}
+	if upd.Line2 != nil {
+		a.Line2 = *upd.Line2
+	}
+	if upd.City != nil {
+		a.City = *upd.City
+	}
+	if upd.State != nil {
+		a.State = *upd.State
+	}
+	if upd.PINCode != nil {
+		a.PINCode = *upd.PINCode
+	}
+	if upd.Primary != nil {
+		a.Primary = *upd.Primary
+	}
+	return a
+}
+
+// normalized returns upd with the fields it sets normalized like
+// Address.Normalize.
+func (upd UpdateAddress) normalized() UpdateAddress {
+	a := upd.Apply(Address{})
+	a.Normalize()
+	for _, f := range []struct {
+		dst **string
+		v   string
+	}{
+		{&upd.Line1, a.Line1}, {&upd.Line2, a.Line2}, {&upd.City, a.City}, {&upd.State, a.State}, {&upd.PINCode, a.PINCode},
+	} {
+		if *f.dst != nil {
+			v := f.v
+			*f.dst = &v
+		}
+	}
+	return upd
+}
+
+var pinCodePattern = regexp.MustCompile(`^[1-9][0-9]{5}$`)
+
+// IsValidPINCode reports whether pin is an Indian postal index number: six
+// digits, the first of which names a postal zone and is never 0.
+func IsValidPINCode(pin string) bool {
+	return pinCodePattern.MatchString(pin)
+}
+
+// Normalize trims the fields of a and removes the space often written in the
+// middle of a PIN code, e.g. "560 001".
+func (a *Address) Normalize() {
+	a.Line1 = strings.TrimSpace(a.Line1)
+	a.Line2 = strings.TrimSpace(a.Line2)
+	a.City = strings.TrimSpace(a.City)
+	a.State = strings.TrimSpace(a.State)
+	a.PINCode = strings.ReplaceAll(strings.TrimSpace(a.PINCode), " ", "")
+}
+
+// Validate checks a normalized address.
+func (a *Address) Validate() error {
+	if !IsValidAddressType(a.Type) {
+		return ErrInvalidAddressType
+	}
+	if a.Line1 == "" || a.City == "" || a.State == "" ||
+		tooLong(a.Line1, 200) || tooLong(a.Line2, 200) || tooLong(a.City, 100) || tooLong(a.State, 100) {
+		return ErrInvalidAddress
+	}
+	if !IsValidPINCode(a.PINCode) {
+		return ErrInvalidPINCode
+	}
+	return nil
+}
+
+func tooLong(s string, n int) bool {
+	return utf8.RuneCountInString(s) > n
+}
@@ -0,0 +1,210 @@
+package customer
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/notify"
+	"github.com/Archiit19/customer-service-go/internal/pii"
+	"github.com/google/uuid"
+)
+
+// ContactChannel is a contact detail whose ownership a customer can prove
+// with a one-time code.
+type ContactChannel string
+
+const (
+	ContactEmail ContactChannel = "email"
+	ContactPhone ContactChannel = "phone"
+)
+
+// IsValidContactChannel returns true only for known ContactChannel values.
+func IsValidContactChannel(c ContactChannel) bool {
+	return c == ContactEmail || c == ContactPhone
+}
+
+// Defaults for ContactLimits fields left zero.
+const (
+	DefaultCodeTTL            = 10 * time.Minute
+	DefaultCodeMaxAttempts    = 5
+	DefaultCodeResendInterval = time.Minute
+	DefaultCodeMaxSends       = 5
+)
+
+// ContactWindow is the period over which the codes sent to a contact, and
+// the incorrect codes entered for it, are counted. It starts with the first
+// code sent once the previous window has ended.
+const ContactWindow = 24 * time.Hour
+
+var (
+	ErrInvalidChannel         = errors.New("invalid channel: expected email or phone")
+	ErrInvalidCode            = errors.New("invalid code: expected 6 digits")
+	ErrContactAlreadyVerified = errors.New("conflict: contact already verified")
+	ErrCodeMismatch           = errors.New("incorrect verification code")
+	ErrCodeExpired            = errors.New("verification code expired or not requested; request a new one")
+	ErrTooManyAttempts        = errors.New("too many incorrect codes; request a new one")
+	ErrDeliveryFailed         = errors.New("verification code could not be delivered")
+	ErrResendTooSoon          = errors.New("a verification code was sent moments ago; wait before requesting another")
+	ErrTooManyCodes           = errors.New("too many verification codes requested or incorrect codes entered; try again later")
+)
+
+// IsThrottledCode reports whether err refuses to send a code because of the
+// limits on sends and incorrect codes.
+func IsThrottledCode(err error) bool {
+	return errors.Is(err, ErrResendTooSoon) || errors.Is(err, ErrTooManyCodes)
+}
+
+// IsRejectedCode reports whether err rejects a well-formed code.
+func IsRejectedCode(err error) bool {
+	return errors.Is(err, ErrCodeMismatch) || errors.Is(err, ErrCodeExpired) || errors.Is(err, ErrTooManyAttempts)
+}
+
+// ContactVerification configures the one-time codes that prove a customer
+// owns their email address or phone number.
+type ContactVerification struct {
+	// Notifier delivers the codes; without one, no code can be sent.
+	Notifier notify.Notifier
+	ContactLimits
+}
+
+// ContactLimits bound the codes of one contact of a customer. Sends and
+// incorrect codes are counted across codes for a ContactWindow, so
+// requesting a new code does not buy more guesses.
+type ContactLimits struct {
+	// TTL is how long a code is valid.
+	TTL time.Duration
+	// MaxAttempts is how many incorrect codes a window allows; the last one
+	// voids the pending code and no more are sent until the window ends.
+	MaxAttempts int
+	// ResendInterval is the least time between two codes, and MaxSends how
+	// many codes a window allows.
+	ResendInterval time.Duration
+	MaxSends       int
+}
+
+// PendingContactVerification describes a code that has been sent.
+type PendingContactVerification struct {
+	Channel ContactChannel
+	// Destination is the masked contact the code was sent to.
+	Destination string
+	ExpiresAt   time.Time
+}
+
+// ContactChallenge is a code sent to a customer. Code and Destination, the
+// normalized contact it was sent to, are only held in memory; the repository
+// stores keyed digests of them. Attempts and Sends count the incorrect codes
+// entered and the codes sent since WindowStart.
+type ContactChallenge struct {
+	ID          uuid.UUID
+	CustomerID  uuid.UUID
+	Channel     ContactChannel
+	Code        string
+	Destination string
+	Attempts    int
+	MaxAttempts int
+	Sends       int
+	WindowStart time.Time
+	SentAt      time.Time
+	ExpiresAt   time.Time
+}
+
+const codeLength = 6
+
+var codePattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+// newContactChallenge generates a code for destination, the first of a new
+// window.
+func newContactChallenge(customerID uuid.UUID, channel ContactChannel, destination string, limits ContactLimits, now time.Time) (*ContactChallenge, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return nil, err
+	}
+	return &ContactChallenge{
+		ID:          uuid.New(),
+		CustomerID:  customerID,
+		Channel:     channel,
+		Code:        fmt.Sprintf("%0*d", codeLength, n.Int64()),
+		Destination: destination,
+		MaxAttempts: limits.MaxAttempts,
+		Sends:       1,
+		WindowStart: now,
+		SentAt:      now,
+		ExpiresAt:   now.Add(limits.TTL),
+	}, nil
+}
+
+// Replace makes ch, sent at ch.SentAt, the successor of prev, carrying its
+// counts over while prev's window lasts. It returns ErrResendTooSoon or
+// ErrTooManyCodes when limits do not allow sending ch.
+func (ch *ContactChallenge) Replace(prev *ContactChallenge, limits ContactLimits) error {
+	if prev == nil || !ch.SentAt.Before(prev.WindowStart.Add(ContactWindow)) {
+		return nil
+	}
+	if ch.SentAt.Before(prev.SentAt.Add(limits.ResendInterval)) {
+		return ErrResendTooSoon
+	}
+	if prev.Sends >= limits.MaxSends || prev.Attempts >= limits.MaxAttempts {
+		return ErrTooManyCodes
+	}
+	ch.Sends = prev.Sends + 1
+	ch.Attempts = prev.Attempts
+	ch.WindowStart = prev.WindowStart
+	return nil
+}
+
+// Check reports whether a code, correct or not, answers the challenge at
+// now: nil, or ErrCodeExpired, ErrCodeMismatch or, when this incorrect code
+// uses up the last attempt, ErrTooManyAttempts. It counts incorrect codes in
+// Attempts.
+func (ch *ContactChallenge) Check(correct bool, now time.Time) error {
+	if !now.Before(ch.ExpiresAt) || ch.Attempts >= ch.MaxAttempts {
+		return ErrCodeExpired
+	}
+	if correct {
+		return nil
+	}
+	ch.Attempts++
+	if ch.Attempts >= ch.MaxAttempts {
+		return ErrTooManyAttempts
+	}
+	return ErrCodeMismatch
+}
+
+// contact returns the normalized contact detail of c on channel.
+func (c *Customer) contact(channel ContactChannel) string {
+	if channel == ContactEmail {
+		return normalizePII(pii.DomainEmail, c.Email)
+	}
+	return normalizePII(pii.DomainPhone, c.Phone)
+}
+
+// contactVerifiedAt returns when the contact detail on channel was verified,
+// or nil.
+func (c *Customer) contactVerifiedAt(channel ContactChannel) *time.Time {
+	if channel == ContactEmail {
+		return c.EmailVerifiedAt
+	}
+	return c.PhoneVerifiedAt
+}
+
+// verifiedColumn is the customers column recording when the contact detail
+// on channel was verified.
+func (channel ContactChannel) verifiedColumn() string {
+	if channel == ContactEmail {
+		return "email_verified_at"
+	}
+	return "phone_verified_at"
+}
+
+// codeMessage is the message delivering code to a customer.
+func codeMessage(c *Customer, channel ContactChannel, code string, ttl time.Duration) notify.Message {
+	body := fmt.Sprintf("Your verification code is %s. It expires in %d minutes. Do not share it with anyone.", code, int(ttl.Round(time.Minute).Minutes()))
+	if channel == ContactEmail {
+		return notify.Message{Channel: notify.ChannelEmail, To: c.Email, Subject: "Verify your email address", Body: body}
+	}
+	return notify.Message{Channel: notify.ChannelSMS, To: c.Phone, Body: body}
+}
@@ -0,0 +1,98 @@
+package customer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var testLimits = ContactLimits{TTL: 10 * time.Minute, MaxAttempts: 3, ResendInterval: time.Minute, MaxSends: 3}
+
+func sendCode(t *testing.T, prev *ContactChallenge, at time.Time) (*ContactChallenge, error) {
+	t.Helper()
+	ch, err := newContactChallenge(uuid.New(), ContactEmail, "a@example.com", testLimits, at)
+	if err != nil {
+		t.Fatalf("newContactChallenge: %v", err)
+	}
+	if !codePattern.MatchString(ch.Code) {
+		t.Fatalf("code %q does not match %s", ch.Code, codePattern)
+	}
+	return ch, ch.Replace(prev, testLimits)
+}
+
+func TestContactChallengeResendLimits(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	first, err := sendCode(t, nil, start)
+	if err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	if _, err := sendCode(t, first, start.Add(30*time.Second)); !errors.Is(err, ErrResendTooSoon) {
+		t.Fatalf("resend within interval: got %v, want ErrResendTooSoon", err)
+	}
+	prev := first
+	for i := 2; i <= testLimits.MaxSends; i++ {
+		next, err := sendCode(t, prev, start.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+		if next.Sends != i || !next.WindowStart.Equal(start) {
+			t.Fatalf("send %d: sends=%d window=%s, want %d and %s", i, next.Sends, next.WindowStart, i, start)
+		}
+		prev = next
+	}
+	if _, err := sendCode(t, prev, start.Add(time.Hour)); !errors.Is(err, ErrTooManyCodes) {
+		t.Fatalf("send beyond MaxSends: got %v, want ErrTooManyCodes", err)
+	}
+	next, err := sendCode(t, prev, start.Add(ContactWindow))
+	if err != nil {
+		t.Fatalf("send in a new window: %v", err)
+	}
+	if next.Sends != 1 || next.Attempts != 0 {
+		t.Fatalf("new window: sends=%d attempts=%d, want 1 and 0", next.Sends, next.Attempts)
+	}
+}
+
+func TestContactChallengeAttemptsCarryOver(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	ch, err := sendCode(t, nil, start)
+	if err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	for i := 1; i < testLimits.MaxAttempts; i++ {
+		if err := ch.Check(false, start); !errors.Is(err, ErrCodeMismatch) {
+			t.Fatalf("incorrect code %d: got %v, want ErrCodeMismatch", i, err)
+		}
+	}
+	ch, err = sendCode(t, ch, start.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("resend: %v", err)
+	}
+	if ch.Attempts != testLimits.MaxAttempts-1 {
+		t.Fatalf("resend reset attempts to %d", ch.Attempts)
+	}
+	if err := ch.Check(false, start.Add(time.Minute)); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("last incorrect code: got %v, want ErrTooManyAttempts", err)
+	}
+	if err := ch.Check(true, start.Add(time.Minute)); !errors.Is(err, ErrCodeExpired) {
+		t.Fatalf("correct code after the last attempt: got %v, want ErrCodeExpired", err)
+	}
+	if _, err := sendCode(t, ch, start.Add(2*time.Minute)); !errors.Is(err, ErrTooManyCodes) {
+		t.Fatalf("send after the last attempt: got %v, want ErrTooManyCodes", err)
+	}
+}
+
+func TestContactChallengeCheck(t *testing.T) {
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	ch, err := sendCode(t, nil, now)
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if err := ch.Check(true, now.Add(testLimits.TTL)); !errors.Is(err, ErrCodeExpired) {
+		t.Fatalf("expired code: got %v, want ErrCodeExpired", err)
+	}
+	if err := ch.Check(true, now); err != nil {
+		t.Fatalf("correct code: %v", err)
+	}
+}
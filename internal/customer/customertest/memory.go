@@ -0,0 +1,650 @@
+// Package customertest provides an in-memory customer.Repository and the
+// fixtures tests of the HTTP, gRPC and client layers share. Nothing in it is
+// meant for production use.
+package customertest
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/google/uuid"
+)
+
+// MemoryRepository keeps customers in process memory so handlers and clients
+// can be exercised without a database. Like PGRepository it scopes every call
+// to the tenant in its context and returns the same errors. It is not a model
+// of the SQL: tenant isolation and duplicate detection are tested against
+// PostgreSQL itself.
+type MemoryRepository struct {
+	mu         sync.Mutex
+	seq        int
+	customers  map[uuid.UUID]*memoryCustomer
+	addresses  map[uuid.UUID]*memoryAddress
+	challenges map[memoryChallengeKey]*customer.ContactChallenge
+	panClaims  map[[2]uuid.UUID]bool
+	panAccess  []memoryPANAccess
+	now        func() time.Time
+}
+
+type memoryCustomer struct {
+	tenant     string
+	seq        int
+	c          customer.Customer
+	v          *customer.Verification
+	deleted    bool
+	mergedInto uuid.UUID
+}
+
+type memoryAddress struct {
+	tenant string
+	seq    int
+	a      customer.Address
+}
+
+type memoryPANAccess struct {
+	tenant string
+	customer.PANAccess
+}
+
+type memoryChallengeKey struct {
+	customer uuid.UUID
+	channel  customer.ContactChannel
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		customers:  make(map[uuid.UUID]*memoryCustomer),
+		addresses:  make(map[uuid.UUID]*memoryAddress),
+		challenges: make(map[memoryChallengeKey]*customer.ContactChallenge),
+		panClaims:  make(map[[2]uuid.UUID]bool),
+		now:        time.Now,
+	}
+}
+
+// PANAccesses returns the PAN access log of the tenant in ctx.
+func (r *MemoryRepository) PANAccesses(ctx context.Context) []customer.PANAccess {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []customer.PANAccess
+	for _, a := range r.panAccess {
+		if a.tenant == auth.TenantFromContext(ctx) {
+			out = append(out, a.PANAccess)
+		}
+	}
+	return out
+}
+
+// live returns the live customer id of the tenant in ctx, or nil.
+func (r *MemoryRepository) live(ctx context.Context, id uuid.UUID) *memoryCustomer {
+	m := r.customers[id]
+	if m == nil || m.deleted || m.tenant != auth.TenantFromContext(ctx) {
+		return nil
+	}
+	return m
+}
+
+// customer returns m as it is read back from PGRepository.
+func (m *memoryCustomer) customer() *customer.Customer {
+	c := m.c
+	c.Metadata = maps.Clone(c.Metadata)
+	c.Tags = slices.Clone(c.Tags)
+	if m.v != nil {
+		c.Status = string(m.v.Status)
+		if m.v.PANNumber != nil {
+			pan := *m.v.PANNumber
+			c.PANNumber = &pan
+		}
+	}
+	return &c
+}
+
+// taken reports whether a live customer of tenant other than except has
+// email or phone.
+func (r *MemoryRepository) taken(tenant, email, phone string, except uuid.UUID) bool {
+	for id, m := range r.customers {
+		if id == except || m.deleted || m.tenant != tenant {
+			continue
+		}
+		if fold(m.c.Email) == fold(email) || fold(m.c.Phone) == fold(phone) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryRepository) insert(ctx context.Context, c *customer.Customer) (*memoryCustomer, error) {
+	tenant := auth.TenantFromContext(ctx)
+	if r.taken(tenant, c.Email, c.Phone, uuid.Nil) {
+		return nil, customer.ErrConflict
+	}
+	if len(c.Tags) > customer.MaxTags {
+		return nil, customer.ErrTooManyTags
+	}
+	now := r.now()
+	r.seq++
+	m := &memoryCustomer{tenant: tenant, seq: r.seq, c: *c}
+	m.c.ID = uuid.New()
+	m.c.Email = strings.ToLower(c.Email)
+	m.c.Status, m.c.PANNumber = "", nil
+	m.c.CreatedAt, m.c.UpdatedAt = now, now
+	m.c.EmailVerifiedAt, m.c.PhoneVerifiedAt = nil, nil
+	m.c.Metadata, m.c.Tags = maps.Clone(c.Metadata), slices.Clone(c.Tags)
+	if m.c.Metadata == nil {
+		m.c.Metadata = map[string]any{}
+	}
+	if m.c.Tags == nil {
+		m.c.Tags = []string{}
+	}
+	m.v = &customer.Verification{ID: uuid.New(), CustomerID: m.c.ID, Status: customer.StatusPending, CreatedAt: now, UpdatedAt: now}
+	r.customers[m.c.ID] = m
+	return m, nil
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, c *customer.Customer) (*customer.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, err := r.insert(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return m.customer(), nil
+}
+
+func (r *MemoryRepository) CreateBatch(ctx context.Context, cs []*customer.Customer, atomic bool) ([]customer.BatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := make([]customer.BatchResult, len(cs))
+	var created []uuid.UUID
+	conflicts := 0
+	for i, c := range cs {
+		m, err := r.insert(ctx, c)
+		if err != nil {
+			results[i].Err = err
+			conflicts++
+			continue
+		}
+		created = append(created, m.c.ID)
+		results[i].Customer = m.customer()
+	}
+	if atomic && conflicts > 0 {
+		for _, id := range created {
+			delete(r.customers, id)
+		}
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = customer.BatchResult{Err: customer.ErrBatchAborted}
+			}
+		}
+	}
+	return results, nil
+}
+
+func (r *MemoryRepository) Get(ctx context.Context, id uuid.UUID) (*customer.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.live(ctx, id)
+	if m == nil {
+		return nil, customer.ErrNotFound
+	}
+	return m.customer(), nil
+}
+
+// matching returns the live customers of the tenant in ctx that match f,
+// oldest first.
+func (r *MemoryRepository) matching(ctx context.Context, f customer.Filter) []*memoryCustomer {
+	var out []*memoryCustomer
+	for _, m := range r.customers {
+		if r.live(ctx, m.c.ID) == nil || !matches(f, m) {
+			continue
+		}
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].seq < out[j].seq })
+	return out
+}
+
+// matches reports whether m satisfies f, as the WHERE clause of
+// PGRepository.List would.
+func matches(f customer.Filter, m *memoryCustomer) bool {
+	if f.Status != "" && (m.v == nil || m.v.Status != f.Status) {
+		return false
+	}
+	if !f.From.IsZero() && m.c.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !m.c.CreatedAt.Before(f.To) {
+		return false
+	}
+	for k, v := range f.Metadata {
+		if s, ok := m.c.Metadata[k].(string); !ok || s != v {
+			return false
+		}
+	}
+	return f.Tag == "" || slices.Contains(m.c.Tags, f.Tag)
+}
+
+func (r *MemoryRepository) List(ctx context.Context, f customer.Filter, offset, limit int) ([]customer.Customer, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	all := r.matching(ctx, f)
+	slices.Reverse(all)
+	var out []customer.Customer
+	for _, m := range all[min(offset, len(all)):min(offset+limit, len(all))] {
+		out = append(out, *m.customer())
+	}
+	return out, len(all), nil
+}
+
+// Export reads a snapshot taken when it starts, so fn may call back into the
+// repository.
+func (r *MemoryRepository) Export(ctx context.Context, f customer.Filter, fn func(*customer.Customer) error) error {
+	r.mu.Lock()
+	var snapshot []*customer.Customer
+	for _, m := range r.matching(ctx, f) {
+		snapshot = append(snapshot, m.customer())
+	}
+	r.mu.Unlock()
+	for _, c := range snapshot {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) Update(ctx context.Context, id uuid.UUID, upd customer.UpdateCustomer) (*customer.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.live(ctx, id)
+	if m == nil {
+		return nil, customer.ErrNotFound
+	}
+	c := *m.customer()
+	if upd.Name != nil {
+		c.Name = *upd.Name
+	}
+	if upd.Email != nil {
+		if email := strings.ToLower(*upd.Email); email != c.Email {
+			c.Email, c.EmailVerifiedAt = email, nil
+		}
+	}
+	if upd.Phone != nil && *upd.Phone != c.Phone {
+		c.Phone, c.PhoneVerifiedAt = *upd.Phone, nil
+	}
+	if r.taken(m.tenant, c.Email, c.Phone, id) {
+		return nil, customer.ErrConflict
+	}
+	c.Profile = upd.Profile.Apply(c.Profile)
+	if upd.Metadata.Set {
+		metadata := map[string]any{}
+		if upd.Metadata.Value != nil {
+			metadata = customer.MergePatch(c.Metadata, *upd.Metadata.Value)
+		}
+		if err := customer.ValidateMetadata(metadata); err != nil {
+			return nil, err
+		}
+		c.Metadata = metadata
+	}
+	return r.save(m, c), nil
+}
+
+// save stores c, as read from m, in m and returns it.
+func (r *MemoryRepository) save(m *memoryCustomer, c customer.Customer) *customer.Customer {
+	c.Status, c.PANNumber = "", nil
+	c.UpdatedAt = r.now()
+	m.c = c
+	return m.customer()
+}
+
+func (r *MemoryRepository) AddTags(ctx context.Context, id uuid.UUID, tags []string) (*customer.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.live(ctx, id)
+	if m == nil {
+		return nil, customer.ErrNotFound
+	}
+	c := *m.customer()
+	c.Tags = append(c.Tags, tags...)
+	slices.Sort(c.Tags)
+	c.Tags = slices.Compact(c.Tags)
+	if len(c.Tags) > customer.MaxTags {
+		return nil, customer.ErrTooManyTags
+	}
+	return r.save(m, c), nil
+}
+
+func (r *MemoryRepository) RemoveTag(ctx context.Context, id uuid.UUID, tag string) (*customer.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.live(ctx, id)
+	if m == nil {
+		return nil, customer.ErrNotFound
+	}
+	c := *m.customer()
+	c.Tags = slices.DeleteFunc(c.Tags, func(t string) bool { return t == tag })
+	return r.save(m, c), nil
+}
+
+func (r *MemoryRepository) SaveContactChallenge(ctx context.Context, ch *customer.ContactChallenge, limits customer.ContactLimits) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.live(ctx, ch.CustomerID) == nil {
+		return customer.ErrNotFound
+	}
+	key := memoryChallengeKey{ch.CustomerID, ch.Channel}
+	if err := ch.Replace(r.challenges[key], limits); err != nil {
+		return err
+	}
+	stored := *ch
+	r.challenges[key] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) ConfirmContact(ctx context.Context, id uuid.UUID, channel customer.ContactChannel, code string) (*customer.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.live(ctx, id)
+	if m == nil {
+		return nil, customer.ErrNotFound
+	}
+	key := memoryChallengeKey{id, channel}
+	ch := r.challenges[key]
+	if ch == nil {
+		return nil, customer.ErrCodeExpired
+	}
+	now := r.now()
+	if err := ch.Check(code == ch.Code, now); err != nil {
+		return nil, err
+	}
+	destination := m.c.Phone
+	if channel == customer.ContactEmail {
+		destination = m.c.Email
+	}
+	if fold(destination) != fold(ch.Destination) {
+		ch.ExpiresAt = now
+		return nil, customer.ErrCodeExpired
+	}
+	delete(r.challenges, key)
+	c := *m.customer()
+	if channel == customer.ContactEmail {
+		c.EmailVerifiedAt = &now
+	} else {
+		c.PhoneVerifiedAt = &now
+	}
+	return r.save(m, c), nil
+}
+
+func (r *MemoryRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.live(ctx, id)
+	if m == nil {
+		return customer.ErrNotFound
+	}
+	m.deleted = true
+	m.c.UpdatedAt = r.now()
+	return nil
+}
+
+// DuplicateCandidates pairs customers with the same name, ignoring case,
+// phones ending in the same ten digits, or one of whom was refused the other's
+// PAN. Names are either similar (1) or not (0); pg_trgm's fuzzier matching is
+// only available from PostgreSQL.
+func (r *MemoryRepository) DuplicateCandidates(ctx context.Context, limit int) ([]customer.DuplicateCandidate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	live := r.matching(ctx, customer.Filter{})
+	var out []customer.DuplicateCandidate
+	for _, a := range live {
+		for _, b := range live {
+			if bytes.Compare(a.c.ID[:], b.c.ID[:]) >= 0 {
+				continue
+			}
+			var sim float64
+			if fold(a.c.Name) == fold(b.c.Name) {
+				sim = 1
+			}
+			claim := r.panClaims[[2]uuid.UUID{a.c.ID, b.c.ID}]
+			if sim == 0 && lastDigits(a.c.Phone) != lastDigits(b.c.Phone) && !claim {
+				continue
+			}
+			out = append(out, customer.DuplicateCandidate{A: *a.customer(), B: *b.customer(), Signals: customer.DuplicateSignals{NameSimilarity: sim, SamePAN: claim}})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Signals.SamePAN != out[j].Signals.SamePAN {
+			return out[i].Signals.SamePAN
+		}
+		if out[i].Signals.NameSimilarity != out[j].Signals.NameSimilarity {
+			return out[i].Signals.NameSimilarity > out[j].Signals.NameSimilarity
+		}
+		if c := bytes.Compare(out[i].A.ID[:], out[j].A.ID[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(out[i].B.ID[:], out[j].B.ID[:]) < 0
+	})
+	return out[:min(limit, len(out))], nil
+}
+
+func lastDigits(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	return digits[max(len(digits)-10, 0):]
+}
+
+func (r *MemoryRepository) Merge(ctx context.Context, survivor, duplicate uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keep, merge := r.live(ctx, survivor), r.live(ctx, duplicate)
+	if keep == nil || merge == nil {
+		return false, customer.ErrNotFound
+	}
+	hasPAN := func(m *memoryCustomer) bool { return m.v != nil && m.v.PANNumber != nil }
+	if hasPAN(keep) && hasPAN(merge) {
+		return false, customer.ErrMergeConflict
+	}
+	now := r.now()
+	moved := hasPAN(merge)
+	if moved {
+		keep.v, merge.v = merge.v, nil
+		keep.v.CustomerID, keep.v.UpdatedAt = survivor, now
+	}
+	for _, m := range r.customers {
+		if m.tenant == keep.tenant && m.mergedInto == duplicate {
+			m.mergedInto = survivor
+		}
+	}
+	hasPrimary := false
+	for _, a := range r.addresses {
+		hasPrimary = hasPrimary || (a.tenant == keep.tenant && a.a.CustomerID == survivor && a.a.Primary)
+	}
+	for _, a := range r.addresses {
+		if a.tenant == keep.tenant && a.a.CustomerID == duplicate {
+			a.a.CustomerID, a.a.UpdatedAt = survivor, now
+			a.a.Primary = a.a.Primary && !hasPrimary
+		}
+	}
+	for key := range r.challenges {
+		if key.customer == duplicate {
+			delete(r.challenges, key)
+		}
+	}
+	merge.deleted, merge.mergedInto, merge.c.UpdatedAt = true, survivor, now
+	keep.c.UpdatedAt = now
+	return moved, nil
+}
+
+func (r *MemoryRepository) CreateVerification(ctx context.Context, v *customer.Verification) (*customer.Verification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.live(ctx, v.CustomerID)
+	if m == nil {
+		return nil, customer.ErrNotFound
+	}
+	if v.PANNumber != nil {
+		pan := fold(*v.PANNumber)
+		for id, other := range r.customers {
+			if id != v.CustomerID && other.tenant == m.tenant && other.v != nil && other.v.PANNumber != nil &&
+				fold(*other.v.PANNumber) == pan {
+				r.panClaims[orderedPair(v.CustomerID, id)] = true
+				return nil, customer.ErrPANAlreadyExists
+			}
+		}
+	}
+	now := r.now()
+	if m.v == nil {
+		m.v = &customer.Verification{ID: uuid.New(), CustomerID: v.CustomerID, CreatedAt: now}
+	}
+	m.v.PANNumber, m.v.Status, m.v.UpdatedAt = v.PANNumber, v.Status, now
+	out := *m.v
+	return &out, nil
+}
+
+func orderedPair(a, b uuid.UUID) [2]uuid.UUID {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return [2]uuid.UUID{a, b}
+}
+
+func (r *MemoryRepository) GetVerificationByCustomerID(ctx context.Context, cid uuid.UUID) (*customer.Verification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.customers[cid]
+	if m == nil || m.tenant != auth.TenantFromContext(ctx) || m.v == nil {
+		return nil, customer.ErrVerificationNotFound
+	}
+	out := *m.v
+	return &out, nil
+}
+
+func (r *MemoryRepository) UpdateVerificationStatus(ctx context.Context, cid uuid.UUID, status customer.VerificationStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m := r.customers[cid]; m != nil && m.tenant == auth.TenantFromContext(ctx) && m.v != nil {
+		m.v.Status, m.v.UpdatedAt = status, r.now()
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RecordPANAccess(ctx context.Context, a *customer.PANAccess) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a.ID, a.AccessedAt = uuid.New(), r.now()
+	r.panAccess = append(r.panAccess, memoryPANAccess{tenant: auth.TenantFromContext(ctx), PANAccess: *a})
+	return nil
+}
+
+// address returns the address id of the live customer customerID, or nil.
+func (r *MemoryRepository) address(ctx context.Context, customerID, id uuid.UUID) *memoryAddress {
+	a := r.addresses[id]
+	if a == nil || a.a.CustomerID != customerID || r.live(ctx, customerID) == nil {
+		return nil
+	}
+	return a
+}
+
+func (r *MemoryRepository) demotePrimary(customerID, keep uuid.UUID) {
+	for id, a := range r.addresses {
+		if a.a.CustomerID == customerID && a.a.Primary && id != keep {
+			a.a.Primary, a.a.UpdatedAt = false, r.now()
+		}
+	}
+}
+
+func (r *MemoryRepository) CreateAddress(ctx context.Context, a *customer.Address) (*customer.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.live(ctx, a.CustomerID)
+	if m == nil {
+		return nil, customer.ErrNotFound
+	}
+	now := r.now()
+	a.ID, a.CreatedAt, a.UpdatedAt = uuid.New(), now, now
+	if a.Primary {
+		r.demotePrimary(a.CustomerID, a.ID)
+	}
+	r.seq++
+	r.addresses[a.ID] = &memoryAddress{tenant: m.tenant, seq: r.seq, a: *a}
+	return a, nil
+}
+
+func (r *MemoryRepository) ListAddresses(ctx context.Context, customerID uuid.UUID) ([]customer.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.live(ctx, customerID) == nil {
+		return nil, customer.ErrNotFound
+	}
+	var list []*memoryAddress
+	for _, a := range r.addresses {
+		if a.a.CustomerID == customerID {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].a.Primary != list[j].a.Primary {
+			return list[i].a.Primary
+		}
+		return list[i].seq < list[j].seq
+	})
+	out := []customer.Address{}
+	for _, a := range list {
+		out = append(out, a.a)
+	}
+	return out, nil
+}
+
+func (r *MemoryRepository) GetAddress(ctx context.Context, customerID, id uuid.UUID) (*customer.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a := r.address(ctx, customerID, id)
+	if a == nil {
+		return nil, customer.ErrAddressNotFound
+	}
+	out := a.a
+	return &out, nil
+}
+
+func (r *MemoryRepository) UpdateAddress(ctx context.Context, customerID, id uuid.UUID, upd customer.UpdateAddress) (*customer.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a := r.address(ctx, customerID, id)
+	if a == nil {
+		return nil, customer.ErrAddressNotFound
+	}
+	if upd.Primary != nil && *upd.Primary {
+		r.demotePrimary(customerID, id)
+	}
+	a.a = upd.Apply(a.a)
+	a.a.UpdatedAt = r.now()
+	out := a.a
+	return &out, nil
+}
+
+func (r *MemoryRepository) DeleteAddress(ctx context.Context, customerID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.address(ctx, customerID, id) == nil {
+		return customer.ErrAddressNotFound
+	}
+	delete(r.addresses, id)
+	return nil
+}
+
+// fold normalizes s for the case-insensitive comparisons the unique indexes
+// make.
+func fold(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,69 @@
+package customertest
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/flags"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/notify"
+)
+
+// ContactLimits are the contact verification limits of services built by
+// NewService.
+var ContactLimits = customer.ContactLimits{TTL: 10 * time.Minute, MaxAttempts: 3, ResendInterval: time.Minute, MaxSends: 3}
+
+// NewService returns a service over repo whose contact verification codes
+// are delivered to the returned Notifier. A nil recorder disables auditing
+// and a nil features client leaves every flag off.
+func NewService(repo customer.Repository, rec audit.Recorder, features *flags.Client) (*customer.Service, *Notifier) {
+	if rec == nil {
+		rec = audit.Nop{}
+	}
+	n := &Notifier{}
+	svc := customer.NewService(repo, logger.NewNop(), rec, nil, nil, features, customer.ContactVerification{
+		Notifier:      n,
+		ContactLimits: ContactLimits,
+	})
+	return svc, n
+}
+
+var sentCode = regexp.MustCompile(`\b\d{6}\b`)
+
+// Notifier keeps the last message sent, so tests can read back one-time
+// codes.
+type Notifier struct {
+	mu   sync.Mutex
+	last notify.Message
+}
+
+func (n *Notifier) Send(_ context.Context, m notify.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.last = m
+	return nil
+}
+
+// Last returns the last message sent.
+func (n *Notifier) Last() notify.Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.last
+}
+
+// Code returns the one-time code in the last message sent, failing t if
+// there is none.
+func (n *Notifier) Code(t testing.TB) string {
+	t.Helper()
+	body := n.Last().Body
+	code := sentCode.FindString(body)
+	if code == "" {
+		t.Fatalf("no code in %q", body)
+	}
+	return code
+}
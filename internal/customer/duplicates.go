@@ -0,0 +1,134 @@
+package customer
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+var (
+	ErrMergeSelf     = errors.New("a customer cannot be merged into itself")
+	ErrMergeConflict = errors.New("conflict: both customers have a PAN")
+)
+
+// Weights of the duplicate signals. A score is their weighted sum, capped at
+// 1, so an exact name match with the same phone scores 0.8.
+const (
+	weightName  = 0.4
+	weightPhone = 0.4
+	weightEmail = 0.2
+	weightPAN   = 0.4
+)
+
+// DuplicateSignals are the similarities found between two customers.
+type DuplicateSignals struct {
+	// NameSimilarity is the trigram similarity of the names, 0 to 1.
+	NameSimilarity float64
+	// EmailSimilarity is the trigram similarity of the email local parts.
+	EmailSimilarity float64
+	// SamePhone is set when the phones are equal once normalised to E.164.
+	SamePhone bool
+	// SamePAN is set when both customers have the same PAN, or one was
+	// refused a PAN because the other holds it.
+	SamePAN bool
+}
+
+// DuplicateCandidate is a pair of customers that may be the same person.
+type DuplicateCandidate struct {
+	A, B    Customer
+	Score   float64
+	Signals DuplicateSignals
+}
+
+// Survivor suggests which customer of the pair to keep: the verified one,
+// else the one with a PAN, else the older one.
+func (d DuplicateCandidate) Survivor() (keep, merge Customer) {
+	rank := func(c Customer) int {
+		switch {
+		case c.Status == string(StatusVerified):
+			return 2
+		case c.PANNumber != nil:
+			return 1
+		}
+		return 0
+	}
+	a, b := rank(d.A), rank(d.B)
+	if a > b || (a == b && !d.B.CreatedAt.Before(d.A.CreatedAt)) {
+		return d.A, d.B
+	}
+	return d.B, d.A
+}
+
+// score fills in the signals other than NameSimilarity, which the
+// repository computes along with SamePAN for refused PANs, and the score. It reports false for pairs that
+// cannot be one person because they have different PANs.
+func (d *DuplicateCandidate) score() bool {
+	if d.A.PANNumber != nil && d.B.PANNumber != nil {
+		if *d.A.PANNumber != *d.B.PANNumber {
+			return false
+		}
+		d.Signals.SamePAN = true
+	}
+	d.Signals.SamePhone = normalizePhone(d.A.Phone) == normalizePhone(d.B.Phone)
+	localA, _, _ := strings.Cut(strings.ToLower(d.A.Email), "@")
+	localB, _, _ := strings.Cut(strings.ToLower(d.B.Email), "@")
+	d.Signals.EmailSimilarity = trigramSimilarity(localA, localB)
+
+	score := weightName*d.Signals.NameSimilarity + weightEmail*d.Signals.EmailSimilarity
+	if d.Signals.SamePhone {
+		score += weightPhone
+	}
+	if d.Signals.SamePAN {
+		score += weightPAN
+	}
+	d.Score = min(score, 1)
+	return true
+}
+
+// normalizePhone returns phone in E.164, or its digits when it does not
+// parse.
+func normalizePhone(phone string) string {
+	num, err := phonenumbers.Parse(phone, "IN")
+	if err != nil {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, phone)
+	}
+	return phonenumbers.Format(num, phonenumbers.E164)
+}
+
+// trigramSimilarity mirrors pg_trgm's similarity(): the share of distinct
+// trigrams two strings have in common, where each lower-cased alphanumeric
+// word is padded with two spaces in front and one behind.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]struct{} {
+	out := map[string]struct{}{}
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		padded := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			out[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package customer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Archiit19/customer-service-go/internal/pii"
+)
+
+func TestPhoneDigitsIndex(t *testing.T) {
+	keys, err := pii.NewKeyring(map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}, "k1", bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	r := &PGRepository{keys: keys}
+	a, b := r.phoneDigitsIndex("+91 98765 43210"), r.phoneDigitsIndex("9876543210")
+	if a == nil || b == nil || *a != *b {
+		t.Fatalf("same last ten digits indexed as %v and %v", a, b)
+	}
+	if c := r.phoneDigitsIndex("+91 98765 43211"); *c == *a {
+		t.Fatal("different phones share an index")
+	}
+	if *a == keys.BlindIndex(pii.DomainPhone, "9876543210") {
+		t.Fatal("digits index equals the phone blind index")
+	}
+	if (&PGRepository{}).phoneDigitsIndex("9876543210") != nil {
+		t.Fatal("index written without a keyring")
+	}
+}
+
+func TestScorePANClaim(t *testing.T) {
+	pan := "ABCDE1234F"
+	claim := DuplicateCandidate{
+		A:       Customer{Name: "Asha Rao", Email: "asha@example.com", Phone: "+919876543210", PANNumber: &pan},
+		B:       Customer{Name: "Asha Rao", Email: "rao.asha@example.org", Phone: "+919812345678"},
+		Signals: DuplicateSignals{NameSimilarity: 1, SamePAN: true},
+	}
+	if !claim.score() {
+		t.Fatal("pair with a PAN claim dropped")
+	}
+	if !claim.Signals.SamePAN || claim.Score < weightName+weightPAN {
+		t.Fatalf("signals %+v score %.2f, want SamePAN and at least %.2f", claim.Signals, claim.Score, weightName+weightPAN)
+	}
+
+	other := "ZYXWV9876K"
+	claim.B.PANNumber = &other
+	if claim.score() {
+		t.Fatal("pair with different PANs kept")
+	}
+}
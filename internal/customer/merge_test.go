@@ -0,0 +1,41 @@
+package customer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/customer/customertest"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// TestMergeDropsDuplicateContactCodes needs TEST_DATABASE_URL.
+func TestMergeDropsDuplicateContactCodes(t *testing.T) {
+	pool := customertest.Postgres(t)
+	svc, _ := customertest.NewService(customer.NewPGRepository(pool, logger.NewNop(), nil, false), nil, nil)
+	ctx := auth.WithTenant(context.Background(), "tenant-a")
+	survivor, err := svc.Create(ctx, &customer.Customer{Name: "Asha Rao", Email: "asha@example.com", Phone: "+919876543210"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicate, err := svc.Create(ctx, &customer.Customer{Name: "Asha R", Email: "asha.rao@example.org", Phone: "+919876543211"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, channel := range []customer.ContactChannel{customer.ContactEmail, customer.ContactPhone} {
+		if _, err := svc.StartContactVerification(ctx, duplicate.ID, channel); err != nil {
+			t.Fatalf("start %s verification: %v", channel, err)
+		}
+	}
+	if _, err := svc.Merge(ctx, survivor.ID, duplicate.ID); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	var codes int
+	if err := pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM contact_verifications WHERE customer_id = $1;", duplicate.ID).Scan(&codes); err != nil {
+		t.Fatal(err)
+	}
+	if codes != 0 {
+		t.Errorf("%d contact codes of the merged duplicate remain", codes)
+	}
+}
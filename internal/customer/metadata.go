@@ -0,0 +1,104 @@
+package customer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Limits on the custom attributes of a customer.
+const (
+	MaxMetadataKeys  = 50
+	MaxMetadataBytes = 16 << 10
+	MaxTags          = 50
+)
+
+var (
+	ErrInvalidMetadata = errors.New("invalid metadata: expected an object of at most 50 keys of 1 to 64 letters, digits, '_' or '-', and at most 16 KiB of JSON")
+	ErrInvalidTag      = errors.New("invalid tag: expected 1 to 50 lower-case letters, digits, '_', '-' or ':', starting with a letter or digit")
+	ErrTooManyTags     = errors.New("conflict: a customer has at most 50 tags")
+)
+
+var (
+	metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	tagPattern         = regexp.MustCompile(`^[a-z0-9][a-z0-9_:-]{0,49}$`)
+)
+
+// IsValidMetadataKey reports whether key may name a top-level metadata
+// attribute. Keys contain no dots, so a list filter such as
+// metadata.segment=gold is unambiguous.
+func IsValidMetadataKey(key string) bool {
+	return metadataKeyPattern.MatchString(key)
+}
+
+// ValidateMetadata checks the limits on a customer's metadata. Nested values
+// are free-form.
+func ValidateMetadata(m map[string]any) error {
+	if len(m) > MaxMetadataKeys {
+		return ErrInvalidMetadata
+	}
+	for k := range m {
+		if !IsValidMetadataKey(k) {
+			return ErrInvalidMetadata
+		}
+	}
+	b, err := json.Marshal(m)
+	if err != nil || len(b) > MaxMetadataBytes {
+		return ErrInvalidMetadata
+	}
+	return nil
+}
+
+// MergePatch applies an RFC 7386 JSON merge patch to target and returns the
+// result, leaving target unchanged: null removes a key, an object is merged
+// into the object under the same key and any other value replaces it.
+func MergePatch(target, patch map[string]any) map[string]any {
+	out := make(map[string]any, len(target)+len(patch))
+	for k, v := range target {
+		out[k] = v
+	}
+	for k, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(out, k)
+		case map[string]any:
+			current, _ := out[k].(map[string]any)
+			out[k] = MergePatch(current, v)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// decodeMetadata decodes stored metadata, keeping numbers exact.
+func decodeMetadata(b []byte) (map[string]any, error) {
+	m := map[string]any{}
+	if len(b) == 0 {
+		return m, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NormalizeTags trims and lower-cases tags, drops duplicates and sorts them,
+// or returns ErrInvalidTag.
+func NormalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !tagPattern.MatchString(t) {
+			return nil, ErrInvalidTag
+		}
+		out = append(out, t)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
@@ -0,0 +1,50 @@
+package customer
+
+import (
+	"github.com/Archiit19/customer-service-go/internal/metrics"
+)
+
+// statusNone labels transitions out of a customer that had no verification.
+const statusNone = "NONE"
+
+// Outcomes of contact verification codes.
+const (
+	contactOutcomeSent        = "sent"
+	contactOutcomeUndelivered = "undelivered"
+	contactOutcomeThrottled   = "throttled"
+	contactOutcomeVerified    = "verified"
+	contactOutcomeRejected    = "rejected"
+)
+
+type serviceMetrics struct {
+	customersCreated        metrics.Counter
+	verificationTransitions metrics.Counter
+	contactCodes            metrics.Counter
+}
+
+func newServiceMetrics(reg metrics.Registry) serviceMetrics {
+	return serviceMetrics{
+		customersCreated: reg.Counter(
+			"customer_service_customers_created_total",
+			"Customers successfully created.",
+		),
+		verificationTransitions: reg.Counter(
+			"customer_service_verification_transitions_total",
+			"Verification status changes by previous and new status.",
+			"from", "to",
+		),
+		contactCodes: reg.Counter(
+			"customer_service_contact_codes_total",
+			"Contact verification codes sent, undelivered, throttled, confirmed or rejected, by channel.",
+			"channel", "outcome",
+		),
+	}
+}
+
+func (m serviceMetrics) transition(from, to VerificationStatus) {
+	f := string(from)
+	if f == "" {
+		f = statusNone
+	}
+	m.verificationTransitions.Inc(f, string(to))
+}
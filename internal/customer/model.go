@@ -3,6 +3,7 @@ package customer
 import (
 	"errors"
 	"net/mail"
+	"regexp"
 	"strings"
 	"time"
 
@@ -19,6 +20,14 @@ type Customer struct {
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Profile
+	// Metadata holds attributes set by API consumers; Tags are sorted.
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Tags     []string       `json:"tags,omitempty"`
+	// EmailVerifiedAt and PhoneVerifiedAt are set when the customer proves
+	// they own the contact detail and cleared when it changes.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
 }
 
 var (
@@ -68,6 +77,20 @@ func (c *Customer) ValidateForUpdate() error {
 	return nil
 }
 
+// isIndianPhone reports whether phone is a valid number assigned to India.
+func isIndianPhone(phone string) bool {
+	num, err := phonenumbers.Parse(phone, "IN")
+	return err == nil && phonenumbers.IsValidNumber(num) && phonenumbers.GetRegionCodeForNumber(num) == "IN"
+}
+
+var panPattern = regexp.MustCompile(`^[A-Z]{5}[0-9]{4}[A-Z]$`)
+
+// IsValidPAN reports whether pan has the PAN format: five letters, four
+// digits and a check letter.
+func IsValidPAN(pan string) bool {
+	return panPattern.MatchString(pan)
+}
+
 type VerificationStatus string
 
 const (
@@ -95,3 +118,70 @@ type Verification struct {
 	CreatedAt  time.Time          `json:"created_at"`
 	UpdatedAt  time.Time          `json:"updated_at"`
 }
+
+// MaskPAN hides the holder-type and surname characters of a PAN, e.g.
+// "ABCDE1234X" becomes "ABCXX1234X". Values that are not 10 characters long
+// are masked entirely.
+func MaskPAN(pan string) string {
+	if len(pan) != 10 {
+		return strings.Repeat("X", len(pan))
+	}
+	return pan[:3] + "XX" + pan[5:]
+}
+
+// MaskEmail keeps the first character of the local part and the domain, e.g.
+// "jane@example.com" becomes "j***@example.com".
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return strings.Repeat("*", len(email))
+	}
+	return local[:1] + "***@" + domain
+}
+
+// MaskPhone keeps only the last four characters of a phone number.
+func MaskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return strings.Repeat("*", len(phone))
+	}
+	return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+}
+
+// Masked returns a copy of the customer with the PAN masked.
+func (c Customer) Masked() Customer {
+	c.PANNumber = maskPANPtr(c.PANNumber)
+	return c
+}
+
+// Masked returns a copy of the verification with the PAN masked.
+func (v Verification) Masked() Verification {
+	v.PANNumber = maskPANPtr(v.PANNumber)
+	return v
+}
+
+func maskPANPtr(pan *string) *string {
+	if pan == nil {
+		return nil
+	}
+	masked := MaskPAN(*pan)
+	return &masked
+}
+
+// PAN access outcomes recorded in the access log.
+const (
+	PANAccessGranted  = "GRANTED"
+	PANAccessDenied   = "DENIED"
+	PANAccessNotFound = "NOT_FOUND"
+)
+
+// PANAccess is one attempt to reveal a customer's full PAN.
+type PANAccess struct {
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	Principal  string
+	RequestID  string
+	ClientIP   string
+	Reason     string
+	Outcome    string
+	AccessedAt time.Time
+}
@@ -0,0 +1,164 @@
+package customer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Archiit19/customer-service-go/internal/pii"
+	"github.com/google/uuid"
+)
+
+// sealedValue is the at-rest representation of one PII attribute: at most one
+// of plain and enc is set, and bidx is the blind index used for uniqueness and
+// exact-match lookups.
+type sealedValue struct {
+	plain *string
+	enc   *string
+	bidx  *string
+}
+
+// normalizePII canonicalises a value before it is indexed, so the blind index
+// follows the same equality rules the plaintext unique indexes did.
+func normalizePII(domain, value string) string {
+	switch domain {
+	case pii.DomainEmail:
+		return strings.ToLower(strings.TrimSpace(value))
+	case pii.DomainPAN:
+		return strings.ToUpper(strings.TrimSpace(value))
+	default:
+		return strings.TrimSpace(value)
+	}
+}
+
+// seal prepares value for storage. Without a keyring values are stored in
+// plaintext as before; with one, the blind index is always written and the
+// value is encrypted when encrypt is true.
+func (r *PGRepository) seal(domain, value string, encrypt bool) (sealedValue, error) {
+	if r.keys == nil {
+		return sealedValue{plain: &value}, nil
+	}
+	bidx := r.keys.BlindIndex(domain, normalizePII(domain, value))
+	if !encrypt {
+		return sealedValue{plain: &value, bidx: &bidx}, nil
+	}
+	enc, err := r.keys.Encrypt(domain, value)
+	if err != nil {
+		return sealedValue{}, fmt.Errorf("encrypt %s: %w", domain, err)
+	}
+	return sealedValue{enc: &enc, bidx: &bidx}, nil
+}
+
+// phoneDigitsIndex returns the blind index duplicate detection pairs
+// encrypted phones by, or nil without a keyring. It covers the last ten
+// digits, like the expression index used for plaintext phones.
+func (r *PGRepository) phoneDigitsIndex(phone string) *string {
+	if r.keys == nil {
+		return nil
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	bidx := r.keys.BlindIndex(pii.DomainPhoneDigits, digits[max(len(digits)-10, 0):])
+	return &bidx
+}
+
+// open returns the plaintext of a stored attribute, preferring the encrypted
+// column and falling back to legacy plaintext rows that have not been
+// re-encrypted yet.
+func (r *PGRepository) open(domain string, plain, enc *string) (*string, error) {
+	if enc == nil {
+		return plain, nil
+	}
+	if r.keys == nil {
+		return nil, fmt.Errorf("decrypt %s: no PII keyring configured", domain)
+	}
+	value, err := r.keys.Decrypt(domain, *enc)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", domain, err)
+	}
+	return &value, nil
+}
+
+// contactDigest hashes a verification code, or the contact it was sent to,
+// for storage. It is keyed with the PII index key, so the stored digests
+// cannot be brute-forced without it, and salted with the challenge ID.
+// Without a keyring a key generated at startup is used, so pending codes do
+// not survive a restart or carry over between replicas.
+func (r *PGRepository) contactDigest(challengeID uuid.UUID, value string) string {
+	salted := challengeID.String() + ":" + value
+	if r.keys != nil {
+		return r.keys.BlindIndex(pii.DomainContactCode, salted)
+	}
+	mac := hmac.New(sha256.New, r.codeKey)
+	mac.Write([]byte(salted))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// matchesDigest reports in constant time whether value hashes to digest.
+func (r *PGRepository) matchesDigest(challengeID uuid.UUID, value, digest string) bool {
+	return hmac.Equal([]byte(r.contactDigest(challengeID, value)), []byte(digest))
+}
+
+// customerColumns are the columns scanned by customerRow.scanTargets, from
+// customers c joined with their verification records v.
+const customerColumns = `c.id, c.name, c.email, c.email_enc, c.phone, c.phone_enc,
+       v.pan_number, v.pan_number_enc, v.status,
+       c.created_at, c.updated_at,
+       c.date_of_birth, c.gender, c.nationality, c.occupation, c.preferred_language,
+       c.metadata, c.tags, c.email_verified_at, c.phone_verified_at`
+
+// customerRow mirrors the columns selected for a customer joined with its
+// verification record.
+type customerRow struct {
+	c        Customer
+	email    *string
+	emailEnc *string
+	phone    *string
+	phoneEnc *string
+	pan      *string
+	panEnc   *string
+	metadata []byte
+}
+
+func (row *customerRow) scanTargets() []any {
+	return []any{
+		&row.c.ID, &row.c.Name, &row.email, &row.emailEnc, &row.phone, &row.phoneEnc,
+		&row.pan, &row.panEnc, &row.c.Status,
+		&row.c.CreatedAt, &row.c.UpdatedAt,
+		&row.c.DateOfBirth, &row.c.Gender, &row.c.Nationality, &row.c.Occupation, &row.c.PreferredLanguage,
+		&row.metadata, &row.c.Tags, &row.c.EmailVerifiedAt, &row.c.PhoneVerifiedAt,
+	}
+}
+
+func (r *PGRepository) decodeCustomer(row *customerRow) (*Customer, error) {
+	email, err := r.open(pii.DomainEmail, row.email, row.emailEnc)
+	if err != nil {
+		return nil, err
+	}
+	phone, err := r.open(pii.DomainPhone, row.phone, row.phoneEnc)
+	if err != nil {
+		return nil, err
+	}
+	pan, err := r.open(pii.DomainPAN, row.pan, row.panEnc)
+	if err != nil {
+		return nil, err
+	}
+	c := row.c
+	if email != nil {
+		c.Email = *email
+	}
+	if phone != nil {
+		c.Phone = *phone
+	}
+	c.PANNumber = pan
+	if c.Metadata, err = decodeMetadata(row.metadata); err != nil {
+		return nil, fmt.Errorf("decode metadata: %w", err)
+	}
+	return &c, nil
+}
@@ -0,0 +1,224 @@
+package customer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// Gender is a customer's self-declared gender.
+type Gender string
+
+const (
+	GenderFemale      Gender = "female"
+	GenderMale        Gender = "male"
+	GenderOther       Gender = "other"
+	GenderUndisclosed Gender = "undisclosed"
+)
+
+// IsValidGender returns true only for known Gender values.
+func IsValidGender(g Gender) bool {
+	switch g {
+	case GenderFemale, GenderMale, GenderOther, GenderUndisclosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// MinAge is the age in years a customer must have reached to be onboarded.
+const MinAge = 18
+
+var (
+	ErrInvalidDateOfBirth = errors.New("invalid date_of_birth: expected a YYYY-MM-DD date after 1900-01-01")
+	ErrUnderage           = errors.New("invalid date_of_birth: customers must be at least 18 years old")
+	ErrInvalidGender      = errors.New("invalid gender: expected female, male, other or undisclosed")
+	ErrInvalidNationality = errors.New("invalid nationality: expected an ISO 3166-1 alpha-2 country code")
+	ErrInvalidOccupation  = errors.New("invalid occupation: expected 1 to 100 characters")
+	ErrInvalidLanguage    = errors.New("invalid preferred_language: expected a language tag such as en or hi-IN")
+)
+
+// IsInvalidProfile reports whether err is a profile validation error.
+func IsInvalidProfile(err error) bool {
+	for _, target := range []error{
+		ErrInvalidDateOfBirth, ErrUnderage, ErrInvalidGender, ErrInvalidNationality, ErrInvalidOccupation, ErrInvalidLanguage,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Profile holds the optional onboarding details of a customer. Nil fields
+// were not provided.
+type Profile struct {
+	DateOfBirth       *time.Time `json:"date_of_birth,omitempty"`
+	Gender            *Gender    `json:"gender,omitempty"`
+	Nationality       *string    `json:"nationality,omitempty"`
+	Occupation        *string    `json:"occupation,omitempty"`
+	PreferredLanguage *string    `json:"preferred_language,omitempty"`
+}
+
+// dateLayout is the format of dates of birth in requests and responses.
+const dateLayout = "2006-01-02"
+
+var earliestDateOfBirth = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ParseDateOfBirth parses a YYYY-MM-DD date.
+func ParseDateOfBirth(s string) (time.Time, error) {
+	t, err := time.Parse(dateLayout, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, ErrInvalidDateOfBirth
+	}
+	return t, nil
+}
+
+// FormatDate formats a date of birth as YYYY-MM-DD.
+func FormatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+// Normalize trims the fields of p, upper-cases the nationality and writes
+// the language subtag in lower case and the region in upper case, so "EN-in"
+// becomes "en-IN".
+func (p *Profile) Normalize() {
+	trim := func(v *string, fn func(string) string) *string {
+		if v == nil {
+			return nil
+		}
+		s := fn(strings.TrimSpace(*v))
+		return &s
+	}
+	keep := func(s string) string { return s }
+	p.Nationality = trim(p.Nationality, strings.ToUpper)
+	p.Occupation = trim(p.Occupation, keep)
+	p.PreferredLanguage = trim(p.PreferredLanguage, func(s string) string {
+		lang, region, ok := strings.Cut(s, "-")
+		if !ok {
+			return strings.ToLower(lang)
+		}
+		return strings.ToLower(lang) + "-" + strings.ToUpper(region)
+	})
+	if p.Gender != nil {
+		g := Gender(strings.ToLower(strings.TrimSpace(string(*p.Gender))))
+		p.Gender = &g
+	}
+}
+
+// Validate checks a normalized profile, using now for the minimum age.
+// Nationality is checked against the regions libphonenumber knows, which
+// cover the ISO 3166-1 countries.
+func (p *Profile) Validate(now time.Time) error {
+	if dob := p.DateOfBirth; dob != nil {
+		if dob.Before(earliestDateOfBirth) || dob.After(now) {
+			return ErrInvalidDateOfBirth
+		}
+		if age(*dob, now) < MinAge {
+			return ErrUnderage
+		}
+	}
+	if p.Gender != nil && !IsValidGender(*p.Gender) {
+		return ErrInvalidGender
+	}
+	if n := p.Nationality; n != nil && (len(*n) != 2 || !phonenumbers.GetSupportedRegions()[*n]) {
+		return ErrInvalidNationality
+	}
+	if o := p.Occupation; o != nil && (*o == "" || utf8.RuneCountInString(*o) > 100) {
+		return ErrInvalidOccupation
+	}
+	if l := p.PreferredLanguage; l != nil && !languagePattern.MatchString(*l) {
+		return ErrInvalidLanguage
+	}
+	return nil
+}
+
+// age returns the number of whole years from dob to now, by calendar date.
+func age(dob, now time.Time) int {
+	now = now.UTC()
+	years := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || now.Month() == dob.Month() && now.Day() < dob.Day() {
+		years--
+	}
+	return years
+}
+
+// Nullable is a change of an optional field in a partial update. Set reports
+// whether the field was given at all; a nil Value clears it. Decoded from
+// JSON, an absent key leaves Set false and null sets it with a nil Value.
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called for keys
+// present in the input.
+func (n *Nullable[T]) UnmarshalJSON(b []byte) error {
+	n.Set = true
+	n.Value = nil
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	// Numbers are kept exact in values decoded into interfaces, such as
+	// metadata.
+	var v T
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	n.Value = &v
+	return nil
+}
+
+// apply returns v changed by n.
+func (n Nullable[T]) apply(v *T) *T {
+	if n.Set {
+		return n.Value
+	}
+	return v
+}
+
+// UpdateProfile changes the profile fields that are Set.
+type UpdateProfile struct {
+	DateOfBirth       Nullable[time.Time]
+	Gender            Nullable[Gender]
+	Nationality       Nullable[string]
+	Occupation        Nullable[string]
+	PreferredLanguage Nullable[string]
+}
+
+// Apply returns p with the changes in upd.
+func (upd UpdateProfile) Apply(p Profile) Profile {
+	p.DateOfBirth = upd.DateOfBirth.apply(p.DateOfBirth)
+	p.Gender = upd.Gender.apply(p.Gender)
+	p.Nationality = upd.Nationality.apply(p.Nationality)
+	p.Occupation = upd.Occupation.apply(p.Occupation)
+	p.PreferredLanguage = upd.PreferredLanguage.apply(p.PreferredLanguage)
+	return p
+}
+
+// normalized returns upd with the values it sets normalized like
+// Profile.Normalize.
+func (upd UpdateProfile) normalized() UpdateProfile {
+	p := Profile{
+		Gender:            upd.Gender.Value,
+		Nationality:       upd.Nationality.Value,
+		Occupation:        upd.Occupation.Value,
+		PreferredLanguage: upd.PreferredLanguage.Value,
+	}
+	p.Normalize()
+	upd.Gender.Value = p.Gender
+	upd.Nationality.Value = p.Nationality
+	upd.Occupation.Value = p.Occupation
+	upd.PreferredLanguage.Value = p.PreferredLanguage
+	return upd
+}
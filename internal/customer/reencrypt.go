@@ -0,0 +1,203 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/pii"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ReencryptStats summarises a ReencryptPII run.
+type ReencryptStats struct {
+	CustomersScanned     int
+	CustomersUpdated     int
+	VerificationsScanned int
+	VerificationsUpdated int
+}
+
+// ReencryptPII brings every stored PII value in line with the current
+// configuration: legacy plaintext is encrypted, values sealed with a retired
+// key are re-sealed with the active key, and missing blind indexes are
+// backfilled. Soft-deleted rows are included. Rows are processed in batches of
+// batchSize ordered by ID so the run can be interrupted and restarted safely.
+func (r *PGRepository) ReencryptPII(ctx context.Context, batchSize int, dryRun bool) (ReencryptStats, error) {
+	var stats ReencryptStats
+	if r.keys == nil {
+		return stats, errors.New("reencrypt: no PII keyring configured")
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	if err := r.reencryptCustomers(ctx, batchSize, dryRun, &stats); err != nil {
+		return stats, err
+	}
+	if err := r.reencryptVerifications(ctx, batchSize, dryRun, &stats); err != nil {
+		return stats, err
+	}
+	r.logger.Info(ctx, "pii re-encryption finished", logger.String("active_key", r.keys.ActiveKeyID()), logger.Bool("dry_run", dryRun), logger.Int("customers_scanned", stats.CustomersScanned), logger.Int("customers_updated", stats.CustomersUpdated), logger.Int("verifications_scanned", stats.VerificationsScanned), logger.Int("verifications_updated", stats.VerificationsUpdated))
+	return stats, nil
+}
+
+func (r *PGRepository) reencryptCustomers(ctx context.Context, batchSize int, dryRun bool, stats *ReencryptStats) error {
+	last := uuid.Nil
+	for {
+		rows, err := r.pool.Query(ctx, `
+SELECT id, email, email_enc, email_bidx, phone, phone_enc, phone_bidx, phone_digits_bidx
+FROM customers
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
+`, last, batchSize)
+		if err != nil {
+			return fmt.Errorf("select customers: %w", err)
+		}
+		type customerPII struct {
+			id              uuid.UUID
+			email, emailEnc *string
+			emailBidx       *string
+			phone, phoneEnc *string
+			phoneBidx       *string
+			phoneDigitsBidx *string
+		}
+		batch, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (customerPII, error) {
+			var c customerPII
+			err := row.Scan(&c.id, &c.email, &c.emailEnc, &c.emailBidx, &c.phone, &c.phoneEnc, &c.phoneBidx, &c.phoneDigitsBidx)
+			return c, err
+		})
+		if err != nil {
+			return fmt.Errorf("scan customers: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		for _, c := range batch {
+			stats.CustomersScanned++
+			email, emailChanged, err := r.reseal(pii.DomainEmail, c.email, c.emailEnc, c.emailBidx, r.encryptContacts)
+			if err != nil {
+				return fmt.Errorf("customer %s: %w", c.id, err)
+			}
+			phone, phoneChanged, err := r.reseal(pii.DomainPhone, c.phone, c.phoneEnc, c.phoneBidx, r.encryptContacts)
+			if err != nil {
+				return fmt.Errorf("customer %s: %w", c.id, err)
+			}
+			// Rows written before migration 0021 lack the digits index.
+			plainPhone, err := r.open(pii.DomainPhone, c.phone, c.phoneEnc)
+			if err != nil {
+				return fmt.Errorf("customer %s: %w", c.id, err)
+			}
+			digitsIndex := c.phoneDigitsBidx
+			if plainPhone != nil {
+				if want := r.phoneDigitsIndex(*plainPhone); digitsIndex == nil || *digitsIndex != *want {
+					digitsIndex, phoneChanged = want, true
+				}
+			}
+			if !emailChanged && !phoneChanged {
+				continue
+			}
+			stats.CustomersUpdated++
+			if dryRun {
+				continue
+			}
+			_, err = r.pool.Exec(ctx, `
+UPDATE customers
+SET email = $2, email_enc = $3, email_bidx = $4,
+    phone = $5, phone_enc = $6, phone_bidx = $7, phone_digits_bidx = $8
+WHERE id = $1;
+`, c.id, email.plain, email.enc, email.bidx, phone.plain, phone.enc, phone.bidx, digitsIndex)
+			if err != nil {
+				return fmt.Errorf("update customer %s: %w", c.id, err)
+			}
+		}
+		last = batch[len(batch)-1].id
+		r.logger.Info(ctx, "pii re-encryption customer batch done", logger.Int("scanned", stats.CustomersScanned), logger.Int("updated", stats.CustomersUpdated))
+	}
+}
+
+func (r *PGRepository) reencryptVerifications(ctx context.Context, batchSize int, dryRun bool, stats *ReencryptStats) error {
+	last := uuid.Nil
+	for {
+		rows, err := r.pool.Query(ctx, `
+SELECT id, pan_number, pan_number_enc, pan_number_bidx
+FROM verifications
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
+`, last, batchSize)
+		if err != nil {
+			return fmt.Errorf("select verifications: %w", err)
+		}
+		type verificationPII struct {
+			id          uuid.UUID
+			pan, panEnc *string
+			panBidx     *string
+		}
+		batch, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (verificationPII, error) {
+			var v verificationPII
+			err := row.Scan(&v.id, &v.pan, &v.panEnc, &v.panBidx)
+			return v, err
+		})
+		if err != nil {
+			return fmt.Errorf("scan verifications: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		for _, v := range batch {
+			stats.VerificationsScanned++
+			pan, changed, err := r.reseal(pii.DomainPAN, v.pan, v.panEnc, v.panBidx, true)
+			if err != nil {
+				return fmt.Errorf("verification %s: %w", v.id, err)
+			}
+			if !changed {
+				continue
+			}
+			stats.VerificationsUpdated++
+			if dryRun {
+				continue
+			}
+			_, err = r.pool.Exec(ctx, `
+UPDATE verifications
+SET pan_number = $2, pan_number_enc = $3, pan_number_bidx = $4
+WHERE id = $1;
+`, v.id, pan.plain, pan.enc, pan.bidx)
+			if err != nil {
+				return fmt.Errorf("update verification %s: %w", v.id, err)
+			}
+		}
+		last = batch[len(batch)-1].id
+		r.logger.Info(ctx, "pii re-encryption verification batch done", logger.Int("scanned", stats.VerificationsScanned), logger.Int("updated", stats.VerificationsUpdated))
+	}
+}
+
+// reseal decides whether a stored value needs rewriting and returns its new
+// sealed form. NULL values stay NULL.
+func (r *PGRepository) reseal(domain string, plain, enc, bidx *string, encrypt bool) (sealedValue, bool, error) {
+	value, err := r.open(domain, plain, enc)
+	if err != nil {
+		return sealedValue{}, false, err
+	}
+	if value == nil {
+		return sealedValue{}, false, nil
+	}
+	wantIndex := r.keys.BlindIndex(domain, normalizePII(domain, *value))
+	changed := bidx == nil || *bidx != wantIndex
+	switch {
+	case encrypt && enc == nil, !encrypt && enc != nil:
+		changed = true
+	case encrypt:
+		id, err := r.keys.KeyID(*enc)
+		if err != nil {
+			return sealedValue{}, false, err
+		}
+		changed = changed || id != r.keys.ActiveKeyID()
+	}
+	if !changed {
+		return sealedValue{plain: plain, enc: enc, bidx: bidx}, false, nil
+	}
+	sealed, err := r.seal(domain, *value, encrypt)
+	return sealed, true, err
+}
@@ -2,11 +2,15 @@ package customer
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/Archiit19/customer-service-go/internal/auth"
 	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/pii"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -18,35 +22,159 @@ var (
 	ErrConflict             = errors.New("conflict: email or phone already exists")
 	ErrVerificationNotFound = errors.New("verification not found")
 	ErrPANAlreadyExists     = errors.New("PAN already exists")
+	ErrForbidden            = errors.New("forbidden")
+	ErrInvalidPAN           = errors.New("a well-formed PAN is required before verification")
 )
 
 type Repository interface {
 	// Customer operations
 	Create(ctx context.Context, c *Customer) (*Customer, error)
+	// CreateBatch creates customers in one transaction and returns one
+	// result per input, in order.
+	CreateBatch(ctx context.Context, cs []*Customer, atomic bool) ([]BatchResult, error)
 	Get(ctx context.Context, id uuid.UUID) (*Customer, error)
-	List(ctx context.Context, offset, limit int) ([]Customer, int, error)
+	List(ctx context.Context, f Filter, offset, limit int) ([]Customer, int, error)
+	// Export streams every customer matching f to fn, oldest first, without
+	// holding the result set in memory. Iteration stops at the first error
+	// fn returns.
+	Export(ctx context.Context, f Filter, fn func(*Customer) error) error
 	Update(ctx context.Context, id uuid.UUID, upd UpdateCustomer) (*Customer, error)
+	// AddTags and RemoveTag change a customer's tags in place and return
+	// the customer.
+	AddTags(ctx context.Context, id uuid.UUID, tags []string) (*Customer, error)
+	RemoveTag(ctx context.Context, id uuid.UUID, tag string) (*Customer, error)
+	// SaveContactChallenge stores a code sent to a live customer, replacing
+	// any pending one for the same channel, or returns ErrResendTooSoon or
+	// ErrTooManyCodes when limits do not allow sending it.
+	SaveContactChallenge(ctx context.Context, ch *ContactChallenge, limits ContactLimits) error
+	// ConfirmContact checks code against the pending challenge of the
+	// customer's channel and, if it answers it, marks the contact verified
+	// and returns the customer. Incorrect codes count against the challenge.
+	ConfirmContact(ctx context.Context, id uuid.UUID, channel ContactChannel, code string) (*Customer, error)
 	SoftDelete(ctx context.Context, id uuid.UUID) error
+	// DuplicateCandidates returns up to limit pairs of customers whose names
+	// are similar, whose phone digits match or one of whom was refused the
+	// other's PAN. Pairs with a refused PAN come first, then the most similar
+	// names. NameSimilarity is set, and SamePAN for refused PANs.
+	DuplicateCandidates(ctx context.Context, limit int) ([]DuplicateCandidate, error)
+	// Merge soft-deletes duplicate with a reference to survivor and moves
+	// its verification to survivor when only duplicate has a PAN. It reports
+	// whether the verification moved.
+	Merge(ctx context.Context, survivor, duplicate uuid.UUID) (bool, error)
+
+	// Address operations, on addresses of the caller's live customers.
+	// CreateAddress and UpdateAddress demote the customer's current primary
+	// address when making another one primary.
+	CreateAddress(ctx context.Context, a *Address) (*Address, error)
+	ListAddresses(ctx context.Context, customerID uuid.UUID) ([]Address, error)
+	GetAddress(ctx context.Context, customerID, id uuid.UUID) (*Address, error)
+	UpdateAddress(ctx context.Context, customerID, id uuid.UUID, upd UpdateAddress) (*Address, error)
+	DeleteAddress(ctx context.Context, customerID, id uuid.UUID) error
 
 	// Verification operations
 	CreateVerification(ctx context.Context, v *Verification) (*Verification, error)
 	GetVerificationByCustomerID(ctx context.Context, cid uuid.UUID) (*Verification, error)
 	UpdateVerificationStatus(ctx context.Context, cid uuid.UUID, status VerificationStatus) error
+	RecordPANAccess(ctx context.Context, a *PANAccess) error
 }
 
 type PGRepository struct {
-	pool   *pgxpool.Pool
-	logger logger.Logger
+	pool            *pgxpool.Pool
+	logger          logger.Logger
+	keys            *pii.Keyring
+	encryptContacts bool
+	// codeKey keys contact code digests when there is no keyring.
+	codeKey []byte
+}
+
+// NewPGRepository creates a repository. With a nil keyring PII is stored in
+// plaintext; otherwise PAN is always encrypted and email/phone are encrypted
+// when encryptContacts is set. Every query is scoped to the tenant in its
+// context, so records of other tenants behave as if they did not exist.
+func NewPGRepository(pool *pgxpool.Pool, log logger.Logger, keys *pii.Keyring, encryptContacts bool) *PGRepository {
+	codeKey := make([]byte, 32)
+	_, _ = rand.Read(codeKey) // never fails; see crypto/rand.Read
+	return &PGRepository{pool: pool, logger: log, keys: keys, encryptContacts: encryptContacts, codeKey: codeKey}
 }
 
-func NewPGRepository(pool *pgxpool.Pool, log logger.Logger) *PGRepository {
-	return &PGRepository{pool: pool, logger: log}
+// Filter narrows a customer listing or export. Zero values are ignored.
+type Filter struct {
+	// Status matches the verification status.
+	Status VerificationStatus
+	// From and To bound the creation time; To is exclusive.
+	From time.Time
+	To   time.Time
+	// Metadata matches customers whose metadata has each key set to the
+	// given string.
+	Metadata map[string]string
+	// Tag matches customers with the tag.
+	Tag string
+}
+
+// where returns the conditions selecting the caller's live customers that
+// match f, with args holding their parameters. It expects customers aliased
+// as c and verifications as v.
+func (f Filter) where(ctx context.Context) (cond string, args []any) {
+	args = []any{auth.TenantFromContext(ctx)}
+	conds := []string{"c.tenant_id = $1", "c.deleted_at IS NULL"}
+	add := func(expr string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(expr, len(args)))
+	}
+	if f.Status != "" {
+		add("v.status = $%d", string(f.Status))
+	}
+	if !f.From.IsZero() {
+		add("c.created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("c.created_at < $%d", f.To)
+	}
+	if len(f.Metadata) > 0 {
+		add("c.metadata @> $%d::jsonb", f.Metadata)
+	}
+	if f.Tag != "" {
+		add("c.tags @> ARRAY[$%d::text]", f.Tag)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// metadata describes f for audit entries.
+func (f Filter) metadata() map[string]any {
+	m := map[string]any{}
+	if f.Status != "" {
+		m["status"] = string(f.Status)
+	}
+	if !f.From.IsZero() {
+		m["from"] = f.From.Format(time.RFC3339)
+	}
+	if !f.To.IsZero() {
+		m["to"] = f.To.Format(time.RFC3339)
+	}
+	for k, v := range f.Metadata {
+		m["metadata."+k] = v
+	}
+	if f.Tag != "" {
+		m["tag"] = f.Tag
+	}
+	return m
 }
 
 type UpdateCustomer struct {
-	Name  *string
-	Email *string
-	Phone *string
+	Name    *string
+	Email   *string
+	Phone   *string
+	Profile UpdateProfile
+	// Metadata is a JSON merge patch applied to the stored metadata; a nil
+	// Value removes every key.
+	Metadata Nullable[map[string]any]
+}
+
+// isCheckViolation reports whether err violates the check constraint named
+// constraint.
+func isCheckViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23514" && pgErr.ConstraintName == constraint
 }
 
 // isUniqueViolation Checks for unique constraint violation
@@ -60,89 +188,222 @@ func isUniqueViolation(err error) bool {
 
 // Create a new customer
 func (r *PGRepository) Create(ctx context.Context, c *Customer) (*Customer, error) {
-	r.logger.Info(ctx, "creating customer", logger.String("email", strings.ToLower(c.Email)), logger.String("phone", c.Phone))
+	r.logger.Info(ctx, "creating customer", logger.Email("email", strings.ToLower(c.Email)), logger.Phone("phone", c.Phone))
 	c.ID = uuid.New()
+	email, err := r.seal(pii.DomainEmail, strings.ToLower(c.Email), r.encryptContacts)
+	if err != nil {
+		r.logger.Error(ctx, "customer email sealing failed", logger.Err(err))
+		return nil, err
+	}
+	phone, err := r.seal(pii.DomainPhone, c.Phone, r.encryptContacts)
+	if err != nil {
+		r.logger.Error(ctx, "customer phone sealing failed", logger.Err(err))
+		return nil, err
+	}
+	metadata, tags := c.insertedMetadata()
+	tenant := auth.TenantFromContext(ctx)
 	q := `
-INSERT INTO customers (id, name, email, phone)
-VALUES ($1, $2, $3, $4)
-RETURNING id, name, email, phone, created_at, updated_at;
+INSERT INTO customers (id, tenant_id, name, email, email_enc, email_bidx, phone, phone_enc, phone_bidx, phone_digits_bidx,
+                       date_of_birth, gender, nationality, occupation, preferred_language, metadata, tags)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+RETURNING id, name, created_at, updated_at;
 `
-	row := r.pool.QueryRow(ctx, q, c.ID, c.Name, strings.ToLower(c.Email), c.Phone)
-	var out Customer
-	if err := row.Scan(&out.ID, &out.Name, &out.Email, &out.Phone, &out.CreatedAt, &out.UpdatedAt); err != nil {
+	row := r.pool.QueryRow(ctx, q, c.ID, tenant, c.Name, email.plain, email.enc, email.bidx, phone.plain, phone.enc, phone.bidx, r.phoneDigitsIndex(c.Phone),
+		c.DateOfBirth, c.Gender, c.Nationality, c.Occupation, c.PreferredLanguage, metadata, tags)
+	out := Customer{Email: strings.ToLower(c.Email), Phone: c.Phone, Profile: c.Profile, Metadata: metadata, Tags: tags}
+	if err := row.Scan(&out.ID, &out.Name, &out.CreatedAt, &out.UpdatedAt); err != nil {
 		if isUniqueViolation(err) {
-			r.logger.Warn(ctx, "customer create conflict", logger.Err(err), logger.String("email", strings.ToLower(c.Email)), logger.String("phone", c.Phone))
+			r.logger.Warn(ctx, "customer create conflict", logger.Err(err), logger.Email("email", strings.ToLower(c.Email)), logger.Phone("phone", c.Phone))
 			return nil, ErrConflict
 		}
+		if isCheckViolation(err, "ck_customers_tags") {
+			r.logger.Warn(ctx, "customer create tag limit reached")
+			return nil, ErrTooManyTags
+		}
 		r.logger.Error(ctx, "customer create query failed", logger.Err(err))
 		return nil, err
 	}
 
 	// create corresponding verification record
-	_, err := r.pool.Exec(ctx,
-		`INSERT INTO verifications (customer_id, status, pan_number) VALUES ($1, 'Pending', NULL);`,
-		out.ID,
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO verifications (customer_id, tenant_id, status, pan_number) VALUES ($1, $2, $3, NULL);`,
+		out.ID, tenant, string(StatusPending),
 	)
 	if err != nil {
 		r.logger.Error(ctx, "verification bootstrap failed", logger.Err(err), logger.String("customer_id", out.ID.String()))
 		return nil, fmt.Errorf("failed to create verification: %w", err)
 	}
 
-	out.Status = "Pending"
+	out.Status = string(StatusPending)
 	out.PANNumber = nil
 	r.logger.Info(ctx, "customer created", logger.String("customer_id", out.ID.String()))
 	return &out, nil
 }
 
+// insertedMetadata returns the metadata and tags to insert for c. Both
+// columns are NOT NULL, which a nil map or slice would be sent as.
+func (c *Customer) insertedMetadata() (map[string]any, []string) {
+	metadata, tags := c.Metadata, c.Tags
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	return metadata, tags
+}
+
+// batchChunk is the number of inserts CreateBatch sends per round trip.
+const batchChunk = 500
+
+// CreateBatch inserts customers and their verification records in one
+// transaction. Rows whose email or phone is already taken, including by an
+// earlier row of the batch, get ErrConflict. With atomic set any conflict
+// rolls the whole batch back and the remaining rows get ErrBatchAborted.
+func (r *PGRepository) CreateBatch(ctx context.Context, cs []*Customer, atomic bool) (_ []BatchResult, err error) {
+	tenant := auth.TenantFromContext(ctx)
+	r.logger.Info(ctx, "creating customer batch", logger.Int("rows", len(cs)), logger.Bool("atomic", atomic))
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error(ctx, "customer batch begin failed", logger.Err(err))
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+		}
+	}()
+
+	// ON CONFLICT DO NOTHING turns a duplicate into an empty result instead
+	// of an error, so one row cannot abort the transaction for the others.
+	const q = `
+INSERT INTO customers (id, tenant_id, name, email, email_enc, email_bidx, phone, phone_enc, phone_bidx, phone_digits_bidx,
+                       date_of_birth, gender, nationality, occupation, preferred_language, metadata, tags)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+ON CONFLICT DO NOTHING
+RETURNING id, name, created_at, updated_at;
+`
+	results := make([]BatchResult, len(cs))
+	conflicts := 0
+	for start := 0; start < len(cs); start += batchChunk {
+		end := min(start+batchChunk, len(cs))
+		batch := &pgx.Batch{}
+		for _, c := range cs[start:end] {
+			email, err := r.seal(pii.DomainEmail, strings.ToLower(c.Email), r.encryptContacts)
+			if err != nil {
+				r.logger.Error(ctx, "customer email sealing failed", logger.Err(err))
+				return nil, err
+			}
+			phone, err := r.seal(pii.DomainPhone, c.Phone, r.encryptContacts)
+			if err != nil {
+				r.logger.Error(ctx, "customer phone sealing failed", logger.Err(err))
+				return nil, err
+			}
+			metadata, tags := c.insertedMetadata()
+			batch.Queue(q, uuid.New(), tenant, c.Name, email.plain, email.enc, email.bidx, phone.plain, phone.enc, phone.bidx, r.phoneDigitsIndex(c.Phone),
+				c.DateOfBirth, c.Gender, c.Nationality, c.Occupation, c.PreferredLanguage, metadata, tags)
+		}
+		br := tx.SendBatch(ctx, batch)
+		for i := start; i < end; i++ {
+			out := Customer{Email: strings.ToLower(cs[i].Email), Phone: cs[i].Phone, Status: string(StatusPending), Profile: cs[i].Profile}
+			out.Metadata, out.Tags = cs[i].insertedMetadata()
+			err := br.QueryRow().Scan(&out.ID, &out.Name, &out.CreatedAt, &out.UpdatedAt)
+			switch {
+			case errors.Is(err, pgx.ErrNoRows):
+				results[i].Err = ErrConflict
+				conflicts++
+			case err != nil:
+				_ = br.Close()
+				r.logger.Error(ctx, "customer batch insert failed", logger.Err(err), logger.Int("row", i))
+				return nil, err
+			default:
+				results[i].Customer = &out
+			}
+		}
+		if err := br.Close(); err != nil {
+			r.logger.Error(ctx, "customer batch insert failed", logger.Err(err))
+			return nil, err
+		}
+	}
+
+	if atomic && conflicts > 0 {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = BatchResult{Err: ErrBatchAborted}
+			}
+		}
+		if err := tx.Rollback(context.WithoutCancel(ctx)); err != nil {
+			r.logger.Error(ctx, "customer batch rollback failed", logger.Err(err))
+			return nil, err
+		}
+		r.logger.Warn(ctx, "customer batch rolled back", logger.Int("conflicts", conflicts))
+		return results, nil
+	}
+
+	rows := make([][]any, 0, len(cs)-conflicts)
+	for _, res := range results {
+		if res.Customer != nil {
+			rows = append(rows, []any{res.Customer.ID, tenant, string(StatusPending)})
+		}
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"verifications"}, []string{"customer_id", "tenant_id", "status"}, pgx.CopyFromRows(rows)); err != nil {
+		r.logger.Error(ctx, "verification batch bootstrap failed", logger.Err(err))
+		return nil, fmt.Errorf("failed to create verifications: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error(ctx, "customer batch commit failed", logger.Err(err))
+		return nil, err
+	}
+	r.logger.Info(ctx, "customer batch created", logger.Int("created", len(rows)), logger.Int("conflicts", conflicts))
+	return results, nil
+}
+
 // Get customer by ID
 func (r *PGRepository) Get(ctx context.Context, id uuid.UUID) (*Customer, error) {
 	q := `
-SELECT c.id, c.name, c.email, c.phone,
-       v.pan_number, v.status,
-       c.created_at, c.updated_at
+SELECT ` + customerColumns + `
 FROM customers c
 LEFT JOIN verifications v ON v.customer_id = c.id
-WHERE c.id = $1 AND c.deleted_at IS NULL;
+WHERE c.id = $1 AND c.tenant_id = $2 AND c.deleted_at IS NULL;
 `
-	var c Customer
-	err := r.pool.QueryRow(ctx, q, id).Scan(
-		&c.ID, &c.Name, &c.Email, &c.Phone,
-		&c.PANNumber, &c.Status,
-		&c.CreatedAt, &c.UpdatedAt,
-	)
+	var row customerRow
+	err := r.pool.QueryRow(ctx, q, id, auth.TenantFromContext(ctx)).Scan(row.scanTargets()...)
 	if errors.Is(err, pgx.ErrNoRows) {
 		r.logger.Warn(ctx, "customer not found", logger.String("customer_id", id.String()))
 		return nil, ErrNotFound
 	}
 	if err != nil {
 		r.logger.Error(ctx, "customer query failed", logger.Err(err), logger.String("customer_id", id.String()))
+		return nil, err
 	}
-	if err == nil {
-		r.logger.Debug(ctx, "customer fetched", logger.String("customer_id", c.ID.String()))
+	c, err := r.decodeCustomer(&row)
+	if err != nil {
+		r.logger.Error(ctx, "customer decode failed", logger.Err(err), logger.String("customer_id", id.String()))
+		return nil, err
 	}
-	return &c, err
+	r.logger.Debug(ctx, "customer fetched", logger.String("customer_id", c.ID.String()))
+	return c, nil
 }
 
 // List customers with pagination
-func (r *PGRepository) List(ctx context.Context, offset, limit int) ([]Customer, int, error) {
-	countSQL := `SELECT COUNT(*) FROM customers WHERE deleted_at IS NULL;`
+func (r *PGRepository) List(ctx context.Context, f Filter, offset, limit int) ([]Customer, int, error) {
+	cond, args := f.where(ctx)
+	countSQL := `SELECT COUNT(*) FROM customers c LEFT JOIN verifications v ON v.customer_id = c.id WHERE ` + cond + `;`
 	var total int
-	if err := r.pool.QueryRow(ctx, countSQL).Scan(&total); err != nil {
+	if err := r.pool.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
 		r.logger.Error(ctx, "customer count query failed", logger.Err(err))
 		return nil, 0, err
 	}
 
-	q := `
-SELECT c.id, c.name, c.email, c.phone,
-       v.pan_number, v.status,
-       c.created_at, c.updated_at
+	q := fmt.Sprintf(`
+SELECT `+customerColumns+`
 FROM customers c
 LEFT JOIN verifications v ON v.customer_id = c.id
-WHERE c.deleted_at IS NULL
+WHERE %s
 ORDER BY c.created_at DESC
-LIMIT $1 OFFSET $2;
-`
-	rows, err := r.pool.Query(ctx, q, limit, offset)
+LIMIT $%d OFFSET $%d;
+`, cond, len(args)+1, len(args)+2)
+	rows, err := r.pool.Query(ctx, q, append(args, limit, offset)...)
 	if err != nil {
 		r.logger.Error(ctx, "customer list query failed", logger.Err(err), logger.Int("limit", limit), logger.Int("offset", offset))
 		return nil, 0, err
@@ -151,22 +412,101 @@ LIMIT $1 OFFSET $2;
 
 	var res []Customer
 	for rows.Next() {
-		var c Customer
-		if err := rows.Scan(
-			&c.ID, &c.Name, &c.Email, &c.Phone,
-			&c.PANNumber, &c.Status,
-			&c.CreatedAt, &c.UpdatedAt,
-		); err != nil {
+		var row customerRow
+		if err := rows.Scan(row.scanTargets()...); err != nil {
 			r.logger.Error(ctx, "customer row scan failed", logger.Err(err))
 			return nil, 0, err
 		}
-		res = append(res, c)
+		c, err := r.decodeCustomer(&row)
+		if err != nil {
+			r.logger.Error(ctx, "customer decode failed", logger.Err(err), logger.String("customer_id", row.c.ID.String()))
+			return nil, 0, err
+		}
+		res = append(res, *c)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error(ctx, "customer rows iteration failed", logger.Err(err))
+		return nil, 0, err
 	}
 	r.logger.Info(ctx, "customers listed", logger.Int("count", len(res)), logger.Int("limit", limit), logger.Int("offset", offset), logger.Int("total", total))
 	return res, total, nil
 }
 
-// Update customer details
+// exportFetchSize is the number of rows fetched from the export cursor per
+// round trip.
+const exportFetchSize = 1000
+
+// Export reads through a server-side cursor inside a read-only REPEATABLE
+// READ transaction, so the extract is a consistent snapshot and at most
+// exportFetchSize rows are held in memory at a time.
+func (r *PGRepository) Export(ctx context.Context, f Filter, fn func(*Customer) error) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		r.logger.Error(ctx, "customer export begin failed", logger.Err(err))
+		return err
+	}
+	defer func() {
+		// Read-only, so rolling back is also how a complete export ends.
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Warn(ctx, "customer export rollback failed", logger.Err(rbErr))
+		}
+	}()
+
+	cond, args := f.where(ctx)
+	q := `
+DECLARE customer_export NO SCROLL CURSOR FOR
+SELECT ` + customerColumns + `
+FROM customers c
+LEFT JOIN verifications v ON v.customer_id = c.id
+WHERE ` + cond + `
+ORDER BY c.created_at, c.id;
+`
+	if _, err := tx.Exec(ctx, q, args...); err != nil {
+		r.logger.Error(ctx, "customer export declare failed", logger.Err(err))
+		return err
+	}
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM customer_export;", exportFetchSize)
+	count := 0
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			r.logger.Error(ctx, "customer export fetch failed", logger.Err(err))
+			return err
+		}
+		fetched := 0
+		for rows.Next() {
+			fetched++
+			var row customerRow
+			if err := rows.Scan(row.scanTargets()...); err != nil {
+				rows.Close()
+				r.logger.Error(ctx, "customer row scan failed", logger.Err(err))
+				return err
+			}
+			c, err := r.decodeCustomer(&row)
+			if err != nil {
+				rows.Close()
+				r.logger.Error(ctx, "customer decode failed", logger.Err(err), logger.String("customer_id", row.c.ID.String()))
+				return err
+			}
+			if err := fn(c); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			r.logger.Error(ctx, "customer export rows iteration failed", logger.Err(err))
+			return err
+		}
+		count += fetched
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+	r.logger.Info(ctx, "customers exported", logger.Int("count", count))
+	return nil
+}
+
 func (r *PGRepository) Update(ctx context.Context, id uuid.UUID, upd UpdateCustomer) (*Customer, error) {
 	setParts := []string{}
 	args := []any{}
@@ -178,32 +518,138 @@ func (r *PGRepository) Update(ctx context.Context, id uuid.UUID, upd UpdateCusto
 		argi++
 	}
 	if upd.Email != nil {
-		setParts = append(setParts, fmt.Sprintf("email = $%d", argi))
-		args = append(args, strings.ToLower(*upd.Email))
-		argi++
+		email, err := r.seal(pii.DomainEmail, strings.ToLower(*upd.Email), r.encryptContacts)
+		if err != nil {
+			r.logger.Error(ctx, "customer email sealing failed", logger.Err(err), logger.String("customer_id", id.String()))
+			return nil, err
+		}
+		setParts = append(setParts, fmt.Sprintf("email = $%d, email_enc = $%d, email_bidx = $%d", argi, argi+1, argi+2),
+			verifiedReset("email", argi, argi+2))
+		args = append(args, email.plain, email.enc, email.bidx)
+		argi += 3
 	}
 	if upd.Phone != nil {
-		setParts = append(setParts, fmt.Sprintf("phone = $%d", argi))
-		args = append(args, *upd.Phone)
-		argi++
+		phone, err := r.seal(pii.DomainPhone, *upd.Phone, r.encryptContacts)
+		if err != nil {
+			r.logger.Error(ctx, "customer phone sealing failed", logger.Err(err), logger.String("customer_id", id.String()))
+			return nil, err
+		}
+		setParts = append(setParts, fmt.Sprintf("phone = $%d, phone_enc = $%d, phone_bidx = $%d, phone_digits_bidx = $%d", argi, argi+1, argi+2, argi+3),
+			verifiedReset("phone", argi, argi+2))
+		args = append(args, phone.plain, phone.enc, phone.bidx, r.phoneDigitsIndex(*upd.Phone))
+		argi += 4
+	}
+	for _, f := range []struct {
+		column string
+		set    bool
+		value  any
+	}{
+		{"date_of_birth", upd.Profile.DateOfBirth.Set, upd.Profile.DateOfBirth.Value},
+		{"gender", upd.Profile.Gender.Set, upd.Profile.Gender.Value},
+		{"nationality", upd.Profile.Nationality.Set, upd.Profile.Nationality.Value},
+		{"occupation", upd.Profile.Occupation.Set, upd.Profile.Occupation.Value},
+		{"preferred_language", upd.Profile.PreferredLanguage.Set, upd.Profile.PreferredLanguage.Value},
+	} {
+		// A nil value clears the column.
+		if f.set {
+			setParts = append(setParts, fmt.Sprintf("%s = $%d", f.column, argi))
+			args = append(args, f.value)
+			argi++
+		}
 	}
 	setParts = append(setParts, "updated_at = now()")
 
-	if len(setParts) == 0 {
-		r.logger.Info(ctx, "customer update skipped", logger.String("customer_id", id.String()))
-		return r.Get(ctx, id) // nothing to update
+	if !upd.Metadata.Set {
+		return r.updateReturning(ctx, r.pool, id, setParts, args)
+	}
+
+	// The patch is applied to the stored metadata with the row locked, so
+	// concurrent patches of different keys do not overwrite each other.
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error(ctx, "customer update begin failed", logger.Err(err), logger.String("customer_id", id.String()))
+		return nil, err
 	}
+	defer func() {
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Warn(ctx, "customer update rollback failed", logger.Err(rbErr), logger.String("customer_id", id.String()))
+		}
+	}()
+	var stored []byte
+	err = tx.QueryRow(ctx,
+		`SELECT metadata FROM customers WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE;`,
+		id, auth.TenantFromContext(ctx),
+	).Scan(&stored)
+	if errors.Is(err, pgx.ErrNoRows) {
+		r.logger.Warn(ctx, "customer update target missing", logger.String("customer_id", id.String()))
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		r.logger.Error(ctx, "customer metadata lock failed", logger.Err(err), logger.String("customer_id", id.String()))
+		return nil, err
+	}
+	metadata := map[string]any{}
+	if upd.Metadata.Value != nil {
+		current, err := decodeMetadata(stored)
+		if err != nil {
+			r.logger.Error(ctx, "customer metadata decode failed", logger.Err(err), logger.String("customer_id", id.String()))
+			return nil, err
+		}
+		metadata = MergePatch(current, *upd.Metadata.Value)
+	}
+	if err := ValidateMetadata(metadata); err != nil {
+		r.logger.Warn(ctx, "customer metadata rejected", logger.Err(err), logger.String("customer_id", id.String()))
+		return nil, err
+	}
+	setParts = append(setParts, fmt.Sprintf("metadata = $%d", len(args)+1))
+	args = append(args, metadata)
+	out, err := r.updateReturning(ctx, tx, id, setParts, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error(ctx, "customer update commit failed", logger.Err(err), logger.String("customer_id", id.String()))
+		return nil, err
+	}
+	return out, nil
+}
 
+// verifiedReset returns the assignment keeping the verification time of a
+// contact column only if the update leaves it unchanged, given the
+// parameters of its new plaintext and blind index. Comparing both covers
+// plaintext rows, whose blind index is NULL without a keyring, and encrypted
+// ones, whose plaintext is NULL.
+func verifiedReset(column string, plainArg, bidxArg int) string {
+	return fmt.Sprintf("%[1]s_verified_at = CASE WHEN %[1]s IS NOT DISTINCT FROM $%[2]d AND %[1]s_bidx IS NOT DISTINCT FROM $%[3]d THEN %[1]s_verified_at END",
+		column, plainArg, bidxArg)
+}
+
+// rowQuerier is implemented by the pool and by transactions.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// updateReturning applies setParts, whose parameters are args, to a live
+// customer and returns it as Get would.
+func (r *PGRepository) updateReturning(ctx context.Context, db rowQuerier, id uuid.UUID, setParts []string, args []any) (*Customer, error) {
+	// Joined with the verification so the result has the same shape as Get.
 	q := fmt.Sprintf(`
-UPDATE customers
+WITH c AS (
+	UPDATE customers
 		SET %s
-		WHERE id = $%d AND deleted_at IS NULL
-		RETURNING id, name, email, phone, created_at, updated_at;
-	`, strings.Join(setParts, ", "), argi)
-	args = append(args, id)
+		WHERE id = $%d AND tenant_id = $%d AND deleted_at IS NULL
+		RETURNING id, name, email, email_enc, phone, phone_enc, created_at, updated_at,
+			date_of_birth, gender, nationality, occupation, preferred_language, metadata, tags,
+			email_verified_at, phone_verified_at
+)
+SELECT `+customerColumns+`
+FROM c
+LEFT JOIN verifications v ON v.customer_id = c.id;
+	`, strings.Join(setParts, ", "), len(args)+1, len(args)+2)
+	args = append(args, id, auth.TenantFromContext(ctx))
 
-	var out Customer
-	err := r.pool.QueryRow(ctx, q, args...).Scan(&out.ID, &out.Name, &out.Email, &out.Phone, &out.CreatedAt, &out.UpdatedAt)
+	var row customerRow
+	err := db.QueryRow(ctx, q, args...).Scan(row.scanTargets()...)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			r.logger.Warn(ctx, "customer update target missing", logger.String("customer_id", id.String()))
@@ -213,11 +659,198 @@ UPDATE customers
 			r.logger.Warn(ctx, "customer update conflict", logger.Err(err), logger.String("customer_id", id.String()))
 			return nil, ErrConflict
 		}
+		if isCheckViolation(err, "ck_customers_tags") {
+			r.logger.Warn(ctx, "customer update tag limit reached", logger.String("customer_id", id.String()))
+			return nil, ErrTooManyTags
+		}
 		r.logger.Error(ctx, "customer update failed", logger.Err(err), logger.String("customer_id", id.String()))
 		return nil, err
 	}
+	out, err := r.decodeCustomer(&row)
+	if err != nil {
+		r.logger.Error(ctx, "customer decode failed", logger.Err(err), logger.String("customer_id", id.String()))
+		return nil, err
+	}
 	r.logger.Info(ctx, "customer updated", logger.String("customer_id", out.ID.String()))
-	return &out, nil
+	return out, nil
+}
+
+// AddTags adds tags to a customer's tags in place.
+func (r *PGRepository) AddTags(ctx context.Context, id uuid.UUID, tags []string) (*Customer, error) {
+	return r.updateReturning(ctx, r.pool, id, []string{
+		"tags = ARRAY(SELECT DISTINCT t FROM unnest(tags || $1::text[]) AS t ORDER BY t)",
+		"updated_at = now()",
+	}, []any{tags})
+}
+
+// RemoveTag removes tag from a customer's tags; removing a tag the customer
+// does not have is not an error.
+func (r *PGRepository) RemoveTag(ctx context.Context, id uuid.UUID, tag string) (*Customer, error) {
+	return r.updateReturning(ctx, r.pool, id, []string{
+		"tags = array_remove(tags, $1::text)",
+		"updated_at = now()",
+	}, []any{tag})
+}
+
+// SaveContactChallenge upserts the pending challenge of a live customer and
+// channel. The customer stays locked from reading the challenge it replaces
+// until the new one is stored, so concurrent sends are all counted.
+func (r *PGRepository) SaveContactChallenge(ctx context.Context, ch *ContactChallenge, limits ContactLimits) error {
+	fields := []logger.Field{logger.String("customer_id", ch.CustomerID.String()), logger.String("channel", string(ch.Channel))}
+	tenant := auth.TenantFromContext(ctx)
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error(ctx, "contact challenge save begin failed", append(fields, logger.Err(err))...)
+		return err
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Warn(ctx, "contact challenge save rollback failed", logger.Err(rbErr))
+		}
+	}()
+	if err := lockCustomer(ctx, tx, ch.CustomerID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			r.logger.Warn(ctx, "contact challenge customer not found", fields...)
+		} else {
+			r.logger.Error(ctx, "contact challenge customer lock failed", append(fields, logger.Err(err))...)
+		}
+		return err
+	}
+	prev := &ContactChallenge{}
+	err = tx.QueryRow(ctx, `
+SELECT attempts, sends, window_start, sent_at
+FROM contact_verifications
+WHERE customer_id = $1 AND channel = $2 AND tenant_id = $3;
+`, ch.CustomerID, string(ch.Channel), tenant).Scan(&prev.Attempts, &prev.Sends, &prev.WindowStart, &prev.SentAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		prev = nil
+	} else if err != nil {
+		r.logger.Error(ctx, "contact challenge query failed", append(fields, logger.Err(err))...)
+		return err
+	}
+	if err := ch.Replace(prev, limits); err != nil {
+		r.logger.Warn(ctx, "contact challenge throttled", append(fields, logger.Err(err))...)
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+INSERT INTO contact_verifications (id, tenant_id, customer_id, channel, code_hash, destination_hash,
+	attempts, max_attempts, sends, window_start, sent_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (customer_id, channel) DO UPDATE
+	SET id = EXCLUDED.id, code_hash = EXCLUDED.code_hash, destination_hash = EXCLUDED.destination_hash,
+		attempts = EXCLUDED.attempts, max_attempts = EXCLUDED.max_attempts, sends = EXCLUDED.sends,
+		window_start = EXCLUDED.window_start, sent_at = EXCLUDED.sent_at, expires_at = EXCLUDED.expires_at;
+`, ch.ID, tenant, ch.CustomerID, string(ch.Channel),
+		r.contactDigest(ch.ID, ch.Code), r.contactDigest(ch.ID, ch.Destination),
+		ch.Attempts, ch.MaxAttempts, ch.Sends, ch.WindowStart, ch.SentAt, ch.ExpiresAt)
+	if err != nil {
+		r.logger.Error(ctx, "contact challenge save failed", append(fields, logger.Err(err))...)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error(ctx, "contact challenge save commit failed", append(fields, logger.Err(err))...)
+		return err
+	}
+	r.logger.Info(ctx, "contact challenge saved", append(fields, logger.Int("sends", ch.Sends))...)
+	return nil
+}
+
+// ConfirmContact answers the pending challenge with the customer and the
+// challenge locked, so concurrent guesses are counted and a contact changed
+// since the code was sent is not marked verified. A challenge is deleted once
+// answered; otherwise it is kept, so its counts carry over to the next code
+// sent within its window.
+func (r *PGRepository) ConfirmContact(ctx context.Context, id uuid.UUID, channel ContactChannel, code string) (*Customer, error) {
+	fields := []logger.Field{logger.String("customer_id", id.String()), logger.String("channel", string(channel))}
+	tenant := auth.TenantFromContext(ctx)
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error(ctx, "contact confirm begin failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Warn(ctx, "contact confirm rollback failed", logger.Err(rbErr))
+		}
+	}()
+	// The customer is locked before the challenge, in the order
+	// SaveContactChallenge takes them.
+	var plain, enc *string
+	column := "email"
+	domain := pii.DomainEmail
+	if channel == ContactPhone {
+		column, domain = "phone", pii.DomainPhone
+	}
+	err = tx.QueryRow(ctx,
+		fmt.Sprintf(`SELECT %[1]s, %[1]s_enc FROM customers WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE;`, column),
+		id, tenant,
+	).Scan(&plain, &enc)
+	if errors.Is(err, pgx.ErrNoRows) {
+		r.logger.Warn(ctx, "contact confirm customer not found", fields...)
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		r.logger.Error(ctx, "contact confirm customer lock failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	ch := ContactChallenge{CustomerID: id, Channel: channel}
+	var codeHash, destinationHash string
+	err = tx.QueryRow(ctx, `
+SELECT id, code_hash, destination_hash, attempts, max_attempts, expires_at
+FROM contact_verifications
+WHERE customer_id = $1 AND channel = $2 AND tenant_id = $3
+FOR UPDATE;
+`, id, string(channel), tenant).Scan(&ch.ID, &codeHash, &destinationHash, &ch.Attempts, &ch.MaxAttempts, &ch.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		r.logger.Warn(ctx, "contact challenge not found", fields...)
+		return nil, ErrCodeExpired
+	}
+	if err != nil {
+		r.logger.Error(ctx, "contact challenge query failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	// The outcome of a rejected code is committed before it is returned.
+	reject := func(rejection error, q string, args ...any) (*Customer, error) {
+		if _, err := tx.Exec(ctx, q, args...); err != nil {
+			r.logger.Error(ctx, "contact challenge update failed", append(fields, logger.Err(err))...)
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			r.logger.Error(ctx, "contact confirm commit failed", append(fields, logger.Err(err))...)
+			return nil, err
+		}
+		r.logger.Warn(ctx, "contact code rejected", append(fields, logger.Err(rejection), logger.Int("attempts", ch.Attempts))...)
+		return nil, rejection
+	}
+	switch err := ch.Check(r.matchesDigest(ch.ID, code, codeHash), time.Now()); {
+	case errors.Is(err, ErrCodeMismatch), errors.Is(err, ErrTooManyAttempts):
+		return reject(err, `UPDATE contact_verifications SET attempts = $3 WHERE customer_id = $1 AND channel = $2;`, id, string(channel), ch.Attempts)
+	case err != nil:
+		r.logger.Warn(ctx, "contact code rejected", append(fields, logger.Err(err), logger.Int("attempts", ch.Attempts))...)
+		return nil, err
+	}
+	current, err := r.open(domain, plain, enc)
+	if err != nil {
+		r.logger.Error(ctx, "contact confirm decrypt failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	if current == nil || !r.matchesDigest(ch.ID, normalizePII(domain, *current), destinationHash) {
+		return reject(ErrCodeExpired, `UPDATE contact_verifications SET expires_at = now() WHERE customer_id = $1 AND channel = $2;`, id, string(channel))
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM contact_verifications WHERE customer_id = $1 AND channel = $2;`, id, string(channel)); err != nil {
+		r.logger.Error(ctx, "contact challenge delete failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	out, err := r.updateReturning(ctx, tx, id, []string{channel.verifiedColumn() + " = now()", "updated_at = now()"}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error(ctx, "contact confirm commit failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	r.logger.Info(ctx, "contact verified", fields...)
+	return out, nil
 }
 
 // Soft delete (mark as deleted)
@@ -225,9 +858,9 @@ func (r *PGRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
 	q := `
 		UPDATE customers
 		SET deleted_at = now(), updated_at = now()
-		WHERE id = $1 AND deleted_at IS NULL;
+		WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL;
 	`
-	ct, err := r.pool.Exec(ctx, q, id)
+	ct, err := r.pool.Exec(ctx, q, id, auth.TenantFromContext(ctx))
 	if err != nil {
 		r.logger.Error(ctx, "customer soft delete failed", logger.Err(err), logger.String("customer_id", id.String()))
 		return err
@@ -240,20 +873,263 @@ func (r *PGRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// CreateVerification creates the verification record
+// DuplicateCandidates pairs customers by trigram similarity of their names
+// (pg_trgm's % operator) and by the last ten digits of plaintext phones.
+func (r *PGRepository) DuplicateCandidates(ctx context.Context, limit int) ([]DuplicateCandidate, error) {
+	tenant := auth.TenantFromContext(ctx)
+	q := `
+WITH pairs AS (
+    SELECT a.id AS a_id, b.id AS b_id, false AS pan_claim
+    FROM customers a
+    JOIN customers b ON b.tenant_id = a.tenant_id AND b.deleted_at IS NULL
+        AND b.id > a.id AND b.name % a.name
+    WHERE a.tenant_id = $1 AND a.deleted_at IS NULL
+    UNION
+    SELECT a.id, b.id, false
+    FROM customers a
+    JOIN customers b ON b.tenant_id = a.tenant_id AND b.deleted_at IS NULL
+        AND b.id > a.id AND b.phone IS NOT NULL
+        AND right(regexp_replace(b.phone, '[^0-9]', '', 'g'), 10) = right(regexp_replace(a.phone, '[^0-9]', '', 'g'), 10)
+    WHERE a.tenant_id = $1 AND a.deleted_at IS NULL AND a.phone IS NOT NULL
+    UNION
+    SELECT a.id, b.id, false
+    FROM customers a
+    JOIN customers b ON b.tenant_id = a.tenant_id AND b.deleted_at IS NULL
+        AND b.id > a.id AND b.phone_digits_bidx = a.phone_digits_bidx
+    WHERE a.tenant_id = $1 AND a.deleted_at IS NULL AND a.phone_digits_bidx IS NOT NULL
+    UNION
+    SELECT least(customer_id, holder_id), greatest(customer_id, holder_id), true
+    FROM pan_claims
+    WHERE tenant_id = $1
+)
+SELECT p.a_id, p.b_id, similarity(a.name, b.name), bool_or(p.pan_claim)
+FROM pairs p
+JOIN customers a ON a.id = p.a_id AND a.deleted_at IS NULL
+JOIN customers b ON b.id = p.b_id AND b.deleted_at IS NULL
+GROUP BY p.a_id, p.b_id, a.name, b.name
+ORDER BY bool_or(p.pan_claim) DESC, 3 DESC, p.a_id, p.b_id
+LIMIT $2;
+`
+	rows, err := r.pool.Query(ctx, q, tenant, limit)
+	if err != nil {
+		r.logger.Error(ctx, "customer duplicate query failed", logger.Err(err))
+		return nil, err
+	}
+	type pair struct {
+		a, b     uuid.UUID
+		sim      float32
+		panClaim bool
+	}
+	var pairs []pair
+	var ids []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for rows.Next() {
+		var p pair
+		if err := rows.Scan(&p.a, &p.b, &p.sim, &p.panClaim); err != nil {
+			rows.Close()
+			r.logger.Error(ctx, "customer duplicate row scan failed", logger.Err(err))
+			return nil, err
+		}
+		pairs = append(pairs, p)
+		for _, id := range []uuid.UUID{p.a, p.b} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.Error(ctx, "customer duplicate rows iteration failed", logger.Err(err))
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	// Contacts may be encrypted, so the customers are loaded and decoded
+	// here and compared by the caller.
+	q = `
+SELECT ` + customerColumns + `
+FROM customers c
+LEFT JOIN verifications v ON v.customer_id = c.id
+WHERE c.tenant_id = $1 AND c.id = ANY($2);
+`
+	rows, err = r.pool.Query(ctx, q, tenant, ids)
+	if err != nil {
+		r.logger.Error(ctx, "customer duplicate load failed", logger.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+	byID := make(map[uuid.UUID]*Customer, len(ids))
+	for rows.Next() {
+		var row customerRow
+		if err := rows.Scan(row.scanTargets()...); err != nil {
+			r.logger.Error(ctx, "customer row scan failed", logger.Err(err))
+			return nil, err
+		}
+		c, err := r.decodeCustomer(&row)
+		if err != nil {
+			r.logger.Error(ctx, "customer decode failed", logger.Err(err), logger.String("customer_id", row.c.ID.String()))
+			return nil, err
+		}
+		byID[c.ID] = c
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error(ctx, "customer rows iteration failed", logger.Err(err))
+		return nil, err
+	}
+	out := make([]DuplicateCandidate, 0, len(pairs))
+	for _, p := range pairs {
+		a, b := byID[p.a], byID[p.b]
+		if a == nil || b == nil {
+			continue
+		}
+		out = append(out, DuplicateCandidate{A: *a, B: *b, Signals: DuplicateSignals{NameSimilarity: float64(p.sim), SamePAN: p.panClaim}})
+	}
+	r.logger.Info(ctx, "customer duplicate candidates found", logger.Int("count", len(out)))
+	return out, nil
+}
+
+// Merge runs in one transaction. Both customers are locked first, in id
+// order so that concurrent merges of overlapping pairs cannot deadlock.
+// Customers already merged into duplicate are re-pointed at survivor, so
+// merged_into always names a live customer. audit_log and pan_access_log are
+// append-only and keep naming duplicate; readers reach them through
+// merged_into, as audit queries with IncludeMerged do. duplicate's pending
+// contact codes are dropped: they were sent to its contact details and could
+// never be confirmed for survivor.
+func (r *PGRepository) Merge(ctx context.Context, survivor, duplicate uuid.UUID) (bool, error) {
+	tenant := auth.TenantFromContext(ctx)
+	fields := []logger.Field{logger.String("customer_id", survivor.String()), logger.String("duplicate_id", duplicate.String())}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error(ctx, "customer merge begin failed", append(fields, logger.Err(err))...)
+		return false, err
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Warn(ctx, "customer merge rollback failed", logger.Err(rbErr))
+		}
+	}()
+
+	ids := []uuid.UUID{survivor, duplicate}
+	rows, err := tx.Query(ctx, `
+SELECT id FROM customers
+WHERE tenant_id = $1 AND id = ANY($2) AND deleted_at IS NULL
+ORDER BY id
+FOR UPDATE;
+`, tenant, ids)
+	if err != nil {
+		r.logger.Error(ctx, "customer merge lock failed", append(fields, logger.Err(err))...)
+		return false, err
+	}
+	locked, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		r.logger.Error(ctx, "customer merge lock failed", append(fields, logger.Err(err))...)
+		return false, err
+	}
+	if len(locked) != 2 {
+		r.logger.Warn(ctx, "customer merge target missing", fields...)
+		return false, ErrNotFound
+	}
+
+	rows, err = tx.Query(ctx, `
+SELECT customer_id FROM verifications
+WHERE tenant_id = $1 AND customer_id = ANY($2)
+  AND (pan_number IS NOT NULL OR pan_number_enc IS NOT NULL)
+FOR UPDATE;
+`, tenant, ids)
+	if err != nil {
+		r.logger.Error(ctx, "customer merge verification query failed", append(fields, logger.Err(err))...)
+		return false, err
+	}
+	withPAN, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		r.logger.Error(ctx, "customer merge verification query failed", append(fields, logger.Err(err))...)
+		return false, err
+	}
+	if len(withPAN) == 2 {
+		// PANs are unique per tenant, so the two are different people.
+		r.logger.Warn(ctx, "customer merge refused: both customers have a PAN", fields...)
+		return false, ErrMergeConflict
+	}
+	moved := len(withPAN) == 1 && withPAN[0] == duplicate
+	if moved {
+		if _, err := tx.Exec(ctx, `DELETE FROM verifications WHERE tenant_id = $1 AND customer_id = $2;`, tenant, survivor); err != nil {
+			r.logger.Error(ctx, "customer merge verification delete failed", append(fields, logger.Err(err))...)
+			return false, err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE verifications SET customer_id = $3, updated_at = now() WHERE tenant_id = $1 AND customer_id = $2;`, tenant, duplicate, survivor); err != nil {
+			r.logger.Error(ctx, "customer merge verification move failed", append(fields, logger.Err(err))...)
+			return false, err
+		}
+	}
+
+	for _, stmt := range []struct {
+		sql  string
+		args []any
+	}{
+		{`UPDATE customers SET merged_into = $3 WHERE tenant_id = $1 AND merged_into = $2;`, []any{tenant, duplicate, survivor}},
+		// Addresses move too; the duplicate's primary stays primary only if the
+		// survivor has none.
+		{`UPDATE addresses SET customer_id = $3, updated_at = now(),
+    is_primary = is_primary AND NOT EXISTS (SELECT 1 FROM addresses WHERE tenant_id = $1 AND customer_id = $3 AND is_primary)
+WHERE tenant_id = $1 AND customer_id = $2;`, []any{tenant, duplicate, survivor}},
+		{`DELETE FROM contact_verifications WHERE tenant_id = $1 AND customer_id = $2;`, []any{tenant, duplicate}},
+		{`UPDATE customers SET merged_into = $3, deleted_at = now(), updated_at = now() WHERE tenant_id = $1 AND id = $2;`, []any{tenant, duplicate, survivor}},
+		{`UPDATE customers SET updated_at = now() WHERE tenant_id = $1 AND id = $2;`, []any{tenant, survivor}},
+	} {
+		if _, err := tx.Exec(ctx, stmt.sql, stmt.args...); err != nil {
+			r.logger.Error(ctx, "customer merge update failed", append(fields, logger.Err(err))...)
+			return false, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error(ctx, "customer merge commit failed", append(fields, logger.Err(err))...)
+		return false, err
+	}
+	r.logger.Info(ctx, "customers merged", append(fields, logger.Bool("verification_moved", moved))...)
+	return moved, nil
+}
+
+// CreateVerification creates the verification record. The customer must
+// exist in the caller's tenant.
 func (r *PGRepository) CreateVerification(ctx context.Context, v *Verification) (*Verification, error) {
+	var pan sealedValue
+	if v.PANNumber != nil {
+		var err error
+		pan, err = r.seal(pii.DomainPAN, *v.PANNumber, true)
+		if err != nil {
+			r.logger.Error(ctx, "verification PAN sealing failed", logger.Err(err), logger.String("customer_id", v.CustomerID.String()))
+			return nil, err
+		}
+	}
 	q := `
-		INSERT INTO verifications (customer_id, pan_number, status)
-		VALUES ($1, $2, $3)
+		INSERT INTO verifications (customer_id, tenant_id, pan_number, pan_number_enc, pan_number_bidx, status)
+		SELECT id, tenant_id, $2, $3, $4, $5
+		FROM customers
+		WHERE id = $1 AND tenant_id = $6 AND deleted_at IS NULL
 		ON CONFLICT (customer_id) DO UPDATE
 		SET pan_number = EXCLUDED.pan_number,
+		    pan_number_enc = EXCLUDED.pan_number_enc,
+		    pan_number_bidx = EXCLUDED.pan_number_bidx,
 		    status = EXCLUDED.status,
 		    updated_at = now()
-		RETURNING id, customer_id, pan_number, status, created_at, updated_at;
+		RETURNING id, customer_id, status, created_at, updated_at;
 	`
-	row := r.pool.QueryRow(ctx, q, v.CustomerID, v.PANNumber, v.Status)
-	err := row.Scan(&v.ID, &v.CustomerID, &v.PANNumber, &v.Status, &v.CreatedAt, &v.UpdatedAt)
+	row := r.pool.QueryRow(ctx, q, v.CustomerID, pan.plain, pan.enc, pan.bidx, v.Status, auth.TenantFromContext(ctx))
+	err := row.Scan(&v.ID, &v.CustomerID, &v.Status, &v.CreatedAt, &v.UpdatedAt)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn(ctx, "verification customer not found", logger.String("customer_id", v.CustomerID.String()))
+			return nil, ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			r.logger.Warn(ctx, "verification PAN conflict", logger.String("customer_id", v.CustomerID.String()))
+			r.recordPANClaim(ctx, v.CustomerID, pan)
+			return nil, ErrPANAlreadyExists
+		}
 		r.logger.Error(ctx, "verification create failed", logger.Err(err), logger.String("customer_id", v.CustomerID.String()))
 		return nil, err
 	}
@@ -261,15 +1137,36 @@ func (r *PGRepository) CreateVerification(ctx context.Context, v *Verification)
 	return v, err
 }
 
+// recordPANClaim notes that customer was refused pan because another
+// customer of the tenant holds it, so the two are reported as duplicates.
+// Failures are logged; the PAN is refused either way.
+func (r *PGRepository) recordPANClaim(ctx context.Context, customer uuid.UUID, pan sealedValue) {
+	_, err := r.pool.Exec(ctx, `
+INSERT INTO pan_claims (tenant_id, customer_id, holder_id)
+SELECT tenant_id, $2, customer_id
+FROM verifications
+WHERE tenant_id = $1 AND customer_id <> $2
+  AND (pan_number_bidx = $3 OR pan_number = $4)
+ON CONFLICT (customer_id, holder_id) DO UPDATE SET claimed_at = now();
+`, auth.TenantFromContext(ctx), customer, pan.bidx, pan.plain)
+	if err != nil {
+		r.logger.Error(ctx, "verification PAN claim record failed", logger.Err(err), logger.String("customer_id", customer.String()))
+	}
+}
+
 // GetVerificationByCustomerID fetches verification by customer ID
 func (r *PGRepository) GetVerificationByCustomerID(ctx context.Context, cid uuid.UUID) (*Verification, error) {
 	q := `
-		SELECT id, customer_id, pan_number, status, created_at, updated_at
+		SELECT id, customer_id, pan_number, pan_number_enc, status, created_at, updated_at
 		FROM verifications
-		WHERE customer_id=$1;
+		WHERE customer_id=$1 AND tenant_id=$2;
 	`
-	var v Verification
-	err := r.pool.QueryRow(ctx, q, cid).Scan(&v.ID, &v.CustomerID, &v.PANNumber, &v.Status, &v.CreatedAt, &v.UpdatedAt)
+	var (
+		v      Verification
+		pan    *string
+		panEnc *string
+	)
+	err := r.pool.QueryRow(ctx, q, cid, auth.TenantFromContext(ctx)).Scan(&v.ID, &v.CustomerID, &pan, &panEnc, &v.Status, &v.CreatedAt, &v.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			r.logger.Warn(ctx, "verification not found", logger.String("customer_id", cid.String()))
@@ -278,6 +1175,10 @@ func (r *PGRepository) GetVerificationByCustomerID(ctx context.Context, cid uuid
 		r.logger.Error(ctx, "verification query failed", logger.Err(err), logger.String("customer_id", cid.String()))
 		return nil, err
 	}
+	if v.PANNumber, err = r.open(pii.DomainPAN, pan, panEnc); err != nil {
+		r.logger.Error(ctx, "verification decode failed", logger.Err(err), logger.String("customer_id", cid.String()))
+		return nil, err
+	}
 	r.logger.Debug(ctx, "verification fetched", logger.String("verification_id", v.ID.String()), logger.String("customer_id", cid.String()))
 	return &v, nil
 }
@@ -287,9 +1188,9 @@ func (r *PGRepository) UpdateVerificationStatus(ctx context.Context, cid uuid.UU
 	q := `
 UPDATE verifications
 SET status=$2, updated_at=now()
-WHERE customer_id=$1;
+WHERE customer_id=$1 AND tenant_id=$3;
 `
-	_, err := r.pool.Exec(ctx, q, cid, status)
+	_, err := r.pool.Exec(ctx, q, cid, status, auth.TenantFromContext(ctx))
 	if err != nil {
 		r.logger.Error(ctx, "verification status update failed", logger.Err(err), logger.String("customer_id", cid.String()), logger.String("status", string(status)))
 		return err
@@ -297,3 +1198,269 @@ WHERE customer_id=$1;
 	r.logger.Info(ctx, "verification status updated", logger.String("customer_id", cid.String()), logger.String("status", string(status)))
 	return nil
 }
+
+// RecordPANAccess appends an entry to the PAN access log
+func (r *PGRepository) RecordPANAccess(ctx context.Context, a *PANAccess) error {
+	q := `
+INSERT INTO pan_access_log (customer_id, tenant_id, principal, request_id, client_ip, reason, outcome)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, accessed_at;
+`
+	err := r.pool.QueryRow(ctx, q, a.CustomerID, auth.TenantFromContext(ctx), a.Principal, a.RequestID, a.ClientIP, a.Reason, a.Outcome).Scan(&a.ID, &a.AccessedAt)
+	if err != nil {
+		r.logger.Error(ctx, "pan access log insert failed", logger.Err(err), logger.String("customer_id", a.CustomerID.String()), logger.String("principal", a.Principal))
+		return err
+	}
+	r.logger.Info(ctx, "pan access recorded", logger.String("access_id", a.ID.String()), logger.String("customer_id", a.CustomerID.String()), logger.String("principal", a.Principal), logger.String("outcome", a.Outcome))
+	return nil
+}
+
+// addressColumns are the columns scanned by Address.scanTargets, for
+// addresses aliased as a.
+const addressColumns = `a.id, a.customer_id, a.type, a.line1, a.line2, a.city, a.state, a.pin_code, a.is_primary, a.created_at, a.updated_at`
+
+func (a *Address) scanTargets() []any {
+	return []any{&a.ID, &a.CustomerID, &a.Type, &a.Line1, &a.Line2, &a.City, &a.State, &a.PINCode, &a.Primary, &a.CreatedAt, &a.UpdatedAt}
+}
+
+// lockCustomer locks a live customer of the caller's tenant for the rest of
+// tx, which serialises changes to its primary address.
+func lockCustomer(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	var one int
+	err := tx.QueryRow(ctx, `SELECT 1 FROM customers WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE;`, id, auth.TenantFromContext(ctx)).Scan(&one)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// demotePrimary clears the primary flag of the customer's addresses other
+// than keep.
+func demotePrimary(ctx context.Context, tx pgx.Tx, customerID, keep uuid.UUID) error {
+	_, err := tx.Exec(ctx, `
+UPDATE addresses SET is_primary = false, updated_at = now()
+WHERE customer_id = $1 AND tenant_id = $2 AND is_primary AND id <> $3;
+`, customerID, auth.TenantFromContext(ctx), keep)
+	return err
+}
+
+// CreateAddress adds an address to a live customer of the caller's tenant.
+func (r *PGRepository) CreateAddress(ctx context.Context, a *Address) (*Address, error) {
+	fields := []logger.Field{logger.String("customer_id", a.CustomerID.String())}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error(ctx, "address create begin failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Warn(ctx, "address create rollback failed", logger.Err(rbErr))
+		}
+	}()
+	if err := lockCustomer(ctx, tx, a.CustomerID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			r.logger.Warn(ctx, "address customer not found", fields...)
+		} else {
+			r.logger.Error(ctx, "address customer lock failed", append(fields, logger.Err(err))...)
+		}
+		return nil, err
+	}
+	a.ID = uuid.New()
+	if a.Primary {
+		if err := demotePrimary(ctx, tx, a.CustomerID, a.ID); err != nil {
+			r.logger.Error(ctx, "address primary demote failed", append(fields, logger.Err(err))...)
+			return nil, err
+		}
+	}
+	q := `
+INSERT INTO addresses (id, tenant_id, customer_id, type, line1, line2, city, state, pin_code, is_primary)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING created_at, updated_at;
+`
+	err = tx.QueryRow(ctx, q, a.ID, auth.TenantFromContext(ctx), a.CustomerID, a.Type, a.Line1, a.Line2, a.City, a.State, a.PINCode, a.Primary).Scan(&a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			r.logger.Warn(ctx, "address primary conflict", fields...)
+			return nil, ErrPrimaryAddressTaken
+		}
+		r.logger.Error(ctx, "address insert failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error(ctx, "address create commit failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	r.logger.Info(ctx, "address created", append(fields, logger.String("address_id", a.ID.String()))...)
+	return a, nil
+}
+
+// ListAddresses returns the addresses of a live customer, primary first and
+// then oldest first.
+func (r *PGRepository) ListAddresses(ctx context.Context, customerID uuid.UUID) ([]Address, error) {
+	fields := []logger.Field{logger.String("customer_id", customerID.String())}
+	tenant := auth.TenantFromContext(ctx)
+	var exists bool
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM customers WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL);`, customerID, tenant).Scan(&exists)
+	if err != nil {
+		r.logger.Error(ctx, "address customer query failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	if !exists {
+		r.logger.Warn(ctx, "address customer not found", fields...)
+		return nil, ErrNotFound
+	}
+	q := `
+SELECT ` + addressColumns + `
+FROM addresses a
+WHERE a.customer_id = $1 AND a.tenant_id = $2
+ORDER BY a.is_primary DESC, a.created_at, a.id;
+`
+	rows, err := r.pool.Query(ctx, q, customerID, tenant)
+	if err != nil {
+		r.logger.Error(ctx, "address list query failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Address{}
+	for rows.Next() {
+		var a Address
+		if err := rows.Scan(a.scanTargets()...); err != nil {
+			r.logger.Error(ctx, "address row scan failed", append(fields, logger.Err(err))...)
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error(ctx, "address rows iteration failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	r.logger.Debug(ctx, "addresses listed", append(fields, logger.Int("count", len(out)))...)
+	return out, nil
+}
+
+// GetAddress returns one address of a live customer.
+func (r *PGRepository) GetAddress(ctx context.Context, customerID, id uuid.UUID) (*Address, error) {
+	fields := []logger.Field{logger.String("customer_id", customerID.String()), logger.String("address_id", id.String())}
+	q := `
+SELECT ` + addressColumns + `
+FROM addresses a
+JOIN customers c ON c.id = a.customer_id AND c.tenant_id = a.tenant_id AND c.deleted_at IS NULL
+WHERE a.id = $1 AND a.customer_id = $2 AND a.tenant_id = $3;
+`
+	var a Address
+	err := r.pool.QueryRow(ctx, q, id, customerID, auth.TenantFromContext(ctx)).Scan(a.scanTargets()...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		r.logger.Warn(ctx, "address not found", fields...)
+		return nil, ErrAddressNotFound
+	}
+	if err != nil {
+		r.logger.Error(ctx, "address query failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	r.logger.Debug(ctx, "address fetched", fields...)
+	return &a, nil
+}
+
+// UpdateAddress changes the fields set in upd.
+func (r *PGRepository) UpdateAddress(ctx context.Context, customerID, id uuid.UUID, upd UpdateAddress) (*Address, error) {
+	fields := []logger.Field{logger.String("customer_id", customerID.String()), logger.String("address_id", id.String())}
+	setParts := []string{"updated_at = now()"}
+	args := []any{id, customerID, auth.TenantFromContext(ctx)}
+	set := func(column string, v any) {
+		args = append(args, v)
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if upd.Type != nil {
+		set("type", *upd.Type)
+	}
+	if upd.Line1 != nil {
+		set("line1", *upd.Line1)
+	}
+	if upd.Line2 != nil {
+		set("line2", *upd.Line2)
+	}
+	if upd.City != nil {
+		set("city", *upd.City)
+	}
+	if upd.State != nil {
+		set("state", *upd.State)
+	}
+	if upd.PINCode != nil {
+		set("pin_code", *upd.PINCode)
+	}
+	if upd.Primary != nil {
+		set("is_primary", *upd.Primary)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error(ctx, "address update begin failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Warn(ctx, "address update rollback failed", logger.Err(rbErr))
+		}
+	}()
+	if err := lockCustomer(ctx, tx, customerID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			r.logger.Warn(ctx, "address customer not found", fields...)
+			return nil, ErrAddressNotFound
+		}
+		r.logger.Error(ctx, "address customer lock failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	if upd.Primary != nil && *upd.Primary {
+		if err := demotePrimary(ctx, tx, customerID, id); err != nil {
+			r.logger.Error(ctx, "address primary demote failed", append(fields, logger.Err(err))...)
+			return nil, err
+		}
+	}
+	q := fmt.Sprintf(`
+UPDATE addresses a SET %s
+WHERE a.id = $1 AND a.customer_id = $2 AND a.tenant_id = $3
+RETURNING %s;
+`, strings.Join(setParts, ", "), addressColumns)
+	var a Address
+	err = tx.QueryRow(ctx, q, args...).Scan(a.scanTargets()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn(ctx, "address update target missing", fields...)
+			return nil, ErrAddressNotFound
+		}
+		if isUniqueViolation(err) {
+			r.logger.Warn(ctx, "address primary conflict", fields...)
+			return nil, ErrPrimaryAddressTaken
+		}
+		r.logger.Error(ctx, "address update failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error(ctx, "address update commit failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	r.logger.Info(ctx, "address updated", fields...)
+	return &a, nil
+}
+
+// DeleteAddress removes an address of a live customer.
+func (r *PGRepository) DeleteAddress(ctx context.Context, customerID, id uuid.UUID) error {
+	fields := []logger.Field{logger.String("customer_id", customerID.String()), logger.String("address_id", id.String())}
+	q := `
+DELETE FROM addresses a
+USING customers c
+WHERE a.id = $1 AND a.customer_id = $2 AND a.tenant_id = $3
+  AND c.id = a.customer_id AND c.tenant_id = a.tenant_id AND c.deleted_at IS NULL;
+`
+	ct, err := r.pool.Exec(ctx, q, id, customerID, auth.TenantFromContext(ctx))
+	if err != nil {
+		r.logger.Error(ctx, "address delete failed", append(fields, logger.Err(err))...)
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		r.logger.Warn(ctx, "address delete target missing", fields...)
+		return ErrAddressNotFound
+	}
+	r.logger.Info(ctx, "address deleted", fields...)
+	return nil
+}
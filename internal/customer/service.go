@@ -2,44 +2,440 @@ package customer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
 
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/flags"
 	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/metrics"
+	"github.com/Archiit19/customer-service-go/internal/tracing"
 	"github.com/google/uuid"
 )
 
+// Audited actions. Verification and address actions use the customer ID as
+// resource ID so a single query by customer returns its whole history.
+const (
+	ActionCustomerCreate     = "customer.create"
+	ActionCustomerBatch      = "customer.batch_create"
+	ActionCustomerRead       = "customer.read"
+	ActionCustomerList       = "customer.list"
+	ActionCustomerExport     = "customer.export"
+	ActionCustomerUpdate     = "customer.update"
+	ActionCustomerDelete     = "customer.delete"
+	ActionCustomerDuplicates = "customer.duplicates"
+	ActionCustomerMerge      = "customer.merge"
+	ActionCustomerTagsAdd    = "customer.tags_add"
+	ActionCustomerTagRemove  = "customer.tag_remove"
+	ActionContactCodeSend    = "customer.contact_code_send"
+	ActionContactConfirm     = "customer.contact_confirm"
+	ActionVerificationRead   = "verification.read"
+	ActionVerificationPAN    = "verification.pan_update"
+	ActionVerificationStatus = "verification.status_update"
+	ActionPANReveal          = "verification.pan_reveal"
+	ActionAddressCreate      = "address.create"
+	ActionAddressRead        = "address.read"
+	ActionAddressList        = "address.list"
+	ActionAddressUpdate      = "address.update"
+	ActionAddressDelete      = "address.delete"
+
+	resourceCustomer     = "customer"
+	resourceVerification = "verification"
+	resourceAddress      = "address"
+)
+
+var ErrInvalidStatus = errors.New("invalid verification status")
+
+// ErrBatchAborted is the result of a valid row that was not created because
+// another row of an all-or-nothing batch failed.
+var ErrBatchAborted = errors.New("not created: another row of the batch failed")
+
+// BatchResult is the outcome of one row of a batch create: the created
+// customer, or the error that kept it from being created.
+type BatchResult struct {
+	Customer *Customer
+	Err      error
+}
+
 // Service handles all customer and verification operations
 type Service struct {
 	customerRepo Repository
 	logger       logger.Logger
+	auditor      audit.Recorder
+	metrics      serviceMetrics
+	tracer       *tracing.Tracer
+	features     *flags.Client
+	contacts     ContactVerification
 }
 
-// NewService creates a new Service instance
-func NewService(repo Repository, log logger.Logger) *Service {
+// NewService creates a new Service instance. Every read and write is reported
+// to auditor; pass audit.Nop{} to disable auditing. Business counters are
+// registered on reg, spans started on tracer and rollout rules read from
+// features; any of the three may be nil, which leaves every flag off.
+// Contact verification codes are sent as configured by contacts.
+func NewService(repo Repository, log logger.Logger, auditor audit.Recorder, reg metrics.Registry, tracer *tracing.Tracer, features *flags.Client, contacts ContactVerification) *Service {
+	if reg == nil {
+		reg = metrics.Nop()
+	}
+	if contacts.TTL <= 0 {
+		contacts.TTL = DefaultCodeTTL
+	}
+	if contacts.MaxAttempts <= 0 {
+		contacts.MaxAttempts = DefaultCodeMaxAttempts
+	}
+	if contacts.ResendInterval <= 0 {
+		contacts.ResendInterval = DefaultCodeResendInterval
+	}
+	if contacts.MaxSends <= 0 {
+		contacts.MaxSends = DefaultCodeMaxSends
+	}
 	return &Service{
 		customerRepo: repo,
 		logger:       log,
+		auditor:      auditor,
+		metrics:      newServiceMetrics(reg),
+		tracer:       tracer,
+		features:     features,
+		contacts:     contacts,
+	}
+}
+
+// enabled evaluates a feature flag for the calling tenant and principal and,
+// when known, the customer being acted on.
+func (s *Service) enabled(ctx context.Context, key, customerID string) bool {
+	return s.features.Enabled(ctx, key, flags.Subject{
+		Tenant:    auth.TenantFromContext(ctx),
+		Principal: auth.FromContext(ctx).ID,
+		Customer:  customerID,
+	})
+}
+
+// trace starts a span for a Service method. Defer the returned function with
+// a pointer to the method's error so failures are recorded on the span.
+func (s *Service) trace(ctx context.Context, method string, attrs ...tracing.Attribute) (context.Context, func(*error)) {
+	ctx, span := s.tracer.Start(ctx, "customer.Service/"+method, tracing.KindInternal, attrs...)
+	return ctx, func(err *error) {
+		span.RecordError(*err)
+		span.End()
+	}
+}
+
+// record writes an audit entry, deriving the outcome from err when the caller
+// has not set one. Audit failures are logged but do not fail the operation.
+func (s *Service) record(ctx context.Context, e audit.Entry, err error) {
+	if e.Outcome == "" {
+		e.Outcome = auditOutcome(err)
+	}
+	if recErr := s.auditor.Record(ctx, e); recErr != nil {
+		s.logger.Error(ctx, "service audit record failed", logger.Err(recErr), logger.String("action", e.Action), logger.String("resource_id", e.ResourceID))
+	}
+}
+
+func auditOutcome(err error) string {
+	switch {
+	case err == nil:
+		return audit.OutcomeSuccess
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrVerificationNotFound), errors.Is(err, ErrAddressNotFound):
+		return audit.OutcomeNotFound
+	case errors.Is(err, ErrForbidden), IsRejectedCode(err), IsThrottledCode(err):
+		return audit.OutcomeDenied
+	case errors.Is(err, ErrInvalidName), errors.Is(err, ErrInvalidEmail), errors.Is(err, ErrInvalidPhone),
+		errors.Is(err, ErrInvalidStatus), errors.Is(err, ErrInvalidPAN),
+		errors.Is(err, ErrInvalidAddressType), errors.Is(err, ErrInvalidAddress), errors.Is(err, ErrInvalidPINCode),
+		IsInvalidProfile(err), errors.Is(err, ErrInvalidMetadata), errors.Is(err, ErrInvalidTag), errors.Is(err, ErrTooManyTags),
+		errors.Is(err, ErrInvalidChannel), errors.Is(err, ErrInvalidCode), errors.Is(err, ErrContactAlreadyVerified):
+		return audit.OutcomeInvalid
+	default:
+		return audit.OutcomeFailure
+	}
+}
+
+// customerChanges diffs two versions of a customer for the audit trail,
+// masking contact details. A nil before means the customer was created.
+func customerChanges(before, after *Customer) map[string]audit.Change {
+	changes := map[string]audit.Change{}
+	var zero Customer
+	if before == nil {
+		before = &zero
+	}
+	if before.Name != after.Name {
+		changes["name"] = audit.Change{Before: nullIfEmpty(before.Name), After: after.Name}
 	}
+	if before.Email != after.Email {
+		changes["email"] = audit.Change{Before: nullIfEmpty(maskIfSet(before.Email, MaskEmail)), After: MaskEmail(after.Email)}
+	}
+	if before.Phone != after.Phone {
+		changes["phone"] = audit.Change{Before: nullIfEmpty(maskIfSet(before.Phone, MaskPhone)), After: MaskPhone(after.Phone)}
+	}
+	profileChanges(changes, before.Profile, after.Profile)
+	if len(before.Metadata)+len(after.Metadata) > 0 && !reflect.DeepEqual(before.Metadata, after.Metadata) {
+		changes["metadata"] = audit.Change{Before: before.Metadata, After: after.Metadata}
+	}
+	if !slices.Equal(before.Tags, after.Tags) {
+		changes["tags"] = audit.Change{Before: before.Tags, After: after.Tags}
+	}
+	if !equalDates(before.EmailVerifiedAt, after.EmailVerifiedAt) {
+		changes["email_verified_at"] = audit.Change{Before: nullIfNilTime(before.EmailVerifiedAt), After: nullIfNilTime(after.EmailVerifiedAt)}
+	}
+	if !equalDates(before.PhoneVerifiedAt, after.PhoneVerifiedAt) {
+		changes["phone_verified_at"] = audit.Change{Before: nullIfNilTime(before.PhoneVerifiedAt), After: nullIfNilTime(after.PhoneVerifiedAt)}
+	}
+	return changes
+}
+
+// profileChanges adds the differences between two profiles to changes,
+// keeping only the year of a date of birth.
+func profileChanges(changes map[string]audit.Change, before, after Profile) {
+	diff := func(field string, b, a *string) {
+		if (b == nil) != (a == nil) || b != nil && *b != *a {
+			changes[field] = audit.Change{Before: nullIfNil(b), After: nullIfNil(a)}
+		}
+	}
+	year := func(t *time.Time) *string {
+		if t == nil {
+			return nil
+		}
+		s := t.Format("2006") + "-**-**"
+		return &s
+	}
+	gender := func(g *Gender) *string {
+		if g == nil {
+			return nil
+		}
+		s := string(*g)
+		return &s
+	}
+	if !equalDates(before.DateOfBirth, after.DateOfBirth) {
+		changes["date_of_birth"] = audit.Change{Before: nullIfNil(year(before.DateOfBirth)), After: nullIfNil(year(after.DateOfBirth))}
+	}
+	diff("gender", gender(before.Gender), gender(after.Gender))
+	diff("nationality", before.Nationality, after.Nationality)
+	diff("occupation", before.Occupation, after.Occupation)
+	diff("preferred_language", before.PreferredLanguage, after.PreferredLanguage)
+}
+
+func equalDates(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func nullIfNil(v *string) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func nullIfNilTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return *t
 }
 
-func (s *Service) Create(ctx context.Context, c *Customer) (*Customer, error) {
+// addressChanges diffs two versions of an address for the audit trail,
+// masking the street lines. A nil before means the address was created and a
+// nil after that it was deleted.
+func addressChanges(before, after *Address) map[string]audit.Change {
+	changes := map[string]audit.Change{}
+	var zero Address
+	if before == nil {
+		before = &zero
+	}
+	if after == nil {
+		after = &zero
+	}
+	diff := func(field string, b, a string, mask func(string) string) {
+		if b != a {
+			changes[field] = audit.Change{Before: nullIfEmpty(maskIfSet(b, mask)), After: nullIfEmpty(maskIfSet(a, mask))}
+		}
+	}
+	keep := func(v string) string { return v }
+	diff("type", string(before.Type), string(after.Type), keep)
+	diff("line1", before.Line1, after.Line1, maskAddressLine)
+	diff("line2", before.Line2, after.Line2, maskAddressLine)
+	diff("city", before.City, after.City, keep)
+	diff("state", before.State, after.State, keep)
+	diff("pin_code", before.PINCode, after.PINCode, keep)
+	if before.Primary != after.Primary {
+		changes["primary"] = audit.Change{Before: before.Primary, After: after.Primary}
+	}
+	return changes
+}
+
+// maskAddressLine keeps only the first character of a street line.
+func maskAddressLine(line string) string {
+	r := []rune(line)
+	return string(r[:1]) + "***"
+}
+
+func maskIfSet(v string, mask func(string) string) string {
+	if v == "" {
+		return ""
+	}
+	return mask(v)
+}
+
+func nullIfEmpty(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+func maskedPAN(pan *string) any {
+	if pan == nil {
+		return nil
+	}
+	return MaskPAN(*pan)
+}
+
+func (s *Service) Create(ctx context.Context, c *Customer) (_ *Customer, err error) {
+	ctx, end := s.trace(ctx, "Create")
+	defer end(&err)
 	s.logger.Info(ctx, "service create customer invoked")
+	entry := audit.NewEntry(ctx, ActionCustomerCreate, resourceCustomer, "")
 	if err := c.ValidateForCreate(); err != nil {
 		s.logger.Warn(ctx, "service create customer validation failed", logger.Err(err))
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	if s.enabled(ctx, flags.PhoneIndiaOnly, "") && !isIndianPhone(c.Phone) {
+		s.logger.Warn(ctx, "service create customer phone region rejected")
+		s.record(ctx, entry, ErrInvalidPhone)
+		return nil, ErrInvalidPhone
+	}
+	c.Profile.Normalize()
+	if err := c.Profile.Validate(time.Now()); err != nil {
+		s.logger.Warn(ctx, "service create customer profile validation failed", logger.Err(err))
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	if err := ValidateMetadata(c.Metadata); err != nil {
+		s.logger.Warn(ctx, "service create customer metadata validation failed", logger.Err(err))
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	if c.Tags, err = NormalizeTags(c.Tags); err != nil || len(c.Tags) > MaxTags {
+		if err == nil {
+			err = ErrTooManyTags
+		}
+		s.logger.Warn(ctx, "service create customer tags validation failed", logger.Err(err))
+		s.record(ctx, entry, err)
 		return nil, err
 	}
 	customer, err := s.customerRepo.Create(ctx, c)
 	if err != nil {
 		s.logger.Error(ctx, "service create customer failed", logger.Err(err))
+		s.record(ctx, entry, err)
 		return nil, err
 	}
+	entry.ResourceID = customer.ID.String()
+	entry.Changes = customerChanges(nil, customer)
+	s.record(ctx, entry, nil)
+	s.metrics.customersCreated.Inc()
 	s.logger.Info(ctx, "service create customer succeeded", logger.String("customer_id", customer.ID.String()))
 	return customer, nil
 }
 
-func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Customer, error) {
+// CreateBatch validates every row like Create and creates the valid ones in
+// one transaction, returning one result per row in order. With atomic set
+// nothing is created unless every row can be; otherwise each valid row that
+// does not conflict with an existing customer is created. The error is
+// reserved for failures of the batch as a whole.
+func (s *Service) CreateBatch(ctx context.Context, rows []*Customer, atomic bool) (_ []BatchResult, err error) {
+	ctx, end := s.trace(ctx, "CreateBatch", tracing.Int("batch.rows", len(rows)), tracing.Bool("batch.atomic", atomic))
+	defer end(&err)
+	s.logger.Info(ctx, "service batch create customers invoked", logger.Int("rows", len(rows)), logger.Bool("atomic", atomic))
+	summary := audit.NewEntry(ctx, ActionCustomerBatch, resourceCustomer, "")
+	results := make([]BatchResult, len(rows))
+	indiaOnly := s.enabled(ctx, flags.PhoneIndiaOnly, "")
+	now := time.Now()
+	var valid []*Customer
+	var positions []int
+	for i, c := range rows {
+		if err := c.ValidateForCreate(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if indiaOnly && !isIndianPhone(c.Phone) {
+			results[i].Err = ErrInvalidPhone
+			continue
+		}
+		c.Profile.Normalize()
+		if err := c.Profile.Validate(now); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := ValidateMetadata(c.Metadata); err != nil {
+			results[i].Err = err
+			continue
+		}
+		tags, err := NormalizeTags(c.Tags)
+		if err == nil && len(tags) > MaxTags {
+			err = ErrTooManyTags
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		c.Tags = tags
+		valid = append(valid, c)
+		positions = append(positions, i)
+	}
+
+	switch {
+	case len(valid) == 0:
+	case atomic && len(valid) < len(rows):
+		for _, i := range positions {
+			results[i].Err = ErrBatchAborted
+		}
+	default:
+		created, err := s.customerRepo.CreateBatch(ctx, valid, atomic)
+		if err != nil {
+			s.logger.Error(ctx, "service batch create customers failed", logger.Err(err))
+			summary.Metadata = map[string]any{"rows": len(rows), "atomic": atomic}
+			s.record(ctx, summary, err)
+			return nil, err
+		}
+		for j, res := range created {
+			results[positions[j]] = res
+		}
+	}
+
+	n := 0
+	for _, res := range results {
+		if res.Customer == nil {
+			continue
+		}
+		n++
+		entry := audit.NewEntry(ctx, ActionCustomerCreate, resourceCustomer, res.Customer.ID.String())
+		entry.Changes = customerChanges(nil, res.Customer)
+		s.record(ctx, entry, nil)
+	}
+	s.metrics.customersCreated.Add(float64(n))
+	summary.Metadata = map[string]any{"rows": len(rows), "atomic": atomic, "created": n, "failed": len(rows) - n}
+	if n < len(rows) {
+		summary.Outcome = audit.OutcomeInvalid
+	}
+	s.record(ctx, summary, nil)
+	s.logger.Info(ctx, "service batch create customers completed", logger.Int("rows", len(rows)), logger.Int("created", n))
+	return results, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (_ *Customer, err error) {
+	ctx, end := s.trace(ctx, "Get", tracing.String("customer.id", id.String()))
+	defer end(&err)
 	s.logger.Info(ctx, "service get customer invoked", logger.String("customer_id", id.String()))
 	customer, err := s.customerRepo.Get(ctx, id)
+	s.record(ctx, audit.NewEntry(ctx, ActionCustomerRead, resourceCustomer, id.String()), err)
 	if err != nil {
 		s.logger.Error(ctx, "service get customer failed", logger.Err(err), logger.String("customer_id", id.String()))
 		return nil, err
@@ -48,7 +444,9 @@ func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Customer, error) {
 	return customer, nil
 }
 
-func (s *Service) List(ctx context.Context, page, limit int) ([]Customer, int, error) {
+func (s *Service) List(ctx context.Context, f Filter, page, limit int) (_ []Customer, _ int, err error) {
+	ctx, end := s.trace(ctx, "List")
+	defer end(&err)
 	s.logger.Info(ctx, "service list customers invoked", logger.Int("page", page), logger.Int("limit", limit))
 	if page <= 0 {
 		page = 1
@@ -57,7 +455,16 @@ func (s *Service) List(ctx context.Context, page, limit int) ([]Customer, int, e
 		limit = 20
 	}
 	offset := (page - 1) * limit
-	items, total, err := s.customerRepo.List(ctx, offset, limit)
+	items, total, err := s.customerRepo.List(ctx, f, offset, limit)
+	entry := audit.NewEntry(ctx, ActionCustomerList, resourceCustomer, "")
+	entry.Metadata = f.metadata()
+	entry.Metadata["page"], entry.Metadata["limit"], entry.Metadata["returned"] = page, limit, len(items)
+	ids := make([]string, len(items))
+	for i := range items {
+		ids[i] = items[i].ID.String()
+	}
+	entry.Metadata[audit.MetadataCustomerIDs] = ids
+	s.record(ctx, entry, err)
 	if err != nil {
 		s.logger.Error(ctx, "service list customers failed", logger.Err(err))
 		return nil, 0, err
@@ -66,25 +473,321 @@ func (s *Service) List(ctx context.Context, page, limit int) ([]Customer, int, e
 	return items, total, nil
 }
 
-func (s *Service) Update(ctx context.Context, id uuid.UUID, name, email, phone *string) (*Customer, error) {
+// maxDuplicateCandidates bounds the pairs FindDuplicates scores per call.
+const maxDuplicateCandidates = 1000
+
+// FindDuplicates returns up to limit pairs of customers scoring at least
+// minScore as likely duplicates, highest score first. Pairs with different
+// PANs are never returned.
+func (s *Service) FindDuplicates(ctx context.Context, minScore float64, limit int) (_ []DuplicateCandidate, err error) {
+	ctx, end := s.trace(ctx, "FindDuplicates", tracing.Int("limit", limit))
+	defer end(&err)
+	s.logger.Info(ctx, "service find duplicates invoked", logger.Any("min_score", minScore), logger.Int("limit", limit))
+	candidates, err := s.customerRepo.DuplicateCandidates(ctx, maxDuplicateCandidates)
+	var out []DuplicateCandidate
+	for _, c := range candidates {
+		if c.score() && c.Score >= minScore {
+			out = append(out, c)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	entry := audit.NewEntry(ctx, ActionCustomerDuplicates, resourceCustomer, "")
+	entry.Metadata = map[string]any{"min_score": minScore, "limit": limit, "candidates": len(candidates), "returned": len(out)}
+	s.record(ctx, entry, err)
+	if err != nil {
+		s.logger.Error(ctx, "service find duplicates failed", logger.Err(err))
+		return nil, err
+	}
+	s.logger.Info(ctx, "service find duplicates succeeded", logger.Int("candidates", len(candidates)), logger.Int("returned", len(out)))
+	return out, nil
+}
+
+// Merge folds duplicate into survivor and returns the survivor. The
+// duplicate is soft-deleted with merged_into set to survivor; its
+// verification moves to survivor when only the duplicate has a PAN, and
+// customers with different PANs are never merged. The merge is audited
+// under both customers.
+func (s *Service) Merge(ctx context.Context, survivor, duplicate uuid.UUID) (_ *Customer, err error) {
+	ctx, end := s.trace(ctx, "Merge", tracing.String("customer.id", survivor.String()), tracing.String("duplicate.id", duplicate.String()))
+	defer end(&err)
+	fields := []logger.Field{logger.String("customer_id", survivor.String()), logger.String("duplicate_id", duplicate.String())}
+	s.logger.Info(ctx, "service merge customers invoked", fields...)
+	kept := audit.NewEntry(ctx, ActionCustomerMerge, resourceCustomer, survivor.String())
+	merged := audit.NewEntry(ctx, ActionCustomerMerge, resourceCustomer, duplicate.String())
+	merged.Metadata = map[string]any{"merged_into": survivor.String()}
+	if survivor == duplicate {
+		s.record(ctx, kept, ErrMergeSelf)
+		return nil, ErrMergeSelf
+	}
+	moved, err := s.customerRepo.Merge(ctx, survivor, duplicate)
+	kept.Metadata = map[string]any{"duplicate_id": duplicate.String(), "verification_moved": moved}
+	s.record(ctx, kept, err)
+	s.record(ctx, merged, err)
+	if err != nil {
+		s.logger.Error(ctx, "service merge customers failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	c, err := s.customerRepo.Get(ctx, survivor)
+	if err != nil {
+		s.logger.Error(ctx, "service merge customers reload failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	s.logger.Info(ctx, "service merge customers succeeded", append(fields, logger.Bool("verification_moved", moved))...)
+	return c, nil
+}
+
+// exportAuditIDs bounds the customer IDs recorded per export audit entry.
+const exportAuditIDs = 1000
+
+// Export streams every customer matching f to fn and returns how many were
+// written. Email and phone are masked unless the caller holds
+// auth.ScopeExportPII; the PAN additionally requires auth.ScopeRevealPAN.
+// The export is audited with the row count and what was revealed, and the
+// IDs of the exported customers are recorded exportAuditIDs per entry.
+func (s *Service) Export(ctx context.Context, f Filter, fn func(*Customer) error) (n int, err error) {
+	ctx, end := s.trace(ctx, "Export")
+	defer end(&err)
+	principal := auth.FromContext(ctx)
+	contacts := principal.HasScope(auth.ScopeExportPII)
+	pan := contacts && principal.HasScope(auth.ScopeRevealPAN)
+	s.logger.Info(ctx, "service export customers invoked", logger.Bool("contacts_revealed", contacts), logger.Bool("pan_revealed", pan))
+	// A row fn fails on may have been written in part, so its ID is
+	// recorded before fn is called.
+	ids := make([]string, 0, exportAuditIDs)
+	recorded := 0
+	err = s.customerRepo.Export(ctx, f, func(c *Customer) error {
+		if len(ids) == exportAuditIDs {
+			part := audit.NewEntry(ctx, ActionCustomerExport, resourceCustomer, "")
+			part.Metadata = map[string]any{"first_row": recorded - len(ids), audit.MetadataCustomerIDs: ids}
+			s.record(ctx, part, nil)
+			ids = make([]string, 0, exportAuditIDs)
+		}
+		ids = append(ids, c.ID.String())
+		recorded++
+		if !contacts {
+			c.Email = MaskEmail(c.Email)
+			c.Phone = MaskPhone(c.Phone)
+		}
+		if !pan {
+			c.PANNumber = maskPANPtr(c.PANNumber)
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	entry := audit.NewEntry(ctx, ActionCustomerExport, resourceCustomer, "")
+	entry.Metadata = f.metadata()
+	entry.Metadata["rows"], entry.Metadata["contacts_revealed"], entry.Metadata["pan_revealed"] = n, contacts, pan
+	entry.Metadata["first_row"], entry.Metadata[audit.MetadataCustomerIDs] = recorded-len(ids), ids
+	s.record(ctx, entry, err)
+	if err != nil {
+		s.logger.Error(ctx, "service export customers failed", logger.Err(err), logger.Int("rows", n))
+		return n, err
+	}
+	s.logger.Info(ctx, "service export customers succeeded", logger.Int("rows", n))
+	return n, nil
+}
+
+// Update changes the fields set in upd. Profile fields set to nil are
+// cleared; the resulting profile must be valid as a whole. A metadata patch
+// is merged into the stored metadata, which must stay within its limits.
+func (s *Service) Update(ctx context.Context, id uuid.UUID, upd UpdateCustomer) (_ *Customer, err error) {
+	ctx, end := s.trace(ctx, "Update", tracing.String("customer.id", id.String()))
+	defer end(&err)
 	s.logger.Info(ctx, "service update customer invoked", logger.String("customer_id", id.String()))
-	upd := UpdateCustomer{
-		Name:  name,
-		Email: email,
-		Phone: phone,
+	entry := audit.NewEntry(ctx, ActionCustomerUpdate, resourceCustomer, id.String())
+	// The phone rule is evaluated for the caller, as on create, so a tenant
+	// in a rollout gets the same answer for new and existing customers.
+	if upd.Phone != nil && s.enabled(ctx, flags.PhoneIndiaOnly, "") && !isIndianPhone(*upd.Phone) {
+		s.logger.Warn(ctx, "service update customer phone region rejected", logger.String("customer_id", id.String()))
+		s.record(ctx, entry, ErrInvalidPhone)
+		return nil, ErrInvalidPhone
+	}
+	before, err := s.customerRepo.Get(ctx, id)
+	if err != nil {
+		s.logger.Error(ctx, "service update customer load failed", logger.Err(err), logger.String("customer_id", id.String()))
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	upd.Profile = upd.Profile.normalized()
+	profile := upd.Profile.Apply(before.Profile)
+	if err := profile.Validate(time.Now()); err != nil {
+		s.logger.Warn(ctx, "service update customer profile validation failed", logger.Err(err), logger.String("customer_id", id.String()))
+		s.record(ctx, entry, err)
+		return nil, err
 	}
 	customer, err := s.customerRepo.Update(ctx, id, upd)
 	if err != nil {
 		s.logger.Error(ctx, "service update customer failed", logger.Err(err), logger.String("customer_id", id.String()))
+		s.record(ctx, entry, err)
 		return nil, err
 	}
+	entry.Changes = customerChanges(before, customer)
+	s.record(ctx, entry, nil)
 	s.logger.Info(ctx, "service update customer succeeded", logger.String("customer_id", customer.ID.String()))
 	return customer, nil
 }
 
-func (s *Service) SoftDelete(ctx context.Context, id uuid.UUID) error {
+// AddTags adds tags to a customer, ignoring those it already has.
+func (s *Service) AddTags(ctx context.Context, id uuid.UUID, tags []string) (_ *Customer, err error) {
+	ctx, end := s.trace(ctx, "AddTags", tracing.String("customer.id", id.String()), tracing.Int("tags", len(tags)))
+	defer end(&err)
+	s.logger.Info(ctx, "service add customer tags invoked", logger.String("customer_id", id.String()), logger.Int("tags", len(tags)))
+	entry := audit.NewEntry(ctx, ActionCustomerTagsAdd, resourceCustomer, id.String())
+	if tags, err = NormalizeTags(tags); err == nil && len(tags) == 0 {
+		err = ErrInvalidTag
+	}
+	if err != nil {
+		s.logger.Warn(ctx, "service add customer tags validation failed", logger.Err(err), logger.String("customer_id", id.String()))
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	return s.retag(ctx, entry, id, func() (*Customer, error) { return s.customerRepo.AddTags(ctx, id, tags) })
+}
+
+// RemoveTag removes a tag from a customer. Removing a tag the customer does
+// not have succeeds.
+func (s *Service) RemoveTag(ctx context.Context, id uuid.UUID, tag string) (_ *Customer, err error) {
+	ctx, end := s.trace(ctx, "RemoveTag", tracing.String("customer.id", id.String()))
+	defer end(&err)
+	s.logger.Info(ctx, "service remove customer tag invoked", logger.String("customer_id", id.String()), logger.String("tag", tag))
+	entry := audit.NewEntry(ctx, ActionCustomerTagRemove, resourceCustomer, id.String())
+	tags, err := NormalizeTags([]string{tag})
+	if err != nil {
+		s.logger.Warn(ctx, "service remove customer tag validation failed", logger.Err(err), logger.String("customer_id", id.String()))
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	return s.retag(ctx, entry, id, func() (*Customer, error) { return s.customerRepo.RemoveTag(ctx, id, tags[0]) })
+}
+
+// retag runs a tag change, auditing the tags before and after it.
+func (s *Service) retag(ctx context.Context, entry audit.Entry, id uuid.UUID, change func() (*Customer, error)) (*Customer, error) {
+	before, err := s.customerRepo.Get(ctx, id)
+	if err != nil {
+		s.logger.Error(ctx, "service retag customer load failed", logger.Err(err), logger.String("customer_id", id.String()))
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	customer, err := change()
+	if err != nil {
+		s.logger.Error(ctx, "service retag customer failed", logger.Err(err), logger.String("customer_id", id.String()))
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	entry.Changes = customerChanges(before, customer)
+	s.record(ctx, entry, nil)
+	s.logger.Info(ctx, "service retag customer succeeded", logger.String("customer_id", id.String()), logger.Int("tags", len(customer.Tags)))
+	return customer, nil
+}
+
+// StartContactVerification sends a one-time code to the customer's email
+// address or phone number, replacing any code sent before on that channel.
+// Contacts that are already verified are rejected, and so are sends beyond
+// the configured ContactLimits.
+func (s *Service) StartContactVerification(ctx context.Context, id uuid.UUID, channel ContactChannel) (_ *PendingContactVerification, err error) {
+	ctx, end := s.trace(ctx, "StartContactVerification", tracing.String("customer.id", id.String()), tracing.String("channel", string(channel)))
+	defer end(&err)
+	fields := []logger.Field{logger.String("customer_id", id.String()), logger.String("channel", string(channel))}
+	s.logger.Info(ctx, "service start contact verification invoked", fields...)
+	entry := audit.NewEntry(ctx, ActionContactCodeSend, resourceCustomer, id.String())
+	entry.Metadata = map[string]any{"channel": string(channel)}
+	fail := func(err error) (*PendingContactVerification, error) {
+		s.logger.Warn(ctx, "service start contact verification failed", append(fields, logger.Err(err))...)
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	if !IsValidContactChannel(channel) {
+		return fail(ErrInvalidChannel)
+	}
+	c, err := s.customerRepo.Get(ctx, id)
+	if err != nil {
+		return fail(err)
+	}
+	if c.contactVerifiedAt(channel) != nil {
+		return fail(ErrContactAlreadyVerified)
+	}
+	if s.contacts.Notifier == nil {
+		return fail(fmt.Errorf("%w: no notifier configured", ErrDeliveryFailed))
+	}
+	ch, err := newContactChallenge(id, channel, c.contact(channel), s.contacts.ContactLimits, time.Now())
+	if err != nil {
+		return fail(err)
+	}
+	if err := s.customerRepo.SaveContactChallenge(ctx, ch, s.contacts.ContactLimits); err != nil {
+		if IsThrottledCode(err) {
+			s.metrics.contactCodes.Inc(string(channel), contactOutcomeThrottled)
+		}
+		return fail(err)
+	}
+	if err := s.contacts.Notifier.Send(ctx, codeMessage(c, channel, ch.Code, s.contacts.TTL)); err != nil {
+		s.metrics.contactCodes.Inc(string(channel), contactOutcomeUndelivered)
+		return fail(fmt.Errorf("%w: %v", ErrDeliveryFailed, err))
+	}
+	s.metrics.contactCodes.Inc(string(channel), contactOutcomeSent)
+	s.record(ctx, entry, nil)
+	pending := &PendingContactVerification{Channel: channel, Destination: MaskEmail(c.Email), ExpiresAt: ch.ExpiresAt}
+	if channel == ContactPhone {
+		pending.Destination = MaskPhone(c.Phone)
+	}
+	s.logger.Info(ctx, "service start contact verification succeeded", append(fields, logger.String("expires_at", ch.ExpiresAt.Format(time.RFC3339)))...)
+	return pending, nil
+}
+
+// ConfirmContactVerification marks the customer's email address or phone
+// number verified if code is the one last sent to it, unexpired and within
+// the attempt limit.
+func (s *Service) ConfirmContactVerification(ctx context.Context, id uuid.UUID, channel ContactChannel, code string) (_ *Customer, err error) {
+	ctx, end := s.trace(ctx, "ConfirmContactVerification", tracing.String("customer.id", id.String()), tracing.String("channel", string(channel)))
+	defer end(&err)
+	fields := []logger.Field{logger.String("customer_id", id.String()), logger.String("channel", string(channel))}
+	s.logger.Info(ctx, "service confirm contact verification invoked", fields...)
+	entry := audit.NewEntry(ctx, ActionContactConfirm, resourceCustomer, id.String())
+	entry.Metadata = map[string]any{"channel": string(channel)}
+	if !IsValidContactChannel(channel) {
+		err = ErrInvalidChannel
+	} else if !codePattern.MatchString(code) {
+		err = ErrInvalidCode
+	}
+	if err != nil {
+		s.logger.Warn(ctx, "service confirm contact verification validation failed", append(fields, logger.Err(err))...)
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	before, err := s.customerRepo.Get(ctx, id)
+	if err != nil {
+		s.logger.Error(ctx, "service confirm contact verification customer load failed", append(fields, logger.Err(err))...)
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	c, err := s.customerRepo.ConfirmContact(ctx, id, channel, code)
+	if IsRejectedCode(err) {
+		s.metrics.contactCodes.Inc(string(channel), contactOutcomeRejected)
+	}
+	if err != nil {
+		s.logger.Warn(ctx, "service confirm contact verification failed", append(fields, logger.Err(err))...)
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	s.metrics.contactCodes.Inc(string(channel), contactOutcomeVerified)
+	entry.Changes = customerChanges(before, c)
+	s.record(ctx, entry, nil)
+	s.logger.Info(ctx, "service confirm contact verification succeeded", fields...)
+	return c, nil
+}
+
+func (s *Service) SoftDelete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, end := s.trace(ctx, "SoftDelete", tracing.String("customer.id", id.String()))
+	defer end(&err)
 	s.logger.Info(ctx, "service soft delete customer invoked", logger.String("customer_id", id.String()))
-	if err := s.customerRepo.SoftDelete(ctx, id); err != nil {
+	err = s.customerRepo.SoftDelete(ctx, id)
+	s.record(ctx, audit.NewEntry(ctx, ActionCustomerDelete, resourceCustomer, id.String()), err)
+	if err != nil {
 		s.logger.Error(ctx, "service soft delete customer failed", logger.Err(err), logger.String("customer_id", id.String()))
 		return err
 	}
@@ -92,13 +795,27 @@ func (s *Service) SoftDelete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (s *Service) CreateVerification(ctx context.Context, customerID, pan string) (*Verification, error) {
+func (s *Service) CreateVerification(ctx context.Context, customerID, pan string) (_ *Verification, err error) {
+	ctx, end := s.trace(ctx, "CreateVerification", tracing.String("customer.id", customerID))
+	defer end(&err)
 	s.logger.Info(ctx, "service create verification invoked", logger.String("customer_id", customerID))
+	entry := audit.NewEntry(ctx, ActionVerificationPAN, resourceVerification, customerID)
 	cid, err := uuid.Parse(customerID)
 	if err != nil {
 		s.logger.Warn(ctx, "service create verification invalid id", logger.Err(err), logger.String("customer_id", customerID))
+		entry.Outcome = audit.OutcomeInvalid
+		s.record(ctx, entry, err)
 		return nil, err
 	}
+	before, err := s.customerRepo.GetVerificationByCustomerID(ctx, cid)
+	if err != nil && !errors.Is(err, ErrVerificationNotFound) {
+		s.logger.Error(ctx, "service create verification load failed", logger.Err(err), logger.String("customer_id", customerID))
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	if before == nil {
+		before = &Verification{}
+	}
 
 	v := &Verification{
 		CustomerID: cid,
@@ -108,20 +825,35 @@ func (s *Service) CreateVerification(ctx context.Context, customerID, pan string
 	verification, err := s.customerRepo.CreateVerification(ctx, v)
 	if err != nil {
 		s.logger.Error(ctx, "service create verification failed", logger.Err(err), logger.String("customer_id", customerID))
+		s.record(ctx, entry, err)
 		return nil, err
 	}
+	entry.Changes = map[string]audit.Change{
+		"pan_number": {Before: maskedPAN(before.PANNumber), After: maskedPAN(verification.PANNumber)},
+	}
+	if before.Status != verification.Status {
+		entry.Changes["status"] = audit.Change{Before: nullIfEmpty(string(before.Status)), After: string(verification.Status)}
+		s.metrics.transition(before.Status, verification.Status)
+	}
+	s.record(ctx, entry, nil)
 	s.logger.Info(ctx, "service create verification succeeded", logger.String("verification_id", verification.ID.String()), logger.String("customer_id", customerID))
 	return verification, nil
 }
 
-func (s *Service) GetVerificationByCustomerID(ctx context.Context, customerID string) (*Verification, error) {
+func (s *Service) GetVerificationByCustomerID(ctx context.Context, customerID string) (_ *Verification, err error) {
+	ctx, end := s.trace(ctx, "GetVerificationByCustomerID", tracing.String("customer.id", customerID))
+	defer end(&err)
 	s.logger.Info(ctx, "service get verification invoked", logger.String("customer_id", customerID))
+	entry := audit.NewEntry(ctx, ActionVerificationRead, resourceVerification, customerID)
 	cid, err := uuid.Parse(customerID)
 	if err != nil {
 		s.logger.Warn(ctx, "service get verification invalid id", logger.Err(err), logger.String("customer_id", customerID))
+		entry.Outcome = audit.OutcomeInvalid
+		s.record(ctx, entry, err)
 		return nil, err
 	}
 	verification, err := s.customerRepo.GetVerificationByCustomerID(ctx, cid)
+	s.record(ctx, entry, err)
 	if err != nil {
 		s.logger.Error(ctx, "service get verification failed", logger.Err(err), logger.String("customer_id", customerID))
 		return nil, err
@@ -130,29 +862,232 @@ func (s *Service) GetVerificationByCustomerID(ctx context.Context, customerID st
 	return verification, nil
 }
 
-func (s *Service) UpdateVerificationStatus(ctx context.Context, customerID, newStatus string) (*Verification, error) {
+func (s *Service) UpdateVerificationStatus(ctx context.Context, customerID, newStatus string) (_ *Verification, err error) {
+	ctx, end := s.trace(ctx, "UpdateVerificationStatus", tracing.String("customer.id", customerID))
+	defer end(&err)
 	s.logger.Info(ctx, "service update verification status invoked", logger.String("customer_id", customerID), logger.String("status", newStatus))
+	entry := audit.NewEntry(ctx, ActionVerificationStatus, resourceVerification, customerID)
 	cid, err := uuid.Parse(customerID)
 	if err != nil {
 		s.logger.Warn(ctx, "service update verification invalid id", logger.Err(err), logger.String("customer_id", customerID))
+		entry.Outcome = audit.OutcomeInvalid
+		s.record(ctx, entry, err)
 		return nil, err
 	}
 
 	status := VerificationStatus(newStatus)
 	if !IsValidStatus(VerificationStatus(status)) {
 		s.logger.Warn(ctx, "service update verification invalid status", logger.String("status", newStatus))
-		return nil, fmt.Errorf("invalid verification status")
+		s.record(ctx, entry, ErrInvalidStatus)
+		return nil, ErrInvalidStatus
 	}
 
+	before, err := s.customerRepo.GetVerificationByCustomerID(ctx, cid)
+	if err != nil {
+		s.logger.Error(ctx, "service get verification before update failed", logger.Err(err), logger.String("customer_id", customerID))
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	if status == StatusVerified && s.enabled(ctx, flags.KYCVerifiedRequiresValidPAN, customerID) &&
+		(before.PANNumber == nil || !IsValidPAN(*before.PANNumber)) {
+		s.logger.Warn(ctx, "service update verification rejected: PAN not well formed", logger.String("customer_id", customerID))
+		s.record(ctx, entry, ErrInvalidPAN)
+		return nil, ErrInvalidPAN
+	}
 	if err := s.customerRepo.UpdateVerificationStatus(ctx, cid, status); err != nil {
 		s.logger.Error(ctx, "service update verification status failed", logger.Err(err), logger.String("customer_id", customerID))
+		s.record(ctx, entry, err)
 		return nil, err
 	}
 	verification, err := s.customerRepo.GetVerificationByCustomerID(ctx, cid)
 	if err != nil {
 		s.logger.Error(ctx, "service get verification after update failed", logger.Err(err), logger.String("customer_id", customerID))
+		s.record(ctx, entry, err)
 		return nil, err
 	}
+	entry.Changes = map[string]audit.Change{
+		"status": {Before: string(before.Status), After: string(verification.Status)},
+	}
+	s.record(ctx, entry, nil)
+	if before.Status != verification.Status {
+		s.metrics.transition(before.Status, verification.Status)
+	}
 	s.logger.Info(ctx, "service update verification status succeeded", logger.String("verification_id", verification.ID.String()), logger.String("customer_id", customerID), logger.String("status", string(verification.Status)))
 	return verification, nil
 }
+
+// RevealPAN returns the verification record with the full PAN. Only principals
+// holding auth.ScopeRevealPAN may reveal, and every attempt, granted or not,
+// is written to the PAN access log. If the access cannot be recorded the PAN
+// is not revealed.
+func (s *Service) RevealPAN(ctx context.Context, customerID, reason string) (_ *Verification, err error) {
+	ctx, end := s.trace(ctx, "RevealPAN", tracing.String("customer.id", customerID))
+	defer end(&err)
+	principal := auth.FromContext(ctx)
+	s.logger.Info(ctx, "service reveal pan invoked", logger.String("customer_id", customerID), logger.String("principal", principal.ID))
+	cid, err := uuid.Parse(customerID)
+	if err != nil {
+		s.logger.Warn(ctx, "service reveal pan invalid id", logger.Err(err), logger.String("customer_id", customerID))
+		return nil, err
+	}
+	access := &PANAccess{
+		CustomerID: cid,
+		Principal:  principal.ID,
+		RequestID:  logger.RequestIDFromContext(ctx),
+		ClientIP:   auth.ClientIPFromContext(ctx),
+		Reason:     reason,
+	}
+
+	entry := audit.NewEntry(ctx, ActionPANReveal, resourceVerification, customerID)
+	entry.Metadata = map[string]any{"reason": reason}
+
+	if !principal.HasScope(auth.ScopeRevealPAN) {
+		s.record(ctx, entry, ErrForbidden)
+		access.Outcome = PANAccessDenied
+		if err := s.customerRepo.RecordPANAccess(ctx, access); err != nil {
+			s.logger.Error(ctx, "service reveal pan audit failed", logger.Err(err), logger.String("customer_id", customerID))
+			return nil, err
+		}
+		s.logger.Warn(ctx, "service reveal pan denied", logger.String("customer_id", customerID), logger.String("principal", principal.ID))
+		return nil, ErrForbidden
+	}
+
+	verification, err := s.customerRepo.GetVerificationByCustomerID(ctx, cid)
+	switch {
+	case errors.Is(err, ErrVerificationNotFound):
+		access.Outcome = PANAccessNotFound
+	case err != nil:
+		s.logger.Error(ctx, "service reveal pan lookup failed", logger.Err(err), logger.String("customer_id", customerID))
+		return nil, err
+	case verification.PANNumber == nil:
+		access.Outcome = PANAccessNotFound
+		err = ErrVerificationNotFound
+	default:
+		access.Outcome = PANAccessGranted
+	}
+	s.record(ctx, entry, err)
+	if auditErr := s.customerRepo.RecordPANAccess(ctx, access); auditErr != nil {
+		s.logger.Error(ctx, "service reveal pan audit failed", logger.Err(auditErr), logger.String("customer_id", customerID))
+		return nil, auditErr
+	}
+	if err != nil {
+		s.logger.Warn(ctx, "service reveal pan nothing to reveal", logger.String("customer_id", customerID))
+		return nil, err
+	}
+	s.logger.Info(ctx, "service reveal pan succeeded", logger.String("customer_id", customerID), logger.String("principal", principal.ID), logger.String("access_id", access.ID.String()))
+	return verification, nil
+}
+
+// CreateAddress validates a and adds it to the customer. Making it primary
+// demotes the customer's current primary address.
+func (s *Service) CreateAddress(ctx context.Context, customerID uuid.UUID, a *Address) (_ *Address, err error) {
+	ctx, end := s.trace(ctx, "CreateAddress", tracing.String("customer.id", customerID.String()))
+	defer end(&err)
+	fields := []logger.Field{logger.String("customer_id", customerID.String())}
+	s.logger.Info(ctx, "service create address invoked", fields...)
+	entry := audit.NewEntry(ctx, ActionAddressCreate, resourceAddress, customerID.String())
+	a.CustomerID = customerID
+	a.Normalize()
+	if err := a.Validate(); err != nil {
+		s.logger.Warn(ctx, "service create address validation failed", append(fields, logger.Err(err))...)
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	created, err := s.customerRepo.CreateAddress(ctx, a)
+	if err != nil {
+		s.logger.Error(ctx, "service create address failed", append(fields, logger.Err(err))...)
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	entry.Metadata = map[string]any{"address_id": created.ID.String()}
+	entry.Changes = addressChanges(nil, created)
+	s.record(ctx, entry, nil)
+	s.logger.Info(ctx, "service create address succeeded", append(fields, logger.String("address_id", created.ID.String()))...)
+	return created, nil
+}
+
+// ListAddresses returns the customer's addresses, primary first.
+func (s *Service) ListAddresses(ctx context.Context, customerID uuid.UUID) (_ []Address, err error) {
+	ctx, end := s.trace(ctx, "ListAddresses", tracing.String("customer.id", customerID.String()))
+	defer end(&err)
+	fields := []logger.Field{logger.String("customer_id", customerID.String())}
+	s.logger.Info(ctx, "service list addresses invoked", fields...)
+	addresses, err := s.customerRepo.ListAddresses(ctx, customerID)
+	entry := audit.NewEntry(ctx, ActionAddressList, resourceAddress, customerID.String())
+	entry.Metadata = map[string]any{"returned": len(addresses)}
+	s.record(ctx, entry, err)
+	if err != nil {
+		s.logger.Error(ctx, "service list addresses failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	s.logger.Debug(ctx, "service list addresses succeeded", append(fields, logger.Int("returned", len(addresses)))...)
+	return addresses, nil
+}
+
+func (s *Service) GetAddress(ctx context.Context, customerID, id uuid.UUID) (_ *Address, err error) {
+	ctx, end := s.trace(ctx, "GetAddress", tracing.String("customer.id", customerID.String()), tracing.String("address.id", id.String()))
+	defer end(&err)
+	fields := []logger.Field{logger.String("customer_id", customerID.String()), logger.String("address_id", id.String())}
+	s.logger.Info(ctx, "service get address invoked", fields...)
+	a, err := s.customerRepo.GetAddress(ctx, customerID, id)
+	entry := audit.NewEntry(ctx, ActionAddressRead, resourceAddress, customerID.String())
+	entry.Metadata = map[string]any{"address_id": id.String()}
+	s.record(ctx, entry, err)
+	if err != nil {
+		s.logger.Error(ctx, "service get address failed", append(fields, logger.Err(err))...)
+		return nil, err
+	}
+	s.logger.Debug(ctx, "service get address succeeded", fields...)
+	return a, nil
+}
+
+// UpdateAddress changes the fields set in upd. The address that results must
+// be valid as a whole.
+func (s *Service) UpdateAddress(ctx context.Context, customerID, id uuid.UUID, upd UpdateAddress) (_ *Address, err error) {
+	ctx, end := s.trace(ctx, "UpdateAddress", tracing.String("customer.id", customerID.String()), tracing.String("address.id", id.String()))
+	defer end(&err)
+	fields := []logger.Field{logger.String("customer_id", customerID.String()), logger.String("address_id", id.String())}
+	s.logger.Info(ctx, "service update address invoked", fields...)
+	entry := audit.NewEntry(ctx, ActionAddressUpdate, resourceAddress, customerID.String())
+	entry.Metadata = map[string]any{"address_id": id.String()}
+	before, err := s.customerRepo.GetAddress(ctx, customerID, id)
+	if err != nil {
+		s.logger.Error(ctx, "service update address load failed", append(fields, logger.Err(err))...)
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	upd = upd.normalized()
+	merged := upd.Apply(*before)
+	if err := merged.Validate(); err != nil {
+		s.logger.Warn(ctx, "service update address validation failed", append(fields, logger.Err(err))...)
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	updated, err := s.customerRepo.UpdateAddress(ctx, customerID, id, upd)
+	if err != nil {
+		s.logger.Error(ctx, "service update address failed", append(fields, logger.Err(err))...)
+		s.record(ctx, entry, err)
+		return nil, err
+	}
+	entry.Changes = addressChanges(before, updated)
+	s.record(ctx, entry, nil)
+	s.logger.Info(ctx, "service update address succeeded", fields...)
+	return updated, nil
+}
+
+func (s *Service) DeleteAddress(ctx context.Context, customerID, id uuid.UUID) (err error) {
+	ctx, end := s.trace(ctx, "DeleteAddress", tracing.String("customer.id", customerID.String()), tracing.String("address.id", id.String()))
+	defer end(&err)
+	fields := []logger.Field{logger.String("customer_id", customerID.String()), logger.String("address_id", id.String())}
+	s.logger.Info(ctx, "service delete address invoked", fields...)
+	entry := audit.NewEntry(ctx, ActionAddressDelete, resourceAddress, customerID.String())
+	entry.Metadata = map[string]any{"address_id": id.String()}
+	err = s.customerRepo.DeleteAddress(ctx, customerID, id)
+	s.record(ctx, entry, err)
+	if err != nil {
+		s.logger.Error(ctx, "service delete address failed", append(fields, logger.Err(err))...)
+		return err
+	}
+	s.logger.Info(ctx, "service delete address succeeded", fields...)
+	return nil
+}
@@ -0,0 +1,158 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/flags"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/google/uuid"
+)
+
+// stubRepository answers the calls Create and Update make with the customer
+// it was given; any other call panics.
+type stubRepository struct {
+	Repository
+}
+
+func (stubRepository) Create(_ context.Context, c *Customer) (*Customer, error) {
+	out := *c
+	out.ID = uuid.New()
+	return &out, nil
+}
+
+func (stubRepository) Get(_ context.Context, id uuid.UUID) (*Customer, error) {
+	return &Customer{ID: id, Name: "Asha", Email: "a@example.com", Phone: "+919876543210"}, nil
+}
+
+func (stubRepository) Update(_ context.Context, id uuid.UUID, upd UpdateCustomer) (*Customer, error) {
+	return &Customer{ID: id, Name: "Asha", Email: "a@example.com", Phone: *upd.Phone}, nil
+}
+
+func newFlaggedService(t *testing.T, spec string) *Service {
+	t.Helper()
+	p, err := flags.NewStatic(spec)
+	if err != nil {
+		t.Fatalf("NewStatic: %v", err)
+	}
+	log := logger.NewNop()
+	return NewService(stubRepository{}, log, audit.Nop{}, nil, nil, flags.NewClient(p, log), ContactVerification{})
+}
+
+func TestPhoneIndiaOnlySameForCreateAndUpdate(t *testing.T) {
+	svc := newFlaggedService(t, flags.PhoneIndiaOnly+"=50%")
+	const foreign = "+14155552671"
+	seen := map[bool]bool{}
+	for i := range 20 {
+		ctx := auth.WithTenant(context.Background(), fmt.Sprintf("tenant-%d", i))
+		_, err := svc.Create(ctx, &Customer{Name: "Asha", Email: "a@example.com", Phone: foreign})
+		onForCreate := errors.Is(err, ErrInvalidPhone)
+		if err != nil && !onForCreate {
+			t.Fatalf("create: %v", err)
+		}
+		seen[onForCreate] = true
+		for range 10 {
+			phone := foreign
+			_, err := svc.Update(ctx, uuid.New(), UpdateCustomer{Phone: &phone})
+			if onForUpdate := errors.Is(err, ErrInvalidPhone); onForUpdate != onForCreate {
+				t.Fatalf("tenant-%d: flag on for create %t, for update %t (err %v)", i, onForCreate, onForUpdate, err)
+			}
+		}
+	}
+	if !seen[true] || !seen[false] {
+		t.Fatalf("a 50%% rollout was on for create in %v of 20 tenants", seen)
+	}
+}
+
+// recordingAuditor keeps every entry recorded.
+type recordingAuditor struct {
+	entries []audit.Entry
+}
+
+func (a *recordingAuditor) Record(_ context.Context, e audit.Entry) error {
+	a.entries = append(a.entries, e)
+	return nil
+}
+
+// listRepository returns n customers from List and Export.
+type listRepository struct {
+	stubRepository
+	customers []Customer
+}
+
+func newListRepository(n int) *listRepository {
+	r := &listRepository{}
+	for range n {
+		r.customers = append(r.customers, Customer{ID: uuid.New(), Name: "Asha", Email: "a@example.com", Phone: "+919876543210"})
+	}
+	return r
+}
+
+func (r *listRepository) List(context.Context, Filter, int, int) ([]Customer, int, error) {
+	return r.customers, len(r.customers), nil
+}
+
+func (r *listRepository) Export(_ context.Context, _ Filter, fn func(*Customer) error) error {
+	for i := range r.customers {
+		c := r.customers[i]
+		if err := fn(&c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestListAuditsReturnedIDs(t *testing.T) {
+	repo, auditor := newListRepository(3), &recordingAuditor{}
+	svc := NewService(repo, logger.NewNop(), auditor, nil, nil, nil, ContactVerification{})
+	if _, _, err := svc.List(context.Background(), Filter{}, 1, 20); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(auditor.entries) != 1 {
+		t.Fatalf("%d audit entries, want 1", len(auditor.entries))
+	}
+	ids, _ := auditor.entries[0].Metadata[audit.MetadataCustomerIDs].([]string)
+	if len(ids) != 3 {
+		t.Fatalf("customer_ids = %v, want 3 IDs", ids)
+	}
+	for i, c := range repo.customers {
+		if ids[i] != c.ID.String() {
+			t.Fatalf("customer_ids[%d] = %s, want %s", i, ids[i], c.ID)
+		}
+	}
+}
+
+func TestExportAuditsExportedIDsInParts(t *testing.T) {
+	repo, auditor := newListRepository(exportAuditIDs*2+5), &recordingAuditor{}
+	svc := NewService(repo, logger.NewNop(), auditor, nil, nil, nil, ContactVerification{})
+	n, err := svc.Export(context.Background(), Filter{}, func(*Customer) error { return nil })
+	if err != nil || n != len(repo.customers) {
+		t.Fatalf("Export: n=%d err=%v", n, err)
+	}
+	if len(auditor.entries) != 3 {
+		t.Fatalf("%d audit entries, want 3", len(auditor.entries))
+	}
+	var got []string
+	for i, e := range auditor.entries {
+		if e.Action != ActionCustomerExport {
+			t.Fatalf("entry %d action %q", i, e.Action)
+		}
+		if first := e.Metadata["first_row"]; first != len(got) {
+			t.Fatalf("entry %d first_row = %v, want %d", i, first, len(got))
+		}
+		ids, _ := e.Metadata[audit.MetadataCustomerIDs].([]string)
+		got = append(got, ids...)
+	}
+	if rows := auditor.entries[2].Metadata["rows"]; rows != len(repo.customers) {
+		t.Fatalf("last entry rows = %v, want %d", rows, len(repo.customers))
+	}
+	for i, c := range repo.customers {
+		if got[i] != c.ID.String() {
+			t.Fatalf("exported ID %d = %s, want %s", i, got[i], c.ID)
+		}
+	}
+}
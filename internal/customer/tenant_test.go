@@ -0,0 +1,118 @@
+package customer_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/customer/customertest"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/google/uuid"
+)
+
+// TestTenantIsolation checks that every call naming a customer, address or
+// verification of tenant-a fails as not found for tenant-b and leaves
+// tenant-a's records untouched. It needs TEST_DATABASE_URL.
+func TestTenantIsolation(t *testing.T) {
+	svc, n := customertest.NewService(customer.NewPGRepository(customertest.Postgres(t), logger.NewNop(), nil, false), nil, nil)
+	a := auth.WithTenant(context.Background(), "tenant-a")
+	b := auth.WithTenant(context.Background(), "tenant-b")
+	suffix := uuid.NewString()[:8]
+
+	newCustomer := func(ctx context.Context, name, phone string) *customer.Customer {
+		t.Helper()
+		c, err := svc.Create(ctx, &customer.Customer{Name: name, Email: strings.ToLower(name) + "." + suffix + "@example.com", Phone: phone})
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		return c
+	}
+	asha := newCustomer(a, "Asha", "+919876543210")
+	ravi := newCustomer(a, "Ravi", "+919812345678")
+	bina := newCustomer(b, "Bina", "+919898989898")
+	addr, err := svc.CreateAddress(a, asha.ID, &customer.Address{Type: customer.AddressResidential, Line1: "12 MG Road", City: "Pune", State: "Maharashtra", PINCode: "411001"})
+	if err != nil {
+		t.Fatalf("create address: %v", err)
+	}
+	if _, err := svc.CreateVerification(a, asha.ID.String(), "ABCDE1234F"); err != nil {
+		t.Fatalf("create verification: %v", err)
+	}
+	if _, err := svc.StartContactVerification(a, asha.ID, customer.ContactEmail); err != nil {
+		t.Fatalf("start contact verification: %v", err)
+	}
+	code := n.Code(t)
+
+	name, city := "Mallory", "Delhi"
+	for _, tc := range []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"Get", func() error { _, err := svc.Get(b, asha.ID); return err }, customer.ErrNotFound},
+		{"Update", func() error { _, err := svc.Update(b, asha.ID, customer.UpdateCustomer{Name: &name}); return err }, customer.ErrNotFound},
+		{"AddTags", func() error { _, err := svc.AddTags(b, asha.ID, []string{"vip"}); return err }, customer.ErrNotFound},
+		{"RemoveTag", func() error { _, err := svc.RemoveTag(b, asha.ID, "vip"); return err }, customer.ErrNotFound},
+		{"Merge into foreign survivor", func() error { _, err := svc.Merge(b, asha.ID, bina.ID); return err }, customer.ErrNotFound},
+		{"Merge foreign duplicate", func() error { _, err := svc.Merge(b, bina.ID, ravi.ID); return err }, customer.ErrNotFound},
+		{"StartContactVerification", func() error { _, err := svc.StartContactVerification(b, asha.ID, customer.ContactEmail); return err }, customer.ErrNotFound},
+		{"ConfirmContactVerification", func() error {
+			_, err := svc.ConfirmContactVerification(b, asha.ID, customer.ContactEmail, code)
+			return err
+		}, customer.ErrNotFound},
+		{"CreateVerification", func() error { _, err := svc.CreateVerification(b, ravi.ID.String(), "FGHIJ5678K"); return err }, customer.ErrNotFound},
+		{"GetVerification", func() error { _, err := svc.GetVerificationByCustomerID(b, asha.ID.String()); return err }, customer.ErrVerificationNotFound},
+		{"CreateAddress", func() error {
+			_, err := svc.CreateAddress(b, asha.ID, &customer.Address{Type: customer.AddressPermanent, Line1: "1 Ring Road", City: "Delhi", State: "Delhi", PINCode: "110001"})
+			return err
+		}, customer.ErrNotFound},
+		{"ListAddresses", func() error { _, err := svc.ListAddresses(b, asha.ID); return err }, customer.ErrNotFound},
+		{"GetAddress", func() error { _, err := svc.GetAddress(b, asha.ID, addr.ID); return err }, customer.ErrAddressNotFound},
+		{"UpdateAddress", func() error {
+			_, err := svc.UpdateAddress(b, asha.ID, addr.ID, customer.UpdateAddress{City: &city})
+			return err
+		}, customer.ErrAddressNotFound},
+		{"UpdateAddress via own customer", func() error {
+			_, err := svc.UpdateAddress(b, bina.ID, addr.ID, customer.UpdateAddress{City: &city})
+			return err
+		}, customer.ErrAddressNotFound},
+		{"DeleteAddress", func() error { return svc.DeleteAddress(b, asha.ID, addr.ID) }, customer.ErrAddressNotFound},
+		{"SoftDelete", func() error { return svc.SoftDelete(b, asha.ID) }, customer.ErrNotFound},
+	} {
+		if err := tc.call(); !errors.Is(err, tc.want) {
+			t.Errorf("%s as tenant-b: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	got, err := svc.Get(a, asha.ID)
+	if err != nil {
+		t.Fatalf("tenant-a get after tenant-b calls: %v", err)
+	}
+	if got.Name != "Asha" || len(got.Tags) != 0 || got.EmailVerifiedAt != nil {
+		t.Errorf("tenant-a customer changed: name %q tags %v email verified %v", got.Name, got.Tags, got.EmailVerifiedAt)
+	}
+	if _, err := svc.Get(a, ravi.ID); err != nil {
+		t.Errorf("tenant-a duplicate merged by tenant-b: %v", err)
+	}
+	if v, err := svc.GetVerificationByCustomerID(a, ravi.ID.String()); err != nil || v.PANNumber != nil {
+		t.Errorf("tenant-a verification after tenant-b calls: %+v, %v", v, err)
+	}
+	if got, err := svc.GetAddress(a, asha.ID, addr.ID); err != nil || got.City != "Pune" {
+		t.Errorf("tenant-a address after tenant-b calls: %+v, %v", got, err)
+	}
+	if list, _, err := svc.List(b, customer.Filter{}, 1, 200); err != nil {
+		t.Errorf("tenant-b list: %v", err)
+	} else {
+		for _, c := range list {
+			if c.ID == asha.ID || c.ID == ravi.ID {
+				t.Errorf("tenant-b list includes tenant-a customer %s", c.ID)
+			}
+		}
+	}
+	// tenant-b's attempt with the right code must not have used it up.
+	if c, err := svc.ConfirmContactVerification(a, asha.ID, customer.ContactEmail, code); err != nil || c.EmailVerifiedAt == nil {
+		t.Errorf("tenant-a confirm: %v", err)
+	}
+}
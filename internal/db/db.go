@@ -2,31 +2,48 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/Archiit19/customer-service-go/internal/auth"
 	"github.com/Archiit19/customer-service-go/internal/config"
+	"github.com/Archiit19/customer-service-go/internal/health"
 	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/metrics"
+	"github.com/Archiit19/customer-service-go/internal/tracing"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewPool(ctx context.Context, cfg *config.Config, log logger.Logger) (*pgxpool.Pool, error) {
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName, cfg.DBSSLMode,
-	)
-
-	poolCfg, err := pgxpool.ParseConfig(dsn)
+// NewPool connects to PostgreSQL. When tracer is non-nil every statement is
+// recorded as a span. With cfg.DBTenantRLS each connection is tagged with the
+// tenant of the context it is acquired for, so row-level security policies
+// can enforce the tenant scoping the repositories already apply.
+func NewPool(ctx context.Context, cfg *config.Config, log logger.Logger, tracer *tracing.Tracer) (*pgxpool.Pool, error) {
+	poolCfg, err := pgxpool.ParseConfig(cfg.DSN())
 	if err != nil {
-		log.Error(ctx, "parse pool config failed", logger.Err(err))
-		return nil, fmt.Errorf("parse pool config: %w", err)
+		// pgx errors can echo the connection string, password included.
+		log.Error(ctx, "parse pool config failed")
+		return nil, errors.New("parse pool config: invalid database connection settings")
 	}
 	poolCfg.MaxConns = cfg.DBMaxConns
 	poolCfg.MinConns = cfg.DBMinConns
 	poolCfg.MaxConnIdleTime = cfg.DBMaxIdleTime
 	poolCfg.HealthCheckPeriod = 30 * time.Second
+	if tracer != nil {
+		poolCfg.ConnConfig.Tracer = queryTracer{tracer: tracer}
+	}
+	if cfg.DBTenantRLS {
+		poolCfg.PrepareConn = func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+			if _, err := conn.Exec(ctx, "SELECT set_config('app.tenant_id', $1, false);", auth.TenantFromContext(ctx)); err != nil {
+				return false, fmt.Errorf("set tenant: %w", err)
+			}
+			return true, nil
+		}
+	}
 
-	log.Info(ctx, "creating database pool", logger.String("host", cfg.DBHost), logger.String("port", cfg.DBPort), logger.String("database", cfg.DBName), logger.Int32("max_conns", cfg.DBMaxConns), logger.Int32("min_conns", cfg.DBMinConns), logger.Duration("max_idle_time", cfg.DBMaxIdleTime))
+	log.Info(ctx, "creating database pool", logger.String("host", poolCfg.ConnConfig.Host), logger.Int("port", int(poolCfg.ConnConfig.Port)), logger.String("database", poolCfg.ConnConfig.Database), logger.Int32("max_conns", cfg.DBMaxConns), logger.Int32("min_conns", cfg.DBMinConns), logger.Duration("max_idle_time", cfg.DBMaxIdleTime))
 	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		log.Error(ctx, "database pool creation failed", logger.Err(err))
@@ -44,3 +61,51 @@ func NewPool(ctx context.Context, cfg *config.Config, log logger.Logger) (*pgxpo
 	log.Info(ctx, "database pool ready")
 	return pool, nil
 }
+
+// RegisterPoolMetrics exposes pgxpool statistics on reg. Values are read from
+// pool.Stat at scrape time.
+func RegisterPoolMetrics(reg metrics.Registry, pool *pgxpool.Pool) {
+	gauge := func(name, help string, fn func(s *pgxpool.Stat) float64) {
+		reg.GaugeFunc(name, help, func() float64 { return fn(pool.Stat()) })
+	}
+	counter := func(name, help string, fn func(s *pgxpool.Stat) float64) {
+		reg.CounterFunc(name, help, func() float64 { return fn(pool.Stat()) })
+	}
+	gauge("db_pool_acquired_conns", "Connections currently checked out of the pool.",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) })
+	gauge("db_pool_idle_conns", "Idle connections in the pool.",
+		func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) })
+	gauge("db_pool_total_conns", "Open connections, acquired, idle and being constructed.",
+		func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) })
+	gauge("db_pool_max_conns", "Configured maximum pool size.",
+		func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) })
+	counter("db_pool_acquires_total", "Successful connection acquires.",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) })
+	counter("db_pool_empty_acquires_total", "Acquires that had to wait because the pool was empty.",
+		func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) })
+	counter("db_pool_acquire_wait_seconds_total", "Time spent waiting for a connection on an empty pool.",
+		func(s *pgxpool.Stat) float64 { return s.EmptyAcquireWaitTime().Seconds() })
+	counter("db_pool_acquire_duration_seconds_total", "Total time spent acquiring connections.",
+		func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() })
+}
+
+// SchemaVersion is the latest migration this binary expects. Bump it with
+// every new file in migrations/.
+const SchemaVersion = 23
+
+// RegisterHealthChecks adds database connectivity and schema version checks
+// to reg.
+func RegisterHealthChecks(reg *health.Registry, pool *pgxpool.Pool) {
+	reg.Register("postgres", 2*time.Second, pool.Ping)
+	reg.Register("migrations", 2*time.Second, func(ctx context.Context) error {
+		var version int
+		err := pool.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations;").Scan(&version)
+		if err != nil {
+			return fmt.Errorf("read schema version: %w", err)
+		}
+		if version < SchemaVersion {
+			return fmt.Errorf("schema at version %d, expected %d", version, SchemaVersion)
+		}
+		return nil
+	})
+}
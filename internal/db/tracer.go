@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Archiit19/customer-service-go/internal/tracing"
+	"github.com/jackc/pgx/v5"
+)
+
+// queryTracer wraps every SQL statement run through the pool in a client
+// span. Only the statement text is recorded; bind arguments may hold PII.
+type queryTracer struct {
+	tracer *tracing.Tracer
+}
+
+func (t queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	sql := strings.Join(strings.Fields(data.SQL), " ")
+	ctx, _ = t.tracer.Start(ctx, "db "+statementVerb(sql), tracing.KindClient,
+		tracing.String("db.system", "postgresql"),
+		tracing.String("db.statement", sql),
+	)
+	return ctx
+}
+
+func (t queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := tracing.SpanFromContext(ctx)
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		span.RecordError(data.Err)
+	} else {
+		span.SetAttributes(tracing.Int("db.rows_affected", int(data.CommandTag.RowsAffected())))
+	}
+	span.End()
+}
+
+func statementVerb(sql string) string {
+	verb, _, _ := strings.Cut(sql, " ")
+	if verb == "" {
+		return "query"
+	}
+	return strings.ToUpper(verb)
+}
@@ -0,0 +1,198 @@
+// Package flags gates behaviour behind feature flags so stricter rules can be
+// rolled out to a subset of tenants, principals or customers without a
+// redeploy.
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// Flags consulted by the service. Every flag is off unless a provider enables
+// it, so an unconfigured deployment keeps the established behaviour.
+const (
+	// KYCVerifiedRequiresValidPAN rejects a transition to VERIFIED unless the
+	// stored PAN is well formed (five letters, four digits, one letter).
+	KYCVerifiedRequiresValidPAN = "kyc.verified_requires_valid_pan"
+	// PhoneIndiaOnly rejects phone numbers outside India (+91) on create and
+	// update. It is evaluated for the tenant or principal on both, never
+	// the customer.
+	PhoneIndiaOnly = "phone.india_only"
+)
+
+// Known lists every flag the service consults, with what it does. Providers
+// reject keys that are not listed here.
+var Known = map[string]string{
+	KYCVerifiedRequiresValidPAN: "Require a well-formed PAN before a customer can become VERIFIED",
+	PhoneIndiaOnly:              "Accept only Indian (+91) phone numbers",
+}
+
+var (
+	ErrUnknownFlag = errors.New("unknown flag")
+	ErrInvalidFlag = errors.New("invalid flag")
+)
+
+// Flag is the rollout state of one flag. A disabled flag is off for everyone.
+// An enabled flag is on for the listed tenants and principals, and for
+// Rollout percent of everyone else.
+type Flag struct {
+	Key        string    `json:"key"`
+	Enabled    bool      `json:"enabled"`
+	Rollout    int       `json:"rollout"`
+	Tenants    []string  `json:"tenants"`
+	Principals []string  `json:"principals"`
+	UpdatedAt  time.Time `json:"updated_at,omitzero"`
+	UpdatedBy  string    `json:"updated_by,omitempty"`
+}
+
+// Validate reports whether f can be stored.
+func (f Flag) Validate() error {
+	if _, ok := Known[f.Key]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownFlag, f.Key)
+	}
+	if f.Rollout < 0 || f.Rollout > 100 {
+		return fmt.Errorf("%w: rollout must be between 0 and 100", ErrInvalidFlag)
+	}
+	return nil
+}
+
+// Subject is who a flag is evaluated for. Percentage rollouts bucket by the
+// most specific ID available, so a customer sees the same result on every
+// request regardless of which principal acts on it.
+type Subject struct {
+	Tenant    string
+	Principal string
+	Customer  string
+}
+
+func (s Subject) unit() string {
+	switch {
+	case s.Customer != "":
+		return "customer:" + s.Customer
+	case s.Tenant != "":
+		return "tenant:" + s.Tenant
+	case s.Principal != "":
+		return "principal:" + s.Principal
+	default:
+		return ""
+	}
+}
+
+// Evaluate reports whether f is on for s.
+func (f Flag) Evaluate(s Subject) bool {
+	if !f.Enabled {
+		return false
+	}
+	if s.Tenant != "" && slices.Contains(f.Tenants, s.Tenant) {
+		return true
+	}
+	if s.Principal != "" && slices.Contains(f.Principals, s.Principal) {
+		return true
+	}
+	if f.Rollout >= 100 {
+		return true
+	}
+	unit := s.unit()
+	if f.Rollout <= 0 || unit == "" {
+		return false
+	}
+	return bucket(f.Key, unit) < f.Rollout
+}
+
+// bucket maps unit to 0-99, independently for each flag so the same subjects
+// are not always the first to receive every rollout.
+func bucket(key, unit string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key + "\x00" + unit))
+	return int(h.Sum32() % 100)
+}
+
+// Provider stores flag state.
+type Provider interface {
+	// List returns the stored state of every flag that has one.
+	List(ctx context.Context) ([]Flag, error)
+	// Get returns the state of key; ok is false when none is stored.
+	Get(ctx context.Context, key string) (f Flag, ok bool, err error)
+	// Set validates and stores f.
+	Set(ctx context.Context, f Flag) error
+}
+
+// Client evaluates flags from a Provider. A nil *Client treats every flag as
+// off.
+type Client struct {
+	provider Provider
+	logger   logger.Logger
+}
+
+func NewClient(p Provider, log logger.Logger) *Client {
+	return &Client{provider: p, logger: log}
+}
+
+// Enabled reports whether key is on for s. Provider errors are logged and
+// treated as off, so an outage falls back to the established behaviour.
+func (c *Client) Enabled(ctx context.Context, key string, s Subject) bool {
+	if c == nil {
+		return false
+	}
+	f, ok, err := c.provider.Get(ctx, key)
+	if err != nil {
+		c.logger.Error(ctx, "flag evaluation failed", logger.String("flag", key), logger.Err(err))
+		return false
+	}
+	on := ok && f.Evaluate(s)
+	c.logger.Debug(ctx, "flag evaluated", logger.String("flag", key), logger.Bool("enabled", on))
+	return on
+}
+
+// List returns every known flag, including those with no stored state, which
+// are reported disabled.
+func (c *Client) List(ctx context.Context) ([]Flag, error) {
+	stored, err := c.provider.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]Flag, len(stored))
+	for _, f := range stored {
+		byKey[f.Key] = f
+	}
+	flags := make([]Flag, 0, len(Known))
+	for key := range Known {
+		f, ok := byKey[key]
+		if !ok {
+			f = Flag{Key: key}
+		}
+		flags = append(flags, f)
+	}
+	slices.SortFunc(flags, func(a, b Flag) int { return strings.Compare(a.Key, b.Key) })
+	return flags, nil
+}
+
+// Get returns the state of key, reported disabled when none is stored.
+func (c *Client) Get(ctx context.Context, key string) (Flag, error) {
+	if _, ok := Known[key]; !ok {
+		return Flag{}, fmt.Errorf("%w %q", ErrUnknownFlag, key)
+	}
+	f, ok, err := c.provider.Get(ctx, key)
+	if err != nil {
+		return Flag{}, err
+	}
+	if !ok {
+		f = Flag{Key: key}
+	}
+	return f, nil
+}
+
+// Set stores f.
+func (c *Client) Set(ctx context.Context, f Flag) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	return c.provider.Set(ctx, f)
+}
@@ -0,0 +1,163 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name string
+		flag Flag
+		subj Subject
+		want bool
+	}{
+		{"disabled", Flag{Key: PhoneIndiaOnly, Rollout: 100, Tenants: []string{"acme"}}, Subject{Tenant: "acme"}, false},
+		{"on for everyone", Flag{Key: PhoneIndiaOnly, Enabled: true, Rollout: 100}, Subject{}, true},
+		{"listed tenant", Flag{Key: PhoneIndiaOnly, Enabled: true, Tenants: []string{"acme"}}, Subject{Tenant: "acme"}, true},
+		{"listed principal", Flag{Key: PhoneIndiaOnly, Enabled: true, Principals: []string{"crm"}}, Subject{Tenant: "other", Principal: "crm"}, true},
+		{"unlisted tenant at 0%", Flag{Key: PhoneIndiaOnly, Enabled: true, Tenants: []string{"acme"}}, Subject{Tenant: "other"}, false},
+		{"rollout without a subject", Flag{Key: PhoneIndiaOnly, Enabled: true, Rollout: 99}, Subject{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.flag.Evaluate(tt.subj); got != tt.want {
+				t.Errorf("Evaluate(%+v) = %v, want %v", tt.subj, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateRolloutIsStableAndProportional(t *testing.T) {
+	f := Flag{Key: KYCVerifiedRequiresValidPAN, Enabled: true, Rollout: 25}
+	on := 0
+	for i := range 2000 {
+		s := Subject{Tenant: "acme", Customer: fmt.Sprintf("customer-%d", i)}
+		got := f.Evaluate(s)
+		if got != f.Evaluate(s) {
+			t.Fatalf("%+v evaluated differently on a second call", s)
+		}
+		// The customer, not the tenant, is the rollout unit.
+		if got != (bucket(f.Key, "customer:"+s.Customer) < 25) {
+			t.Fatalf("%+v not bucketed by customer", s)
+		}
+		if got {
+			on++
+		}
+	}
+	if on < 400 || on > 600 {
+		t.Errorf("25%% rollout enabled %d of 2000 customers", on)
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	flags, err := ParseSpec(" phone.india_only=0%,tenants:acme|beta, principals:crm ; kyc.verified_requires_valid_pan=on;")
+	if err != nil {
+		t.Fatalf("ParseSpec: %v", err)
+	}
+	if len(flags) != 2 {
+		t.Fatalf("ParseSpec returned %d flags, want 2", len(flags))
+	}
+	phone, kyc := flags[0], flags[1]
+	if phone.Key != PhoneIndiaOnly || !phone.Enabled || phone.Rollout != 0 ||
+		!slices.Equal(phone.Tenants, []string{"acme", "beta"}) || !slices.Equal(phone.Principals, []string{"crm"}) {
+		t.Errorf("phone flag %+v", phone)
+	}
+	if kyc.Key != KYCVerifiedRequiresValidPAN || !kyc.Enabled || kyc.Rollout != 100 {
+		t.Errorf("kyc flag %+v", kyc)
+	}
+
+	for _, bad := range []string{
+		"phone.india_only",
+		"phone.india_only=maybe",
+		"phone.india_only=x%",
+		"phone.india_only=150%",
+		"phone.india_only=on,regions:eu",
+		"phone.india_only=on,tenants",
+		"no.such_flag=on",
+	} {
+		if _, err := ParseSpec(bad); err == nil {
+			t.Errorf("ParseSpec(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestStaticSet(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewStatic("phone.india_only=off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ctx, Flag{Key: "no.such_flag", Enabled: true}); !errors.Is(err, ErrUnknownFlag) {
+		t.Errorf("Set of an unknown flag: %v, want %v", err, ErrUnknownFlag)
+	}
+	if err := s.Set(ctx, Flag{Key: PhoneIndiaOnly, Enabled: true, Rollout: 101}); !errors.Is(err, ErrInvalidFlag) {
+		t.Errorf("Set with rollout 101: %v, want %v", err, ErrInvalidFlag)
+	}
+	if err := s.Set(ctx, Flag{Key: PhoneIndiaOnly, Enabled: true, Rollout: 100}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	f, ok, err := s.Get(ctx, PhoneIndiaOnly)
+	if err != nil || !ok || !f.Enabled || f.UpdatedAt.IsZero() {
+		t.Errorf("Get after Set = %+v, %v, %v", f, ok, err)
+	}
+	if _, ok, _ := s.Get(ctx, KYCVerifiedRequiresValidPAN); ok {
+		t.Error("Get of an unset flag reported it stored")
+	}
+}
+
+// failingProvider fails every call, standing in for an unreachable store.
+type failingProvider struct{ err error }
+
+func (p failingProvider) List(context.Context) ([]Flag, error) {
+	return nil, p.err
+}
+
+func (p failingProvider) Get(context.Context, string) (Flag, bool, error) {
+	return Flag{}, false, p.err
+}
+
+func (p failingProvider) Set(context.Context, Flag) error {
+	return p.err
+}
+
+func TestClientFallsBackToOff(t *testing.T) {
+	ctx := context.Background()
+	var nilClient *Client
+	if nilClient.Enabled(ctx, PhoneIndiaOnly, Subject{Tenant: "acme"}) {
+		t.Error("nil client reported a flag on")
+	}
+	c := NewClient(failingProvider{err: errors.New("connection refused")}, logger.NewNop())
+	if c.Enabled(ctx, PhoneIndiaOnly, Subject{Tenant: "acme"}) {
+		t.Error("flag reported on while the provider fails")
+	}
+}
+
+func TestClientListReportsEveryKnownFlag(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewStatic("phone.india_only=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(s, logger.NewNop())
+	flags, err := c.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != len(Known) {
+		t.Fatalf("List returned %d flags, want %d", len(flags), len(Known))
+	}
+	for _, f := range flags {
+		if want := f.Key == PhoneIndiaOnly; f.Enabled != want {
+			t.Errorf("flag %s enabled %v, want %v", f.Key, f.Enabled, want)
+		}
+	}
+	if _, err := c.Get(ctx, "no.such_flag"); !errors.Is(err, ErrUnknownFlag) {
+		t.Errorf("Get of an unknown flag: %v, want %v", err, ErrUnknownFlag)
+	}
+}
@@ -0,0 +1,121 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PGProvider stores flags in the feature_flags table so every replica shares
+// them. Reads are served from a cache refreshed by Run; writes go to the
+// database and update the local cache immediately, and reach other replicas
+// on their next refresh.
+type PGProvider struct {
+	pool   *pgxpool.Pool
+	logger logger.Logger
+
+	mu    sync.RWMutex
+	flags map[string]Flag
+}
+
+func NewPGProvider(pool *pgxpool.Pool, log logger.Logger) *PGProvider {
+	return &PGProvider{pool: pool, logger: log, flags: map[string]Flag{}}
+}
+
+// Refresh reloads the cache from the database.
+func (p *PGProvider) Refresh(ctx context.Context) error {
+	rows, err := p.pool.Query(ctx, `
+SELECT key, enabled, rollout, tenants, principals, updated_at, updated_by
+FROM feature_flags;
+`)
+	if err != nil {
+		return fmt.Errorf("query feature flags: %w", err)
+	}
+	flags, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Flag, error) {
+		var f Flag
+		err := row.Scan(&f.Key, &f.Enabled, &f.Rollout, &f.Tenants, &f.Principals, &f.UpdatedAt, &f.UpdatedBy)
+		return f, err
+	})
+	if err != nil {
+		return fmt.Errorf("scan feature flags: %w", err)
+	}
+	m := make(map[string]Flag, len(flags))
+	for _, f := range flags {
+		m[f.Key] = f
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flags = m
+	return nil
+}
+
+// Run refreshes the cache every interval until ctx is cancelled. A failed
+// refresh keeps the previous flags.
+func (p *PGProvider) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := p.Refresh(ctx); err != nil && ctx.Err() == nil {
+				p.logger.Warn(ctx, "feature flag refresh failed", logger.Err(err))
+			}
+		}
+	}
+}
+
+func (p *PGProvider) List(context.Context) ([]Flag, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	flags := make([]Flag, 0, len(p.flags))
+	for _, f := range p.flags {
+		flags = append(flags, f)
+	}
+	return flags, nil
+}
+
+func (p *PGProvider) Get(_ context.Context, key string) (Flag, bool, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	f, ok := p.flags[key]
+	return f, ok, nil
+}
+
+func (p *PGProvider) Set(ctx context.Context, f Flag) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	if f.Tenants == nil {
+		f.Tenants = []string{}
+	}
+	if f.Principals == nil {
+		f.Principals = []string{}
+	}
+	err := p.pool.QueryRow(ctx, `
+INSERT INTO feature_flags (key, enabled, rollout, tenants, principals, updated_by, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, now())
+ON CONFLICT (key) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    rollout = EXCLUDED.rollout,
+    tenants = EXCLUDED.tenants,
+    principals = EXCLUDED.principals,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = EXCLUDED.updated_at
+RETURNING updated_at;
+`, f.Key, f.Enabled, f.Rollout, f.Tenants, f.Principals, f.UpdatedBy).Scan(&f.UpdatedAt)
+	if err != nil {
+		p.logger.Error(ctx, "feature flag update failed", logger.String("flag", f.Key), logger.Err(err))
+		return fmt.Errorf("update feature flag: %w", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flags[f.Key] = f
+	return nil
+}
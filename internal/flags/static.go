@@ -0,0 +1,132 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Static keeps flags in memory, seeded from configuration. Changes made
+// through Set last until the process restarts or Replace is called, and are
+// not shared between replicas.
+type Static struct {
+	mu    sync.RWMutex
+	flags map[string]Flag
+}
+
+// NewStatic returns a provider holding the flags described by spec; see
+// ParseSpec.
+func NewStatic(spec string) (*Static, error) {
+	flags, err := ParseSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	s := &Static{}
+	s.Replace(flags)
+	return s, nil
+}
+
+// Replace discards every stored flag and stores flags instead.
+func (s *Static) Replace(flags []Flag) {
+	m := make(map[string]Flag, len(flags))
+	for _, f := range flags {
+		m[f.Key] = f
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags = m
+}
+
+func (s *Static) List(context.Context) ([]Flag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	flags := make([]Flag, 0, len(s.flags))
+	for _, f := range s.flags {
+		flags = append(flags, f)
+	}
+	return flags, nil
+}
+
+func (s *Static) Get(_ context.Context, key string) (Flag, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.flags[key]
+	return f, ok, nil
+}
+
+func (s *Static) Set(_ context.Context, f Flag) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	f.UpdatedAt = time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags[f.Key] = f
+	return nil
+}
+
+// ParseSpec parses a ";"-separated list of "<key>=<state>[,<target>...]"
+// entries. The state is "on", "off" or a rollout percentage such as "25%";
+// targets are "tenants:<id>|<id>" and "principals:<id>|<id>". For example
+// "phone.india_only=0%,tenants:acme" enables a flag for tenant acme only.
+func ParseSpec(spec string) ([]Flag, error) {
+	var flags []Flag
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, rest, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("flag %q: expected <key>=<state>", entry)
+		}
+		parts := strings.Split(rest, ",")
+		f := Flag{Key: strings.TrimSpace(key)}
+		switch state := strings.ToLower(strings.TrimSpace(parts[0])); {
+		case state == "on":
+			f.Enabled, f.Rollout = true, 100
+		case state == "off":
+		case strings.HasSuffix(state, "%"):
+			n, err := strconv.Atoi(strings.TrimSuffix(state, "%"))
+			if err != nil {
+				return nil, fmt.Errorf("flag %q: invalid rollout %q", f.Key, state)
+			}
+			f.Enabled, f.Rollout = true, n
+		default:
+			return nil, fmt.Errorf("flag %q: state must be on, off or a percentage", f.Key)
+		}
+		for _, target := range parts[1:] {
+			kind, ids, ok := strings.Cut(strings.TrimSpace(target), ":")
+			if !ok {
+				return nil, fmt.Errorf("flag %q: target %q: expected <kind>:<id>|<id>", f.Key, target)
+			}
+			list := splitIDs(ids)
+			switch strings.TrimSpace(kind) {
+			case "tenants":
+				f.Tenants = list
+			case "principals":
+				f.Principals = list
+			default:
+				return nil, fmt.Errorf("flag %q: unknown target %q; expected tenants or principals", f.Key, kind)
+			}
+		}
+		if err := f.Validate(); err != nil {
+			return nil, err
+		}
+		flags = append(flags, f)
+	}
+	return flags, nil
+}
+
+func splitIDs(s string) []string {
+	var ids []string
+	for _, id := range strings.Split(s, "|") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
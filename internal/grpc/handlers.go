@@ -0,0 +1,266 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	customerv1 "github.com/Archiit19/customer-service-go/proto/customer/v1"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Handler implements customerv1.CustomerServiceServer on top of
+// customer.Service.
+type Handler struct {
+	customerv1.UnimplementedCustomerServiceServer
+	svc    *customer.Service
+	logger logger.Logger
+}
+
+func NewHandler(svc *customer.Service, log logger.Logger) *Handler {
+	return &Handler{svc: svc, logger: log}
+}
+
+func (h *Handler) CreateCustomer(ctx context.Context, req *customerv1.CreateCustomerRequest) (*customerv1.Customer, error) {
+	h.logger.Info(ctx, "grpc create customer received")
+	created, err := h.svc.Create(ctx, &customer.Customer{
+		Name:  req.GetName(),
+		Email: req.GetEmail(),
+		Phone: req.GetPhone(),
+	})
+	if err != nil {
+		return nil, h.fail(ctx, "create customer", err)
+	}
+	h.logger.Info(ctx, "grpc create customer succeeded", logger.String("customer_id", created.ID.String()))
+	return toCustomer(created), nil
+}
+
+func (h *Handler) GetCustomer(ctx context.Context, req *customerv1.GetCustomerRequest) (*customerv1.Customer, error) {
+	h.logger.Info(ctx, "grpc get customer received", logger.String("customer_id", req.GetCustomerId()))
+	id, err := h.parseID(ctx, "get customer", req.GetCustomerId())
+	if err != nil {
+		return nil, err
+	}
+	cust, err := h.svc.Get(ctx, id)
+	if err != nil {
+		return nil, h.fail(ctx, "get customer", err, logger.String("customer_id", req.GetCustomerId()))
+	}
+	h.logger.Info(ctx, "grpc get customer succeeded", logger.String("customer_id", cust.ID.String()))
+	return toCustomer(cust), nil
+}
+
+func (h *Handler) ListCustomers(ctx context.Context, req *customerv1.ListCustomersRequest) (*customerv1.ListCustomersResponse, error) {
+	page, limit := int(req.GetPage()), int(req.GetLimit())
+	if page < 0 || limit < 0 {
+		h.logger.Warn(ctx, "grpc list customers invalid paging", logger.Int("page", page), logger.Int("limit", limit))
+		return nil, status.Error(codes.InvalidArgument, "page and limit must not be negative")
+	}
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = 20
+	}
+	h.logger.Info(ctx, "grpc list customers received", logger.Int("page", page), logger.Int("limit", limit))
+	items, total, err := h.svc.List(ctx, customer.Filter{}, page, limit)
+	if err != nil {
+		return nil, h.fail(ctx, "list customers", err)
+	}
+	resp := &customerv1.ListCustomersResponse{
+		Page:  int32(page),
+		Limit: int32(limit),
+		Total: int32(total),
+	}
+	for i := range items {
+		resp.Customers = append(resp.Customers, toCustomer(&items[i]))
+	}
+	h.logger.Info(ctx, "grpc list customers succeeded", logger.Int("returned", len(items)), logger.Int("total", total))
+	return resp, nil
+}
+
+func (h *Handler) UpdateCustomer(ctx context.Context, req *customerv1.UpdateCustomerRequest) (*customerv1.Customer, error) {
+	h.logger.Info(ctx, "grpc update customer received", logger.String("customer_id", req.GetCustomerId()))
+	id, err := h.parseID(ctx, "update customer", req.GetCustomerId())
+	if err != nil {
+		return nil, err
+	}
+	updated, err := h.svc.Update(ctx, id, customer.UpdateCustomer{Name: req.Name, Email: req.Email, Phone: req.Phone})
+	if err != nil {
+		return nil, h.fail(ctx, "update customer", err, logger.String("customer_id", req.GetCustomerId()))
+	}
+	h.logger.Info(ctx, "grpc update customer succeeded", logger.String("customer_id", updated.ID.String()))
+	return toCustomer(updated), nil
+}
+
+func (h *Handler) DeleteCustomer(ctx context.Context, req *customerv1.DeleteCustomerRequest) (*customerv1.DeleteCustomerResponse, error) {
+	h.logger.Info(ctx, "grpc delete customer received", logger.String("customer_id", req.GetCustomerId()))
+	id, err := h.parseID(ctx, "delete customer", req.GetCustomerId())
+	if err != nil {
+		return nil, err
+	}
+	if err := h.svc.SoftDelete(ctx, id); err != nil {
+		return nil, h.fail(ctx, "delete customer", err, logger.String("customer_id", req.GetCustomerId()))
+	}
+	h.logger.Info(ctx, "grpc delete customer succeeded", logger.String("customer_id", req.GetCustomerId()))
+	return &customerv1.DeleteCustomerResponse{}, nil
+}
+
+func (h *Handler) GetVerification(ctx context.Context, req *customerv1.GetVerificationRequest) (*customerv1.Verification, error) {
+	id := req.GetCustomerId()
+	h.logger.Info(ctx, "grpc get verification received", logger.String("customer_id", id))
+	if _, err := h.parseID(ctx, "get verification", id); err != nil {
+		return nil, err
+	}
+	verification, err := h.svc.GetVerificationByCustomerID(ctx, id)
+	if err != nil {
+		return nil, h.fail(ctx, "get verification", err, logger.String("customer_id", id))
+	}
+	h.logger.Info(ctx, "grpc get verification succeeded", logger.String("customer_id", id))
+	return toVerification(verification.Masked()), nil
+}
+
+func (h *Handler) SubmitPAN(ctx context.Context, req *customerv1.SubmitPANRequest) (*customerv1.Verification, error) {
+	id := req.GetCustomerId()
+	h.logger.Info(ctx, "grpc submit pan received", logger.String("customer_id", id))
+	if _, err := h.parseID(ctx, "submit pan", id); err != nil {
+		return nil, err
+	}
+	if req.GetPanNumber() == "" {
+		h.logger.Warn(ctx, "grpc submit pan missing pan_number", logger.String("customer_id", id))
+		return nil, status.Error(codes.InvalidArgument, "pan_number is required")
+	}
+	verification, err := h.svc.CreateVerification(ctx, id, req.GetPanNumber())
+	if err != nil {
+		return nil, h.fail(ctx, "submit pan", err, logger.String("customer_id", id))
+	}
+	h.logger.Info(ctx, "grpc submit pan succeeded", logger.String("verification_id", verification.ID.String()), logger.String("customer_id", id))
+	return toVerification(verification.Masked()), nil
+}
+
+func (h *Handler) UpdateVerificationStatus(ctx context.Context, req *customerv1.UpdateVerificationStatusRequest) (*customerv1.Verification, error) {
+	id := req.GetCustomerId()
+	h.logger.Info(ctx, "grpc update verification status received", logger.String("customer_id", id), logger.String("status", req.GetStatus()))
+	if _, err := h.parseID(ctx, "update verification status", id); err != nil {
+		return nil, err
+	}
+	current, err := h.svc.GetVerificationByCustomerID(ctx, id)
+	if err != nil {
+		return nil, h.fail(ctx, "update verification status", err, logger.String("customer_id", id))
+	}
+	if current.PANNumber == nil || *current.PANNumber == "" {
+		h.logger.Warn(ctx, "grpc update verification status rejected: missing PAN", logger.String("customer_id", id))
+		return nil, status.Error(codes.FailedPrecondition, "pan_number must be provided before updating status")
+	}
+	verification, err := h.svc.UpdateVerificationStatus(ctx, id, req.GetStatus())
+	if err != nil {
+		return nil, h.fail(ctx, "update verification status", err, logger.String("customer_id", id))
+	}
+	h.logger.Info(ctx, "grpc update verification status succeeded", logger.String("verification_id", verification.ID.String()), logger.String("customer_id", id), logger.String("status", string(verification.Status)))
+	return toVerification(verification.Masked()), nil
+}
+
+// RevealPAN returns the verification record with the unmasked PAN. The reason
+// comes from the request, falling back to the x-access-reason metadata entry.
+func (h *Handler) RevealPAN(ctx context.Context, req *customerv1.RevealPANRequest) (*customerv1.Verification, error) {
+	id := req.GetCustomerId()
+	h.logger.Info(ctx, "grpc reveal pan received", logger.String("customer_id", id))
+	if _, err := h.parseID(ctx, "reveal pan", id); err != nil {
+		return nil, err
+	}
+	reason := req.GetReason()
+	if reason == "" {
+		reason = incoming(ctx, accessReasonKey)
+	}
+	verification, err := h.svc.RevealPAN(ctx, id, reason)
+	if err != nil {
+		return nil, h.fail(ctx, "reveal pan", err, logger.String("customer_id", id))
+	}
+	h.logger.Info(ctx, "grpc reveal pan succeeded", logger.String("customer_id", id))
+	return toVerification(*verification), nil
+}
+
+func (h *Handler) parseID(ctx context.Context, op, s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		h.logger.Warn(ctx, "grpc "+op+" invalid id", logger.Err(err), logger.String("customer_id", s))
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid customer_id")
+	}
+	return id, nil
+}
+
+// fail logs err and converts it to a gRPC status. Failures the caller can fix
+// are logged as warnings and keep their message; anything else is logged as
+// an error and reported as codes.Internal without detail.
+func (h *Handler) fail(ctx context.Context, op string, err error, fields ...logger.Field) error {
+	st := toStatus(err)
+	fields = append(fields, logger.Err(err), logger.String("code", st.Code().String()))
+	if st.Code() == codes.Internal {
+		h.logger.Error(ctx, "grpc "+op+" internal failure", fields...)
+	} else {
+		h.logger.Warn(ctx, "grpc "+op+" failed", fields...)
+	}
+	return st.Err()
+}
+
+// toStatus maps customer.Service errors to gRPC status codes, matching the
+// HTTP status each error produces on the REST API.
+func toStatus(err error) *status.Status {
+	var code codes.Code
+	switch {
+	case errors.Is(err, customer.ErrInvalidName), errors.Is(err, customer.ErrInvalidEmail),
+		errors.Is(err, customer.ErrInvalidPhone), errors.Is(err, customer.ErrInvalidStatus),
+		customer.IsInvalidProfile(err):
+		code = codes.InvalidArgument
+	case errors.Is(err, customer.ErrNotFound), errors.Is(err, customer.ErrVerificationNotFound):
+		code = codes.NotFound
+	case errors.Is(err, customer.ErrConflict), errors.Is(err, customer.ErrPANAlreadyExists):
+		code = codes.AlreadyExists
+	case errors.Is(err, customer.ErrInvalidPAN):
+		code = codes.FailedPrecondition
+	case errors.Is(err, customer.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	default:
+		return status.New(codes.Internal, "internal error")
+	}
+	return status.New(code, err.Error())
+}
+
+func toCustomer(c *customer.Customer) *customerv1.Customer {
+	out := &customerv1.Customer{
+		CustomerId: c.ID.String(),
+		Name:       c.Name,
+		Email:      c.Email,
+		Phone:      c.Phone,
+		Status:     c.Status,
+		CreatedAt:  timestamppb.New(c.CreatedAt),
+		UpdatedAt:  timestamppb.New(c.UpdatedAt),
+	}
+	if pan := c.Masked().PANNumber; pan != nil {
+		out.PanNumber = *pan
+	}
+	return out
+}
+
+// toVerification converts v as given; callers mask the PAN unless it is
+// being revealed.
+func toVerification(v customer.Verification) *customerv1.Verification {
+	out := &customerv1.Verification{
+		Id:         v.ID.String(),
+		CustomerId: v.CustomerID.String(),
+		Status:     string(v.Status),
+		CreatedAt:  timestamppb.New(v.CreatedAt),
+		UpdatedAt:  timestamppb.New(v.UpdatedAt),
+	}
+	if v.PANNumber != nil {
+		out.PanNumber = *v.PANNumber
+	}
+	return out
+}
@@ -0,0 +1,206 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/ratelimit"
+	"github.com/Archiit19/customer-service-go/internal/tracing"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// WithRequestContext stores the caller's x-request-id, or a new one, in the
+// context, echoes it in the response header and logs each call.
+func WithRequestContext(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		requestID := incoming(ctx, requestIDKey)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		ctx = logger.WithRequestID(ctx, requestID)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+		start := time.Now()
+		log.Info(ctx, "grpc request started", logger.String("method", info.FullMethod), logger.String("remote_addr", peerAddr(ctx)))
+		resp, err := handler(ctx, req)
+		log.Info(ctx, "grpc request completed", logger.String("method", info.FullMethod), logger.String("code", status.Code(err).String()), logger.Duration("duration", time.Since(start)))
+		return resp, err
+	}
+}
+
+// Recovery turns a panic in a handler into codes.Internal.
+func Recovery(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Error(ctx, "grpc panic recovered", logger.Any("panic", rec), logger.String("method", info.FullMethod))
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// Tracing starts a server span named after the full method for each call,
+// continuing the caller's trace when a traceparent entry is present.
+func Tracing(tracer *tracing.Tracer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if sc, err := tracing.ParseTraceparent(incoming(ctx, traceparentKey)); err == nil {
+			ctx = tracing.ContextWithRemote(ctx, sc)
+		}
+		ctx, span := tracer.Start(ctx, info.FullMethod, tracing.KindServer,
+			tracing.String("rpc.system", "grpc"),
+			tracing.String("rpc.method", info.FullMethod),
+		)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		code := status.Code(err)
+		span.SetAttributes(tracing.Int("rpc.grpc.status_code", int(code)))
+		if serverFault(code) {
+			span.RecordError(err)
+		}
+		return resp, err
+	}
+}
+
+// Authenticate resolves the x-api-key entry to a principal and stores it,
+// together with the client IP, in the context. It follows the same rules as
+// the HTTP middleware of the same name, including the limit on failed
+// authentications per client IP.
+func Authenticate(authn *auth.Authenticator, required bool, limiter *ratelimit.Limiter, log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ip := clientIP(ctx)
+		ctx = auth.WithClientIP(ctx, ip)
+		principal := auth.Anonymous
+		var key string
+		if authn.Enabled() {
+			key = incoming(ctx, apiKeyKey)
+		}
+		switch {
+		case key != "":
+			if limiter != nil {
+				res, err := limiter.Check(ctx, "ip:"+ip, "", ratelimit.AuthFailures)
+				if err != nil {
+					log.Warn(ctx, "authentication failure limit check failed; allowing request", logger.Err(err))
+				} else if !res.Allowed {
+					_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds())))))
+					log.Warn(ctx, "grpc request authentication throttled", logger.String("method", info.FullMethod), logger.Duration("retry_after", res.RetryAfter))
+					return nil, status.Error(codes.ResourceExhausted, "too many failed authentications")
+				}
+			}
+			p, err := authn.Authenticate(key)
+			if err != nil {
+				if limiter != nil {
+					if _, err := limiter.Allow(ctx, "ip:"+ip, "", ratelimit.AuthFailures); err != nil {
+						log.Warn(ctx, "authentication failure count failed", logger.Err(err))
+					}
+				}
+				log.Warn(ctx, "grpc request authentication failed", logger.String("method", info.FullMethod))
+				return nil, status.Error(codes.Unauthenticated, "invalid API key")
+			}
+			principal = p
+		case required:
+			log.Warn(ctx, "grpc request missing credentials", logger.String("method", info.FullMethod))
+			return nil, status.Error(codes.Unauthenticated, "missing API key")
+		}
+		return handler(auth.WithPrincipal(ctx, principal), req)
+	}
+}
+
+// ResolveTenant stores the tenant a call acts in, chosen from x-tenant-id by
+// auth.ResolveTenant.
+func ResolveTenant(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		principal := auth.FromContext(ctx)
+		requested := incoming(ctx, tenantKey)
+		tenant, err := auth.ResolveTenant(principal, requested)
+		switch {
+		case errors.Is(err, auth.ErrTenantNotPermitted):
+			log.Warn(ctx, "grpc request tenant not permitted", logger.String("principal", principal.ID), logger.String("tenant", requested))
+			return nil, status.Error(codes.PermissionDenied, "tenant not permitted")
+		case err != nil:
+			log.Warn(ctx, "grpc request invalid tenant", logger.String("tenant", requested))
+			return nil, status.Error(codes.InvalidArgument, "invalid "+tenantKey)
+		}
+		return handler(auth.WithTenant(ctx, tenant), req)
+	}
+}
+
+// RateLimit enforces the limiter's token buckets per client and method. Calls
+// are matched against routes as "POST <full method>", e.g.
+// "POST /customer.v1.CustomerService/ListCustomers", and otherwise fall under
+// the default rule. Store failures let the call through, as over HTTP.
+func RateLimit(limiter *ratelimit.Limiter, log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		res, err := limiter.Allow(ctx, clientKey(ctx), "POST", info.FullMethod)
+		if err != nil {
+			log.Warn(ctx, "rate limit check failed; allowing request", logger.Err(err))
+			return handler(ctx, req)
+		}
+		if !res.Allowed {
+			retry := strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds())))
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retry))
+			log.Warn(ctx, "grpc request rate limited", logger.String("method", info.FullMethod), logger.Duration("retry_after", res.RetryAfter))
+			return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// clientKey identifies the caller for rate limiting in the same way as the
+// HTTP middleware: by principal, or by client IP but never by metadata the
+// caller chooses.
+func clientKey(ctx context.Context) string {
+	if p := auth.FromContext(ctx); !p.IsAnonymous() {
+		return "principal:" + p.ID
+	}
+	return "ip:" + auth.ClientIPFromContext(ctx)
+}
+
+// incoming returns the first value of key in the call's metadata.
+func incoming(ctx context.Context, key string) string {
+	if v := metadata.ValueFromIncomingContext(ctx, key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// clientIP returns the host part of the peer address. gRPC clients connect
+// directly, so no forwarding headers are consulted.
+func clientIP(ctx context.Context) string {
+	addr := peerAddr(ctx)
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// serverFault reports whether code indicates a failure of the service rather
+// than of the request.
+func serverFault(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
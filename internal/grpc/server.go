@@ -0,0 +1,60 @@
+// Package grpc serves the customer API over gRPC. It shares customer.Service
+// with the HTTP handlers and mirrors their middleware: request IDs, logging,
+// tracing, API-key authentication, tenants and rate limits.
+package grpc
+
+import (
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/ratelimit"
+	"github.com/Archiit19/customer-service-go/internal/tracing"
+	customerv1 "github.com/Archiit19/customer-service-go/proto/customer/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Metadata keys, the gRPC counterparts of the HTTP headers.
+const (
+	requestIDKey    = "x-request-id"
+	apiKeyKey       = "x-api-key"
+	tenantKey       = "x-tenant-id"
+	accessReasonKey = "x-access-reason"
+	traceparentKey  = "traceparent"
+)
+
+// Options carries the server's optional collaborators.
+type Options struct {
+	// Limiter enforces rate limits; nil disables rate limiting.
+	Limiter *ratelimit.Limiter
+	// Authn resolves API keys; nil or empty treats every caller as anonymous.
+	Authn *auth.Authenticator
+	// AuthRequired rejects calls without an API key.
+	AuthRequired bool
+	// Tracer starts a server span per call; nil disables tracing.
+	Tracer *tracing.Tracer
+}
+
+// NewServer returns a gRPC server with CustomerService and server reflection
+// registered. The caller owns serving and stopping it, so tests can serve it
+// on a bufconn listener.
+func NewServer(svc *customer.Service, log logger.Logger, opts Options) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{}
+	if opts.Tracer != nil {
+		// Before WithRequestContext so request logs carry the trace ID.
+		interceptors = append(interceptors, Tracing(opts.Tracer))
+	}
+	interceptors = append(interceptors,
+		WithRequestContext(log),
+		Recovery(log),
+		Authenticate(opts.Authn, opts.AuthRequired, opts.Limiter, log),
+		ResolveTenant(log),
+	)
+	if opts.Limiter != nil {
+		interceptors = append(interceptors, RateLimit(opts.Limiter, log))
+	}
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	customerv1.RegisterCustomerServiceServer(srv, NewHandler(svc, log))
+	reflection.Register(srv)
+	return srv
+}
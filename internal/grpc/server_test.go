@@ -0,0 +1,221 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/customer/customertest"
+	"github.com/Archiit19/customer-service-go/internal/flags"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/ratelimit"
+	customerv1 "github.com/Archiit19/customer-service-go/proto/customer/v1"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testKeys = "ops@tenant-a:ops-key:pan:reveal,clerk@tenant-a:clerk-key:,partner:partner-key:tenant:any,crm:crm-key:"
+
+// dial serves a server built from repo and opts on an in-memory listener and
+// returns a client connected to it.
+func dial(t *testing.T, repo customer.Repository, opts Options) customerv1.CustomerServiceClient {
+	t.Helper()
+	log := logger.NewNop()
+	p, err := flags.NewStatic(flags.KYCVerifiedRequiresValidPAN + "=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := customer.NewService(repo, log, audit.Nop{}, nil, nil, flags.NewClient(p, log), customer.ContactVerification{})
+	srv := NewServer(svc, log, opts)
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+		srv.Stop()
+	})
+	return customerv1.NewCustomerServiceClient(conn)
+}
+
+func testAuthenticator(t *testing.T) *auth.Authenticator {
+	t.Helper()
+	authn, err := auth.ParseAPIKeys(testKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authn
+}
+
+// withMetadata returns a context sending the given key/value pairs.
+func withMetadata(kv ...string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), kv...)
+}
+
+func wantCode(t *testing.T, what string, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Errorf("%s: got %s (%v), want %s", what, got, err, want)
+	}
+}
+
+// brokenRepository fails every lookup as a database outage would.
+type brokenRepository struct {
+	*customertest.MemoryRepository
+}
+
+func (brokenRepository) Get(context.Context, uuid.UUID) (*customer.Customer, error) {
+	return nil, errors.New("connection refused: 10.0.0.5:5432")
+}
+
+func TestErrorsMapToStatusCodes(t *testing.T) {
+	c := dial(t, customertest.NewMemoryRepository(), Options{Authn: testAuthenticator(t)})
+	ops, clerk := withMetadata(apiKeyKey, "ops-key"), withMetadata(apiKeyKey, "clerk-key")
+
+	created, err := c.CreateCustomer(ops, &customerv1.CreateCustomerRequest{Name: "Asha", Email: "asha@example.com", Phone: "+919876543210"})
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	id := created.GetCustomerId()
+	_, err = c.CreateCustomer(ops, &customerv1.CreateCustomerRequest{Name: "Asha", Email: "asha@example.com", Phone: "+919876543210"})
+	wantCode(t, "duplicate customer", err, codes.AlreadyExists)
+	_, err = c.CreateCustomer(ops, &customerv1.CreateCustomerRequest{Name: "Asha", Email: "not-an-email", Phone: "+919876543211"})
+	wantCode(t, "invalid email", err, codes.InvalidArgument)
+	if msg := status.Convert(err).Message(); msg == "" || msg == "internal error" {
+		t.Errorf("invalid email message %q, want the validation error", msg)
+	}
+	_, err = c.GetCustomer(ops, &customerv1.GetCustomerRequest{CustomerId: "not-a-uuid"})
+	wantCode(t, "malformed id", err, codes.InvalidArgument)
+	_, err = c.GetCustomer(ops, &customerv1.GetCustomerRequest{CustomerId: uuid.NewString()})
+	wantCode(t, "unknown customer", err, codes.NotFound)
+	if _, err := c.SubmitPAN(ops, &customerv1.SubmitPANRequest{CustomerId: id, PanNumber: "12345"}); err != nil {
+		t.Fatalf("SubmitPAN: %v", err)
+	}
+	_, err = c.UpdateVerificationStatus(ops, &customerv1.UpdateVerificationStatusRequest{CustomerId: id, Status: "VERIFIED"})
+	wantCode(t, "verifying a malformed PAN", err, codes.FailedPrecondition)
+	_, err = c.UpdateVerificationStatus(ops, &customerv1.UpdateVerificationStatusRequest{CustomerId: id, Status: "MAYBE"})
+	wantCode(t, "invalid status", err, codes.InvalidArgument)
+	if _, err := c.SubmitPAN(ops, &customerv1.SubmitPANRequest{CustomerId: id, PanNumber: "ABCDE1234F"}); err != nil {
+		t.Fatalf("SubmitPAN: %v", err)
+	}
+	other, err := c.CreateCustomer(ops, &customerv1.CreateCustomerRequest{Name: "Ravi", Email: "ravi@example.com", Phone: "+919812345678"})
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	_, err = c.SubmitPAN(ops, &customerv1.SubmitPANRequest{CustomerId: other.GetCustomerId(), PanNumber: "ABCDE1234F"})
+	wantCode(t, "PAN of another customer", err, codes.AlreadyExists)
+	_, err = c.GetVerification(ops, &customerv1.GetVerificationRequest{CustomerId: uuid.NewString()})
+	wantCode(t, "verification of an unknown customer", err, codes.NotFound)
+	_, err = c.RevealPAN(clerk, &customerv1.RevealPANRequest{CustomerId: id, Reason: "curious"})
+	wantCode(t, "reveal without pan:reveal", err, codes.PermissionDenied)
+	v, err := c.RevealPAN(ops, &customerv1.RevealPANRequest{CustomerId: id, Reason: "KYC review"})
+	if err != nil || v.GetPanNumber() != "ABCDE1234F" {
+		t.Fatalf("RevealPAN: %v, %v", v, err)
+	}
+
+	broken := dial(t, brokenRepository{customertest.NewMemoryRepository()}, Options{})
+	_, err = broken.GetCustomer(context.Background(), &customerv1.GetCustomerRequest{CustomerId: id})
+	wantCode(t, "repository failure", err, codes.Internal)
+	if msg := status.Convert(err).Message(); msg != "internal error" {
+		t.Errorf("repository failure message %q leaks detail", msg)
+	}
+}
+
+func TestAuthenticateInterceptor(t *testing.T) {
+	c := dial(t, customertest.NewMemoryRepository(), Options{Authn: testAuthenticator(t), AuthRequired: true})
+	req := &customerv1.ListCustomersRequest{}
+
+	_, err := c.ListCustomers(context.Background(), req)
+	wantCode(t, "no API key", err, codes.Unauthenticated)
+	_, err = c.ListCustomers(withMetadata(apiKeyKey, "wrong-key"), req)
+	wantCode(t, "unknown API key", err, codes.Unauthenticated)
+	if _, err := c.ListCustomers(withMetadata(apiKeyKey, "ops-key"), req); err != nil {
+		t.Errorf("valid API key: %v", err)
+	}
+
+	noKeys := dial(t, customertest.NewMemoryRepository(), Options{AuthRequired: true})
+	_, err = noKeys.ListCustomers(context.Background(), req)
+	wantCode(t, "authentication required without configured keys", err, codes.Unauthenticated)
+	_, err = noKeys.ListCustomers(withMetadata(apiKeyKey, "ops-key"), req)
+	wantCode(t, "API key with no keys configured", err, codes.Unauthenticated)
+
+	optional := dial(t, customertest.NewMemoryRepository(), Options{Authn: testAuthenticator(t)})
+	if _, err := optional.ListCustomers(context.Background(), req); err != nil {
+		t.Errorf("anonymous call with authentication optional: %v", err)
+	}
+	_, err = optional.ListCustomers(withMetadata(apiKeyKey, "wrong-key"), req)
+	wantCode(t, "unknown API key with authentication optional", err, codes.Unauthenticated)
+}
+
+func TestAuthenticateThrottlesFailedKeys(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Rule{Limit: 100, Window: time.Minute},
+		map[string]ratelimit.Rule{ratelimit.AuthFailures: {Limit: 2, Window: time.Minute}})
+	c := dial(t, customertest.NewMemoryRepository(), Options{Authn: testAuthenticator(t), Limiter: limiter})
+	req := &customerv1.ListCustomersRequest{}
+
+	for i := range 2 {
+		_, err := c.ListCustomers(withMetadata(apiKeyKey, "bad-key"), req)
+		wantCode(t, "failed authentication", err, codes.Unauthenticated)
+		if t.Failed() {
+			t.Fatalf("failed authentication %d", i+1)
+		}
+	}
+	var header metadata.MD
+	_, err := c.ListCustomers(withMetadata(apiKeyKey, "another-bad-key"), req, grpc.Header(&header))
+	wantCode(t, "failed authentication beyond the limit", err, codes.ResourceExhausted)
+	if got := header.Get("retry-after"); len(got) != 1 || got[0] == "0" {
+		t.Errorf("retry-after = %v, want a positive delay", got)
+	}
+	_, err = c.ListCustomers(withMetadata(apiKeyKey, "ops-key"), req)
+	wantCode(t, "valid key from a throttled client", err, codes.ResourceExhausted)
+	if _, err := c.ListCustomers(context.Background(), req); err != nil {
+		t.Errorf("anonymous call from a throttled client: %v", err)
+	}
+}
+
+func TestResolveTenantInterceptor(t *testing.T) {
+	c := dial(t, customertest.NewMemoryRepository(), Options{Authn: testAuthenticator(t)})
+	created, err := c.CreateCustomer(withMetadata(apiKeyKey, "ops-key"),
+		&customerv1.CreateCustomerRequest{Name: "Asha", Email: "asha@example.com", Phone: "+919876543210"})
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	get := &customerv1.GetCustomerRequest{CustomerId: created.GetCustomerId()}
+
+	_, err = c.GetCustomer(withMetadata(apiKeyKey, "ops-key", tenantKey, "tenant-b"), get)
+	wantCode(t, "bound key naming another tenant", err, codes.PermissionDenied)
+	if _, err := c.GetCustomer(withMetadata(apiKeyKey, "ops-key", tenantKey, "tenant-a"), get); err != nil {
+		t.Errorf("bound key naming its own tenant: %v", err)
+	}
+	_, err = c.GetCustomer(withMetadata(apiKeyKey, "partner-key", tenantKey, "Not A Tenant!"), get)
+	wantCode(t, "malformed tenant", err, codes.InvalidArgument)
+	_, err = c.GetCustomer(withMetadata(apiKeyKey, "partner-key", tenantKey, "tenant-b"), get)
+	wantCode(t, "unbound key in another tenant", err, codes.NotFound)
+	_, err = c.GetCustomer(withMetadata(apiKeyKey, "partner-key"), get)
+	wantCode(t, "unbound key in the default tenant", err, codes.NotFound)
+	if _, err := c.GetCustomer(withMetadata(apiKeyKey, "partner-key", tenantKey, "tenant-a"), get); err != nil {
+		t.Errorf("unbound key choosing the customer's tenant: %v", err)
+	}
+	_, err = c.GetCustomer(withMetadata(apiKeyKey, "crm-key", tenantKey, "tenant-a"), get)
+	wantCode(t, "unbound key without tenant:any naming a tenant", err, codes.PermissionDenied)
+	_, err = c.GetCustomer(withMetadata(apiKeyKey, "crm-key"), get)
+	wantCode(t, "unbound key without tenant:any in the default tenant", err, codes.NotFound)
+	_, err = c.GetCustomer(withMetadata(tenantKey, "tenant-a"), get)
+	wantCode(t, "anonymous caller naming a tenant", err, codes.PermissionDenied)
+	_, err = c.GetCustomer(withMetadata(tenantKey, "default"), get)
+	wantCode(t, "anonymous caller naming the default tenant", err, codes.NotFound)
+}
@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Report statuses.
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// DefaultTimeout bounds a check registered without its own timeout.
+const DefaultTimeout = 2 * time.Second
+
+var errShuttingDown = errors.New("shutting down")
+
+// Check reports whether a dependency is usable. It must honour ctx.
+type Check func(ctx context.Context) error
+
+type check struct {
+	name     string
+	timeout  time.Duration
+	fn       Check
+	critical bool
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status     string  `json:"status"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+	// NonCritical marks a check whose failure is reported without failing
+	// readiness.
+	NonCritical bool `json:"non_critical,omitempty"`
+}
+
+// Report is the readiness of the service and each of its dependencies.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// OK reports whether the service should receive traffic.
+func (r Report) OK() bool { return r.Status == StatusOK }
+
+// Registry holds the dependency checks that gate readiness. Components
+// register their own checks at startup.
+type Registry struct {
+	mu           sync.RWMutex
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a named check. A non-positive timeout uses DefaultTimeout.
+func (r *Registry) Register(name string, timeout time.Duration, fn Check) {
+	r.add(check{name: name, timeout: timeout, fn: fn, critical: true})
+}
+
+// RegisterNonCritical adds a check that is reported but does not fail
+// readiness, for dependencies the service degrades without, such as trace
+// export or notification delivery. Taking the pod out of rotation would not
+// fix them.
+func (r *Registry) RegisterNonCritical(name string, timeout time.Duration, fn Check) {
+	r.add(check{name: name, timeout: timeout, fn: fn})
+}
+
+func (r *Registry) add(c check) {
+	if c.timeout <= 0 {
+		c.timeout = DefaultTimeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, c)
+}
+
+// SetShuttingDown makes every later readiness report fail so load balancers
+// stop routing new requests before the server drains.
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether SetShuttingDown was called.
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Ready runs all checks concurrently, each under its own timeout. The
+// report fails if any critical check does.
+func (r *Registry) Ready(ctx context.Context) Report {
+	if r.ShuttingDown() {
+		return Report{Status: StatusShuttingDown, Checks: map[string]Result{
+			"shutdown": {Status: StatusFail, Error: errShuttingDown.Error()},
+		}}
+	}
+	r.mu.RLock()
+	checks := append([]check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		results[i].NonCritical = !c.critical
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK && c.critical {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func run(ctx context.Context, c check) (res Result) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	defer func() {
+		if rec := recover(); rec != nil {
+			res = Result{Status: StatusFail, Error: "check panicked"}
+		}
+		res.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+	}()
+	if err := c.fn(ctx); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return Result{Status: StatusFail, Error: "timed out after " + c.timeout.String()}
+		}
+		return Result{Status: StatusFail, Error: err.Error()}
+	}
+	return Result{Status: StatusOK}
+}
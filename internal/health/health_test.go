@@ -0,0 +1,151 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReady(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	tests := []struct {
+		name      string
+		register  func(r *Registry)
+		status    string
+		checks    map[string]string
+		errSubstr map[string]string
+	}{
+		{
+			name:   "no checks",
+			status: StatusOK,
+			checks: map[string]string{},
+		},
+		{
+			name: "all pass",
+			register: func(r *Registry) {
+				r.Register("postgres", 0, ok)
+				r.Register("migrations", 0, ok)
+			},
+			status: StatusOK,
+			checks: map[string]string{"postgres": StatusOK, "migrations": StatusOK},
+		},
+		{
+			name: "critical failure",
+			register: func(r *Registry) {
+				r.Register("postgres", 0, down)
+				r.Register("migrations", 0, ok)
+			},
+			status:    StatusFail,
+			checks:    map[string]string{"postgres": StatusFail, "migrations": StatusOK},
+			errSubstr: map[string]string{"postgres": "connection refused"},
+		},
+		{
+			name: "non-critical failure",
+			register: func(r *Registry) {
+				r.Register("postgres", 0, ok)
+				r.RegisterNonCritical("smtp", 0, down)
+			},
+			status:    StatusOK,
+			checks:    map[string]string{"postgres": StatusOK, "smtp": StatusFail},
+			errSubstr: map[string]string{"smtp": "connection refused"},
+		},
+		{
+			name: "timeout",
+			register: func(r *Registry) {
+				r.Register("postgres", 10*time.Millisecond, hang)
+			},
+			status:    StatusFail,
+			checks:    map[string]string{"postgres": StatusFail},
+			errSubstr: map[string]string{"postgres": "timed out after 10ms"},
+		},
+		{
+			name: "panic",
+			register: func(r *Registry) {
+				r.Register("postgres", 0, func(context.Context) error { panic("boom") })
+			},
+			status:    StatusFail,
+			checks:    map[string]string{"postgres": StatusFail},
+			errSubstr: map[string]string{"postgres": "check panicked"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			if tt.register != nil {
+				tt.register(r)
+			}
+			report := r.Ready(context.Background())
+			if report.Status != tt.status {
+				t.Errorf("status %q, want %q", report.Status, tt.status)
+			}
+			if report.OK() != (tt.status == StatusOK) {
+				t.Errorf("OK() = %v for status %q", report.OK(), report.Status)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("checks %v, want %v", report.Checks, tt.checks)
+			}
+			for name, want := range tt.checks {
+				got, found := report.Checks[name]
+				if !found || got.Status != want {
+					t.Errorf("check %s = %+v, want status %q", name, got, want)
+				}
+			}
+			for name, want := range tt.errSubstr {
+				if got := report.Checks[name].Error; !strings.Contains(got, want) {
+					t.Errorf("check %s error %q, want it to contain %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadyMarksNonCriticalChecks(t *testing.T) {
+	r := NewRegistry()
+	r.Register("postgres", 0, func(context.Context) error { return nil })
+	r.RegisterNonCritical("otlp_exporter", 0, func(context.Context) error { return nil })
+	report := r.Ready(context.Background())
+	if report.Checks["postgres"].NonCritical {
+		t.Error("postgres reported as non-critical")
+	}
+	if !report.Checks["otlp_exporter"].NonCritical {
+		t.Error("otlp_exporter not reported as non-critical")
+	}
+}
+
+func TestReadyFailsOnceShuttingDown(t *testing.T) {
+	r := NewRegistry()
+	ran := false
+	r.Register("postgres", 0, func(context.Context) error {
+		ran = true
+		return nil
+	})
+	if report := r.Ready(context.Background()); !report.OK() {
+		t.Fatalf("before shutdown: %+v", report)
+	}
+	if r.ShuttingDown() {
+		t.Fatal("ShuttingDown before SetShuttingDown")
+	}
+
+	r.SetShuttingDown()
+	ran = false
+	report := r.Ready(context.Background())
+	if !r.ShuttingDown() {
+		t.Error("ShuttingDown false after SetShuttingDown")
+	}
+	if report.OK() || report.Status != StatusShuttingDown {
+		t.Errorf("status %q, want %q", report.Status, StatusShuttingDown)
+	}
+	if res := report.Checks["shutdown"]; res.Status != StatusFail {
+		t.Errorf("shutdown check %+v, want failing", res)
+	}
+	if ran {
+		t.Error("dependency checks ran while shutting down")
+	}
+}
@@ -0,0 +1,194 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type createAddressRequest struct {
+	Type    string `json:"type"`
+	Line1   string `json:"line1"`
+	Line2   string `json:"line2"`
+	City    string `json:"city"`
+	State   string `json:"state"`
+	PINCode string `json:"pin_code"`
+	Primary bool   `json:"primary"`
+}
+
+type patchAddressRequest struct {
+	Type    *string `json:"type,omitempty"`
+	Line1   *string `json:"line1,omitempty"`
+	Line2   *string `json:"line2,omitempty"`
+	City    *string `json:"city,omitempty"`
+	State   *string `json:"state,omitempty"`
+	PINCode *string `json:"pin_code,omitempty"`
+	Primary *bool   `json:"primary,omitempty"`
+}
+
+// CreateAddress serves POST /v1/customers/{id}/addresses.
+func (h *Handler) CreateAddress(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	customerID, _, ok := h.addressIDs(w, r, "create", false)
+	if !ok {
+		return
+	}
+	var req createAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn(ctx, "http create address decode failed", logger.Err(err))
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	a := &customer.Address{
+		Type:    customer.AddressType(req.Type),
+		Line1:   req.Line1,
+		Line2:   req.Line2,
+		City:    req.City,
+		State:   req.State,
+		PINCode: req.PINCode,
+		Primary: req.Primary,
+	}
+	created, err := h.svc.CreateAddress(ctx, customerID, a)
+	if err != nil {
+		h.addressFailed(ctx, w, "create", err, logger.String("customer_id", customerID.String()))
+		return
+	}
+	h.logger.Info(ctx, "http create address succeeded", logger.String("customer_id", customerID.String()), logger.String("address_id", created.ID.String()))
+	w.Header().Set("Location", addressPath(created.CustomerID, created.ID))
+	writeJSON(w, http.StatusCreated, newAddressV1(*created))
+}
+
+// ListAddresses serves GET /v1/customers/{id}/addresses, primary first.
+func (h *Handler) ListAddresses(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	customerID, _, ok := h.addressIDs(w, r, "list", false)
+	if !ok {
+		return
+	}
+	addresses, err := h.svc.ListAddresses(ctx, customerID)
+	if err != nil {
+		h.addressFailed(ctx, w, "list", err, logger.String("customer_id", customerID.String()))
+		return
+	}
+	h.logger.Info(ctx, "http list addresses succeeded", logger.String("customer_id", customerID.String()), logger.Int("returned", len(addresses)))
+	writeJSON(w, http.StatusOK, AddressListV1{Data: newAddressesV1(addresses)})
+}
+
+// GetAddress serves GET /v1/customers/{id}/addresses/{addressID}.
+func (h *Handler) GetAddress(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	customerID, id, ok := h.addressIDs(w, r, "get", true)
+	if !ok {
+		return
+	}
+	a, err := h.svc.GetAddress(ctx, customerID, id)
+	if err != nil {
+		h.addressFailed(ctx, w, "get", err, logger.String("customer_id", customerID.String()), logger.String("address_id", id.String()))
+		return
+	}
+	h.logger.Info(ctx, "http get address succeeded", logger.String("customer_id", customerID.String()), logger.String("address_id", id.String()))
+	writeJSON(w, http.StatusOK, newAddressV1(*a))
+}
+
+// PatchAddress serves PATCH /v1/customers/{id}/addresses/{addressID},
+// changing the fields present in the body.
+func (h *Handler) PatchAddress(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	customerID, id, ok := h.addressIDs(w, r, "patch", true)
+	if !ok {
+		return
+	}
+	var req patchAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn(ctx, "http patch address decode failed", logger.Err(err))
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	upd := customer.UpdateAddress{
+		Line1:   req.Line1,
+		Line2:   req.Line2,
+		City:    req.City,
+		State:   req.State,
+		PINCode: req.PINCode,
+		Primary: req.Primary,
+	}
+	if req.Type != nil {
+		t := customer.AddressType(*req.Type)
+		upd.Type = &t
+	}
+	updated, err := h.svc.UpdateAddress(ctx, customerID, id, upd)
+	if err != nil {
+		h.addressFailed(ctx, w, "patch", err, logger.String("customer_id", customerID.String()), logger.String("address_id", id.String()))
+		return
+	}
+	h.logger.Info(ctx, "http patch address succeeded", logger.String("customer_id", customerID.String()), logger.String("address_id", id.String()))
+	writeJSON(w, http.StatusOK, newAddressV1(*updated))
+}
+
+// DeleteAddress serves DELETE /v1/customers/{id}/addresses/{addressID}.
+func (h *Handler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	customerID, id, ok := h.addressIDs(w, r, "delete", true)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteAddress(ctx, customerID, id); err != nil {
+		h.addressFailed(ctx, w, "delete", err, logger.String("customer_id", customerID.String()), logger.String("address_id", id.String()))
+		return
+	}
+	h.logger.Info(ctx, "http delete address succeeded", logger.String("customer_id", customerID.String()), logger.String("address_id", id.String()))
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+// addressIDs parses the customer ID and, when withAddress is set, the
+// address ID in the path, writing the error response itself when they are
+// invalid.
+func (h *Handler) addressIDs(w http.ResponseWriter, r *http.Request, op string, withAddress bool) (customerID, addressID uuid.UUID, ok bool) {
+	ctx := r.Context()
+	idStr, addressStr := chi.URLParam(r, "id"), chi.URLParam(r, "addressID")
+	h.logger.Info(ctx, "http "+op+" address received", logger.String("customer_id", idStr), logger.String("address_id", addressStr))
+	customerID, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Warn(ctx, "http "+op+" address invalid customer id", logger.Err(err), logger.String("customer_id", idStr))
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return uuid.Nil, uuid.Nil, false
+	}
+	if !withAddress {
+		return customerID, uuid.Nil, true
+	}
+	addressID, err = uuid.Parse(addressStr)
+	if err != nil {
+		h.logger.Warn(ctx, "http "+op+" address invalid address id", logger.Err(err), logger.String("address_id", addressStr))
+		writeError(w, http.StatusBadRequest, "invalid address id")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return customerID, addressID, true
+}
+
+// addressFailed writes the error response for a failed address operation.
+func (h *Handler) addressFailed(ctx context.Context, w http.ResponseWriter, op string, err error, fields ...logger.Field) {
+	fields = append(fields, logger.Err(err))
+	switch {
+	case errors.Is(err, customer.ErrInvalidAddressType), errors.Is(err, customer.ErrInvalidAddress), errors.Is(err, customer.ErrInvalidPINCode):
+		h.logger.Warn(ctx, "http "+op+" address validation failed", fields...)
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, customer.ErrNotFound):
+		h.logger.Warn(ctx, "http "+op+" address customer not found", fields...)
+		writeError(w, http.StatusNotFound, "not found")
+	case errors.Is(err, customer.ErrAddressNotFound):
+		h.logger.Warn(ctx, "http "+op+" address not found", fields...)
+		writeError(w, http.StatusNotFound, "address not found")
+	case errors.Is(err, customer.ErrPrimaryAddressTaken):
+		h.logger.Warn(ctx, "http "+op+" address conflict", fields...)
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		h.logger.Error(ctx, "http "+op+" address internal failure", fields...)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/flags"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	actionLogLevelChange = "admin.log_level.change"
+	actionFlagChange     = "admin.flag.change"
+)
+
+type AdminHandler struct {
+	level    *logger.AtomicLevel
+	features *flags.Client
+	audit    audit.Recorder
+	logger   logger.Logger
+}
+
+// NewAdminHandler serves runtime settings. A nil recorder skips auditing.
+func NewAdminHandler(level *logger.AtomicLevel, features *flags.Client, rec audit.Recorder, log logger.Logger) *AdminHandler {
+	if rec == nil {
+		rec = audit.Nop{}
+	}
+	return &AdminHandler{level: level, features: features, audit: rec, logger: log}
+}
+
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+// GetLogLevel serves GET /admin/log-level.
+func (h *AdminHandler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, logLevelBody{Level: h.level.String()})
+}
+
+// SetLogLevel serves PUT /admin/log-level. The change applies immediately to
+// every logger in the process and lasts until the next restart, or until
+// LOG_LEVEL itself is changed in the config file.
+func (h *AdminHandler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req logLevelBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn(ctx, "http set log level decode failed", logger.Err(err))
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	h.logger.Info(ctx, "http set log level received", logger.String("level", req.Level))
+	previous := h.level.String()
+	if err := h.level.Set(req.Level); err != nil {
+		h.logger.Warn(ctx, "http set log level invalid level", logger.Err(err))
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	record := audit.NewEntry(ctx, actionLogLevelChange, "log_level", "")
+	record.Outcome = audit.OutcomeSuccess
+	record.Changes = map[string]audit.Change{"level": {Before: previous, After: h.level.String()}}
+	if err := h.audit.Record(ctx, record); err != nil {
+		h.logger.Error(ctx, "http set log level record failed", logger.Err(err))
+	}
+	h.logger.Info(ctx, "http set log level succeeded", logger.String("from", previous), logger.String("to", h.level.String()))
+	writeJSON(w, http.StatusOK, logLevelBody{Level: h.level.String()})
+}
+
+type flagResponse struct {
+	flags.Flag
+	Description string `json:"description"`
+}
+
+func newFlagResponse(f flags.Flag) flagResponse {
+	if f.Tenants == nil {
+		f.Tenants = []string{}
+	}
+	if f.Principals == nil {
+		f.Principals = []string{}
+	}
+	return flagResponse{Flag: f, Description: flags.Known[f.Key]}
+}
+
+// ListFlags serves GET /admin/flags with every flag the service consults.
+func (h *AdminHandler) ListFlags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Info(ctx, "http list flags received")
+	list, err := h.features.List(ctx)
+	if err != nil {
+		h.logger.Error(ctx, "http list flags internal failure", logger.Err(err))
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	data := make([]flagResponse, 0, len(list))
+	for _, f := range list {
+		data = append(data, newFlagResponse(f))
+	}
+	h.logger.Info(ctx, "http list flags succeeded", logger.Int("returned", len(data)))
+	writeJSON(w, http.StatusOK, map[string]any{"data": data})
+}
+
+type setFlagRequest struct {
+	Enabled    bool     `json:"enabled"`
+	Rollout    int      `json:"rollout"`
+	Tenants    []string `json:"tenants"`
+	Principals []string `json:"principals"`
+}
+
+// SetFlag serves PUT /admin/flags/{key}, replacing the flag's rollout state.
+func (h *AdminHandler) SetFlag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	key := chi.URLParam(r, "key")
+	h.logger.Info(ctx, "http set flag received", logger.String("flag", key))
+	var req setFlagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn(ctx, "http set flag decode failed", logger.Err(err))
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	record := audit.NewEntry(ctx, actionFlagChange, "feature_flag", key)
+	before, err := h.features.Get(ctx, key)
+	if errors.Is(err, flags.ErrUnknownFlag) {
+		h.logger.Warn(ctx, "http set flag not found", logger.String("flag", key))
+		writeError(w, http.StatusNotFound, "unknown flag")
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "http set flag load failed", logger.Err(err), logger.String("flag", key))
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	after := flags.Flag{
+		Key:        key,
+		Enabled:    req.Enabled,
+		Rollout:    req.Rollout,
+		Tenants:    req.Tenants,
+		Principals: req.Principals,
+		UpdatedBy:  auth.FromContext(ctx).ID,
+	}
+	err = h.features.Set(ctx, after)
+	switch {
+	case errors.Is(err, flags.ErrInvalidFlag):
+		record.Outcome = audit.OutcomeInvalid
+	case err != nil:
+		record.Outcome = audit.OutcomeFailure
+	default:
+		record.Outcome = audit.OutcomeSuccess
+		record.Changes = flagChanges(before, after)
+	}
+	if recErr := h.audit.Record(ctx, record); recErr != nil {
+		h.logger.Error(ctx, "http set flag record failed", logger.Err(recErr))
+	}
+	if errors.Is(err, flags.ErrInvalidFlag) {
+		h.logger.Warn(ctx, "http set flag invalid", logger.Err(err), logger.String("flag", key))
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "http set flag internal failure", logger.Err(err), logger.String("flag", key))
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	updated, err := h.features.Get(ctx, key)
+	if err != nil {
+		updated = after
+	}
+	h.logger.Info(ctx, "http set flag succeeded", logger.String("flag", key), logger.Bool("enabled", updated.Enabled), logger.Int("rollout", updated.Rollout))
+	writeJSON(w, http.StatusOK, newFlagResponse(updated))
+}
+
+func flagChanges(before, after flags.Flag) map[string]audit.Change {
+	changes := map[string]audit.Change{}
+	if before.Enabled != after.Enabled {
+		changes["enabled"] = audit.Change{Before: before.Enabled, After: after.Enabled}
+	}
+	if before.Rollout != after.Rollout {
+		changes["rollout"] = audit.Change{Before: before.Rollout, After: after.Rollout}
+	}
+	if !slices.Equal(before.Tenants, after.Tenants) {
+		changes["tenants"] = audit.Change{Before: before.Tenants, After: after.Tenants}
+	}
+	if !slices.Equal(before.Principals, after.Principals) {
+		changes["principals"] = audit.Change{Before: before.Principals, After: after.Principals}
+	}
+	return changes
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/google/uuid"
+)
+
+const actionAuditQuery = "audit.query"
+
+type AuditHandler struct {
+	store  audit.Store
+	logger logger.Logger
+}
+
+func NewAuditHandler(store audit.Store, log logger.Logger) *AuditHandler {
+	return &AuditHandler{store: store, logger: log}
+}
+
+// ListAuditEntries serves GET /v1/audit?customer_id=&actor=&from=&to=&page=&limit=.
+// from and to are RFC 3339 timestamps; to is exclusive. A customer's trail
+// includes the customers merged into it. The query itself is audited so
+// access to the trail is traceable too.
+func (h *AuditHandler) ListAuditEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	f := audit.Filter{
+		Tenant:        auth.TenantFromContext(ctx),
+		ResourceID:    q.Get("customer_id"),
+		IncludeMerged: true,
+		Actor:         q.Get("actor"),
+	}
+	h.logger.Info(ctx, "http list audit entries received", logger.String("customer_id", f.ResourceID), logger.String("actor", f.Actor))
+	if f.ResourceID != "" {
+		if _, err := uuid.Parse(f.ResourceID); err != nil {
+			h.logger.Warn(ctx, "http list audit entries invalid customer id", logger.Err(err))
+			writeError(w, http.StatusBadRequest, "invalid customer_id")
+			return
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			h.logger.Warn(ctx, "http list audit entries invalid time", logger.String(p.name, v))
+			writeError(w, http.StatusBadRequest, "invalid "+p.name+": expected RFC 3339 timestamp")
+			return
+		}
+		*p.dst = t
+	}
+	page, limit := 1, 100
+	if v := q.Get("page"); v != "" {
+		if _, err := fmtSscanf(v, &page); err != nil || page < 1 {
+			writeError(w, http.StatusBadRequest, "invalid page")
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if _, err := fmtSscanf(v, &limit); err != nil || limit < 1 || limit > 1000 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+	f.Limit = limit
+	f.Offset = (page - 1) * limit
+
+	entries, total, err := h.store.Query(ctx, f)
+	record := audit.NewEntry(ctx, actionAuditQuery, "audit_log", f.ResourceID)
+	record.Outcome = audit.OutcomeSuccess
+	if err != nil {
+		record.Outcome = audit.OutcomeFailure
+	}
+	record.Metadata = map[string]any{"actor": f.Actor, "from": q.Get("from"), "to": q.Get("to"), "page": page, "limit": limit}
+	if recErr := h.store.Record(ctx, record); recErr != nil {
+		h.logger.Error(ctx, "http audit query record failed", logger.Err(recErr))
+	}
+	if err != nil {
+		h.logger.Error(ctx, "http list audit entries internal failure", logger.Err(err))
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	h.logger.Info(ctx, "http list audit entries succeeded", logger.Int("returned", len(entries)), logger.Int("total", total))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  entries,
+	})
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/ratelimit"
+)
+
+// tenantHeader names the tenant for principals granted auth.ScopeAnyTenant.
+const tenantHeader = "X-Tenant-ID"
+
+// Authenticate resolves the X-API-Key header to a principal and stores it,
+// together with the client IP, in the request context. Requests without a key
+// run as auth.Anonymous unless required is set. When no keys are configured
+// the header is ignored, so every request is anonymous or, with required,
+// refused.
+//
+// With a limiter, invalid keys count against the client IP under
+// ratelimit.AuthFailures, and once that bucket is empty keys from the IP are
+// refused with 429 without being checked.
+func Authenticate(authn *auth.Authenticator, required bool, limiter *ratelimit.Limiter, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r)
+			ctx := auth.WithClientIP(r.Context(), ip)
+			principal := auth.Anonymous
+			var key string
+			if authn.Enabled() {
+				key = r.Header.Get(apiKeyHeader)
+			}
+			switch {
+			case key != "":
+				if limiter != nil {
+					res, err := limiter.Check(ctx, "ip:"+ip, "", ratelimit.AuthFailures)
+					if err != nil {
+						log.Warn(ctx, "authentication failure limit check failed; allowing request", logger.Err(err))
+					} else if !res.Allowed {
+						w.Header().Set("Retry-After", ceilSeconds(res.RetryAfter))
+						log.Warn(ctx, "http request authentication throttled", logger.String("method", r.Method), logger.String("path", r.URL.Path), logger.Duration("retry_after", res.RetryAfter))
+						writeError(w, http.StatusTooManyRequests, "too many failed authentications")
+						return
+					}
+				}
+				p, err := authn.Authenticate(key)
+				if err != nil {
+					if limiter != nil {
+						if _, err := limiter.Allow(ctx, "ip:"+ip, "", ratelimit.AuthFailures); err != nil {
+							log.Warn(ctx, "authentication failure count failed", logger.Err(err))
+						}
+					}
+					log.Warn(ctx, "http request authentication failed", logger.String("method", r.Method), logger.String("path", r.URL.Path))
+					writeError(w, http.StatusUnauthorized, "invalid API key")
+					return
+				}
+				principal = p
+			case required:
+				log.Warn(ctx, "http request missing credentials", logger.String("method", r.Method), logger.String("path", r.URL.Path))
+				writeError(w, http.StatusUnauthorized, "missing API key")
+				return
+			}
+			ctx = auth.WithPrincipal(ctx, principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ResolveTenant stores the tenant a request acts in, chosen from X-Tenant-ID
+// by auth.ResolveTenant. Tenants the principal may not act in are refused
+// with 403.
+func ResolveTenant(log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			principal := auth.FromContext(ctx)
+			requested := r.Header.Get(tenantHeader)
+			tenant, err := auth.ResolveTenant(principal, requested)
+			switch {
+			case errors.Is(err, auth.ErrTenantNotPermitted):
+				log.Warn(ctx, "http request tenant not permitted", logger.String("principal", principal.ID), logger.String("tenant", requested))
+				writeError(w, http.StatusForbidden, "tenant not permitted")
+				return
+			case err != nil:
+				log.Warn(ctx, "http request invalid tenant", logger.String("tenant", requested))
+				writeError(w, http.StatusBadRequest, "invalid "+tenantHeader)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithTenant(ctx, tenant)))
+		})
+	}
+}
+
+// RequireScope rejects requests whose principal lacks scope.
+func RequireScope(scope string, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			principal := auth.FromContext(ctx)
+			if !principal.HasScope(scope) {
+				log.Warn(ctx, "http request missing scope", logger.String("principal", principal.ID), logger.String("scope", scope))
+				writeError(w, http.StatusForbidden, "forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP returns the host part of RemoteAddr, which middleware.RealIP has
+// already replaced with the forwarded client address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/ratelimit"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+
+func serve(t *testing.T, h http.Handler, ip, key string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/v1/customers", nil)
+	req.RemoteAddr = ip + ":1234"
+	if key != "" {
+		req.Header.Set(apiKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAuthenticateThrottlesFailedKeys(t *testing.T) {
+	authn, err := auth.ParseAPIKeys("crm:good-key:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Rule{Limit: 100, Window: time.Minute},
+		map[string]ratelimit.Rule{ratelimit.AuthFailures: {Limit: 2, Window: time.Minute}})
+	h := Authenticate(authn, false, limiter, logger.NewNop())(okHandler)
+
+	for i := 0; i < 2; i++ {
+		if code := serve(t, h, "10.0.0.1", "bad-key"); code != http.StatusUnauthorized {
+			t.Fatalf("failed authentication %d: got %d, want 401", i+1, code)
+		}
+	}
+	if code := serve(t, h, "10.0.0.1", "another-bad-key"); code != http.StatusTooManyRequests {
+		t.Fatalf("failed authentication beyond the limit: got %d, want 429", code)
+	}
+	if code := serve(t, h, "10.0.0.1", "good-key"); code != http.StatusTooManyRequests {
+		t.Fatalf("key from a throttled IP: got %d, want 429 before the key is checked", code)
+	}
+	if code := serve(t, h, "10.0.0.1", ""); code != http.StatusOK {
+		t.Fatalf("anonymous request from a throttled IP: got %d, want 200", code)
+	}
+	if code := serve(t, h, "10.0.0.2", "good-key"); code != http.StatusOK {
+		t.Fatalf("key from another IP: got %d, want 200", code)
+	}
+}
+
+func TestRateLimitKeysAnonymousCallersByIP(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Rule{Limit: 1, Window: time.Minute}, nil)
+	log := logger.NewNop()
+	// Without configured keys X-API-Key is ignored, so it must not pick the
+	// bucket either.
+	h := Authenticate(nil, false, limiter, log)(RateLimit(limiter, log)(okHandler))
+
+	if code := serve(t, h, "10.0.0.1", "key-1"); code != http.StatusOK {
+		t.Fatalf("first request: got %d, want 200", code)
+	}
+	if code := serve(t, h, "10.0.0.1", "key-2"); code != http.StatusTooManyRequests {
+		t.Fatalf("second request with a new X-API-Key: got %d, want 429", code)
+	}
+	if code := serve(t, h, "10.0.0.2", "key-2"); code != http.StatusOK {
+		t.Fatalf("request from another IP: got %d, want 200", code)
+	}
+}
+
+func TestAuthenticateRequiredWithoutKeys(t *testing.T) {
+	h := Authenticate(nil, true, nil, logger.NewNop())(okHandler)
+	if code := serve(t, h, "10.0.0.1", ""); code != http.StatusUnauthorized {
+		t.Errorf("request without a key: got %d, want 401", code)
+	}
+	if code := serve(t, h, "10.0.0.1", "any-key"); code != http.StatusUnauthorized {
+		t.Errorf("request with a key: got %d, want 401", code)
+	}
+}
+
+func TestResolveTenantConfinesAnonymousCallers(t *testing.T) {
+	h := Authenticate(nil, false, nil, logger.NewNop())(ResolveTenant(logger.NewNop())(okHandler))
+	for tenant, want := range map[string]int{"": http.StatusOK, auth.DefaultTenant: http.StatusOK, "tenant-a": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/customers", nil)
+		if tenant != "" {
+			req.Header.Set(tenantHeader, tenant)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("anonymous request naming %q: got %d, want %d", tenant, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/importfile"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// Batch create modes.
+const (
+	batchModeAtomic  = "atomic"
+	batchModePartial = "partial"
+)
+
+const (
+	maxBatchRows  = 5000
+	maxBatchBytes = 10 << 20
+)
+
+var errBatchTooLarge = fmt.Errorf("batch exceeds %d rows", maxBatchRows)
+
+// BatchCreateCustomers serves POST /v1/customers:batchCreate. The body is a
+// JSON array of create requests, NDJSON or CSV with a name,email,phone
+// header, chosen by Content-Type. In the default atomic mode nothing is
+// created unless every row can be (201, or 422 with the failing rows);
+// ?mode=partial creates every row it can (201, or 207 when some failed).
+func (h *Handler) BatchCreateCustomers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = batchModeAtomic
+	}
+	h.logger.Info(ctx, "http batch create customers received", logger.String("mode", mode))
+	if mode != batchModeAtomic && mode != batchModePartial {
+		h.logger.Warn(ctx, "http batch create customers invalid mode", logger.String("mode", mode))
+		writeError(w, http.StatusBadRequest, "mode must be atomic or partial")
+		return
+	}
+	body := http.MaxBytesReader(w, r.Body, maxBatchBytes)
+	rows, err := parseBatch(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			h.logger.Warn(ctx, "http batch create customers body too large")
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBatchBytes))
+		case errors.Is(err, importfile.ErrUnsupportedFormat):
+			h.logger.Warn(ctx, "http batch create customers unsupported content type", logger.String("content_type", r.Header.Get("Content-Type")))
+			writeError(w, http.StatusUnsupportedMediaType, err.Error())
+		default:
+			h.logger.Warn(ctx, "http batch create customers decode failed", logger.Err(err))
+			writeError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+	if len(rows) == 0 {
+		h.logger.Warn(ctx, "http batch create customers empty batch")
+		writeError(w, http.StatusBadRequest, "batch is empty")
+		return
+	}
+
+	// Rows that did not parse are passed on empty so the service counts them
+	// as invalid, which aborts an atomic batch.
+	input := make([]*customer.Customer, len(rows))
+	for i := range rows {
+		input[i] = rows[i].Customer()
+	}
+	results, err := h.svc.CreateBatch(ctx, input, mode == batchModeAtomic)
+	if err != nil {
+		h.logger.Error(ctx, "http batch create customers internal failure", logger.Err(err))
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	resp := BatchV1{Mode: mode, Total: len(rows), Results: make([]BatchRowV1, len(rows))}
+	for i, res := range results {
+		row := BatchRowV1{Index: i}
+		switch {
+		case res.Err == nil:
+			c := newCustomerV1(*res.Customer)
+			row.Status, row.Customer = BatchRowCreated, &c
+			resp.Created++
+		case rows[i].Err != nil:
+			row.Status, row.Error = BatchRowInvalid, rows[i].Err.Error()
+		case errors.Is(res.Err, customer.ErrConflict):
+			row.Status, row.Error = BatchRowConflict, res.Err.Error()
+		case errors.Is(res.Err, customer.ErrBatchAborted):
+			row.Status, row.Error = BatchRowAborted, res.Err.Error()
+		default:
+			row.Status, row.Error = BatchRowInvalid, res.Err.Error()
+		}
+		resp.Results[i] = row
+	}
+	resp.Failed = resp.Total - resp.Created
+
+	status := http.StatusCreated
+	switch {
+	case resp.Failed == 0:
+	case mode == batchModeAtomic:
+		status = http.StatusUnprocessableEntity
+	default:
+		status = http.StatusMultiStatus
+	}
+	h.logger.Info(ctx, "http batch create customers completed", logger.String("mode", mode), logger.Int("rows", resp.Total), logger.Int("created", resp.Created), logger.Int("failed", resp.Failed))
+	writeJSON(w, status, resp)
+}
+
+// parseBatch reads every row of a batch in the format named by contentType,
+// up to maxBatchRows.
+func parseBatch(contentType string, body io.Reader) ([]importfile.Row, error) {
+	format, err := importfile.FormatFor(contentType)
+	if err != nil {
+		return nil, err
+	}
+	rd, err := importfile.NewReader(format, body)
+	if err != nil {
+		return nil, err
+	}
+	var rows []importfile.Row
+	for {
+		row, err := rd.Next()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == maxBatchRows {
+			return nil, errBatchTooLarge
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,159 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	customerservice "github.com/Archiit19/customer-service-go"
+	"github.com/Archiit19/customer-service-go/internal/flags"
+	httph "github.com/Archiit19/customer-service-go/internal/http"
+	"github.com/Archiit19/customer-service-go/internal/http/routertest"
+	"github.com/Archiit19/customer-service-go/internal/metrics"
+	"github.com/Archiit19/customer-service-go/internal/openapi"
+)
+
+// Headers the conformance requests send.
+const (
+	apiKeyHeader       = "X-API-Key"
+	accessReasonHeader = "X-Access-Reason"
+)
+
+// conformance serves requests through the router and checks each response
+// against the operation the API description documents for it.
+type conformance struct {
+	t      *testing.T
+	h      http.Handler
+	spec   *openapi.Spec
+	served map[*openapi.Operation]bool
+}
+
+func (c *conformance) do(method, path, key, contentType, body string, want int) map[string]any {
+	c.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(apiKeyHeader, key)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set(accessReasonHeader, "conformance test")
+	rec := httptest.NewRecorder()
+	c.h.ServeHTTP(rec, req)
+	if rec.Code != want {
+		c.t.Fatalf("%s %s: got %d, want %d: %s", method, path, rec.Code, want, rec.Body)
+	}
+	op, _ := c.spec.Find(method, req.URL.Path)
+	if op == nil {
+		c.t.Fatalf("%s %s: not in the API description", method, req.URL.Path)
+	}
+	c.served[op] = true
+	if err := op.ValidateResponse(rec.Code, rec.Header(), rec.Body.Bytes()); err != nil {
+		c.t.Errorf("%s %s: %d response does not match %s %s: %v", method, path, rec.Code, op.Method, op.Path, err)
+	}
+	var out map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &out)
+	return out
+}
+
+func TestRoutesConformToOpenAPI(t *testing.T) {
+	spec, err := openapi.Load(customerservice.OpenAPI)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	r := routertest.New(t, httph.Options{
+		Metrics:          metrics.NewRegistry(),
+		Spec:             spec,
+		ValidateRequests: true,
+	})
+	c := &conformance{t: t, h: r, spec: spec, served: map[*openapi.Operation]bool{}}
+	const ops, clerk, jsonType = routertest.OpsKey, routertest.ClerkKey, "application/json"
+	missing := "/v1/customers/5d1f3a7e-8c2b-4e6f-9a0d-1b2c3d4e5f60"
+
+	c.do(http.MethodGet, "/healthz", "", "", "", http.StatusOK)
+	c.do(http.MethodGet, "/livez", "", "", "", http.StatusOK)
+	c.do(http.MethodGet, "/readyz", "", "", "", http.StatusOK)
+	c.do(http.MethodGet, "/metrics", "", "", "", http.StatusOK)
+	c.do(http.MethodGet, "/openapi.yaml", "", "", "", http.StatusOK)
+	c.do(http.MethodGet, "/docs", "", "", "", http.StatusOK)
+
+	asha := c.do(http.MethodPost, "/v1/customers", ops, jsonType,
+		`{"name":"Asha Rao","email":"asha@example.com","phone":"+919876543210","gender":"female","tags":["new"],"metadata":{"segment":"retail"}}`, http.StatusCreated)
+	id, _ := asha["customer_id"].(string)
+	path := "/v1/customers/" + id
+	c.do(http.MethodPost, "/v1/customers", ops, jsonType, `{"name":"Asha Rao","email":"asha@example.com","phone":"+919876543210"}`, http.StatusConflict)
+	c.do(http.MethodPost, "/v1/customers", ops, jsonType, `{"name":"Asha"}`, http.StatusBadRequest)
+	c.do(http.MethodPost, "/v1/customers", "", jsonType, `{"name":"Asha"}`, http.StatusUnauthorized)
+	c.do(http.MethodGet, "/v1/customers?page=1&limit=10&tag=new", ops, "", "", http.StatusOK)
+	c.do(http.MethodGet, path, ops, "", "", http.StatusOK)
+	c.do(http.MethodGet, path+"?include=addresses", ops, "", "", http.StatusOK)
+	c.do(http.MethodGet, missing, ops, "", "", http.StatusNotFound)
+	c.do(http.MethodGet, "/v1/customers/not-a-uuid", ops, "", "", http.StatusBadRequest)
+	c.do(http.MethodPatch, path, ops, jsonType, `{"name":"Asha R.","gender":null}`, http.StatusOK)
+	c.do(http.MethodGet, path+"/status", ops, "", "", http.StatusOK)
+	c.do(http.MethodPatch, path+"/verification", ops, jsonType, `{"pan_number":"ABCDE1234F"}`, http.StatusCreated)
+	c.do(http.MethodPatch, path+"/verification", ops, jsonType, `{"status":"VERIFIED"}`, http.StatusOK)
+	c.do(http.MethodGet, path+"/verification/pan", ops, "", "", http.StatusOK)
+	c.do(http.MethodGet, path+"/verification/pan", clerk, "", "", http.StatusForbidden)
+	c.do(http.MethodPost, path+"/tags", ops, jsonType, `{"tags":["vip"]}`, http.StatusOK)
+	c.do(http.MethodDelete, path+"/tags/new", ops, "", "", http.StatusOK)
+
+	addr := c.do(http.MethodPost, path+"/addresses", ops, jsonType,
+		`{"type":"residential","line1":"12 MG Road","city":"Pune","state":"Maharashtra","pin_code":"411001","primary":true}`, http.StatusCreated)
+	addrPath := path + "/addresses/" + addr["id"].(string)
+	c.do(http.MethodGet, path+"/addresses", ops, "", "", http.StatusOK)
+	c.do(http.MethodGet, addrPath, ops, "", "", http.StatusOK)
+	c.do(http.MethodPatch, addrPath, ops, jsonType, `{"city":"Mumbai"}`, http.StatusOK)
+	c.do(http.MethodDelete, addrPath, ops, "", "", http.StatusNoContent)
+	c.do(http.MethodGet, addrPath, ops, "", "", http.StatusNotFound)
+
+	c.do(http.MethodPost, path+"/contact-verification", ops, jsonType, `{"channel":"email"}`, http.StatusAccepted)
+	c.do(http.MethodPost, path+"/contact-verification", ops, jsonType, `{"channel":"email"}`, http.StatusTooManyRequests)
+	c.do(http.MethodPost, path+"/contact-verification/confirm", ops, jsonType, `{"channel":"email","code":"`+r.Notifier.Code(t)+`"}`, http.StatusOK)
+
+	c.do(http.MethodPost, "/v1/customers:batchCreate?mode=partial", ops, jsonType,
+		`[{"name":"Asha R.","email":"asha.rao@example.org","phone":"+919876543211"},{"name":"X"}]`, http.StatusMultiStatus)
+	dups := c.do(http.MethodGet, "/v1/customers:duplicates?min_score=0.3", ops, "", "", http.StatusOK)
+	data, _ := dups["data"].([]any)
+	if len(data) == 0 {
+		t.Fatalf("no duplicates found: %v", dups)
+	}
+	pair, _ := data[0].(map[string]any)
+	survivor, _ := pair["survivor_id"].(string)
+	duplicate := ""
+	for _, cust := range pair["customers"].([]any) {
+		if cid := cust.(map[string]any)["customer_id"].(string); cid != survivor {
+			duplicate = cid
+		}
+	}
+	c.do(http.MethodPost, "/v1/customers/"+survivor+"/merge", ops, jsonType, `{"duplicate_id":"`+duplicate+`"}`, http.StatusOK)
+	c.do(http.MethodGet, "/v1/customers:export?format=ndjson", ops, "", "", http.StatusOK)
+	c.do(http.MethodGet, "/v1/customers:export?format=csv", clerk, "", "", http.StatusOK)
+
+	job := c.do(http.MethodPost, "/v1/customers:import", ops, "text/csv", "name,email,phone\nMeera,meera@example.com,+919811111111\n", http.StatusAccepted)
+	jobPath := "/v1/jobs/" + job["id"].(string)
+	c.do(http.MethodPost, "/v1/customers:import", ops, "application/xml", "<customers/>", http.StatusBadRequest)
+	c.do(http.MethodGet, jobPath, ops, "", "", http.StatusOK)
+	c.do(http.MethodGet, jobPath+"/errors", ops, "", "", http.StatusOK)
+	c.do(http.MethodGet, "/v1/jobs/5d1f3a7e-8c2b-4e6f-9a0d-1b2c3d4e5f60", ops, "", "", http.StatusNotFound)
+
+	c.do(http.MethodGet, "/v1/audit?customer_id="+id+"&limit=5", ops, "", "", http.StatusOK)
+	c.do(http.MethodGet, "/v1/audit", clerk, "", "", http.StatusForbidden)
+	c.do(http.MethodGet, "/admin/log-level", ops, "", "", http.StatusOK)
+	c.do(http.MethodPut, "/admin/log-level", ops, jsonType, `{"level":"DEBUG"}`, http.StatusOK)
+	c.do(http.MethodPut, "/admin/log-level", ops, jsonType, `{"level":"LOUD"}`, http.StatusBadRequest)
+	c.do(http.MethodGet, "/admin/flags", ops, "", "", http.StatusOK)
+	c.do(http.MethodPut, "/admin/flags/"+flags.PhoneIndiaOnly, ops, jsonType, `{"enabled":true,"rollout":100,"tenants":[],"principals":[]}`, http.StatusOK)
+	c.do(http.MethodPut, "/admin/flags/no.such.flag", ops, jsonType, `{"enabled":true,"rollout":100,"tenants":[],"principals":[]}`, http.StatusNotFound)
+
+	c.do(http.MethodDelete, path, ops, "", "", http.StatusNoContent)
+	c.do(http.MethodDelete, path, ops, "", "", http.StatusNotFound)
+
+	for _, op := range spec.Operations() {
+		if !c.served[op] {
+			t.Errorf("%s %s was not exercised", op.Method, op.Path)
+		}
+	}
+}
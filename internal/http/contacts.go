@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type contactVerificationRequest struct {
+	Channel string `json:"channel"`
+}
+
+type contactConfirmRequest struct {
+	Channel string `json:"channel"`
+	Code    string `json:"code"`
+}
+
+// StartContactVerification serves POST
+// /v1/customers/{id}/contact-verification, sending a one-time code to the
+// customer's email or phone.
+func (h *Handler) StartContactVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	idStr := chi.URLParam(r, "id")
+	h.logger.Info(ctx, "http start contact verification received", logger.String("customer_id", idStr))
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Warn(ctx, "http start contact verification invalid id", logger.Err(err), logger.String("customer_id", idStr))
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req contactVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn(ctx, "http start contact verification decode failed", logger.Err(err))
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	pending, err := h.svc.StartContactVerification(ctx, id, customer.ContactChannel(req.Channel))
+	if err != nil {
+		h.contactVerificationFailed(ctx, w, "start contact verification", err, logger.String("customer_id", idStr), logger.String("channel", req.Channel))
+		return
+	}
+	h.logger.Info(ctx, "http start contact verification succeeded", logger.String("customer_id", idStr), logger.String("channel", req.Channel))
+	writeJSON(w, http.StatusAccepted, newContactVerificationV1(*pending))
+}
+
+// ConfirmContactVerification serves POST
+// /v1/customers/{id}/contact-verification/confirm, marking the contact
+// verified when the code matches and returning the customer.
+func (h *Handler) ConfirmContactVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	idStr := chi.URLParam(r, "id")
+	h.logger.Info(ctx, "http confirm contact verification received", logger.String("customer_id", idStr))
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Warn(ctx, "http confirm contact verification invalid id", logger.Err(err), logger.String("customer_id", idStr))
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req contactConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn(ctx, "http confirm contact verification decode failed", logger.Err(err))
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	verified, err := h.svc.ConfirmContactVerification(ctx, id, customer.ContactChannel(req.Channel), req.Code)
+	if err != nil {
+		h.contactVerificationFailed(ctx, w, "confirm contact verification", err, logger.String("customer_id", idStr), logger.String("channel", req.Channel))
+		return
+	}
+	h.logger.Info(ctx, "http confirm contact verification succeeded", logger.String("customer_id", idStr), logger.String("channel", req.Channel))
+	writeJSON(w, http.StatusOK, newCustomerV1(*verified))
+}
+
+// contactVerificationFailed writes the response for a failed contact
+// verification step.
+func (h *Handler) contactVerificationFailed(ctx context.Context, w http.ResponseWriter, op string, err error, fields ...logger.Field) {
+	fields = append(fields, logger.Err(err))
+	switch {
+	case errors.Is(err, customer.ErrInvalidChannel), errors.Is(err, customer.ErrInvalidCode):
+		h.logger.Warn(ctx, "http "+op+" validation failed", fields...)
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, customer.ErrNotFound):
+		h.logger.Warn(ctx, "http "+op+" not found", fields...)
+		writeError(w, http.StatusNotFound, "not found")
+	case errors.Is(err, customer.ErrContactAlreadyVerified):
+		h.logger.Warn(ctx, "http "+op+" conflict", fields...)
+		writeError(w, http.StatusConflict, err.Error())
+	case customer.IsThrottledCode(err):
+		h.logger.Warn(ctx, "http "+op+" throttled", fields...)
+		writeError(w, http.StatusTooManyRequests, err.Error())
+	case customer.IsRejectedCode(err):
+		h.logger.Warn(ctx, "http "+op+" code rejected", fields...)
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, customer.ErrDeliveryFailed):
+		h.logger.Error(ctx, "http "+op+" delivery failed", fields...)
+		writeError(w, http.StatusBadGateway, customer.ErrDeliveryFailed.Error())
+	default:
+		h.logger.Error(ctx, "http "+op+" internal failure", fields...)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Defaults and bounds of GET /v1/customers:duplicates.
+const (
+	defaultDuplicateMinScore = 0.5
+	defaultDuplicateLimit    = 50
+	maxDuplicateLimit        = 200
+)
+
+type mergeCustomerRequest struct {
+	DuplicateID string `json:"duplicate_id"`
+}
+
+// ListDuplicates serves GET /v1/customers:duplicates?min_score=&limit=,
+// listing pairs of customers that are likely the same person.
+func (h *Handler) ListDuplicates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	minScore, limit := defaultDuplicateMinScore, defaultDuplicateLimit
+	if v := q.Get("min_score"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			h.logger.Warn(ctx, "http list duplicates invalid min score", logger.String("min_score", v))
+			writeError(w, http.StatusBadRequest, "invalid min_score: expected a number between 0 and 1")
+			return
+		}
+		minScore = f
+	}
+	if v := q.Get("limit"); v != "" {
+		if _, err := fmtSscanf(v, &limit); err != nil || limit < 1 || limit > maxDuplicateLimit {
+			h.logger.Warn(ctx, "http list duplicates invalid limit", logger.String("limit", v))
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+	h.logger.Info(ctx, "http list duplicates received", logger.Any("min_score", minScore), logger.Int("limit", limit))
+	found, err := h.svc.FindDuplicates(ctx, minScore, limit)
+	if err != nil {
+		h.logger.Error(ctx, "http list duplicates internal failure", logger.Err(err))
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	resp := DuplicateListV1{MinScore: minScore, Limit: limit, Data: make([]DuplicateV1, 0, len(found))}
+	for _, d := range found {
+		resp.Data = append(resp.Data, newDuplicateV1(d))
+	}
+	h.logger.Info(ctx, "http list duplicates succeeded", logger.Int("returned", len(resp.Data)))
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// MergeCustomer serves POST /v1/customers/{id}/merge, merging the customer
+// named by duplicate_id into the one in the path and returning the latter.
+func (h *Handler) MergeCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	idStr := chi.URLParam(r, "id")
+	h.logger.Info(ctx, "http merge customer received", logger.String("customer_id", idStr))
+	survivor, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Warn(ctx, "http merge customer invalid id", logger.Err(err), logger.String("customer_id", idStr))
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req mergeCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn(ctx, "http merge customer decode failed", logger.Err(err))
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	duplicate, err := uuid.Parse(req.DuplicateID)
+	if err != nil {
+		h.logger.Warn(ctx, "http merge customer invalid duplicate id", logger.String("duplicate_id", req.DuplicateID))
+		writeError(w, http.StatusBadRequest, "invalid duplicate_id")
+		return
+	}
+	merged, err := h.svc.Merge(ctx, survivor, duplicate)
+	if err != nil {
+		switch {
+		case errors.Is(err, customer.ErrMergeSelf):
+			h.logger.Warn(ctx, "http merge customer into itself", logger.String("customer_id", idStr))
+			writeError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, customer.ErrNotFound):
+			h.logger.Warn(ctx, "http merge customer not found", logger.String("customer_id", idStr), logger.String("duplicate_id", req.DuplicateID))
+			writeError(w, http.StatusNotFound, "not found")
+		case errors.Is(err, customer.ErrMergeConflict):
+			h.logger.Warn(ctx, "http merge customer conflict", logger.String("customer_id", idStr), logger.String("duplicate_id", req.DuplicateID))
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			h.logger.Error(ctx, "http merge customer internal failure", logger.Err(err), logger.String("customer_id", idStr))
+			writeError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+	h.logger.Info(ctx, "http merge customer succeeded", logger.String("customer_id", idStr), logger.String("duplicate_id", req.DuplicateID))
+	writeJSON(w, http.StatusOK, newCustomerV1(*merged))
+}
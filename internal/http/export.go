@@ -0,0 +1,291 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	// exportFlushRows is how many rows are written between flushes to the
+	// client.
+	exportFlushRows = 1000
+	// exportWriteTimeout replaces the server's write timeout and is renewed
+	// on every flush, so an export may run long but a stalled client is
+	// still dropped.
+	exportWriteTimeout = time.Minute
+	// exportRowGroupSize is the number of rows per Parquet row group, which
+	// bounds the rows a Parquet export buffers.
+	exportRowGroupSize = 10000
+	// exportStatusTrailer is sent after the body as "complete" or
+	// "incomplete", since the status code goes out with the first row.
+	exportStatusTrailer = "X-Export-Status"
+)
+
+// exportColumns are the fields of an exported customer, in order.
+var exportColumns = []string{"customer_id", "name", "email", "phone", "pan_number", "status", "created_at", "updated_at"}
+
+// exportFormat is one of the formats served by ExportCustomers.
+type exportFormat struct {
+	contentType string
+	newEncoder  func(io.Writer) (exportEncoder, error)
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv":     {"text/csv; charset=utf-8", newCSVEncoder},
+	"ndjson":  {"application/x-ndjson", newNDJSONEncoder},
+	"parquet": {"application/vnd.apache.parquet", newParquetEncoder},
+}
+
+// exportEncoder writes exported customers in one format. close completes
+// the output but does not close the underlying writer.
+type exportEncoder interface {
+	encode(c *customer.Customer) error
+	close() error
+}
+
+type ExportHandler struct {
+	svc         *customer.Service
+	logger      logger.Logger
+	maxDuration time.Duration
+}
+
+// NewExportHandler creates a handler whose exports are cut off after
+// maxDuration.
+func NewExportHandler(svc *customer.Service, log logger.Logger, maxDuration time.Duration) *ExportHandler {
+	return &ExportHandler{svc: svc, logger: log, maxDuration: maxDuration}
+}
+
+// ExportCustomers serves GET /v1/customers:export?format=&status=&from=&to=,
+// streaming every matching customer as CSV (the default), NDJSON or Parquet.
+// Rows are written as they are read from the database. The route is not
+// subject to the request timeout; instead the export is bounded by
+// maxDuration and the write deadline is renewed as data is flushed. Once the
+// first row is sent a failure can only cut the body short, which the
+// X-Export-Status trailer reports.
+func (h *ExportHandler) ExportCustomers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	name := q.Get("format")
+	if name == "" {
+		name = "csv"
+	}
+	format, ok := exportFormats[name]
+	if !ok {
+		h.logger.Warn(ctx, "http export customers invalid format", logger.String("format", name))
+		writeError(w, http.StatusBadRequest, "invalid format: expected csv, ndjson or parquet")
+		return
+	}
+	f, _, err := parseFilter(q)
+	if err != nil {
+		h.logger.Warn(ctx, "http export customers invalid filter", logger.Err(err))
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.logger.Info(ctx, "http export customers received", logger.String("format", name))
+
+	ctx, cancel := context.WithTimeout(ctx, h.maxDuration)
+	defer cancel()
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+	bw := bufio.NewWriterSize(w, 64<<10)
+	flush := func() error {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		_ = rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+		return nil
+	}
+
+	// The headers are sent with the first row, so that a failure before it
+	// still gets an error response.
+	var enc exportEncoder
+	start := func() (err error) {
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="customers-%s.%s"`, time.Now().UTC().Format("20060102T150405Z"), name))
+		w.Header().Set("Trailer", exportStatusTrailer)
+		w.WriteHeader(http.StatusOK)
+		enc, err = format.newEncoder(bw)
+		return err
+	}
+	written := 0
+	rows, err := h.svc.Export(ctx, f, func(c *customer.Customer) error {
+		if enc == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := enc.encode(c); err != nil {
+			return err
+		}
+		if written++; written%exportFlushRows == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil && enc == nil {
+		err = start()
+	}
+	if err == nil {
+		err = enc.close()
+	}
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		if enc == nil {
+			h.logger.Error(ctx, "http export customers internal failure", logger.Err(err))
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		_ = bw.Flush()
+		w.Header().Set(exportStatusTrailer, "incomplete")
+		h.logger.Error(ctx, "http export customers cut short", logger.Err(err), logger.Int("rows", rows))
+		return
+	}
+	w.Header().Set(exportStatusTrailer, "complete")
+	h.logger.Info(ctx, "http export customers succeeded", logger.String("format", name), logger.Int("rows", rows))
+}
+
+// exportRecord returns the fields of c in exportColumns order. A missing PAN
+// is empty.
+func exportRecord(c *customer.Customer) []string {
+	pan := ""
+	if c.PANNumber != nil {
+		pan = *c.PANNumber
+	}
+	return []string{
+		c.ID.String(), c.Name, c.Email, c.Phone, pan, c.Status,
+		c.CreatedAt.UTC().Format(time.RFC3339Nano), c.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) (exportEncoder, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportColumns); err != nil {
+		return nil, err
+	}
+	return &csvEncoder{w: cw}, nil
+}
+
+func (e *csvEncoder) encode(c *customer.Customer) error {
+	record := exportRecord(c)
+	for i, v := range record {
+		record[i] = csvCell(v)
+	}
+	return e.w.Write(record)
+}
+
+// csvCell prefixes v with ' when a spreadsheet opening the export would
+// otherwise evaluate it as a formula, as it would a name such as
+// "=HYPERLINK(...)".
+func csvCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+func (e *csvEncoder) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// exportRowV1 is one line of an NDJSON export.
+type exportRowV1 struct {
+	ID        string    `json:"customer_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	PANNumber *string   `json:"pan_number"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func newNDJSONEncoder(w io.Writer) (exportEncoder, error) {
+	return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+}
+
+func (e *ndjsonEncoder) encode(c *customer.Customer) error {
+	return e.enc.Encode(exportRowV1{
+		ID:        c.ID.String(),
+		Name:      c.Name,
+		Email:     c.Email,
+		Phone:     c.Phone,
+		PANNumber: c.PANNumber,
+		Status:    c.Status,
+		CreatedAt: c.CreatedAt.UTC(),
+		UpdatedAt: c.UpdatedAt.UTC(),
+	})
+}
+
+func (e *ndjsonEncoder) close() error {
+	return nil
+}
+
+// exportParquetRowV1 is one row of a Parquet export. Strings are stored as
+// UTF-8 and timestamps as UTC microseconds.
+type exportParquetRowV1 struct {
+	ID        string    `parquet:"customer_id"`
+	Name      string    `parquet:"name"`
+	Email     string    `parquet:"email"`
+	Phone     string    `parquet:"phone"`
+	PANNumber *string   `parquet:"pan_number,optional"`
+	Status    string    `parquet:"status"`
+	CreatedAt time.Time `parquet:"created_at,timestamp(microsecond)"`
+	UpdatedAt time.Time `parquet:"updated_at,timestamp(microsecond)"`
+}
+
+type parquetEncoder struct {
+	w   *parquet.GenericWriter[exportParquetRowV1]
+	row [1]exportParquetRowV1
+}
+
+func newParquetEncoder(w io.Writer) (exportEncoder, error) {
+	return &parquetEncoder{w: parquet.NewGenericWriter[exportParquetRowV1](w,
+		parquet.Compression(&parquet.Gzip),
+		parquet.MaxRowsPerRowGroup(exportRowGroupSize),
+	)}, nil
+}
+
+func (e *parquetEncoder) encode(c *customer.Customer) error {
+	e.row[0] = exportParquetRowV1{
+		ID:        c.ID.String(),
+		Name:      c.Name,
+		Email:     c.Email,
+		Phone:     c.Phone,
+		PANNumber: c.PANNumber,
+		Status:    c.Status,
+		CreatedAt: c.CreatedAt.UTC(),
+		UpdatedAt: c.UpdatedAt.UTC(),
+	}
+	_, err := e.w.Write(e.row[:])
+	return err
+}
+
+func (e *parquetEncoder) close() error {
+	return e.w.Close()
+}
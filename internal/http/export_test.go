@@ -0,0 +1,115 @@
+package http
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParquetExportRoundTrips(t *testing.T) {
+	pan := "ABCDE1234F"
+	at := time.Date(2024, 3, 1, 9, 30, 0, 123456000, time.FixedZone("IST", 19800))
+	customers := []customer.Customer{
+		{ID: uuid.New(), Name: "Asha Rao", Email: "asha@example.com", Phone: "+919876543210", PANNumber: &pan, Status: "VERIFIED", CreatedAt: at, UpdatedAt: at.Add(time.Hour)},
+		{ID: uuid.New(), Name: "ग्राहक", Email: "", Phone: "*********3210", Status: "PENDING", CreatedAt: time.Unix(0, 0), UpdatedAt: at},
+	}
+	var buf bytes.Buffer
+	enc, err := newParquetEncoder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range customers {
+		if err := enc.encode(&customers[i]); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+	}
+	if err := enc.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	fields := f.Schema().Fields()
+	if len(fields) != len(exportColumns) {
+		t.Fatalf("schema has %d fields, want %d", len(fields), len(exportColumns))
+	}
+	for i, field := range fields {
+		if field.Name() != exportColumns[i] || field.Optional() != (field.Name() == "pan_number") {
+			t.Errorf("field %d: %s optional=%t", i, field.Name(), field.Optional())
+		}
+		lt := field.Type().LogicalType()
+		switch field.Name() {
+		case "created_at", "updated_at":
+			if lt == nil || lt.Timestamp == nil || !lt.Timestamp.IsAdjustedToUTC || lt.Timestamp.Unit.Micros == nil {
+				t.Errorf("%s: logical type %v, want TIMESTAMP(MICROS, UTC)", field.Name(), lt)
+			}
+		default:
+			if lt == nil || lt.UTF8 == nil {
+				t.Errorf("%s: logical type %v, want STRING", field.Name(), lt)
+			}
+		}
+	}
+
+	rows, err := parquet.Read[exportParquetRowV1](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(rows) != len(customers) {
+		t.Fatalf("read %d rows, want %d", len(rows), len(customers))
+	}
+	for i, c := range customers {
+		got := rows[i]
+		if got.ID != c.ID.String() || got.Name != c.Name || got.Email != c.Email || got.Phone != c.Phone || got.Status != c.Status {
+			t.Errorf("row %d: %+v, want %+v", i, got, c)
+		}
+		if (got.PANNumber == nil) != (c.PANNumber == nil) || got.PANNumber != nil && *got.PANNumber != *c.PANNumber {
+			t.Errorf("row %d: PAN %v, want %v", i, got.PANNumber, c.PANNumber)
+		}
+		if !got.CreatedAt.Equal(c.CreatedAt) || !got.UpdatedAt.Equal(c.UpdatedAt) {
+			t.Errorf("row %d: times %v, %v, want %v, %v", i, got.CreatedAt, got.UpdatedAt, c.CreatedAt, c.UpdatedAt)
+		}
+	}
+}
+
+func TestCSVExportEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := newCSVEncoder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := customer.Customer{ID: uuid.New(), Name: `=HYPERLINK("http://evil.example","x")`, Email: "@asha@example.com", Phone: "+919876543210", Status: "-PENDING"}
+	if err := enc.encode(&c); err != nil {
+		t.Fatal(err)
+	}
+	plain := customer.Customer{ID: uuid.New(), Name: "Asha Rao", Email: "asha@example.com", Phone: "*********3210", Status: "PENDING"}
+	if err := enc.encode(&plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.close(); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("%d records, want a header and 2 rows", len(records))
+	}
+	for i, want := range []string{`'=HYPERLINK("http://evil.example","x")`, "'@asha@example.com", "'+919876543210", "", "'-PENDING"} {
+		if got := records[1][i+1]; got != want {
+			t.Errorf("%s = %q, want %q", exportColumns[i+1], got, want)
+		}
+	}
+	for i, want := range []string{"Asha Rao", "asha@example.com", "*********3210", "", "PENDING"} {
+		if got := records[2][i+1]; got != want {
+			t.Errorf("%s = %q, want %q unchanged", exportColumns[i+1], got, want)
+		}
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/Archiit19/customer-service-go/internal/customer"
 	"github.com/Archiit19/customer-service-go/internal/logger"
@@ -12,21 +15,68 @@ import (
 	"github.com/google/uuid"
 )
 
+const accessReasonHeader = "X-Access-Reason"
+
+// maxListLimit is the largest page Service.List returns; larger limits fall
+// back to the default of 20.
+const maxListLimit = 200
+
 type Handler struct {
 	svc    *customer.Service
 	logger logger.Logger
 }
 
 type createCustomerRequest struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Phone string `json:"phone"`
+	Name              string           `json:"name"`
+	Email             string           `json:"email"`
+	Phone             string           `json:"phone"`
+	DateOfBirth       *string          `json:"date_of_birth"`
+	Gender            *customer.Gender `json:"gender"`
+	Nationality       *string          `json:"nationality"`
+	Occupation        *string          `json:"occupation"`
+	PreferredLanguage *string          `json:"preferred_language"`
+	Metadata          map[string]any   `json:"metadata"`
+	Tags              []string         `json:"tags"`
 }
 
+// patchCustomerRequest changes the fields present in the body. Profile
+// fields may be null to clear them.
 type patchCustomerRequest struct {
-	Name  *string `json:"name,omitempty"`
-	Email *string `json:"email,omitempty"`
-	Phone *string `json:"phone,omitempty"`
+	Name              *string                            `json:"name,omitempty"`
+	Email             *string                            `json:"email,omitempty"`
+	Phone             *string                            `json:"phone,omitempty"`
+	DateOfBirth       customer.Nullable[string]          `json:"date_of_birth"`
+	Gender            customer.Nullable[customer.Gender] `json:"gender"`
+	Nationality       customer.Nullable[string]          `json:"nationality"`
+	Occupation        customer.Nullable[string]          `json:"occupation"`
+	PreferredLanguage customer.Nullable[string]          `json:"preferred_language"`
+	// Metadata is a JSON merge patch; null removes every key.
+	Metadata customer.Nullable[map[string]any] `json:"metadata"`
+}
+
+// update converts the request, parsing the date of birth.
+func (req patchCustomerRequest) update() (customer.UpdateCustomer, error) {
+	upd := customer.UpdateCustomer{
+		Name:  req.Name,
+		Email: req.Email,
+		Phone: req.Phone,
+		Profile: customer.UpdateProfile{
+			DateOfBirth:       customer.Nullable[time.Time]{Set: req.DateOfBirth.Set},
+			Gender:            req.Gender,
+			Nationality:       req.Nationality,
+			Occupation:        req.Occupation,
+			PreferredLanguage: req.PreferredLanguage,
+		},
+		Metadata: req.Metadata,
+	}
+	if v := req.DateOfBirth.Value; v != nil {
+		dob, err := customer.ParseDateOfBirth(*v)
+		if err != nil {
+			return upd, err
+		}
+		upd.Profile.DateOfBirth.Value = &dob
+	}
+	return upd, nil
 }
 
 func NewHandler(svc *customer.Service, log logger.Logger) *Handler {
@@ -37,7 +87,9 @@ func (h *Handler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	h.logger.Info(ctx, "http create customer received")
 	var req createCustomerRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber() // keeps metadata numbers exact
+	if err := dec.Decode(&req); err != nil {
 		h.logger.Warn(ctx, "http create customer decode failed", logger.Err(err))
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
@@ -46,14 +98,32 @@ func (h *Handler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
 		Name:  req.Name,
 		Email: req.Email,
 		Phone: req.Phone,
+		Profile: customer.Profile{
+			Gender:            req.Gender,
+			Nationality:       req.Nationality,
+			Occupation:        req.Occupation,
+			PreferredLanguage: req.PreferredLanguage,
+		},
+		Metadata: req.Metadata,
+		Tags:     req.Tags,
+	}
+	if req.DateOfBirth != nil {
+		dob, err := customer.ParseDateOfBirth(*req.DateOfBirth)
+		if err != nil {
+			h.logger.Warn(ctx, "http create customer validation failed", logger.Err(err))
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.DateOfBirth = &dob
 	}
 	created, err := h.svc.Create(ctx, c)
 	if err != nil {
 		switch {
-		case errors.Is(err, customer.ErrInvalidEmail), errors.Is(err, customer.ErrInvalidName), errors.Is(err, customer.ErrInvalidPhone):
+		case errors.Is(err, customer.ErrInvalidEmail), errors.Is(err, customer.ErrInvalidName), errors.Is(err, customer.ErrInvalidPhone),
+			customer.IsInvalidProfile(err), errors.Is(err, customer.ErrInvalidMetadata), errors.Is(err, customer.ErrInvalidTag):
 			h.logger.Warn(ctx, "http create customer validation failed", logger.Err(err))
 			writeError(w, http.StatusBadRequest, err.Error())
-		case errors.Is(err, customer.ErrConflict):
+		case errors.Is(err, customer.ErrConflict), errors.Is(err, customer.ErrTooManyTags):
 			h.logger.Warn(ctx, "http create customer conflict", logger.Err(err))
 			writeError(w, http.StatusConflict, err.Error())
 		default:
@@ -62,20 +132,13 @@ func (h *Handler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	resp := map[string]any{
-		"customer_id":      created.ID,
-		"name":             created.Name,
-		"email":            created.Email,
-		"phone":            created.Phone,
-		"created_at":       created.CreatedAt,
-		"updated_at":       created.UpdatedAt,
-		"status_url":       fmt.Sprintf("/v1/customers/%s/status", created.ID),
-		"verification_url": fmt.Sprintf("/v1/customers/%s/verification", created.ID),
-	}
 	h.logger.Info(ctx, "http create customer succeeded", logger.String("customer_id", created.ID.String()))
-	writeJSON(w, http.StatusCreated, resp)
+	w.Header().Set("Location", customerPath(created.ID))
+	writeJSON(w, http.StatusCreated, newCustomerV1(*created))
 }
 
+// GetCustomer serves GET /v1/customers/{id}?expand=addresses; expand
+// includes the customer's addresses in the response.
 func (h *Handler) GetCustomer(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	idStr := chi.URLParam(r, "id")
@@ -86,6 +149,18 @@ func (h *Handler) GetCustomer(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid id")
 		return
 	}
+	withAddresses := false
+	for _, v := range strings.Split(r.URL.Query().Get("expand"), ",") {
+		switch strings.TrimSpace(v) {
+		case "":
+		case "addresses":
+			withAddresses = true
+		default:
+			h.logger.Warn(ctx, "http get customer invalid expand", logger.String("expand", v))
+			writeError(w, http.StatusBadRequest, "invalid expand: expected addresses")
+			return
+		}
+	}
 	cust, err := h.svc.Get(ctx, id)
 	if err != nil {
 		if errors.Is(err, customer.ErrNotFound) {
@@ -97,17 +172,15 @@ func (h *Handler) GetCustomer(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	resp := map[string]any{
-		"customer_id":      cust.ID,
-		"name":             cust.Name,
-		"email":            cust.Email,
-		"phone":            cust.Phone,
-		"created_at":       cust.CreatedAt,
-		"updated_at":       cust.UpdatedAt,
-		"pan_number":       cust.PANNumber,
-		"status":           cust.Status,
-		"status_url":       fmt.Sprintf("/v1/customers/%s/status", cust.ID),
-		"verification_url": fmt.Sprintf("/v1/customers/%s/verification", cust.ID),
+	resp := newCustomerV1(*cust)
+	if withAddresses {
+		addresses, err := h.svc.ListAddresses(ctx, id)
+		if err != nil {
+			h.addressFailed(ctx, w, "list", err, logger.String("customer_id", idStr))
+			return
+		}
+		list := newAddressesV1(addresses)
+		resp.Addresses = &list
 	}
 	h.logger.Info(ctx, "http get customer succeeded", logger.String("customer_id", cust.ID.String()))
 	writeJSON(w, http.StatusOK, resp)
@@ -132,36 +205,83 @@ func (h *Handler) ListCustomers(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if limit > maxListLimit {
+		// Matches Service.List, so the envelope and its links describe the
+		// page actually returned.
+		limit = 20
+	}
+	f, filter, err := parseFilter(q)
+	if err != nil {
+		h.logger.Warn(ctx, "http list customers invalid filter", logger.Err(err))
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	h.logger.Info(ctx, "http list customers received", logger.Int("page", page), logger.Int("limit", limit))
-	items, total, err := h.svc.List(ctx, page, limit)
+	items, total, err := h.svc.List(ctx, f, page, limit)
 	if err != nil {
 		h.logger.Error(ctx, "http list customers internal failure", logger.Err(err))
 		writeError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
-	var out []map[string]any
-	for _, c := range items {
-		out = append(out, map[string]any{
-			"customer_id":      c.ID,
-			"name":             c.Name,
-			"email":            c.Email,
-			"phone":            c.Phone,
-			"created_at":       c.CreatedAt,
-			"updated_at":       c.UpdatedAt,
-			"status_url":       fmt.Sprintf("/v1/customers/%s/status", c.ID),
-			"verification_url": fmt.Sprintf("/v1/customers/%s/verification", c.ID),
-		})
-	}
-	resp := map[string]any{
-		"page":  page,
-		"limit": limit,
-		"total": total,
-		"data":  out,
-	}
-	h.logger.Info(ctx, "http list customers succeeded", logger.Int("returned", len(out)), logger.Int("total", total))
+	resp := newListV1("/v1/customers", filter, page, limit, total, items, newCustomerV1)
+	h.logger.Info(ctx, "http list customers succeeded", logger.Int("returned", len(resp.Data)), logger.Int("total", total))
 	writeJSON(w, http.StatusOK, resp)
 }
 
+// parseFilter reads the customer filter shared by listing and export:
+// status, from and to as RFC 3339 creation times with to exclusive, tag, and
+// metadata.<key> for each metadata attribute to match. It also returns the
+// filter parameters that were set, for building links.
+func parseFilter(q url.Values) (customer.Filter, url.Values, error) {
+	var f customer.Filter
+	set := url.Values{}
+	if v := q.Get("status"); v != "" {
+		f.Status = customer.VerificationStatus(v)
+		if !customer.IsValidStatus(f.Status) {
+			return f, nil, errors.New("invalid status")
+		}
+		set.Set("status", v)
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, nil, fmt.Errorf("invalid %s: expected RFC 3339 timestamp", p.name)
+		}
+		*p.dst = t
+		set.Set(p.name, v)
+	}
+	if v := q.Get("tag"); v != "" {
+		tags, err := customer.NormalizeTags([]string{v})
+		if err != nil {
+			return f, nil, err
+		}
+		f.Tag = tags[0]
+		set.Set("tag", v)
+	}
+	for name, vs := range q {
+		key, ok := strings.CutPrefix(name, "metadata.")
+		if !ok {
+			continue
+		}
+		if !customer.IsValidMetadataKey(key) {
+			return f, nil, fmt.Errorf("invalid metadata filter %q: keys are 1 to 64 letters, digits, '_' or '-'", name)
+		}
+		if f.Metadata == nil {
+			f.Metadata = map[string]string{}
+		}
+		f.Metadata[key] = vs[0]
+		set.Set(name, vs[0])
+	}
+	return f, set, nil
+}
+
 func (h *Handler) PatchCustomer(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	idStr := chi.URLParam(r, "id")
@@ -178,7 +298,13 @@ func (h *Handler) PatchCustomer(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
-	updated, err := h.svc.Update(ctx, id, req.Name, req.Email, req.Phone)
+	upd, err := req.update()
+	if err != nil {
+		h.logger.Warn(ctx, "http patch customer validation failed", logger.Err(err), logger.String("customer_id", idStr))
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	updated, err := h.svc.Update(ctx, id, upd)
 	if err != nil {
 		if errors.Is(err, customer.ErrNotFound) {
 			h.logger.Warn(ctx, "http patch customer not found", logger.String("customer_id", idStr))
@@ -186,6 +312,9 @@ func (h *Handler) PatchCustomer(w http.ResponseWriter, r *http.Request) {
 		} else if errors.Is(err, customer.ErrConflict) {
 			h.logger.Warn(ctx, "http patch customer conflict", logger.Err(err), logger.String("customer_id", idStr))
 			writeError(w, http.StatusConflict, err.Error())
+		} else if errors.Is(err, customer.ErrInvalidPhone) || customer.IsInvalidProfile(err) || errors.Is(err, customer.ErrInvalidMetadata) {
+			h.logger.Warn(ctx, "http patch customer validation failed", logger.Err(err), logger.String("customer_id", idStr))
+			writeError(w, http.StatusBadRequest, err.Error())
 		} else {
 			h.logger.Error(ctx, "http patch customer internal failure", logger.Err(err), logger.String("customer_id", idStr))
 			writeError(w, http.StatusInternalServerError, "internal error")
@@ -193,7 +322,7 @@ func (h *Handler) PatchCustomer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	h.logger.Info(ctx, "http patch customer succeeded", logger.String("customer_id", updated.ID.String()))
-	writeJSON(w, http.StatusOK, updated)
+	writeJSON(w, http.StatusOK, newCustomerV1(*updated))
 }
 
 func (h *Handler) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
@@ -231,7 +360,7 @@ func (h *Handler) GetCustomerKYCStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	h.logger.Info(ctx, "http get verification status succeeded", logger.String("customer_id", id))
-	writeJSON(w, http.StatusOK, verification)
+	writeJSON(w, http.StatusOK, newVerificationV1(verification.Masked()))
 }
 
 func (h *Handler) UpdateKYC(w http.ResponseWriter, r *http.Request) {
@@ -250,12 +379,22 @@ func (h *Handler) UpdateKYC(w http.ResponseWriter, r *http.Request) {
 	if payload.PAN != "" {
 		verification, err := h.svc.CreateVerification(ctx, id, payload.PAN)
 		if err != nil {
+			if errors.Is(err, customer.ErrPANAlreadyExists) {
+				h.logger.Warn(ctx, "http create verification conflict", logger.String("customer_id", id))
+				writeError(w, http.StatusConflict, err.Error())
+				return
+			}
+			if errors.Is(err, customer.ErrNotFound) {
+				h.logger.Warn(ctx, "http create verification customer not found", logger.String("customer_id", id))
+				writeError(w, http.StatusNotFound, "not found")
+				return
+			}
 			h.logger.Error(ctx, "http create verification failed", logger.Err(err), logger.String("customer_id", id))
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 		h.logger.Info(ctx, "http create verification succeeded", logger.String("verification_id", verification.ID.String()), logger.String("customer_id", id))
-		writeJSON(w, http.StatusCreated, verification)
+		writeJSON(w, http.StatusCreated, newVerificationV1(verification.Masked()))
 		return
 	}
 	if payload.Status != "" {
@@ -272,19 +411,56 @@ func (h *Handler) UpdateKYC(w http.ResponseWriter, r *http.Request) {
 		}
 
 		verification, err := h.svc.UpdateVerificationStatus(ctx, id, payload.Status)
+		if errors.Is(err, customer.ErrInvalidPAN) {
+			h.logger.Warn(ctx, "http update verification status rejected", logger.Err(err), logger.String("customer_id", id), logger.String("status", payload.Status))
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
 		if err != nil {
 			h.logger.Error(ctx, "http update verification status failed", logger.Err(err), logger.String("customer_id", id), logger.String("status", payload.Status))
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 		h.logger.Info(ctx, "http update verification status succeeded", logger.String("verification_id", verification.ID.String()), logger.String("customer_id", id), logger.String("status", payload.Status))
-		writeJSON(w, http.StatusOK, verification)
+		writeJSON(w, http.StatusOK, newVerificationV1(verification.Masked()))
 		return
 	}
 	h.logger.Warn(ctx, "http update verification nothing to update", logger.String("customer_id", id))
 	writeError(w, http.StatusBadRequest, "nothing to update")
 }
 
+// RevealPAN returns the verification record with the unmasked PAN. The
+// service enforces the reveal scope and records the access; callers may state
+// why they need the value in the X-Access-Reason header.
+func (h *Handler) RevealPAN(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+	h.logger.Info(ctx, "http reveal pan received", logger.String("customer_id", id))
+	if _, err := uuid.Parse(id); err != nil {
+		h.logger.Warn(ctx, "http reveal pan invalid id", logger.Err(err), logger.String("customer_id", id))
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	verification, err := h.svc.RevealPAN(ctx, id, r.Header.Get(accessReasonHeader))
+	if err != nil {
+		switch {
+		case errors.Is(err, customer.ErrForbidden):
+			h.logger.Warn(ctx, "http reveal pan forbidden", logger.String("customer_id", id))
+			writeError(w, http.StatusForbidden, "forbidden")
+		case errors.Is(err, customer.ErrVerificationNotFound):
+			h.logger.Warn(ctx, "http reveal pan not found", logger.String("customer_id", id))
+			writeError(w, http.StatusNotFound, "verification record not found")
+		default:
+			h.logger.Error(ctx, "http reveal pan internal failure", logger.Err(err), logger.String("customer_id", id))
+			writeError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+	h.logger.Info(ctx, "http reveal pan succeeded", logger.String("customer_id", id))
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, newVerificationV1(*verification))
+}
+
 func fmtSscanf(s string, dst *int) (int, error) {
 	var n int
 	for i := 0; i < len(s); i++ {
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Archiit19/customer-service-go/internal/health"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// HealthHandler serves the liveness and readiness probes.
+type HealthHandler struct {
+	registry *health.Registry
+	logger   logger.Logger
+}
+
+func NewHealthHandler(registry *health.Registry, log logger.Logger) *HealthHandler {
+	return &HealthHandler{registry: registry, logger: log}
+}
+
+// Livez reports that the process is up and serving HTTP. It deliberately
+// ignores dependencies: restarting the pod does not fix a database outage.
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": health.StatusOK})
+}
+
+// Readyz runs the registered dependency checks and returns 503 if any fails
+// or the service is shutting down.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := health.Report{Status: health.StatusOK}
+	if h.registry != nil {
+		report = h.registry.Ready(r.Context())
+	}
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+		h.logger.Warn(r.Context(), "http readiness check failed", logger.String("status", report.Status), logger.Any("checks", report.Checks))
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, report)
+}
@@ -0,0 +1,198 @@
+package http
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/importfile"
+	"github.com/Archiit19/customer-service-go/internal/jobs"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const actionJobCreate = "job.create"
+
+// uploadTimeout replaces the server's read and write timeouts for an import
+// upload, which may be far larger than an ordinary request body.
+const uploadTimeout = 5 * time.Minute
+
+type JobsHandler struct {
+	store     jobs.Store
+	audit     audit.Recorder
+	logger    logger.Logger
+	maxUpload int64
+}
+
+// NewJobsHandler creates a handler accepting uploads of up to maxUpload
+// bytes. A nil recorder disables auditing of submissions.
+func NewJobsHandler(store jobs.Store, rec audit.Recorder, log logger.Logger, maxUpload int64) *JobsHandler {
+	if rec == nil {
+		rec = audit.Nop{}
+	}
+	return &JobsHandler{store: store, audit: rec, logger: log, maxUpload: maxUpload}
+}
+
+// ImportCustomers serves POST /v1/customers:import. The file, in any format
+// BatchCreateCustomers accepts, is checked for structure and queued; rows
+// are validated and created by the job worker, and the response points at
+// the job to poll.
+func (h *JobsHandler) ImportCustomers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Info(ctx, "http import customers received")
+	format, err := importfile.FormatFor(r.Header.Get("Content-Type"))
+	if err != nil {
+		h.logger.Warn(ctx, "http import customers unsupported content type", logger.String("content_type", r.Header.Get("Content-Type")))
+		writeError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Now().Add(uploadTimeout))
+	_ = rc.SetWriteDeadline(time.Now().Add(uploadTimeout))
+	// Sizing the buffer from Content-Length avoids io.ReadAll's doubling,
+	// which could hold twice the upload at once. ReadFrom needs MinRead
+	// spare bytes to see the end of the body.
+	var buf bytes.Buffer
+	if r.ContentLength > 0 && r.ContentLength <= h.maxUpload {
+		buf.Grow(int(r.ContentLength) + bytes.MinRead)
+	}
+	_, err = buf.ReadFrom(http.MaxBytesReader(w, r.Body, h.maxUpload))
+	data := buf.Bytes()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.logger.Warn(ctx, "http import customers body too large")
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", h.maxUpload))
+			return
+		}
+		h.logger.Warn(ctx, "http import customers body read failed", logger.Err(err))
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	total, err := countRows(format, data)
+	if err != nil {
+		h.logger.Warn(ctx, "http import customers decode failed", logger.Err(err))
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if total == 0 {
+		h.logger.Warn(ctx, "http import customers empty file")
+		writeError(w, http.StatusBadRequest, "import is empty")
+		return
+	}
+
+	job, err := h.store.Create(ctx, &jobs.Job{
+		Kind:      jobs.KindCustomerImport,
+		Format:    format,
+		TotalRows: total,
+		CreatedBy: auth.FromContext(ctx).ID,
+		RequestID: logger.RequestIDFromContext(ctx),
+		ClientIP:  auth.ClientIPFromContext(ctx),
+	}, data)
+	record := audit.NewEntry(ctx, actionJobCreate, "job", "")
+	record.Metadata = map[string]any{"kind": jobs.KindCustomerImport, "format": string(format), "rows": total, "bytes": len(data)}
+	record.Outcome = audit.OutcomeSuccess
+	if err != nil {
+		record.Outcome = audit.OutcomeFailure
+	} else {
+		record.ResourceID = job.ID.String()
+	}
+	if recErr := h.audit.Record(ctx, record); recErr != nil {
+		h.logger.Error(ctx, "http import customers audit record failed", logger.Err(recErr))
+	}
+	if err != nil {
+		h.logger.Error(ctx, "http import customers internal failure", logger.Err(err))
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	h.logger.Info(ctx, "http import customers queued", logger.String("job_id", job.ID.String()), logger.Int("rows", total))
+	w.Header().Set("Location", jobPath(job.ID))
+	writeJSON(w, http.StatusAccepted, newJobV1(*job))
+}
+
+// countRows reads every row of data to reject malformed files before they
+// are queued.
+func countRows(format importfile.Format, data []byte) (int, error) {
+	rd, err := importfile.NewReader(format, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for {
+		_, err := rd.Next()
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("row %d: %w", n, err)
+		}
+		n++
+	}
+}
+
+// GetJob serves GET /v1/jobs/{id}.
+func (h *JobsHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	job, ok := h.job(w, r)
+	if !ok {
+		return
+	}
+	h.logger.Info(ctx, "http get job succeeded", logger.String("job_id", job.ID.String()), logger.String("status", job.Status))
+	writeJSON(w, http.StatusOK, newJobV1(*job))
+}
+
+// GetJobErrors serves GET /v1/jobs/{id}/errors, a CSV report of the rows that
+// failed so far, in input order.
+func (h *JobsHandler) GetJobErrors(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	job, ok := h.job(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="job-%s-errors.csv"`, job.ID))
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"index", "status", "error"})
+	err := h.store.Errors(ctx, job.ID, func(e jobs.RowError) error {
+		return cw.Write([]string{strconv.Itoa(e.Index), e.Status, e.Error})
+	})
+	cw.Flush()
+	if err != nil {
+		// The status has been sent, so the report is cut short instead.
+		h.logger.Error(ctx, "http get job errors failed", logger.String("job_id", job.ID.String()), logger.Err(err))
+		return
+	}
+	h.logger.Info(ctx, "http get job errors succeeded", logger.String("job_id", job.ID.String()))
+}
+
+// job loads the job named in the path, writing the error response itself
+// when it cannot.
+func (h *JobsHandler) job(w http.ResponseWriter, r *http.Request) (*jobs.Job, bool) {
+	ctx := r.Context()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.logger.Warn(ctx, "http get job invalid id", logger.String("id", chi.URLParam(r, "id")))
+		writeError(w, http.StatusBadRequest, "invalid job id")
+		return nil, false
+	}
+	job, err := h.store.Get(ctx, id)
+	if errors.Is(err, jobs.ErrNotFound) {
+		h.logger.Warn(ctx, "http get job not found", logger.String("job_id", id.String()))
+		writeError(w, http.StatusNotFound, "job not found")
+		return nil, false
+	}
+	if err != nil {
+		h.logger.Error(ctx, "http get job internal failure", logger.Err(err))
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, false
+	}
+	return job, true
+}
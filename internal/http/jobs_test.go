@@ -0,0 +1,53 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/customer/customertest"
+	"github.com/Archiit19/customer-service-go/internal/jobs"
+	"github.com/Archiit19/customer-service-go/internal/jobs/jobstest"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// deadlineStore records whether an import reached it with a deadline.
+type deadlineStore struct {
+	*jobstest.MemoryStore
+	deadline bool
+}
+
+func (s *deadlineStore) Create(ctx context.Context, j *jobs.Job, input []byte) (*jobs.Job, error) {
+	_, s.deadline = ctx.Deadline()
+	return s.MemoryStore.Create(ctx, j, input)
+}
+
+func TestImportIsNotBoundByRequestTimeout(t *testing.T) {
+	log := logger.NewNop()
+	svc := customer.NewService(customertest.NewMemoryRepository(), log, audit.Nop{}, nil, nil, nil, customer.ContactVerification{})
+	store := &deadlineStore{MemoryStore: jobstest.NewMemoryStore()}
+	h := NewRouter(svc, log, Options{Jobs: store, MaxUploadBytes: 1 << 20})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/customers:import", strings.NewReader("name,email,phone\nAsha,asha@example.com,+919876543210\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("import: got %d: %s", rec.Code, rec.Body)
+	}
+	if store.deadline {
+		t.Error("import ran under the request timeout instead of uploadTimeout")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/customers:import", strings.NewReader(strings.Repeat("x", 2<<20)))
+	req.Header.Set("Content-Type", "text/csv")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized import: got %d, want 413", rec.Code)
+	}
+}
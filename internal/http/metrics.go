@@ -0,0 +1,69 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+// unmatchedRoute labels requests that matched no route, so scanners probing
+// random paths cannot blow up label cardinality.
+const unmatchedRoute = "unmatched"
+
+// otherMethod labels requests with a method outside the standard set, which
+// clients can otherwise choose freely.
+const otherMethod = "OTHER"
+
+// Metrics records request count and latency labelled by method, chi route
+// pattern and status class. The raw path is never used as a label.
+func Metrics(reg metrics.Registry) func(http.Handler) http.Handler {
+	requests := reg.Counter(
+		"http_requests_total",
+		"HTTP requests served.",
+		"method", "route", "status",
+	)
+	duration := reg.Histogram(
+		"http_request_duration_seconds",
+		"HTTP request latency.",
+		metrics.DefaultBuckets,
+		"method", "route", "status",
+	)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			start := time.Now()
+			next.ServeHTTP(rw, r)
+
+			// The pattern is only complete once chi has finished routing.
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if p := rctx.RoutePattern(); p != "" {
+					route = p
+				}
+			}
+			method, status := methodLabel(r.Method), statusClass(rw.status)
+			requests.Inc(method, route, status)
+			duration.Observe(time.Since(start).Seconds(), method, route, status)
+		})
+	}
+}
+
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return otherMethod
+	}
+}
+
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
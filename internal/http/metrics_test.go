@@ -0,0 +1,36 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Archiit19/customer-service-go/internal/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMetricsLabelsUnknownMethodsOther(t *testing.T) {
+	reg := metrics.NewRegistry()
+	r := chi.NewRouter()
+	r.Use(Metrics(reg))
+	r.Get("/v1/customers", okHandler)
+	for _, method := range []string{http.MethodGet, "BREW", "X-RANDOM-1", "X-RANDOM-2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/v1/customers", nil))
+	}
+
+	var out bytes.Buffer
+	if err := reg.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	for _, method := range []string{"BREW", "X-RANDOM"} {
+		if strings.Contains(text, `method="`+method) {
+			t.Fatalf("method %s used as a label:\n%s", method, text)
+		}
+	}
+	if !strings.Contains(text, `method="OTHER"`) || !strings.Contains(text, `method="GET"`) {
+		t.Fatalf("want GET and OTHER labels:\n%s", text)
+	}
+}
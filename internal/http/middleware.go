@@ -26,6 +26,11 @@ func (w *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Unwrap lets http.ResponseController reach the underlying connection.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func WithRequestContext(log logger.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -53,7 +58,7 @@ func Recovery(log logger.Logger) func(http.Handler) http.Handler {
 				if rec := recover(); rec != nil {
 					ctx := r.Context()
 					log.Error(ctx, "http panic recovered", logger.Any("panic", rec))
-					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					writeError(w, http.StatusInternalServerError, "internal error")
 				}
 			}()
 			next.ServeHTTP(w, r)
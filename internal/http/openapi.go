@@ -0,0 +1,149 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/openapi"
+)
+
+// maxValidatedBody bounds how much of a request body ValidateRequests reads;
+// larger bodies are passed on with only their parameters validated.
+const maxValidatedBody = 1 << 20
+
+// docsPage renders the API description with Redoc.
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Customer Service API</title>
+</head>
+<body>
+<redoc spec-url="/openapi.yaml"></redoc>
+<script src="https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+// SpecHandler serves the API description and its documentation page.
+type SpecHandler struct {
+	spec *openapi.Spec
+}
+
+func NewSpecHandler(spec *openapi.Spec) *SpecHandler {
+	return &SpecHandler{spec: spec}
+}
+
+// Spec serves the API description as YAML.
+func (h *SpecHandler) Spec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(h.spec.Raw())
+}
+
+// Docs serves a page rendering the API description.
+func (h *SpecHandler) Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = io.WriteString(w, docsPage)
+}
+
+// ValidateRequests rejects requests whose parameters or body do not match
+// the API description with 400. Requests for paths the description does not
+// cover pass through.
+func ValidateRequests(spec *openapi.Spec, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, params := spec.Find(r.Method, r.URL.Path)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxValidatedBody+1))
+			if err != nil {
+				log.Warn(ctx, "http request body read failed", logger.Err(err))
+				writeError(w, http.StatusBadRequest, "invalid request body")
+				return
+			}
+			if len(body) > maxValidatedBody {
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+				body = nil
+			} else {
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			if err := op.ValidateRequest(r, params, body); err != nil {
+				var verr *openapi.ValidationError
+				errors.As(err, &verr)
+				log.Warn(ctx, "http request does not match API schema", logger.String("operation", op.Method+" "+op.Path), logger.Any("problems", verr.Problems))
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ValidateResponses logs responses that do not match the API description.
+// The response itself is passed on unchanged.
+func ValidateResponses(spec *openapi.Spec, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, _ := spec.Find(r.Method, r.URL.Path)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			if err := op.ValidateResponse(rec.status, w.Header(), rec.body.Bytes()); err != nil {
+				var verr *openapi.ValidationError
+				errors.As(err, &verr)
+				log.Warn(r.Context(), "http response does not match API schema", logger.String("operation", op.Method+" "+op.Path), logger.Int("status", rec.status), logger.Any("problems", verr.Problems))
+			}
+		})
+	}
+}
+
+// readCloser reads from a replayed prefix of a body and closes the original.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// recordingWriter keeps a copy of the status and body it passes on. Only
+// JSON bodies are validated, so others, such as streamed exports, are not
+// kept.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	// json is decided on the first Write, once the handler has set the
+	// content type.
+	json *bool
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.json == nil {
+		mt, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		isJSON := mt == "application/json" || strings.HasSuffix(mt, "+json")
+		w.json = &isJSON
+	}
+	if *w.json {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
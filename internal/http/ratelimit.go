@@ -0,0 +1,74 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/ratelimit"
+	"github.com/go-chi/chi/v5"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// RateLimit enforces the limiter's token buckets per client and route. It must
+// run after middleware.RealIP and Authenticate so the client is known. Store
+// failures are logged and the request is let through rather than turning a
+// database hiccup into an outage.
+func RateLimit(limiter *ratelimit.Limiter, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			pattern := routePattern(r)
+			res, err := limiter.Allow(ctx, clientKey(r), r.Method, pattern)
+			if err != nil {
+				log.Warn(ctx, "rate limit check failed; allowing request", logger.Err(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+			if !res.Allowed {
+				h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+				log.Warn(ctx, "http request rate limited", logger.String("method", r.Method), logger.String("route", pattern), logger.Duration("retry_after", res.RetryAfter))
+				writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey identifies the caller: the authenticated principal if there is
+// one, otherwise the client IP. Anonymous callers are never told apart by
+// headers they choose, such as an X-API-Key ignored because authentication is
+// disabled, or each new value would get a fresh bucket.
+func clientKey(r *http.Request) string {
+	if p := auth.FromContext(r.Context()); !p.IsAnonymous() {
+		return "principal:" + p.ID
+	}
+	return "ip:" + clientIP(r)
+}
+
+// routePattern resolves the chi route pattern for r before routing has
+// happened, so limits are keyed by "/v1/customers/{id}" rather than raw paths.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return ""
+	}
+	tctx := chi.NewRouteContext()
+	if !rctx.Routes.Match(tctx, r.Method, r.URL.Path) {
+		return ""
+	}
+	return tctx.RoutePattern()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
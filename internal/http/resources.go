@@ -0,0 +1,400 @@
+package http
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/jobs"
+	"github.com/google/uuid"
+)
+
+// CustomerV1 is a customer as returned by every /v1 customer endpoint. The
+// PAN is always masked.
+type CustomerV1 struct {
+	ID        uuid.UUID `json:"customer_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	PANNumber *string   `json:"pan_number"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ProfileV1
+	Metadata map[string]any `json:"metadata"`
+	Tags     []string       `json:"tags"`
+	// EmailVerifiedAt and PhoneVerifiedAt are null until the customer
+	// confirms a code sent to the contact.
+	EmailVerifiedAt *time.Time      `json:"email_verified_at"`
+	PhoneVerifiedAt *time.Time      `json:"phone_verified_at"`
+	Links           CustomerLinksV1 `json:"links"`
+	// Addresses is set only when requested with ?expand=addresses.
+	Addresses *[]AddressV1 `json:"addresses,omitempty"`
+
+	// StatusURL and VerificationURL predate Links and are kept for existing
+	// clients.
+	StatusURL       string `json:"status_url"`
+	VerificationURL string `json:"verification_url"`
+}
+
+// ProfileV1 are a customer's optional onboarding details, null when not
+// provided.
+type ProfileV1 struct {
+	DateOfBirth       *string `json:"date_of_birth"`
+	Gender            *string `json:"gender"`
+	Nationality       *string `json:"nationality"`
+	Occupation        *string `json:"occupation"`
+	PreferredLanguage *string `json:"preferred_language"`
+}
+
+// ContactVerificationV1 describes a verification code that has been sent.
+type ContactVerificationV1 struct {
+	Channel     string    `json:"channel"`
+	Destination string    `json:"destination"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func newContactVerificationV1(p customer.PendingContactVerification) ContactVerificationV1 {
+	return ContactVerificationV1{Channel: string(p.Channel), Destination: p.Destination, ExpiresAt: p.ExpiresAt}
+}
+
+// CustomerLinksV1 are the resources related to a customer.
+type CustomerLinksV1 struct {
+	Self         string `json:"self"`
+	Status       string `json:"status"`
+	Verification string `json:"verification"`
+	Addresses    string `json:"addresses"`
+}
+
+// AddressV1 is a customer's postal address.
+type AddressV1 struct {
+	ID         uuid.UUID      `json:"id"`
+	CustomerID uuid.UUID      `json:"customer_id"`
+	Type       string         `json:"type"`
+	Line1      string         `json:"line1"`
+	Line2      string         `json:"line2"`
+	City       string         `json:"city"`
+	State      string         `json:"state"`
+	PINCode    string         `json:"pin_code"`
+	Primary    bool           `json:"primary"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	Links      AddressLinksV1 `json:"links"`
+}
+
+// AddressLinksV1 are the resources related to an address.
+type AddressLinksV1 struct {
+	Self     string `json:"self"`
+	Customer string `json:"customer"`
+}
+
+// AddressListV1 lists a customer's addresses, primary first. It is not
+// paginated.
+type AddressListV1 struct {
+	Data []AddressV1 `json:"data"`
+}
+
+// VerificationV1 is a verification record. The PAN is masked except on the
+// reveal endpoint.
+type VerificationV1 struct {
+	ID         uuid.UUID           `json:"id"`
+	CustomerID uuid.UUID           `json:"customer_id"`
+	PANNumber  *string             `json:"pan_number"`
+	Status     string              `json:"status"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+	Links      VerificationLinksV1 `json:"links"`
+}
+
+// VerificationLinksV1 are the resources related to a verification record.
+type VerificationLinksV1 struct {
+	Self     string `json:"self"`
+	Customer string `json:"customer"`
+	// Update is where the PAN is submitted and the status changed.
+	Update string `json:"update"`
+}
+
+// ListV1 is one page of a collection.
+type ListV1[T any] struct {
+	Page  int         `json:"page"`
+	Limit int         `json:"limit"`
+	Total int         `json:"total"`
+	Data  []T         `json:"data"`
+	Links PageLinksV1 `json:"links"`
+}
+
+// PageLinksV1 navigate a paginated collection; Prev and Next are omitted on
+// the first and last page.
+type PageLinksV1 struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last"`
+}
+
+func customerPath(id uuid.UUID) string {
+	return "/v1/customers/" + id.String()
+}
+
+// newCustomerV1 maps a domain customer to its v1 representation.
+func newCustomerV1(c customer.Customer) CustomerV1 {
+	self := customerPath(c.ID)
+	links := CustomerLinksV1{
+		Self:         self,
+		Status:       self + "/status",
+		Verification: self + "/verification",
+		Addresses:    self + "/addresses",
+	}
+	// Always an object and an array, never null.
+	if c.Metadata == nil {
+		c.Metadata = map[string]any{}
+	}
+	if c.Tags == nil {
+		c.Tags = []string{}
+	}
+	return CustomerV1{
+		ID:              c.ID,
+		Name:            c.Name,
+		Email:           c.Email,
+		Phone:           c.Phone,
+		PANNumber:       c.Masked().PANNumber,
+		Status:          c.Status,
+		CreatedAt:       c.CreatedAt,
+		UpdatedAt:       c.UpdatedAt,
+		ProfileV1:       newProfileV1(c.Profile),
+		Metadata:        c.Metadata,
+		Tags:            c.Tags,
+		EmailVerifiedAt: c.EmailVerifiedAt,
+		PhoneVerifiedAt: c.PhoneVerifiedAt,
+		Links:           links,
+		StatusURL:       links.Status,
+		VerificationURL: links.Verification,
+	}
+}
+
+// newProfileV1 maps a customer's profile, formatting the date of birth as
+// YYYY-MM-DD.
+func newProfileV1(p customer.Profile) ProfileV1 {
+	out := ProfileV1{
+		Nationality:       p.Nationality,
+		Occupation:        p.Occupation,
+		PreferredLanguage: p.PreferredLanguage,
+	}
+	if p.DateOfBirth != nil {
+		dob := customer.FormatDate(*p.DateOfBirth)
+		out.DateOfBirth = &dob
+	}
+	if p.Gender != nil {
+		g := string(*p.Gender)
+		out.Gender = &g
+	}
+	return out
+}
+
+func addressPath(customerID, id uuid.UUID) string {
+	return customerPath(customerID) + "/addresses/" + id.String()
+}
+
+// newAddressV1 maps a domain address to its v1 representation.
+func newAddressV1(a customer.Address) AddressV1 {
+	return AddressV1{
+		ID:         a.ID,
+		CustomerID: a.CustomerID,
+		Type:       string(a.Type),
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		State:      a.State,
+		PINCode:    a.PINCode,
+		Primary:    a.Primary,
+		CreatedAt:  a.CreatedAt,
+		UpdatedAt:  a.UpdatedAt,
+		Links: AddressLinksV1{
+			Self:     addressPath(a.CustomerID, a.ID),
+			Customer: customerPath(a.CustomerID),
+		},
+	}
+}
+
+func newAddressesV1(addresses []customer.Address) []AddressV1 {
+	out := make([]AddressV1, 0, len(addresses))
+	for _, a := range addresses {
+		out = append(out, newAddressV1(a))
+	}
+	return out
+}
+
+// newVerificationV1 maps a verification record to its v1 representation.
+// The PAN is copied as is; callers mask it unless revealing it.
+func newVerificationV1(v customer.Verification) VerificationV1 {
+	customerURL := customerPath(v.CustomerID)
+	return VerificationV1{
+		ID:         v.ID,
+		CustomerID: v.CustomerID,
+		PANNumber:  v.PANNumber,
+		Status:     string(v.Status),
+		CreatedAt:  v.CreatedAt,
+		UpdatedAt:  v.UpdatedAt,
+		Links: VerificationLinksV1{
+			Self:     customerURL + "/status",
+			Customer: customerURL,
+			Update:   customerURL + "/verification",
+		},
+	}
+}
+
+// newListV1 maps one page of items, linking to the neighbouring pages of
+// path with the same limit and filter parameters.
+func newListV1[T, D any](path string, filter url.Values, page, limit, total int, items []T, mapFn func(T) D) ListV1[D] {
+	data := make([]D, 0, len(items))
+	for _, item := range items {
+		data = append(data, mapFn(item))
+	}
+	last := max(1, (total+limit-1)/limit)
+	pageURL := func(p int) string {
+		q := url.Values{"page": {strconv.Itoa(p)}, "limit": {strconv.Itoa(limit)}}
+		for k, v := range filter {
+			q[k] = v
+		}
+		return fmt.Sprintf("%s?%s", path, q.Encode())
+	}
+	links := PageLinksV1{Self: pageURL(page), First: pageURL(1), Last: pageURL(last)}
+	if page > 1 {
+		links.Prev = pageURL(min(page-1, last))
+	}
+	if page < last {
+		links.Next = pageURL(page + 1)
+	}
+	return ListV1[D]{Page: page, Limit: limit, Total: total, Data: data, Links: links}
+}
+
+// Outcomes of one row of a batch create.
+const (
+	BatchRowCreated  = "created"
+	BatchRowInvalid  = "invalid"
+	BatchRowConflict = "conflict"
+	BatchRowAborted  = "aborted"
+)
+
+// BatchRowV1 is the outcome of one row of a batch create. Index is the row's
+// zero-based position in the input, not counting a CSV header.
+type BatchRowV1 struct {
+	Index    int         `json:"index"`
+	Status   string      `json:"status"`
+	Customer *CustomerV1 `json:"customer,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// BatchV1 reports a batch create row by row.
+type BatchV1 struct {
+	Mode    string       `json:"mode"`
+	Total   int          `json:"total"`
+	Created int          `json:"created"`
+	Failed  int          `json:"failed"`
+	Results []BatchRowV1 `json:"results"`
+}
+
+// JobV1 is a background job and its progress.
+type JobV1 struct {
+	ID            uuid.UUID  `json:"id"`
+	Kind          string     `json:"kind"`
+	Status        string     `json:"status"`
+	Format        string     `json:"format"`
+	TotalRows     int        `json:"total_rows"`
+	ProcessedRows int        `json:"processed_rows"`
+	CreatedRows   int        `json:"created_rows"`
+	FailedRows    int        `json:"failed_rows"`
+	Error         string     `json:"error,omitempty"`
+	Attempts      int        `json:"attempts"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	Links         JobLinksV1 `json:"links"`
+}
+
+// JobLinksV1 are the resources related to a job.
+type JobLinksV1 struct {
+	Self string `json:"self"`
+	// Errors is a CSV report of the rows that failed so far.
+	Errors string `json:"errors"`
+}
+
+func jobPath(id uuid.UUID) string {
+	return "/v1/jobs/" + id.String()
+}
+
+// newJobV1 maps a job to its v1 representation.
+func newJobV1(j jobs.Job) JobV1 {
+	self := jobPath(j.ID)
+	return JobV1{
+		ID:            j.ID,
+		Kind:          j.Kind,
+		Status:        j.Status,
+		Format:        string(j.Format),
+		TotalRows:     j.TotalRows,
+		ProcessedRows: j.ProcessedRows,
+		CreatedRows:   j.CreatedRows,
+		FailedRows:    j.FailedRows,
+		Error:         j.Error,
+		Attempts:      j.Attempts,
+		CreatedAt:     j.CreatedAt,
+		StartedAt:     j.StartedAt,
+		FinishedAt:    j.FinishedAt,
+		Links:         JobLinksV1{Self: self, Errors: self + "/errors"},
+	}
+}
+
+// DuplicateV1 is a pair of customers that may be the same person.
+type DuplicateV1 struct {
+	Score     float64            `json:"score"`
+	Signals   DuplicateSignalsV1 `json:"signals"`
+	Customers [2]CustomerV1      `json:"customers"`
+	// Survivor is the customer suggested to keep: the verified one, else the
+	// one with a PAN, else the older one.
+	Survivor uuid.UUID        `json:"survivor_id"`
+	Links    DuplicateLinksV1 `json:"links"`
+}
+
+// DuplicateSignalsV1 are the similarities behind a duplicate's score.
+type DuplicateSignalsV1 struct {
+	NameSimilarity  float64 `json:"name_similarity"`
+	EmailSimilarity float64 `json:"email_similarity"`
+	SamePhone       bool    `json:"same_phone"`
+	SamePAN         bool    `json:"same_pan"`
+}
+
+// DuplicateLinksV1 are the actions on a suspected duplicate.
+type DuplicateLinksV1 struct {
+	// Merge merges the other customer into the suggested survivor.
+	Merge string `json:"merge"`
+}
+
+// DuplicateListV1 lists suspected duplicates, highest score first.
+type DuplicateListV1 struct {
+	MinScore float64       `json:"min_score"`
+	Limit    int           `json:"limit"`
+	Data     []DuplicateV1 `json:"data"`
+}
+
+// newDuplicateV1 maps a duplicate candidate, rounding similarities to three
+// decimals.
+func newDuplicateV1(d customer.DuplicateCandidate) DuplicateV1 {
+	round := func(f float64) float64 { return math.Round(f*1000) / 1000 }
+	keep, _ := d.Survivor()
+	return DuplicateV1{
+		Score: round(d.Score),
+		Signals: DuplicateSignalsV1{
+			NameSimilarity:  round(d.Signals.NameSimilarity),
+			EmailSimilarity: round(d.Signals.EmailSimilarity),
+			SamePhone:       d.Signals.SamePhone,
+			SamePAN:         d.Signals.SamePAN,
+		},
+		Customers: [2]CustomerV1{newCustomerV1(d.A), newCustomerV1(d.B)},
+		Survivor:  keep.ID,
+		Links:     DuplicateLinksV1{Merge: customerPath(keep.ID) + "/merge"},
+	}
+}
@@ -9,12 +9,19 @@ type errorResponse struct {
 	Error string `json:"error"`
 }
 
+// writeJSON writes v as the response body. A nil v writes only the status,
+// without a Content-Type, as for 204 responses.
 func writeJSON(w http.ResponseWriter, status int, v any) {
+	if v == nil {
+		w.WriteHeader(status)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	if v != nil {
-		_ = json.NewEncoder(w).Encode(v)
-	}
+	enc := json.NewEncoder(w)
+	// Links carry query strings; keep their "&" readable.
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
 }
 
 func writeError(w http.ResponseWriter, status int, msg string) {
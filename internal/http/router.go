@@ -4,35 +4,173 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/Archiit19/customer-service-go/internal/auth"
 	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/flags"
+	"github.com/Archiit19/customer-service-go/internal/health"
+	"github.com/Archiit19/customer-service-go/internal/jobs"
 	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/metrics"
+	"github.com/Archiit19/customer-service-go/internal/openapi"
+	"github.com/Archiit19/customer-service-go/internal/ratelimit"
+	"github.com/Archiit19/customer-service-go/internal/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
-// NewRouter configures all routes
-func NewRouter(svc *customer.Service, log logger.Logger) http.Handler {
+// Options carries the router's optional collaborators.
+type Options struct {
+	// Limiter enforces rate limits; nil disables rate limiting.
+	Limiter *ratelimit.Limiter
+	// Authn resolves API keys; nil or empty treats every caller as anonymous.
+	Authn *auth.Authenticator
+	// AuthRequired rejects /v1 requests without an API key.
+	AuthRequired bool
+	// Audit serves GET /v1/audit; nil leaves the route unregistered.
+	Audit audit.Store
+	// Metrics instruments requests and serves GET /metrics; nil disables both.
+	Metrics metrics.Registry
+	// Tracer starts a server span per request; nil disables tracing.
+	Tracer *tracing.Tracer
+	// Health holds the dependency checks behind GET /readyz; nil reports ready
+	// unconditionally.
+	Health *health.Registry
+	// LogLevel is served and changed through /admin/log-level; nil leaves the
+	// routes unregistered.
+	LogLevel *logger.AtomicLevel
+	// Flags is listed and changed through /admin/flags; nil leaves the routes
+	// unregistered.
+	Flags *flags.Client
+	// Jobs queues customer imports and serves /v1/jobs; nil leaves the routes
+	// unregistered.
+	Jobs jobs.Store
+	// MaxUploadBytes bounds an import upload.
+	MaxUploadBytes int64
+	// ExportMaxDuration bounds a customer export; zero means 30 minutes.
+	ExportMaxDuration time.Duration
+	// Spec is served at /openapi.yaml and /docs; nil leaves the routes
+	// unregistered and disables validation.
+	Spec *openapi.Spec
+	// ValidateRequests rejects /v1 and /admin requests that do not match Spec.
+	ValidateRequests bool
+	// ValidateResponses logs responses that do not match Spec.
+	ValidateResponses bool
+}
+
+// NewRouter configures all routes. Probes, /metrics and the API description
+// sit outside authentication and rate limiting so probes and scrapers keep
+// working regardless of client configuration.
+func NewRouter(svc *customer.Service, log logger.Logger, opts Options) http.Handler {
 	r := chi.NewRouter()
 
 	r.Use(
 		middleware.RequestID,
 		middleware.RealIP,
+	)
+	if opts.Tracer != nil {
+		// Before WithRequestContext so request logs carry the trace ID.
+		r.Use(Tracing(opts.Tracer))
+	}
+	r.Use(
 		WithRequestContext(log),
 		Recovery(log),
-		middleware.Timeout(60*time.Second),
 	)
+	if opts.Spec != nil && opts.ValidateResponses {
+		r.Use(ValidateResponses(opts.Spec, log))
+	}
+	if opts.Metrics != nil {
+		r.Use(Metrics(opts.Metrics))
+		r.Method(http.MethodGet, "/metrics", opts.Metrics.Handler())
+	}
+	if opts.Spec != nil {
+		sh := NewSpecHandler(opts.Spec)
+		r.Get("/openapi.yaml", sh.Spec)
+		r.Get("/docs", sh.Docs)
+	}
 
 	h := NewHandler(svc, log)
-	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		log.Info(r.Context(), "health check")
-		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	hh := NewHealthHandler(opts.Health, log)
+	r.Get("/livez", hh.Livez)
+	r.Get("/readyz", hh.Readyz)
+	// Kept for existing probes and load balancers; equivalent to /livez.
+	r.Get("/healthz", hh.Livez)
+
+	r.Group(func(r chi.Router) {
+		r.Use(Authenticate(opts.Authn, opts.AuthRequired, opts.Limiter, log))
+		r.Use(ResolveTenant(log))
+		if opts.Limiter != nil {
+			r.Use(RateLimit(opts.Limiter, log))
+		}
+		if opts.Spec != nil && opts.ValidateRequests {
+			r.Use(ValidateRequests(opts.Spec, log))
+		}
+		// Exports stream for longer than the request timeout allows and
+		// bound themselves instead.
+		exportMax := opts.ExportMaxDuration
+		if exportMax <= 0 {
+			exportMax = 30 * time.Minute
+		}
+		r.Get("/v1/customers:export", NewExportHandler(svc, log, exportMax).ExportCustomers)
+
+		var rec audit.Recorder
+		if opts.Audit != nil {
+			rec = opts.Audit
+		}
+		var jh *JobsHandler
+		if opts.Jobs != nil {
+			jh = NewJobsHandler(opts.Jobs, rec, log, opts.MaxUploadBytes)
+			// Uploads are bounded by uploadTimeout rather than the request
+			// timeout.
+			r.Post("/v1/customers:import", jh.ImportCustomers)
+		}
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Timeout(60 * time.Second))
+
+			r.Post("/v1/customers", h.CreateCustomer)
+			r.Post("/v1/customers:batchCreate", h.BatchCreateCustomers)
+			r.Delete("/v1/customers/{id}", h.DeleteCustomer)
+			r.Patch("/v1/customers/{id}", h.PatchCustomer)
+			r.Get("/v1/customers", h.ListCustomers)
+			r.Get("/v1/customers/{id}", h.GetCustomer)
+			r.Get("/v1/customers/{id}/status", h.GetCustomerKYCStatus)
+			r.Patch("/v1/customers/{id}/verification", h.UpdateKYC)
+			r.Get("/v1/customers/{id}/verification/pan", h.RevealPAN)
+			r.Get("/v1/customers:duplicates", h.ListDuplicates)
+			r.Post("/v1/customers/{id}/merge", h.MergeCustomer)
+			r.Post("/v1/customers/{id}/tags", h.AddTags)
+			r.Delete("/v1/customers/{id}/tags/{tag}", h.RemoveTag)
+			r.Post("/v1/customers/{id}/contact-verification", h.StartContactVerification)
+			r.Post("/v1/customers/{id}/contact-verification/confirm", h.ConfirmContactVerification)
+			r.Get("/v1/customers/{id}/addresses", h.ListAddresses)
+			r.Post("/v1/customers/{id}/addresses", h.CreateAddress)
+			r.Get("/v1/customers/{id}/addresses/{addressID}", h.GetAddress)
+			r.Patch("/v1/customers/{id}/addresses/{addressID}", h.PatchAddress)
+			r.Delete("/v1/customers/{id}/addresses/{addressID}", h.DeleteAddress)
+
+			if jh != nil {
+				r.Get("/v1/jobs/{id}", jh.GetJob)
+				r.Get("/v1/jobs/{id}/errors", jh.GetJobErrors)
+			}
+			if opts.Audit != nil {
+				ah := NewAuditHandler(opts.Audit, log)
+				r.With(RequireScope(auth.ScopeAuditRead, log)).Get("/v1/audit", ah.ListAuditEntries)
+			}
+			if opts.LogLevel != nil || opts.Flags != nil {
+				adm := NewAdminHandler(opts.LogLevel, opts.Flags, rec, log)
+				r.With(RequireScope(auth.ScopeAdmin, log)).Route("/admin", func(r chi.Router) {
+					if opts.LogLevel != nil {
+						r.Get("/log-level", adm.GetLogLevel)
+						r.Put("/log-level", adm.SetLogLevel)
+					}
+					if opts.Flags != nil {
+						r.Get("/flags", adm.ListFlags)
+						r.Put("/flags/{key}", adm.SetFlag)
+					}
+				})
+			}
+		})
 	})
-	r.Post("/v1/customers", h.CreateCustomer)
-	r.Delete("/v1/customers/{id}", h.DeleteCustomer)
-	r.Patch("/v1/customers/{id}", h.PatchCustomer)
-	r.Get("/v1/customers", h.ListCustomers)
-	r.Get("/v1/customers/{id}", h.GetCustomer)
-	r.Get("/v1/customers/{id}/status", h.GetCustomerKYCStatus)
-	r.Patch("/v1/customers/{id}/verification", h.UpdateKYC)
 	return r
 }
@@ -0,0 +1,68 @@
+// Package routertest builds the service's real HTTP router over in-memory
+// stores for tests of the HTTP and client layers. Nothing in it is meant for
+// production use.
+package routertest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Archiit19/customer-service-go/internal/audit/audittest"
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/customer/customertest"
+	"github.com/Archiit19/customer-service-go/internal/flags"
+	httph "github.com/Archiit19/customer-service-go/internal/http"
+	"github.com/Archiit19/customer-service-go/internal/jobs/jobstest"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// API keys of Router callers, both bound to tenant acme. OpsKey holds every
+// scope; ClerkKey holds none.
+const (
+	OpsKey   = "ops-key"
+	ClerkKey = "clerk-key"
+	apiKeys  = "ops@acme:" + OpsKey + ":pan:reveal|audit:read|admin:write|pii:export,clerk@acme:" + ClerkKey + ":"
+)
+
+// Router is the service's router with the stores behind it, for tests that
+// need to inspect them or run the job worker.
+type Router struct {
+	http.Handler
+	Service  *customer.Service
+	Notifier *customertest.Notifier
+	Audit    *audittest.MemoryStore
+	Jobs     *jobstest.MemoryStore
+}
+
+// New builds a router requiring the API keys above, with auditing, the
+// admin log level and flag routes, and customer imports enabled. The
+// authenticator and stores in opts are always replaced, LogLevel and
+// MaxUploadBytes are filled in when unset, and the rest, such as Metrics or
+// Spec, are used as given.
+func New(t testing.TB, opts httph.Options) *Router {
+	t.Helper()
+	log := logger.NewNop()
+	authn, err := auth.ParseAPIKeys(apiKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := flags.NewStatic(flags.PhoneIndiaOnly + "=off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	features := flags.NewClient(p, log)
+	r := &Router{Audit: audittest.NewMemoryStore(), Jobs: jobstest.NewMemoryStore()}
+	r.Service, r.Notifier = customertest.NewService(customertest.NewMemoryRepository(), r.Audit, features)
+
+	opts.Authn, opts.AuthRequired = authn, true
+	opts.Audit, opts.Jobs, opts.Flags = r.Audit, r.Jobs, features
+	if opts.LogLevel == nil {
+		opts.LogLevel = logger.NewAtomicLevel("INFO")
+	}
+	if opts.MaxUploadBytes == 0 {
+		opts.MaxUploadBytes = 1 << 20
+	}
+	r.Handler = httph.NewRouter(r.Service, log, opts)
+	return r
+}
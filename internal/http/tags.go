@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type addTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// AddTags serves POST /v1/customers/{id}/tags, adding the tags in the body
+// to the customer and returning it.
+func (h *Handler) AddTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	idStr := chi.URLParam(r, "id")
+	h.logger.Info(ctx, "http add tags received", logger.String("customer_id", idStr))
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Warn(ctx, "http add tags invalid id", logger.Err(err), logger.String("customer_id", idStr))
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req addTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Warn(ctx, "http add tags decode failed", logger.Err(err))
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	tagged, err := h.svc.AddTags(ctx, id, req.Tags)
+	if err != nil {
+		h.tagsFailed(ctx, w, "add tags", err, logger.String("customer_id", idStr))
+		return
+	}
+	h.logger.Info(ctx, "http add tags succeeded", logger.String("customer_id", idStr), logger.Int("tags", len(tagged.Tags)))
+	writeJSON(w, http.StatusOK, newCustomerV1(*tagged))
+}
+
+// RemoveTag serves DELETE /v1/customers/{id}/tags/{tag}, returning the
+// customer without the tag.
+func (h *Handler) RemoveTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	idStr, tag := chi.URLParam(r, "id"), chi.URLParam(r, "tag")
+	h.logger.Info(ctx, "http remove tag received", logger.String("customer_id", idStr), logger.String("tag", tag))
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Warn(ctx, "http remove tag invalid id", logger.Err(err), logger.String("customer_id", idStr))
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	untagged, err := h.svc.RemoveTag(ctx, id, tag)
+	if err != nil {
+		h.tagsFailed(ctx, w, "remove tag", err, logger.String("customer_id", idStr), logger.String("tag", tag))
+		return
+	}
+	h.logger.Info(ctx, "http remove tag succeeded", logger.String("customer_id", idStr), logger.String("tag", tag))
+	writeJSON(w, http.StatusOK, newCustomerV1(*untagged))
+}
+
+// tagsFailed writes the response for a failed tag operation.
+func (h *Handler) tagsFailed(ctx context.Context, w http.ResponseWriter, op string, err error, fields ...logger.Field) {
+	fields = append(fields, logger.Err(err))
+	switch {
+	case errors.Is(err, customer.ErrInvalidTag):
+		h.logger.Warn(ctx, "http "+op+" validation failed", fields...)
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, customer.ErrNotFound):
+		h.logger.Warn(ctx, "http "+op+" not found", fields...)
+		writeError(w, http.StatusNotFound, "not found")
+	case errors.Is(err, customer.ErrTooManyTags):
+		h.logger.Warn(ctx, "http "+op+" conflict", fields...)
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		h.logger.Error(ctx, "http "+op+" internal failure", fields...)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Archiit19/customer-service-go/internal/audit"
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/customer/customertest"
+	"github.com/Archiit19/customer-service-go/internal/jobs"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// call serves one request with the API key and an optional JSON body.
+func call(t *testing.T, h http.Handler, method, path, key, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(apiKeyHeader, key)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestOtherTenantsRecordsAreNotFound needs TEST_DATABASE_URL: tenant scoping
+// is enforced by the SQL, so only PostgreSQL can show it holds.
+func TestOtherTenantsRecordsAreNotFound(t *testing.T) {
+	pool := customertest.Postgres(t)
+	authn, err := auth.ParseAPIKeys("alice@tenant-a:key-a:pan:reveal,bob@tenant-b:key-b:pan:reveal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logger.NewNop()
+	svc := customer.NewService(customer.NewPGRepository(pool, log, nil, false), log, audit.Nop{}, nil, nil, nil, customer.ContactVerification{})
+	store := jobs.NewPGStore(pool, log, nil)
+	h := NewRouter(svc, log, Options{Authn: authn, AuthRequired: true, Jobs: store, MaxUploadBytes: 1 << 20})
+
+	a := auth.WithTenant(context.Background(), "tenant-a")
+	asha, err := svc.Create(a, &customer.Customer{Name: "Asha", Email: "asha@example.com", Phone: "+919876543210"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	addr, err := svc.CreateAddress(a, asha.ID, &customer.Address{Type: customer.AddressResidential, Line1: "12 MG Road", City: "Pune", State: "Maharashtra", PINCode: "411001"})
+	if err != nil {
+		t.Fatalf("create address: %v", err)
+	}
+	if _, err := svc.CreateVerification(a, asha.ID.String(), "ABCDE1234F"); err != nil {
+		t.Fatalf("create verification: %v", err)
+	}
+	job, err := store.Create(a, &jobs.Job{Kind: jobs.KindCustomerImport, Format: "csv", TotalRows: 1}, []byte("name\n"))
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	bina, err := svc.Create(auth.WithTenant(context.Background(), "tenant-b"), &customer.Customer{Name: "Bina", Email: "bina@example.com", Phone: "+919812345678"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	customerPath := "/v1/customers/" + asha.ID.String()
+	addressPath := customerPath + "/addresses/" + addr.ID.String()
+	for _, tc := range []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, customerPath, ""},
+		{http.MethodPatch, customerPath, `{"name":"Mallory"}`},
+		{http.MethodDelete, customerPath, ""},
+		{http.MethodGet, customerPath + "/status", ""},
+		{http.MethodGet, customerPath + "/verification/pan", ""},
+		{http.MethodPost, customerPath + "/tags", `{"tags":["vip"]}`},
+		{http.MethodPost, customerPath + "/merge", `{"duplicate_id":"` + bina.ID.String() + `"}`},
+		{http.MethodPost, "/v1/customers/" + bina.ID.String() + "/merge", `{"duplicate_id":"` + asha.ID.String() + `"}`},
+		{http.MethodPost, customerPath + "/contact-verification", `{"channel":"email"}`},
+		{http.MethodPost, customerPath + "/contact-verification/confirm", `{"channel":"email","code":"123456"}`},
+		{http.MethodGet, customerPath + "/addresses", ""},
+		{http.MethodPost, customerPath + "/addresses", `{"type":"permanent","line1":"1 Ring Road","city":"Delhi","state":"Delhi","pin_code":"110001"}`},
+		{http.MethodGet, addressPath, ""},
+		{http.MethodPatch, addressPath, `{"city":"Delhi"}`},
+		{http.MethodDelete, addressPath, ""},
+		{http.MethodGet, "/v1/jobs/" + job.ID.String(), ""},
+		{http.MethodGet, "/v1/jobs/" + job.ID.String() + "/errors", ""},
+	} {
+		if rec := call(t, h, tc.method, tc.path, "key-b", tc.body); rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s as tenant-b: got %d, want 404: %s", tc.method, tc.path, rec.Code, rec.Body)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, customerPath, nil)
+	req.Header.Set(apiKeyHeader, "key-b")
+	req.Header.Set(tenantHeader, "tenant-a")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("tenant-b key naming tenant-a: got %d, want 403", rec.Code)
+	}
+
+	for _, path := range []string{customerPath, addressPath, customerPath + "/verification/pan", "/v1/jobs/" + job.ID.String()} {
+		if rec := call(t, h, http.MethodGet, path, "key-a", ""); rec.Code != http.StatusOK {
+			t.Errorf("GET %s as tenant-a after tenant-b calls: got %d: %s", path, rec.Code, rec.Body)
+		}
+	}
+	if rec := call(t, h, http.MethodGet, customerPath, "key-a", ""); strings.Contains(rec.Body.String(), "Mallory") {
+		t.Errorf("tenant-b renamed tenant-a's customer: %s", rec.Body)
+	}
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Archiit19/customer-service-go/internal/tracing"
+	"github.com/go-chi/chi/v5"
+)
+
+// Tracing starts a server span for each request, continuing the caller's
+// trace when a W3C traceparent header is present. The span is named after the
+// chi route pattern once routing has finished.
+func Tracing(tracer *tracing.Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if sc, ok := tracing.Extract(r.Header); ok {
+				ctx = tracing.ContextWithRemote(ctx, sc)
+			}
+			ctx, span := tracer.Start(ctx, r.Method, tracing.KindServer,
+				tracing.String("http.request.method", r.Method),
+				tracing.String("url.path", r.URL.Path),
+			)
+			defer span.End()
+
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+				span.SetName(r.Method + " " + rctx.RoutePattern())
+				span.SetAttributes(tracing.String("http.route", rctx.RoutePattern()))
+			}
+			span.SetAttributes(tracing.Int("http.response.status_code", rw.status))
+			if rw.status >= http.StatusInternalServerError {
+				span.RecordError(fmt.Errorf("http status %d", rw.status))
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+// Package importfile reads customer rows from the file formats accepted for
+// bulk creation: a JSON array, NDJSON and CSV with a header row.
+package importfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+	"strings"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+)
+
+// Format is a supported file format.
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+	FormatCSV    Format = "csv"
+)
+
+var ErrUnsupportedFormat = errors.New("Content-Type must be application/json, application/x-ndjson or text/csv")
+
+// maxLine bounds one NDJSON line.
+const maxLine = 1 << 20
+
+// FormatFor returns the format named by a Content-Type header; JSON is assumed
+// when it is empty.
+func FormatFor(contentType string) (Format, error) {
+	if contentType == "" {
+		return FormatJSON, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", ErrUnsupportedFormat
+	}
+	switch mediaType {
+	case "application/json":
+		return FormatJSON, nil
+	case "application/x-ndjson", "application/ndjson":
+		return FormatNDJSON, nil
+	case "text/csv":
+		return FormatCSV, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// Row is one customer read from a file, with the fields of a single create
+// request. Err is set, and the fields are empty, when the row itself is
+// malformed but the rest of the file can still be read.
+type Row struct {
+	Name              string         `json:"name"`
+	Email             string         `json:"email"`
+	Phone             string         `json:"phone"`
+	DateOfBirth       *string        `json:"date_of_birth"`
+	Gender            *string        `json:"gender"`
+	Nationality       *string        `json:"nationality"`
+	Occupation        *string        `json:"occupation"`
+	PreferredLanguage *string        `json:"preferred_language"`
+	Metadata          map[string]any `json:"metadata"`
+	Tags              []string       `json:"tags"`
+	Err               error          `json:"-"`
+}
+
+// Customer returns the customer to create for the row. A malformed row, or
+// one whose date of birth does not parse, gets Err set and an empty customer,
+// which fails validation like any other invalid row.
+func (r *Row) Customer() *customer.Customer {
+	if r.Err != nil {
+		return &customer.Customer{}
+	}
+	c := &customer.Customer{
+		Name:  r.Name,
+		Email: r.Email,
+		Phone: r.Phone,
+		Profile: customer.Profile{
+			Gender:            (*customer.Gender)(r.Gender),
+			Nationality:       r.Nationality,
+			Occupation:        r.Occupation,
+			PreferredLanguage: r.PreferredLanguage,
+		},
+		Metadata: r.Metadata,
+		Tags:     r.Tags,
+	}
+	if r.DateOfBirth != nil {
+		dob, err := customer.ParseDateOfBirth(*r.DateOfBirth)
+		if err != nil {
+			r.Err = err
+			return &customer.Customer{}
+		}
+		c.DateOfBirth = &dob
+	}
+	return c
+}
+
+// Reader reads rows one at a time, so files need not fit in memory as rows.
+type Reader struct {
+	next func() (Row, error)
+}
+
+// NewReader starts reading r in format f, consuming the opening bracket of a
+// JSON array or the header of a CSV file. The CSV header names the name,
+// email and phone columns, and optionally the columns in csvOptional, in any
+// order and case.
+func NewReader(f Format, r io.Reader) (*Reader, error) {
+	switch f {
+	case FormatJSON:
+		return newJSONReader(r)
+	case FormatNDJSON:
+		return newNDJSONReader(r), nil
+	case FormatCSV:
+		return newCSVReader(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// Next returns the next row, or io.EOF after the last one. Any other error
+// means the rest of the file cannot be read.
+func (r *Reader) Next() (Row, error) {
+	return r.next()
+}
+
+func newJSONReader(r io.Reader) (*Reader, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber() // keeps metadata numbers exact
+	errNotArray := errors.New("body must be a JSON array of customers")
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, jsonError(err, errNotArray)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errNotArray
+	}
+	done := false
+	return &Reader{next: func() (Row, error) {
+		if done {
+			return Row{}, io.EOF
+		}
+		if !dec.More() {
+			if _, err := dec.Token(); err != nil {
+				return Row{}, jsonError(err, errNotArray)
+			}
+			done = true
+			return Row{}, io.EOF
+		}
+		var row Row
+		err := dec.Decode(&row)
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr):
+			// The value was consumed, so the following rows can still be read.
+			return Row{Err: fmt.Errorf("invalid %s: expected a %s", typeErr.Field, typeErr.Type)}, nil
+		case err != nil:
+			return Row{}, jsonError(err, errNotArray)
+		}
+		return row, nil
+	}}, nil
+}
+
+// jsonError describes a JSON syntax error as generic, keeping read errors such
+// as an exceeded body limit recognisable.
+func jsonError(err, generic error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return generic
+	}
+	return err
+}
+
+func newNDJSONReader(r io.Reader) *Reader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLine)
+	return &Reader{next: func() (Row, error) {
+		for sc.Scan() {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var row Row
+			dec := json.NewDecoder(bytes.NewReader(line))
+			dec.UseNumber()
+			if err := dec.Decode(&row); err != nil {
+				return Row{Err: errors.New("invalid JSON")}, nil
+			}
+			return row, nil
+		}
+		if err := sc.Err(); err != nil {
+			if errors.Is(err, bufio.ErrTooLong) {
+				return Row{}, fmt.Errorf("NDJSON line longer than %d bytes", maxLine)
+			}
+			return Row{}, err
+		}
+		return Row{}, io.EOF
+	}}
+}
+
+func newCSVReader(r io.Reader) (*Reader, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return &Reader{next: func() (Row, error) { return Row{}, io.EOF }}, nil
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "name", "email", "phone":
+		default:
+			if !slices.Contains(csvOptional, name) {
+				return nil, fmt.Errorf("unknown CSV column %q; expected name, email, phone and optionally %s", name, strings.Join(csvOptional, ", "))
+			}
+		}
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("duplicate CSV column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"name", "email", "phone"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header lacks the %s column", name)
+		}
+	}
+	return &Reader{next: func() (Row, error) {
+		record, err := cr.Read()
+		switch {
+		case errors.Is(err, io.EOF):
+			return Row{}, io.EOF
+		case errors.Is(err, csv.ErrFieldCount):
+			return Row{Err: fmt.Errorf("expected %d fields, got %d", len(header), len(record))}, nil
+		case err != nil:
+			return Row{}, csvError(err)
+		}
+		row := Row{
+			Name:  record[columns["name"]],
+			Email: record[columns["email"]],
+			Phone: record[columns["phone"]],
+		}
+		// Empty optional cells are left unset.
+		cell := func(name string) *string {
+			if i, ok := columns[name]; ok && strings.TrimSpace(record[i]) != "" {
+				return &record[i]
+			}
+			return nil
+		}
+		row.DateOfBirth = cell("date_of_birth")
+		row.Gender = cell("gender")
+		row.Nationality = cell("nationality")
+		row.Occupation = cell("occupation")
+		row.PreferredLanguage = cell("preferred_language")
+		if tags := cell("tags"); tags != nil {
+			row.Tags = strings.Split(*tags, "|")
+		}
+		if metadata := cell("metadata"); metadata != nil {
+			dec := json.NewDecoder(strings.NewReader(*metadata))
+			dec.UseNumber()
+			if err := dec.Decode(&row.Metadata); err != nil {
+				return Row{Err: errors.New("invalid metadata: expected a JSON object")}, nil
+			}
+		}
+		return row, nil
+	}}, nil
+}
+
+// csvOptional are the CSV columns besides name, email and phone. tags are
+// separated by "|" and metadata is a JSON object.
+var csvOptional = []string{"date_of_birth", "gender", "nationality", "occupation", "preferred_language", "tags", "metadata"}
+
+// csvError describes CSV syntax errors, keeping read errors recognisable.
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("invalid CSV: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package importfile
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+)
+
+func readAll(t *testing.T, f Format, body string) []Row {
+	t.Helper()
+	rd, err := NewReader(f, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	var rows []Row
+	for {
+		row, err := rd.Next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestReadProfile(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		body   string
+	}{
+		{"json", FormatJSON, `[{"name":"Asha","email":"a@example.com","phone":"+919876543210","date_of_birth":"1990-04-01","gender":"female","nationality":"in","occupation":"Engineer","preferred_language":"hi-IN"}]`},
+		{"ndjson", FormatNDJSON, `{"name":"Asha","email":"a@example.com","phone":"+919876543210","date_of_birth":"1990-04-01","gender":"female","nationality":"in","occupation":"Engineer","preferred_language":"hi-IN"}` + "\n"},
+		{"csv", FormatCSV, "Phone,name,email,date_of_birth,gender,nationality,occupation,preferred_language\n+919876543210,Asha,a@example.com,1990-04-01,female,in,Engineer,hi-IN\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := readAll(t, tt.format, tt.body)
+			if len(rows) != 1 {
+				t.Fatalf("got %d rows, want 1", len(rows))
+			}
+			c := rows[0].Customer()
+			if rows[0].Err != nil {
+				t.Fatalf("row error: %v", rows[0].Err)
+			}
+			if c.Name != "Asha" || c.Email != "a@example.com" || c.Phone != "+919876543210" {
+				t.Fatalf("contact fields: %+v", c)
+			}
+			if c.DateOfBirth == nil || customer.FormatDate(*c.DateOfBirth) != "1990-04-01" {
+				t.Fatalf("date_of_birth = %v", c.DateOfBirth)
+			}
+			if c.Gender == nil || *c.Gender != "female" || c.Nationality == nil || *c.Nationality != "in" ||
+				c.Occupation == nil || *c.Occupation != "Engineer" || c.PreferredLanguage == nil || *c.PreferredLanguage != "hi-IN" {
+				t.Fatalf("profile: %+v", c.Profile)
+			}
+		})
+	}
+}
+
+func TestReadCSVEmptyOptionalCells(t *testing.T) {
+	rows := readAll(t, FormatCSV, "name,email,phone,gender,date_of_birth\nAsha,a@example.com,+919876543210,,\n")
+	c := rows[0].Customer()
+	if c.Gender != nil || c.DateOfBirth != nil {
+		t.Fatalf("empty cells set the profile: %+v", c.Profile)
+	}
+}
+
+func TestReadCSVUnknownColumn(t *testing.T) {
+	if _, err := NewReader(FormatCSV, strings.NewReader("name,email,phone,shoe_size\n")); err == nil {
+		t.Fatal("unknown column accepted")
+	}
+}
+
+func TestRowCustomerInvalidDateOfBirth(t *testing.T) {
+	rows := readAll(t, FormatCSV, "name,email,phone,date_of_birth\nAsha,a@example.com,+919876543210,01/04/1990\n")
+	c := rows[0].Customer()
+	if !errors.Is(rows[0].Err, customer.ErrInvalidDateOfBirth) {
+		t.Fatalf("Err = %v, want ErrInvalidDateOfBirth", rows[0].Err)
+	}
+	if c.ValidateForCreate() == nil {
+		t.Fatal("customer of an invalid row validates")
+	}
+}
+
+func TestReadMetadataAndTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		body   string
+	}{
+		{"json", FormatJSON, `[{"name":"Asha","email":"a@example.com","phone":"+919876543210","metadata":{"crm_id":12345678901234567890},"tags":["vip","kyc:done"]}]`},
+		{"ndjson", FormatNDJSON, `{"name":"Asha","email":"a@example.com","phone":"+919876543210","metadata":{"crm_id":12345678901234567890},"tags":["vip","kyc:done"]}` + "\n"},
+		{"csv", FormatCSV, "name,email,phone,tags,metadata\nAsha,a@example.com,+919876543210,vip|kyc:done,\"{\"\"crm_id\"\":12345678901234567890}\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := readAll(t, tt.format, tt.body)[0].Customer()
+			if n, ok := c.Metadata["crm_id"].(json.Number); !ok || n.String() != "12345678901234567890" {
+				t.Fatalf("metadata = %#v, want the exact number", c.Metadata)
+			}
+			if err := customer.ValidateMetadata(c.Metadata); err != nil {
+				t.Fatalf("ValidateMetadata: %v", err)
+			}
+			if !slices.Equal(c.Tags, []string{"vip", "kyc:done"}) {
+				t.Fatalf("tags = %q", c.Tags)
+			}
+		})
+	}
+}
+
+func TestReadCSVInvalidMetadata(t *testing.T) {
+	rows := readAll(t, FormatCSV, "name,email,phone,metadata\nAsha,a@example.com,+919876543210,[1]\n")
+	if rows[0].Err == nil {
+		t.Fatal("metadata that is not an object accepted")
+	}
+}
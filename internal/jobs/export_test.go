@@ -0,0 +1,12 @@
+package jobs
+
+import "context"
+
+// Hooks for the tests in package jobs_test, which use jobstest and so cannot
+// be in package jobs.
+
+const RetryBackoff = retryBackoff
+
+func (r *Runner) RunOnce(ctx context.Context) (bool, error) { return r.runOnce(ctx) }
+
+func (r *Runner) Settle(ctx context.Context, j *Job, err error) { r.settle(ctx, j, err) }
@@ -0,0 +1,96 @@
+// Package jobs runs long operations, such as customer imports, in the
+// background. Jobs are stored in PostgreSQL so any replica can pick them up
+// and an interrupted job resumes from its last checkpoint.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/importfile"
+	"github.com/google/uuid"
+)
+
+// KindCustomerImport creates customers from an uploaded file.
+const KindCustomerImport = "customer_import"
+
+// Job statuses.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Row error statuses, matching the batch create results.
+const (
+	RowInvalid  = "invalid"
+	RowConflict = "conflict"
+)
+
+var ErrNotFound = errors.New("job not found")
+
+// Job is a unit of background work and its progress. ProcessedRows counts
+// the rows up to the last checkpoint, which is where a resumed job starts.
+type Job struct {
+	ID            uuid.UUID
+	Tenant        string
+	Kind          string
+	Status        string
+	Format        importfile.Format
+	TotalRows     int
+	ProcessedRows int
+	CreatedRows   int
+	FailedRows    int
+	// Error explains why a job failed, or why its last attempt did not
+	// finish.
+	Error    string
+	Attempts int
+	// CreatedBy, RequestID and ClientIP identify the submitting request; the
+	// job acts, and is audited, as that principal.
+	CreatedBy  string
+	RequestID  string
+	ClientIP   string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// Finished reports whether the job has stopped for good.
+func (j *Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// RowError records why one input row was not processed.
+type RowError struct {
+	// Index is the row's zero-based position in the input, not counting a
+	// CSV header.
+	Index  int
+	Status string
+	Error  string
+}
+
+// Store persists jobs. Create, Get and Errors are scoped to the tenant in
+// their context; the worker methods act across tenants.
+type Store interface {
+	// Create queues j with its input.
+	Create(ctx context.Context, j *Job, input []byte) (*Job, error)
+	Get(ctx context.Context, id uuid.UUID) (*Job, error)
+	// Errors calls fn for each row error of a job, in input order.
+	Errors(ctx context.Context, id uuid.UUID, fn func(RowError) error) error
+
+	// Claim marks the oldest queued job that is due, or a running job whose
+	// heartbeat is older than staleAfter, as running and returns it with its
+	// input. It returns nil when there is nothing to do.
+	Claim(ctx context.Context, staleAfter time.Duration) (*Job, []byte, error)
+	// Checkpoint saves j's progress and the row errors found since the last
+	// checkpoint, and renews its heartbeat.
+	Checkpoint(ctx context.Context, j *Job, errs []RowError) error
+	// Finish records j's final status and discards its input.
+	Finish(ctx context.Context, j *Job) error
+	// Release returns a running job to the queue, e.g. on shutdown or after
+	// a failed attempt. It is not claimed again until delay has passed.
+	Release(ctx context.Context, j *Job, delay time.Duration) error
+}
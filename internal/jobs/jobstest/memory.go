@@ -0,0 +1,162 @@
+// Package jobstest provides an in-memory jobs.Store for tests. Nothing in it
+// is meant for production use.
+package jobstest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/jobs"
+	"github.com/google/uuid"
+)
+
+// MemoryStore keeps jobs in process memory. Like jobs.PGStore it scopes
+// Create, Get and Errors to the tenant in their context.
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*memoryJob
+	now  func() time.Time
+}
+
+type memoryJob struct {
+	job       jobs.Job
+	input     []byte
+	errors    map[int]jobs.RowError
+	heartbeat time.Time
+	runAfter  time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[uuid.UUID]*memoryJob), now: time.Now}
+}
+
+// SetClock makes s read the time from now, so tests can step past the delay
+// a job is released with.
+func (s *MemoryStore) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+func (s *MemoryStore) Create(ctx context.Context, j *jobs.Job, input []byte) (*jobs.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	created := jobs.Job{
+		ID: uuid.New(), Tenant: auth.TenantFromContext(ctx), Kind: j.Kind, Status: jobs.StatusQueued, Format: j.Format,
+		TotalRows: j.TotalRows, CreatedBy: j.CreatedBy, RequestID: j.RequestID, ClientIP: j.ClientIP,
+		CreatedAt: now, UpdatedAt: now,
+	}
+	s.jobs[created.ID] = &memoryJob{job: created, input: append([]byte(nil), input...), errors: map[int]jobs.RowError{}}
+	return &created, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id uuid.UUID) (*jobs.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.jobs[id]
+	if m == nil || m.job.Tenant != auth.TenantFromContext(ctx) {
+		return nil, jobs.ErrNotFound
+	}
+	j := m.job
+	return &j, nil
+}
+
+func (s *MemoryStore) Errors(ctx context.Context, id uuid.UUID, fn func(jobs.RowError) error) error {
+	s.mu.Lock()
+	m := s.jobs[id]
+	var errs []jobs.RowError
+	if m != nil && m.job.Tenant == auth.TenantFromContext(ctx) {
+		for _, e := range m.errors {
+			errs = append(errs, e)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Index < errs[j].Index })
+	for _, e := range errs {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Claim(_ context.Context, staleAfter time.Duration) (*jobs.Job, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	var next *memoryJob
+	for _, m := range s.jobs {
+		due := (m.job.Status == jobs.StatusQueued && !now.Before(m.runAfter)) ||
+			(m.job.Status == jobs.StatusRunning && m.heartbeat.Before(now.Add(-staleAfter)))
+		if due && (next == nil || m.job.CreatedAt.Before(next.job.CreatedAt)) {
+			next = m
+		}
+	}
+	if next == nil {
+		return nil, nil, nil
+	}
+	next.job.Status = jobs.StatusRunning
+	next.job.Attempts++
+	if next.job.StartedAt == nil {
+		next.job.StartedAt = &now
+	}
+	next.heartbeat, next.job.UpdatedAt = now, now
+	j := next.job
+	return &j, append([]byte(nil), next.input...), nil
+}
+
+// running returns the job j is an attempt of, if that attempt still holds it.
+func (s *MemoryStore) running(j *jobs.Job) (*memoryJob, error) {
+	m := s.jobs[j.ID]
+	if m == nil || m.job.Attempts != j.Attempts || m.job.Status != jobs.StatusRunning {
+		return nil, jobs.ErrLost
+	}
+	return m, nil
+}
+
+func (s *MemoryStore) Checkpoint(_ context.Context, j *jobs.Job, errs []jobs.RowError) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.running(j)
+	if err != nil {
+		return err
+	}
+	for _, e := range errs {
+		m.errors[e.Index] = e
+	}
+	m.job.ProcessedRows, m.job.CreatedRows, m.job.FailedRows = j.ProcessedRows, j.CreatedRows, j.FailedRows
+	m.heartbeat, m.job.UpdatedAt = s.now(), s.now()
+	return nil
+}
+
+func (s *MemoryStore) Finish(_ context.Context, j *jobs.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.running(j)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	m.job.Status, m.job.Error = j.Status, j.Error
+	m.job.ProcessedRows, m.job.CreatedRows, m.job.FailedRows = j.ProcessedRows, j.CreatedRows, j.FailedRows
+	m.job.FinishedAt, m.job.UpdatedAt = &now, now
+	m.input = nil
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, j *jobs.Job, delay time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.running(j)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	m.job.Status, m.job.Error, m.job.UpdatedAt = jobs.StatusQueued, j.Error, now
+	m.runAfter = now.Add(delay)
+	return nil
+}
@@ -0,0 +1,216 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/Archiit19/customer-service-go/internal/pii"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrLost means a job was claimed by another worker after its heartbeat went
+// stale, so this worker must stop processing it.
+var ErrLost = errors.New("job claimed by another worker")
+
+const jobColumns = `id, tenant_id, kind, status, format, total_rows, processed_rows, created_rows, failed_rows,
+error, attempts, created_by, request_id, client_ip, created_at, updated_at, started_at, finished_at`
+
+// PGStore stores jobs in the jobs and job_errors tables.
+type PGStore struct {
+	pool   *pgxpool.Pool
+	logger logger.Logger
+	keys   *pii.Keyring
+}
+
+// NewPGStore creates a store. With a keyring, job inputs are encrypted while
+// they are kept.
+func NewPGStore(pool *pgxpool.Pool, log logger.Logger, keys *pii.Keyring) *PGStore {
+	return &PGStore{pool: pool, logger: log, keys: keys}
+}
+
+func scanJob(row pgx.Row, extra ...any) (*Job, error) {
+	var j Job
+	targets := []any{&j.ID, &j.Tenant, &j.Kind, &j.Status, &j.Format, &j.TotalRows, &j.ProcessedRows, &j.CreatedRows, &j.FailedRows,
+		&j.Error, &j.Attempts, &j.CreatedBy, &j.RequestID, &j.ClientIP, &j.CreatedAt, &j.UpdatedAt, &j.StartedAt, &j.FinishedAt}
+	if err := row.Scan(append(targets, extra...)...); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (s *PGStore) Create(ctx context.Context, j *Job, input []byte) (*Job, error) {
+	data, encrypted := input, false
+	if s.keys != nil {
+		sealed, err := s.keys.Encrypt(pii.DomainJobInput, string(input))
+		if err != nil {
+			return nil, fmt.Errorf("encrypt job input: %w", err)
+		}
+		data, encrypted = []byte(sealed), true
+	}
+	q := `
+INSERT INTO jobs (tenant_id, kind, format, input, input_encrypted, total_rows, created_by, request_id, client_ip)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING ` + jobColumns + `;
+`
+	created, err := scanJob(s.pool.QueryRow(ctx, q, auth.TenantFromContext(ctx), j.Kind, j.Format, data, encrypted, j.TotalRows, j.CreatedBy, j.RequestID, j.ClientIP))
+	if err != nil {
+		s.logger.Error(ctx, "job insert failed", logger.Err(err))
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *PGStore) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	q := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1 AND tenant_id = $2;`
+	j, err := scanJob(s.pool.QueryRow(ctx, q, id, auth.TenantFromContext(ctx)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		s.logger.Error(ctx, "job query failed", logger.Err(err), logger.String("job_id", id.String()))
+		return nil, err
+	}
+	return j, nil
+}
+
+func (s *PGStore) Errors(ctx context.Context, id uuid.UUID, fn func(RowError) error) error {
+	q := `
+SELECT e.row_index, e.status, e.error
+FROM job_errors e
+JOIN jobs j ON j.id = e.job_id
+WHERE e.job_id = $1 AND j.tenant_id = $2
+ORDER BY e.row_index;
+`
+	rows, err := s.pool.Query(ctx, q, id, auth.TenantFromContext(ctx))
+	if err != nil {
+		s.logger.Error(ctx, "job errors query failed", logger.Err(err), logger.String("job_id", id.String()))
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e RowError
+		if err := rows.Scan(&e.Index, &e.Status, &e.Error); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *PGStore) Claim(ctx context.Context, staleAfter time.Duration) (*Job, []byte, error) {
+	q := `
+UPDATE jobs
+SET status = 'running', attempts = attempts + 1, started_at = COALESCE(started_at, now()), heartbeat_at = now(), updated_at = now()
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE (status = 'queued' AND (run_after IS NULL OR run_after <= now()))
+       OR (status = 'running' AND heartbeat_at < now() - make_interval(secs => $1))
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + jobColumns + `, input, input_encrypted;
+`
+	var (
+		data      []byte
+		encrypted bool
+	)
+	j, err := scanJob(s.pool.QueryRow(ctx, q, staleAfter.Seconds()), &data, &encrypted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if encrypted {
+		if s.keys == nil {
+			return j, nil, errors.New("job input is encrypted but no PII keys are configured")
+		}
+		plain, err := s.keys.Decrypt(pii.DomainJobInput, string(data))
+		if err != nil {
+			return j, nil, fmt.Errorf("decrypt job input: %w", err)
+		}
+		data = []byte(plain)
+	}
+	return j, data, nil
+}
+
+func (s *PGStore) Checkpoint(ctx context.Context, j *Job, errs []RowError) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+	if len(errs) > 0 {
+		indexes := make([]int32, len(errs))
+		statuses := make([]string, len(errs))
+		messages := make([]string, len(errs))
+		for i, e := range errs {
+			indexes[i], statuses[i], messages[i] = int32(e.Index), e.Status, e.Error
+		}
+		// A chunk interrupted before its checkpoint is processed again, so
+		// its errors may already be stored.
+		q := `
+INSERT INTO job_errors (job_id, row_index, status, error)
+SELECT $1::uuid, * FROM unnest($2::int[], $3::text[], $4::text[])
+ON CONFLICT (job_id, row_index) DO UPDATE SET status = EXCLUDED.status, error = EXCLUDED.error;
+`
+		if _, err := tx.Exec(ctx, q, j.ID, indexes, statuses, messages); err != nil {
+			return err
+		}
+	}
+	q := `
+UPDATE jobs
+SET processed_rows = $3, created_rows = $4, failed_rows = $5, heartbeat_at = now(), updated_at = now()
+WHERE id = $1 AND attempts = $2 AND status = 'running';
+`
+	ct, err := tx.Exec(ctx, q, j.ID, j.Attempts, j.ProcessedRows, j.CreatedRows, j.FailedRows)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrLost
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *PGStore) Finish(ctx context.Context, j *Job) error {
+	q := `
+UPDATE jobs
+SET status = $3, error = $4, processed_rows = $5, created_rows = $6, failed_rows = $7,
+    input = NULL, heartbeat_at = NULL, finished_at = now(), updated_at = now()
+WHERE id = $1 AND attempts = $2 AND status = 'running';
+`
+	ct, err := s.pool.Exec(ctx, q, j.ID, j.Attempts, j.Status, j.Error, j.ProcessedRows, j.CreatedRows, j.FailedRows)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrLost
+	}
+	return nil
+}
+
+func (s *PGStore) Release(ctx context.Context, j *Job, delay time.Duration) error {
+	q := `
+UPDATE jobs
+SET status = 'queued', error = $3, heartbeat_at = NULL, run_after = now() + make_interval(secs => $4), updated_at = now()
+WHERE id = $1 AND attempts = $2 AND status = 'running';
+`
+	ct, err := s.pool.Exec(ctx, q, j.ID, j.Attempts, j.Error, delay.Seconds())
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrLost
+	}
+	return nil
+}
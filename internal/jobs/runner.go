@@ -0,0 +1,224 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/importfile"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// maxAttempts bounds how often a job is started before it is failed, so a
+// job that keeps crashing its worker cannot block the queue.
+const maxAttempts = 3
+
+// retryBackoff is how long a job waits after its first failed attempt; the
+// wait doubles with every further attempt.
+const retryBackoff = 30 * time.Second
+
+// Importer creates customers; *customer.Service satisfies it.
+type Importer interface {
+	CreateBatch(ctx context.Context, rows []*customer.Customer, atomic bool) ([]customer.BatchResult, error)
+}
+
+// inputError means a job's input cannot be read any further, so retrying the
+// job cannot help.
+type inputError struct {
+	err error
+}
+
+func (e *inputError) Error() string { return e.err.Error() }
+func (e *inputError) Unwrap() error { return e.err }
+
+// Runner claims jobs from a Store and processes them one at a time.
+type Runner struct {
+	store      Store
+	importer   Importer
+	logger     logger.Logger
+	chunkSize  int
+	staleAfter time.Duration
+}
+
+// NewRunner creates a runner that imports chunkSize rows per transaction and
+// takes over running jobs whose heartbeat is older than staleAfter.
+func NewRunner(store Store, importer Importer, log logger.Logger, chunkSize int, staleAfter time.Duration) *Runner {
+	return &Runner{store: store, importer: importer, logger: log, chunkSize: chunkSize, staleAfter: staleAfter}
+}
+
+// Run processes jobs until ctx is cancelled, checking for new ones every
+// interval while the queue is empty. A job interrupted by cancellation is
+// returned to the queue.
+func (r *Runner) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		found, err := r.runOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Warn(ctx, "job claim failed", logger.Err(err))
+		}
+		if found && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// runOnce claims and processes one job, reporting whether there was one.
+func (r *Runner) runOnce(ctx context.Context) (bool, error) {
+	j, input, err := r.store.Claim(ctx, r.staleAfter)
+	if j == nil {
+		return false, err
+	}
+	ctx = jobContext(ctx, j)
+	r.logger.Info(ctx, "job started", logger.String("job_id", j.ID.String()), logger.String("kind", j.Kind), logger.Int("attempt", j.Attempts), logger.Int("processed_rows", j.ProcessedRows))
+	switch {
+	case err != nil:
+		err = &inputError{err}
+	case j.Attempts > maxAttempts:
+		err = &inputError{fmt.Errorf("gave up after %d attempts: %s", maxAttempts, j.Error)}
+	case j.Kind == KindCustomerImport:
+		err = r.importCustomers(ctx, j, input)
+	default:
+		err = &inputError{fmt.Errorf("unsupported job kind %q", j.Kind)}
+	}
+	r.settle(ctx, j, err)
+	return true, nil
+}
+
+// settle records the outcome of processing j.
+func (r *Runner) settle(ctx context.Context, j *Job, err error) {
+	fields := []logger.Field{logger.String("job_id", j.ID.String()), logger.Int("processed_rows", j.ProcessedRows), logger.Int("created_rows", j.CreatedRows), logger.Int("failed_rows", j.FailedRows)}
+	// The outcome is saved even when ctx was cancelled by shutdown.
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	var inputErr *inputError
+	var saveErr error
+	switch {
+	case errors.Is(err, ErrLost):
+		r.logger.Warn(ctx, "job taken over by another worker", fields...)
+		return
+	case err == nil:
+		j.Status, j.Error = StatusSucceeded, ""
+		saveErr = r.store.Finish(saveCtx, j)
+		r.logger.Info(ctx, "job succeeded", fields...)
+	case errors.As(err, &inputErr):
+		j.Status, j.Error = StatusFailed, err.Error()
+		saveErr = r.store.Finish(saveCtx, j)
+		r.logger.Warn(ctx, "job failed", append(fields, logger.Err(err))...)
+	case ctx.Err() != nil:
+		j.Error = "interrupted by shutdown"
+		saveErr = r.store.Release(saveCtx, j, 0)
+		r.logger.Info(ctx, "job interrupted; returned to the queue", fields...)
+	default:
+		j.Error = err.Error()
+		delay := retryBackoff << (max(j.Attempts, 1) - 1)
+		saveErr = r.store.Release(saveCtx, j, delay)
+		r.logger.Warn(ctx, "job attempt failed; returned to the queue", append(fields, logger.Err(err), logger.Duration("retry_after", delay))...)
+	}
+	if saveErr != nil {
+		// The heartbeat goes stale and the job is claimed again.
+		r.logger.Error(ctx, "job status update failed", append(fields, logger.Err(saveErr))...)
+	}
+}
+
+// importCustomers creates the customers in j's input, starting after the
+// rows processed before the last checkpoint.
+func (r *Runner) importCustomers(ctx context.Context, j *Job, input []byte) error {
+	rd, err := importfile.NewReader(j.Format, bytes.NewReader(input))
+	if err != nil {
+		return &inputError{err}
+	}
+	for i := 0; i < j.ProcessedRows; i++ {
+		if _, err := rd.Next(); err != nil {
+			return &inputError{fmt.Errorf("row %d: %w", i, err)}
+		}
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rows, readErr := readChunk(rd, r.chunkSize)
+		if len(rows) > 0 {
+			if err := r.importChunk(ctx, j, rows); err != nil {
+				return err
+			}
+		}
+		switch {
+		case errors.Is(readErr, io.EOF):
+			return nil
+		case readErr != nil:
+			return &inputError{fmt.Errorf("row %d: %w", j.ProcessedRows, readErr)}
+		}
+	}
+}
+
+// readChunk reads up to n rows. The error, io.EOF included, applies after
+// the rows returned.
+func readChunk(rd *importfile.Reader, n int) ([]importfile.Row, error) {
+	rows := make([]importfile.Row, 0, n)
+	for len(rows) < n {
+		row, err := rd.Next()
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// importChunk creates the customers in rows, which follow the rows already
+// processed, and checkpoints j.
+func (r *Runner) importChunk(ctx context.Context, j *Job, rows []importfile.Row) error {
+	// Rows that did not parse are passed on empty so they are counted as
+	// invalid.
+	input := make([]*customer.Customer, len(rows))
+	for i := range rows {
+		input[i] = rows[i].Customer()
+	}
+	results, err := r.importer.CreateBatch(ctx, input, false)
+	if err != nil {
+		return err
+	}
+	var errs []RowError
+	created := 0
+	for i, res := range results {
+		index := j.ProcessedRows + i
+		switch {
+		case res.Err == nil:
+			created++
+		case rows[i].Err != nil:
+			errs = append(errs, RowError{Index: index, Status: RowInvalid, Error: rows[i].Err.Error()})
+		case errors.Is(res.Err, customer.ErrConflict):
+			errs = append(errs, RowError{Index: index, Status: RowConflict, Error: res.Err.Error()})
+		default:
+			errs = append(errs, RowError{Index: index, Status: RowInvalid, Error: res.Err.Error()})
+		}
+	}
+	next := *j
+	next.ProcessedRows += len(rows)
+	next.CreatedRows += created
+	next.FailedRows += len(errs)
+	if err := r.store.Checkpoint(ctx, &next, errs); err != nil {
+		return err
+	}
+	*j = next
+	r.logger.Debug(ctx, "job checkpoint saved", logger.String("job_id", j.ID.String()), logger.Int("processed_rows", j.ProcessedRows), logger.Int("total_rows", j.TotalRows))
+	return nil
+}
+
+// jobContext makes work done for j act as the request that submitted it, so
+// tenancy applies and audit entries name the submitter.
+func jobContext(ctx context.Context, j *Job) context.Context {
+	ctx = auth.WithTenant(ctx, j.Tenant)
+	ctx = auth.WithPrincipal(ctx, auth.Principal{ID: j.CreatedBy, Tenant: j.Tenant})
+	ctx = auth.WithClientIP(ctx, j.ClientIP)
+	return logger.WithRequestID(ctx, j.RequestID)
+}
@@ -0,0 +1,75 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/auth"
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/importfile"
+	"github.com/Archiit19/customer-service-go/internal/jobs"
+	"github.com/Archiit19/customer-service-go/internal/jobs/jobstest"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// failingImporter fails every batch, as when the database is unavailable.
+type failingImporter struct{}
+
+func (failingImporter) CreateBatch(context.Context, []*customer.Customer, bool) ([]customer.BatchResult, error) {
+	return nil, errors.New("connection refused")
+}
+
+// queue returns a store holding one queued import, read against a clock the
+// test advances.
+func queue(t *testing.T) (*jobstest.MemoryStore, *time.Time) {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	store := jobstest.NewMemoryStore()
+	store.SetClock(func() time.Time { return now })
+	_, err := store.Create(auth.WithTenant(context.Background(), auth.DefaultTenant), &jobs.Job{Kind: jobs.KindCustomerImport, Format: importfile.FormatCSV, TotalRows: 1},
+		[]byte("name,email,phone\nAsha,a@example.com,+919876543210\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, &now
+}
+
+func TestRunnerBacksOffFailedAttempts(t *testing.T) {
+	store, now := queue(t)
+	r := jobs.NewRunner(store, failingImporter{}, logger.NewNop(), 10, time.Minute)
+	ctx := context.Background()
+
+	if found, err := r.RunOnce(ctx); !found || err != nil {
+		t.Fatalf("first attempt: found=%t err=%v", found, err)
+	}
+	for attempt, backoff := range []time.Duration{jobs.RetryBackoff, 2 * jobs.RetryBackoff} {
+		if found, _ := r.RunOnce(ctx); found {
+			t.Fatalf("job released after attempt %d claimed again at once", attempt+1)
+		}
+		*now = now.Add(backoff - time.Second)
+		if found, _ := r.RunOnce(ctx); found {
+			t.Fatalf("job released after attempt %d claimed before its %s backoff passed", attempt+1, backoff)
+		}
+		*now = now.Add(time.Second)
+		if found, _ := r.RunOnce(ctx); !found {
+			t.Fatalf("job released after attempt %d not claimed after its %s backoff", attempt+1, backoff)
+		}
+	}
+}
+
+func TestRunnerReleasesOnShutdownWithoutDelay(t *testing.T) {
+	store, _ := queue(t)
+	r := jobs.NewRunner(store, failingImporter{}, logger.NewNop(), 10, time.Minute)
+	j, _, err := store.Claim(context.Background(), time.Minute)
+	if err != nil || j == nil {
+		t.Fatalf("claim: %v, %v", j, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Settle(ctx, j, context.Canceled)
+	if again, _, err := store.Claim(context.Background(), time.Minute); err != nil || again == nil {
+		t.Fatalf("job interrupted by shutdown not claimable at once: %v, %v", again, err)
+	}
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels accepted by NewAtomicLevel and AtomicLevel.Set.
+var Levels = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// AtomicLevel is the minimum level of a logger. It is shared by the logger
+// and every logger derived from it, so changing it takes effect immediately
+// for the whole process.
+type AtomicLevel struct {
+	zl zap.AtomicLevel
+}
+
+// NewAtomicLevel returns a level set to level, or INFO if level is not one of
+// Levels.
+func NewAtomicLevel(level string) *AtomicLevel {
+	zl, err := parseLevel(level)
+	if err != nil {
+		zl = zapcore.InfoLevel
+	}
+	return &AtomicLevel{zl: zap.NewAtomicLevelAt(zl)}
+}
+
+// String returns the current level, e.g. "INFO".
+func (a *AtomicLevel) String() string {
+	return strings.ToUpper(a.zl.Level().String())
+}
+
+// Set changes the level for every logger sharing a.
+func (a *AtomicLevel) Set(level string) error {
+	zl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	a.zl.SetLevel(zl)
+	return nil
+}
+
+func parseLevel(level string) (zapcore.Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(level)) {
+	case "DEBUG":
+		return zapcore.DebugLevel, nil
+	case "INFO":
+		return zapcore.InfoLevel, nil
+	case "WARN":
+		return zapcore.WarnLevel, nil
+	case "ERROR":
+		return zapcore.ErrorLevel, nil
+	default:
+		return zapcore.InfoLevel, fmt.Errorf("invalid log level %q; expected one of %s", level, strings.Join(Levels, ", "))
+	}
+}
@@ -2,9 +2,9 @@ package logger
 
 import (
 	"context"
-	"strings"
 	"time"
 
+	"github.com/Archiit19/customer-service-go/internal/tracing"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -27,9 +27,14 @@ type zapLogger struct {
 	base *zap.Logger
 }
 
-func New(level string) (Logger, error) {
+// New builds a JSON logger whose minimum level is level; changing level later
+// affects this logger and everything derived from it. redaction selects the
+// RedactionPolicy applied to PII fields and to anything in messages or field
+// values that looks like an email, phone number or PAN. Redaction happens in
+// the logger's core, so no field reaches the output without it.
+func New(level *AtomicLevel, redaction string) (Logger, error) {
 	cfg := zap.Config{
-		Level:             zap.NewAtomicLevelAt(parseLevel(level)),
+		Level:             level.zl,
 		Development:       false,
 		Encoding:          "json",
 		OutputPaths:       []string{"stdout"},
@@ -38,13 +43,18 @@ func New(level string) (Logger, error) {
 		DisableCaller:     true,
 		DisableStacktrace: true,
 	}
-	lg, err := cfg.Build()
+	lg, err := cfg.Build(withRedaction(redaction))
 	if err != nil {
 		return nil, err
 	}
 	return &zapLogger{base: lg}, nil
 }
 
+// NewNop returns a logger that discards everything, for tests.
+func NewNop() Logger {
+	return &zapLogger{base: zap.NewNop()}
+}
+
 func (l *zapLogger) Debug(ctx context.Context, msg string, fields ...Field) {
 	l.withContext(ctx).Debug(msg, fields...)
 }
@@ -65,25 +75,18 @@ func (l *zapLogger) Sync() error {
 	return l.base.Sync()
 }
 
+// withContext adds the request ID and, when ctx carries a span, the trace and
+// span IDs so log lines can be joined with traces.
 func (l *zapLogger) withContext(ctx context.Context) *zap.Logger {
 	requestID := RequestIDFromContext(ctx)
 	if requestID == "" {
 		requestID = "system"
 	}
-	return l.base.With(zap.String(string(requestIDKey), requestID))
-}
-
-func parseLevel(level string) zapcore.Level {
-	switch strings.ToUpper(level) {
-	case "DEBUG":
-		return zapcore.DebugLevel
-	case "WARN":
-		return zapcore.WarnLevel
-	case "ERROR":
-		return zapcore.ErrorLevel
-	default:
-		return zapcore.InfoLevel
+	fields := []Field{zap.String(string(requestIDKey), requestID)}
+	if sc := tracing.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields, zap.String("trace_id", sc.TraceID.String()), zap.String("span_id", sc.SpanID.String()))
 	}
+	return l.base.With(fields...)
 }
 
 func encoderConfig() zapcore.EncoderConfig {
@@ -152,3 +155,18 @@ func Err(err error) Field {
 func Any(key string, value any) Field {
 	return zap.Any(key, value)
 }
+
+// Email logs an email address subject to the logger's redaction policy.
+func Email(key, value string) Field {
+	return zap.Any(key, piiValue{kind: piiEmail, value: value})
+}
+
+// Phone logs a phone number subject to the logger's redaction policy.
+func Phone(key, value string) Field {
+	return zap.Any(key, piiValue{kind: piiPhone, value: value})
+}
+
+// PAN logs a PAN subject to the logger's redaction policy.
+func PAN(key, value string) Field {
+	return zap.Any(key, piiValue{kind: piiPAN, value: value})
+}
@@ -0,0 +1,185 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactionPolicy controls how PII is rendered in log output.
+type RedactionPolicy string
+
+const (
+	// RedactPartial keeps enough of a value to tell records apart while
+	// debugging: the first character of an email's local part and the whole
+	// domain, the last four characters of phone numbers and PANs.
+	RedactPartial RedactionPolicy = "PARTIAL"
+	// RedactFull replaces values with a fixed placeholder.
+	RedactFull RedactionPolicy = "FULL"
+	// RedactHash replaces values with a short SHA-256 digest so the same
+	// value can be correlated across log lines without being revealed.
+	RedactHash RedactionPolicy = "HASH"
+	// RedactNone disables redaction. Only meant for local development.
+	RedactNone RedactionPolicy = "NONE"
+)
+
+const redactedPlaceholder = "[REDACTED]"
+
+type piiKind int
+
+const (
+	piiEmail piiKind = iota
+	piiPhone
+	piiPAN
+)
+
+// piiValue marks a field value as PII; the logger renders it according to its
+// redaction policy instead of logging it verbatim.
+type piiValue struct {
+	kind  piiKind
+	value string
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	panPattern   = regexp.MustCompile(`(?i)\b[a-z]{5}[0-9]{4}[a-z]\b`)
+	phonePattern = regexp.MustCompile(`\+91[\s-]?[6-9][0-9]{9}\b|\+[1-9][0-9]{9,14}\b|\b[6-9][0-9]{9}\b`)
+)
+
+func parseRedactionPolicy(policy string) RedactionPolicy {
+	switch p := RedactionPolicy(strings.ToUpper(policy)); p {
+	case RedactFull, RedactHash, RedactNone:
+		return p
+	default:
+		return RedactPartial
+	}
+}
+
+type redactor struct {
+	policy RedactionPolicy
+}
+
+// withRedaction wraps a logger's core so that everything it writes, including
+// fields added with With, is redacted under policy.
+func withRedaction(policy string) zap.Option {
+	r := redactor{policy: parseRedactionPolicy(policy)}
+	return zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return redactingCore{Core: c, redact: r}
+	})
+}
+
+// redactingCore scrubs messages and renders field values before the wrapped
+// core encodes them.
+type redactingCore struct {
+	zapcore.Core
+	redact redactor
+}
+
+func (c redactingCore) With(fields []Field) zapcore.Core {
+	return redactingCore{Core: c.Core.With(c.redact.fields(fields)), redact: c.redact}
+}
+
+func (c redactingCore) Write(ent zapcore.Entry, fields []Field) error {
+	ent.Message = c.redact.scrub(ent.Message)
+	return c.Core.Write(ent, c.redact.fields(fields))
+}
+
+func (r redactor) mask(kind piiKind, value string) string {
+	switch r.policy {
+	case RedactNone:
+		return value
+	case RedactFull:
+		return redactedPlaceholder
+	case RedactHash:
+		sum := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(sum[:6])
+	}
+	switch kind {
+	case piiEmail:
+		return maskEmail(value)
+	default:
+		return maskTail(value, 4)
+	}
+}
+
+// scrub masks anything that looks like an email, PAN or phone number in free
+// text such as messages and error strings.
+func (r redactor) scrub(s string) string {
+	if r.policy == RedactNone || s == "" {
+		return s
+	}
+	s = emailPattern.ReplaceAllStringFunc(s, func(m string) string { return r.mask(piiEmail, m) })
+	s = panPattern.ReplaceAllStringFunc(s, func(m string) string { return r.mask(piiPAN, m) })
+	s = phonePattern.ReplaceAllStringFunc(s, func(m string) string { return r.mask(piiPhone, m) })
+	return s
+}
+
+// fields renders PII fields and scrubs free-text values. Values of other
+// types (structs, maps, driver errors with details) are scrubbed through their
+// JSON form so nested PII is caught too.
+func (r redactor) fields(fields []Field) []Field {
+	out := make([]Field, len(fields))
+	for i, f := range fields {
+		out[i] = Field{Key: f.Key, Value: r.value(f.Value)}
+	}
+	return out
+}
+
+func (r redactor) value(v any) any {
+	if r.policy == RedactNone {
+		if val, ok := v.(piiValue); ok {
+			return val.value
+		}
+		return v
+	}
+	switch val := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return val
+	case piiValue:
+		return r.mask(val.kind, val.value)
+	case string:
+		return r.scrub(val)
+	case error:
+		return r.scrub(val.Error())
+	case fmt.Stringer:
+		return r.scrub(val.String())
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return r.scrub(fmt.Sprint(val))
+		}
+		return json.RawMessage(r.scrub(string(data)))
+	}
+}
+
+// maskEmail keeps the first character of the local part and the domain, e.g.
+// "jane@example.com" becomes "j***@example.com".
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return maskTail(email, 0)
+	}
+	_, size := utf8.DecodeRuneInString(local)
+	return local[:size] + "***@" + domain
+}
+
+// maskTail replaces every character except the last keep with '*'.
+func maskTail(s string, keep int) string {
+	n := utf8.RuneCountInString(s)
+	if n <= keep {
+		return strings.Repeat("*", n)
+	}
+	i := len(s)
+	for range keep {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return strings.Repeat("*", n-keep) + s[i:]
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// capture returns a logger writing through the redacting core into buf.
+func capture(t *testing.T, policy string) (Logger, *bytes.Buffer) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig()), buf, NewAtomicLevel("DEBUG").zl)
+	return &zapLogger{base: zap.New(core, withRedaction(policy))}, buf
+}
+
+func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	return line
+}
+
+func TestCoreRedactsMessagesAndFields(t *testing.T) {
+	log, buf := capture(t, "PARTIAL")
+	ctx := WithRequestID(context.Background(), "req-9876543210")
+	log.Info(ctx, "lookup for jane@example.com failed",
+		Email("email", "jane@example.com"),
+		Phone("phone", "+919876543210"),
+		PAN("pan", "ABCDE1234F"),
+		Err(errors.New("duplicate key: pan ABCDE1234F")),
+		Any("detail", map[string]string{"contact": "+919812345678"}),
+		Int("attempt", 2),
+	)
+	line := decodeLine(t, buf)
+	want := map[string]any{
+		"message":    "lookup for j***@example.com failed",
+		"email":      "j***@example.com",
+		"phone":      "*********3210",
+		"pan":        "******234F",
+		"error":      "duplicate key: pan ******234F",
+		"request_id": "req-******3210",
+		"attempt":    float64(2),
+	}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s = %v, want %v", k, line[k], v)
+		}
+	}
+	if detail, _ := json.Marshal(line["detail"]); strings.Contains(string(detail), "9812345678") {
+		t.Errorf("detail logged verbatim: %s", detail)
+	}
+}
+
+func TestCoreRedactsFieldsAddedWithWith(t *testing.T) {
+	log, buf := capture(t, "FULL")
+	zl := log.(*zapLogger)
+	zl.base = zl.base.With(Email("email", "jane@example.com"))
+	log.Warn(context.Background(), "called")
+	if got := decodeLine(t, buf)["email"]; got != redactedPlaceholder {
+		t.Fatalf("email = %v, want %s", got, redactedPlaceholder)
+	}
+}
+
+func TestRedactionPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy, want string
+	}{
+		{"PARTIAL", "j***@example.com"},
+		{"FULL", redactedPlaceholder},
+		{"NONE", "jane@example.com"},
+	} {
+		log, buf := capture(t, tc.policy)
+		log.Info(context.Background(), "created", Email("email", "jane@example.com"))
+		if got := decodeLine(t, buf)["email"]; got != tc.want {
+			t.Errorf("%s: email = %v, want %s", tc.policy, got, tc.want)
+		}
+	}
+	log, buf := capture(t, "HASH")
+	log.Info(context.Background(), "created", Email("email", "jane@example.com"))
+	if got, _ := decodeLine(t, buf)["email"].(string); !strings.HasPrefix(got, "sha256:") || strings.Contains(got, "jane") {
+		t.Errorf("HASH: email = %q", got)
+	}
+}
+
+func TestMaskMultibyte(t *testing.T) {
+	for _, tc := range []struct {
+		got, want string
+	}{
+		{maskEmail("élodie@example.fr"), "é***@example.fr"},
+		{maskEmail("张伟@example.cn"), "张***@example.cn"},
+		{maskTail("电话१२३४५६", 4), "****३४५६"},
+		{maskTail("ab", 4), "**"},
+	} {
+		if !utf8.ValidString(tc.got) || tc.got != tc.want {
+			t.Errorf("got %q, want %q", tc.got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net/http"
+)
+
+// DefaultBuckets suit request and query latencies measured in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Counter is a monotonically increasing value, optionally partitioned by
+// labels. Label values are passed in the order the label names were declared.
+type Counter interface {
+	Inc(labelValues ...string)
+	Add(v float64, labelValues ...string)
+}
+
+// Histogram samples observations into buckets.
+type Histogram interface {
+	Observe(v float64, labelValues ...string)
+}
+
+// Registry creates metrics and exposes them for scraping. Code that records
+// metrics depends only on this interface, so the exposition backend can be
+// swapped without touching call sites.
+type Registry interface {
+	Counter(name, help string, labelNames ...string) Counter
+	Histogram(name, help string, buckets []float64, labelNames ...string) Histogram
+	// GaugeFunc and CounterFunc report a value computed at scrape time, for
+	// state owned by another component such as a connection pool.
+	GaugeFunc(name, help string, fn func() float64)
+	CounterFunc(name, help string, fn func() float64)
+	// Handler serves the registry in the Prometheus text exposition format.
+	Handler() http.Handler
+}
+
+type nopRegistry struct{}
+
+type nopMetric struct{}
+
+func (nopMetric) Inc(...string)              {}
+func (nopMetric) Add(float64, ...string)     {}
+func (nopMetric) Observe(float64, ...string) {}
+
+// Nop returns a registry whose metrics record nothing.
+func Nop() Registry { return nopRegistry{} }
+
+func (nopRegistry) Counter(string, string, ...string) Counter { return nopMetric{} }
+func (nopRegistry) Histogram(string, string, []float64, ...string) Histogram {
+	return nopMetric{}
+}
+func (nopRegistry) GaugeFunc(string, string, func() float64)   {}
+func (nopRegistry) CounterFunc(string, string, func() float64) {}
+func (nopRegistry) Handler() http.Handler                      { return http.NotFoundHandler() }
@@ -0,0 +1,46 @@
+// Package notify delivers messages to customers, such as one-time codes
+// proving they own an email address or phone number.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Channel is how a message reaches its recipient.
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+)
+
+// Message is one message to a single recipient. Subject is ignored by SMS.
+type Message struct {
+	Channel Channel
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages. Send returns once the message has been handed
+// to the channel's provider; delivery to the recipient is not confirmed.
+type Notifier interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// ErrUnsupportedChannel is returned by notifiers asked to send a message on
+// a channel they do not serve.
+var ErrUnsupportedChannel = errors.New("notify: unsupported channel")
+
+// Channels routes each message to the notifier of its channel.
+type Channels map[Channel]Notifier
+
+func (c Channels) Send(ctx context.Context, m Message) error {
+	n, ok := c[m.Channel]
+	if !ok || n == nil {
+		return fmt.Errorf("%w %q", ErrUnsupportedChannel, m.Channel)
+	}
+	return n.Send(ctx, m)
+}
@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SMSProvider is an SMS gateway. Implementations for specific vendors only
+// need to send a text to one E.164 number.
+type SMSProvider interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+// SMS sends text messages through an SMSProvider.
+type SMS struct {
+	provider SMSProvider
+}
+
+// NewSMS returns a notifier sending SMS messages through provider.
+func NewSMS(provider SMSProvider) *SMS {
+	return &SMS{provider: provider}
+}
+
+func (n *SMS) Send(ctx context.Context, m Message) error {
+	if m.Channel != ChannelSMS {
+		return fmt.Errorf("%w %q", ErrUnsupportedChannel, m.Channel)
+	}
+	return n.provider.SendSMS(ctx, m.To, m.Body)
+}
+
+const webhookTimeout = 10 * time.Second
+
+// Webhook is an SMSProvider posting {"to": ..., "body": ...} as JSON to a
+// URL, for gateways or in-house relays that accept that shape. Any 2xx
+// response counts as accepted.
+type Webhook struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewWebhook returns a provider posting to url, with token, when set, sent
+// as a bearer token.
+func NewWebhook(url, token string) *Webhook {
+	return &Webhook{url: url, token: token, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Check opens a TCP connection to the webhook's host. Posting would send a
+// message, so reachability is all it can tell.
+func (p *Webhook) Check(ctx context.Context) error {
+	u, err := url.Parse(p.url)
+	if err != nil {
+		return fmt.Errorf("sms webhook: %w", err)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return fmt.Errorf("sms webhook: %w", err)
+	}
+	return conn.Close()
+}
+
+func (p *Webhook) SendSMS(ctx context.Context, to, body string) error {
+	payload, err := json.Marshal(map[string]string{"to": to, "body": body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sms webhook: unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const smtpTimeout = 15 * time.Second
+
+// SMTP sends email through a mail server, upgrading the connection with
+// STARTTLS whenever the server offers it. Servers expecting TLS from the
+// first byte, usually on port 465, are not supported.
+type SMTP struct {
+	addr string
+	host string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTP returns a notifier sending email as from through the server at
+// host:port. With an empty username no authentication is attempted;
+// otherwise PLAIN authentication is used, which net/smtp only allows over
+// TLS or to localhost.
+func NewSMTP(host string, port int, username, password, from string) *SMTP {
+	n := &SMTP{addr: net.JoinHostPort(host, strconv.Itoa(port)), host: host, from: from}
+	if username != "" {
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return n
+}
+
+func (n *SMTP) Send(ctx context.Context, m Message) error {
+	if m.Channel != ChannelEmail {
+		return fmt.Errorf("%w %q", ErrUnsupportedChannel, m.Channel)
+	}
+	if strings.ContainsAny(m.To+m.Subject, "\r\n") {
+		return errors.New("smtp: line break in recipient or subject")
+	}
+	c, err := n.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if n.auth != nil {
+		if err := c.Auth(n.auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := c.Mail(n.from); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	if err := c.Rcpt(m.To); err != nil {
+		return fmt.Errorf("smtp rcpt to: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(n.compose(m)); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	return c.Quit()
+}
+
+// Check connects to the server and waits for its greeting, without sending
+// or authenticating.
+func (n *SMTP) Check(ctx context.Context) error {
+	c, err := n.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Quit()
+}
+
+// dial connects to the server and reads its greeting, bounding the whole
+// session by smtpTimeout or ctx's deadline, whichever is sooner.
+func (n *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	d := net.Dialer{Timeout: smtpTimeout}
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp dial: %w", err)
+	}
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+	c, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("smtp greeting: %w", err)
+	}
+	return c, nil
+}
+
+// compose renders m as a plain-text RFC 5322 message.
+func (n *SMTP) compose(m Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
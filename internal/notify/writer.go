@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Writer writes every message as one line to w instead of delivering it.
+// It stands in for real providers in development, where codes are read off
+// the console or a file.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriter returns a notifier writing to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// NewConsole returns a notifier writing to standard error, away from the
+// JSON logs on standard output.
+func NewConsole() *Writer {
+	return NewWriter(os.Stderr)
+}
+
+func (n *Writer) Send(_ context.Context, m Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err := io.WriteString(n.w, formatLine(m))
+	return err
+}
+
+// File appends every message as one line to a file, which is opened for each
+// message so it can be rotated or removed while the service runs.
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFile returns a notifier appending to the file at path.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (n *File) Send(_ context.Context, m Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	f, err := os.OpenFile(n.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, formatLine(m)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func formatLine(m Message) string {
+	return fmt.Sprintf("%s channel=%s to=%q subject=%q body=%q\n", time.Now().UTC().Format(time.RFC3339), m.Channel, m.To, m.Subject, m.Body)
+}
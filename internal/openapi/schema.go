@@ -0,0 +1,360 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema is a compiled OpenAPI 3.0 schema object. The keywords the service's
+// API description uses are enforced; others, such as descriptions and
+// examples, are ignored.
+type Schema struct {
+	Type     string
+	Nullable bool
+	Enum     []any
+	Format   string
+	Pattern  *regexp.Regexp
+
+	MinLength, MaxLength *int
+	Minimum, Maximum     *float64
+	ExclusiveMin         bool
+	ExclusiveMax         bool
+	MinItems, MaxItems   *int
+
+	Required   []string
+	Properties map[string]*Schema
+	// Additional validates properties not listed in Properties; nil allows
+	// any. NoAdditional rejects them.
+	Additional   *Schema
+	NoAdditional bool
+	Items        *Schema
+
+	AllOf, AnyOf, OneOf []*Schema
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Validate checks v, a value decoded by encoding/json with UseNumber, and
+// returns one problem per violation, each prefixed by the location in v
+// starting at path.
+func (s *Schema) Validate(path string, v any) []string {
+	if s == nil {
+		return nil
+	}
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+	for _, sub := range s.AllOf {
+		problems = append(problems, sub.Validate(path, v)...)
+	}
+	if len(s.AnyOf) > 0 && !slices.ContainsFunc(s.AnyOf, func(sub *Schema) bool { return len(sub.Validate(path, v)) == 0 }) {
+		add("must match at least one of the allowed schemas")
+	}
+	if len(s.OneOf) > 0 {
+		n := 0
+		for _, sub := range s.OneOf {
+			if len(sub.Validate(path, v)) == 0 {
+				n++
+			}
+		}
+		if n != 1 {
+			add("must match exactly one of the allowed schemas")
+		}
+	}
+	if v == nil {
+		if s.Type != "" && !s.Nullable {
+			add("must not be null")
+		}
+		return problems
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return equalJSON(e, v) }) {
+		add("must be one of %s", formatEnum(s.Enum))
+	}
+
+	switch v := v.(type) {
+	case string:
+		if s.Type != "" && s.Type != "string" {
+			add("must be %s", article(s.Type))
+			break
+		}
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			add("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			add("must be at most %d characters", *s.MaxLength)
+		}
+		if s.Pattern != nil && !s.Pattern.MatchString(v) {
+			add("must match %s", s.Pattern)
+		}
+		if msg := checkFormat(s.Format, v); msg != "" {
+			add("%s", msg)
+		}
+	case json.Number:
+		f, err := v.Float64()
+		switch {
+		case s.Type == "integer":
+			if _, ierr := strconv.ParseInt(v.String(), 10, 64); ierr != nil && (err != nil || f != math.Trunc(f)) {
+				add("must be an integer")
+				return problems
+			}
+		case s.Type != "" && s.Type != "number":
+			add("must be %s", article(s.Type))
+			return problems
+		}
+		if s.Minimum != nil && (f < *s.Minimum || (s.ExclusiveMin && f == *s.Minimum)) {
+			add("must be at least %s", formatNumber(*s.Minimum, s.ExclusiveMin))
+		}
+		if s.Maximum != nil && (f > *s.Maximum || (s.ExclusiveMax && f == *s.Maximum)) {
+			add("must be at most %s", formatNumber(*s.Maximum, s.ExclusiveMax))
+		}
+	case bool:
+		if s.Type != "" && s.Type != "boolean" {
+			add("must be %s", article(s.Type))
+		}
+	case []any:
+		if s.Type != "" && s.Type != "array" {
+			add("must be %s", article(s.Type))
+			break
+		}
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			add("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			add("must have at most %d items", *s.MaxItems)
+		}
+		for i, item := range v {
+			problems = append(problems, s.Items.Validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+		}
+	case map[string]any:
+		if s.Type != "" && s.Type != "object" {
+			add("must be %s", article(s.Type))
+			break
+		}
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				add("missing required property %q", name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				problems = append(problems, prop.Validate(path+"."+k, v[k])...)
+				continue
+			}
+			switch {
+			case s.NoAdditional:
+				add("unknown property %q", k)
+			case s.Additional != nil:
+				problems = append(problems, s.Additional.Validate(path+"."+k, v[k])...)
+			}
+		}
+	default:
+		add("unsupported value %T", v)
+	}
+	return problems
+}
+
+func checkFormat(format, v string) string {
+	switch format {
+	case "uuid":
+		if !uuidPattern.MatchString(v) {
+			return "must be a UUID"
+		}
+	case "email":
+		if _, err := mail.ParseAddress(v); err != nil {
+			return "must be an email address"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, v); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	}
+	return ""
+}
+
+// equalJSON compares a value from the API description with a decoded JSON
+// value, treating numbers by value.
+func equalJSON(spec, v any) bool {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		if err != nil {
+			return false
+		}
+		switch s := spec.(type) {
+		case int64:
+			return float64(s) == f
+		case float64:
+			return s == f
+		}
+		return false
+	}
+	return spec == v
+}
+
+func formatEnum(values []any) string {
+	out := "["
+	for i, v := range values {
+		if i > 0 {
+			out += ", "
+		}
+		out += fmt.Sprint(v)
+	}
+	return out + "]"
+}
+
+func formatNumber(f float64, exclusive bool) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if exclusive {
+		return "more extreme than " + s
+	}
+	return s
+}
+
+func article(typ string) string {
+	switch typ {
+	case "integer", "array", "object":
+		return "an " + typ
+	default:
+		return "a " + typ
+	}
+}
+
+// compileSchema builds a Schema from its decoded description, following
+// $refs through the spec. Schemas reached through the same $ref are shared,
+// which also makes recursive schemas terminate.
+func (sp *Spec) compileSchema(node any) (*Schema, error) {
+	m, ok := node.(map[string]any)
+	if !ok {
+		if node == nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("schema must be an object, got %T", node)
+	}
+	if ref, ok := m["$ref"].(string); ok {
+		if s, ok := sp.schemas[ref]; ok {
+			return s, nil
+		}
+		target, err := sp.lookup(ref)
+		if err != nil {
+			return nil, err
+		}
+		s := &Schema{}
+		sp.schemas[ref] = s
+		compiled, err := sp.compileSchema(target)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ref, err)
+		}
+		if compiled != nil {
+			*s = *compiled
+		}
+		return s, nil
+	}
+
+	s := &Schema{}
+	s.Type, _ = m["type"].(string)
+	s.Nullable, _ = m["nullable"].(bool)
+	s.Format, _ = m["format"].(string)
+	if enum, ok := m["enum"].([]any); ok {
+		s.Enum = enum
+	}
+	if p, ok := m["pattern"].(string); ok {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", p, err)
+		}
+		s.Pattern = re
+	}
+	s.MinLength, s.MaxLength = intKeyword(m, "minLength"), intKeyword(m, "maxLength")
+	s.MinItems, s.MaxItems = intKeyword(m, "minItems"), intKeyword(m, "maxItems")
+	s.Minimum, s.Maximum = floatKeyword(m, "minimum"), floatKeyword(m, "maximum")
+	s.ExclusiveMin, _ = m["exclusiveMinimum"].(bool)
+	s.ExclusiveMax, _ = m["exclusiveMaximum"].(bool)
+	if req, ok := m["required"].([]any); ok {
+		for _, r := range req {
+			if name, ok := r.(string); ok {
+				s.Required = append(s.Required, name)
+			}
+		}
+	}
+	if props, ok := m["properties"].(map[string]any); ok {
+		s.Properties = make(map[string]*Schema, len(props))
+		for name, p := range props {
+			ps, err := sp.compileSchema(p)
+			if err != nil {
+				return nil, fmt.Errorf("property %s: %w", name, err)
+			}
+			if ps == nil {
+				ps = &Schema{}
+			}
+			s.Properties[name] = ps
+		}
+	}
+	switch ap := m["additionalProperties"].(type) {
+	case bool:
+		s.NoAdditional = !ap
+	case map[string]any:
+		as, err := sp.compileSchema(ap)
+		if err != nil {
+			return nil, fmt.Errorf("additionalProperties: %w", err)
+		}
+		s.Additional = as
+	}
+	if items, ok := m["items"]; ok {
+		is, err := sp.compileSchema(items)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		s.Items = is
+	}
+	for _, kw := range []struct {
+		name string
+		dst  *[]*Schema
+	}{{"allOf", &s.AllOf}, {"anyOf", &s.AnyOf}, {"oneOf", &s.OneOf}} {
+		list, _ := m[kw.name].([]any)
+		for i, item := range list {
+			is, err := sp.compileSchema(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", kw.name, i, err)
+			}
+			*kw.dst = append(*kw.dst, is)
+		}
+	}
+	return s, nil
+}
+
+func intKeyword(m map[string]any, key string) *int {
+	if n, ok := m[key].(int64); ok {
+		i := int(n)
+		return &i
+	}
+	return nil
+}
+
+func floatKeyword(m map[string]any, key string) *float64 {
+	switch n := m[key].(type) {
+	case int64:
+		f := float64(n)
+		return &f
+	case float64:
+		return &n
+	}
+	return nil
+}
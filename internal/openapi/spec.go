@@ -0,0 +1,499 @@
+// Package openapi loads the service's OpenAPI 3.0 description and checks
+// HTTP requests and responses against it.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var methods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+	http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace,
+}
+
+// Spec is a loaded API description.
+type Spec struct {
+	raw     []byte
+	doc     map[string]any
+	ops     []*Operation
+	schemas map[string]*Schema
+}
+
+// Operation is one method on one path of the API.
+type Operation struct {
+	Method string
+	// Path is the path template, e.g. "/v1/customers/{id}".
+	Path      string
+	segments  []string
+	params    []*parameter
+	body      *requestBody
+	responses map[string]*response
+}
+
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	schema   *Schema
+}
+
+type requestBody struct {
+	required bool
+	content  map[string]*Schema
+}
+
+type response struct {
+	content map[string]*Schema
+}
+
+// Load parses and compiles an API description written in YAML or JSON.
+func Load(data []byte) (*Spec, error) {
+	parsed, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	doc, ok := parsed.(map[string]any)
+	if !ok {
+		return nil, errors.New("openapi: document is not a mapping")
+	}
+	if v, _ := doc["openapi"].(string); !strings.HasPrefix(v, "3.") {
+		return nil, fmt.Errorf("openapi: unsupported version %q", v)
+	}
+	sp := &Spec{raw: data, doc: doc, schemas: map[string]*Schema{}}
+	paths, _ := doc["paths"].(map[string]any)
+	for path, item := range paths {
+		if err := sp.addPath(path, item); err != nil {
+			return nil, fmt.Errorf("openapi: %s: %w", path, err)
+		}
+	}
+	sort.Slice(sp.ops, func(i, j int) bool {
+		if sp.ops[i].Path != sp.ops[j].Path {
+			return sp.ops[i].Path < sp.ops[j].Path
+		}
+		return sp.ops[i].Method < sp.ops[j].Method
+	})
+	return sp, nil
+}
+
+// decode parses a YAML or JSON document into the values the compiler works
+// on: map[string]any, []any, string, bool, int64, float64 and nil.
+func decode(data []byte) (any, error) {
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return normalize(v), nil
+}
+
+// normalize widens the ints yaml.v3 decodes to int64.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = normalize(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = normalize(e)
+		}
+	case int:
+		return int64(v)
+	}
+	return v
+}
+
+// Raw returns the document Load was given.
+func (sp *Spec) Raw() []byte {
+	return sp.raw
+}
+
+// Operations lists every operation, ordered by path and method.
+func (sp *Spec) Operations() []*Operation {
+	return sp.ops
+}
+
+// lookup resolves a local reference such as "#/components/schemas/Customer".
+func (sp *Spec) lookup(ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are resolved", ref)
+	}
+	var node any = sp.doc
+	for _, tok := range strings.Split(ref[2:], "/") {
+		tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if node, ok = m[tok]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// deref follows $refs on node until it reaches a mapping.
+func (sp *Spec) deref(node any) (map[string]any, error) {
+	for range 32 {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an object, got %T", node)
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m, nil
+		}
+		var err error
+		if node, err = sp.lookup(ref); err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.New("$ref chain too long")
+}
+
+func (sp *Spec) addPath(path string, node any) error {
+	item, err := sp.deref(node)
+	if err != nil {
+		return err
+	}
+	shared, err := sp.parameters(item["parameters"])
+	if err != nil {
+		return err
+	}
+	for _, method := range methods {
+		opNode, ok := item[strings.ToLower(method)]
+		if !ok {
+			continue
+		}
+		op, err := sp.operation(method, path, opNode, shared)
+		if err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+		sp.ops = append(sp.ops, op)
+	}
+	return nil
+}
+
+func (sp *Spec) operation(method, path string, node any, shared []*parameter) (*Operation, error) {
+	m, err := sp.deref(node)
+	if err != nil {
+		return nil, err
+	}
+	op := &Operation{Method: method, Path: path, segments: strings.Split(path, "/"), responses: map[string]*response{}}
+	own, err := sp.parameters(m["parameters"])
+	if err != nil {
+		return nil, err
+	}
+	// Operation parameters override path-level ones with the same name and
+	// location.
+	for _, p := range shared {
+		if !containsParam(own, p) {
+			op.params = append(op.params, p)
+		}
+	}
+	op.params = append(op.params, own...)
+
+	if rb, ok := m["requestBody"]; ok {
+		rbm, err := sp.deref(rb)
+		if err != nil {
+			return nil, fmt.Errorf("requestBody: %w", err)
+		}
+		content, err := sp.content(rbm["content"])
+		if err != nil {
+			return nil, fmt.Errorf("requestBody: %w", err)
+		}
+		required, _ := rbm["required"].(bool)
+		op.body = &requestBody{required: required, content: content}
+	}
+	responses, _ := m["responses"].(map[string]any)
+	for code, r := range responses {
+		rm, err := sp.deref(r)
+		if err != nil {
+			return nil, fmt.Errorf("response %s: %w", code, err)
+		}
+		content, err := sp.content(rm["content"])
+		if err != nil {
+			return nil, fmt.Errorf("response %s: %w", code, err)
+		}
+		op.responses[strings.ToUpper(code)] = &response{content: content}
+	}
+	return op, nil
+}
+
+func containsParam(list []*parameter, p *parameter) bool {
+	for _, q := range list {
+		if q.in == p.in && strings.EqualFold(q.name, p.name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sp *Spec) parameters(node any) ([]*parameter, error) {
+	list, _ := node.([]any)
+	var out []*parameter
+	for i, n := range list {
+		m, err := sp.deref(n)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %d: %w", i, err)
+		}
+		p := &parameter{}
+		p.name, _ = m["name"].(string)
+		p.in, _ = m["in"].(string)
+		p.required, _ = m["required"].(bool)
+		if p.name == "" || p.in == "" {
+			return nil, fmt.Errorf("parameter %d: name and in are required", i)
+		}
+		if p.schema, err = sp.compileSchema(m["schema"]); err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", p.name, err)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (sp *Spec) content(node any) (map[string]*Schema, error) {
+	m, _ := node.(map[string]any)
+	if len(m) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]*Schema, len(m))
+	for mediaType, mt := range m {
+		mtm, _ := mt.(map[string]any)
+		s, err := sp.compileSchema(mtm["schema"])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", mediaType, err)
+		}
+		out[mediaType] = s
+	}
+	return out, nil
+}
+
+// Find returns the operation serving method and path, with the values of
+// its path parameters. Templates with more literal segments win, so
+// "/v1/customers/export" is preferred over "/v1/customers/{id}".
+func (sp *Spec) Find(method, path string) (*Operation, map[string]string) {
+	segments := strings.Split(path, "/")
+	var best *Operation
+	var bestParams map[string]string
+	bestLiterals := -1
+	for _, op := range sp.ops {
+		if op.Method != method || len(op.segments) != len(segments) {
+			continue
+		}
+		params, literals, ok := op.match(segments)
+		if ok && literals > bestLiterals {
+			best, bestParams, bestLiterals = op, params, literals
+		}
+	}
+	return best, bestParams
+}
+
+func (op *Operation) match(segments []string) (map[string]string, int, bool) {
+	params := map[string]string{}
+	literals := 0
+	for i, tmpl := range op.segments {
+		seg := segments[i]
+		if strings.HasPrefix(tmpl, "{") && strings.HasSuffix(tmpl, "}") {
+			if seg == "" {
+				return nil, 0, false
+			}
+			params[tmpl[1:len(tmpl)-1]] = seg
+			continue
+		}
+		if tmpl != seg {
+			return nil, 0, false
+		}
+		literals++
+	}
+	return params, literals, true
+}
+
+// ValidationError lists every way a request or response departs from the
+// API description.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+func asError(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// ValidateRequest checks r's parameters and body, which the caller has read
+// into body, against the operation. pathParams are the values Find
+// returned. A nil body skips body validation, for bodies too large to read.
+func (op *Operation) ValidateRequest(r *http.Request, pathParams map[string]string, body []byte) error {
+	var problems []string
+	query := r.URL.Query()
+	for _, p := range op.params {
+		var values []string
+		switch p.in {
+		case "path":
+			if v, ok := pathParams[p.name]; ok {
+				values = []string{v}
+			}
+		case "query":
+			values = query[p.name]
+		case "header":
+			values = r.Header.Values(p.name)
+		case "cookie":
+			if c, err := r.Cookie(p.name); err == nil {
+				values = []string{c.Value}
+			}
+		}
+		where := p.in + " parameter " + p.name
+		if len(values) == 0 {
+			if p.required || p.in == "path" {
+				problems = append(problems, where+": required")
+			}
+			continue
+		}
+		problems = append(problems, p.schema.Validate(where, coerce(p.schema, values))...)
+	}
+
+	if op.body == nil || body == nil {
+		return asError(problems)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.body.required {
+			problems = append(problems, "body: required")
+		}
+		return asError(problems)
+	}
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		// Callers commonly omit the header; assume the first JSON type the
+		// operation accepts.
+		for mt := range op.body.content {
+			if isJSON(mt) {
+				contentType = mt
+				break
+			}
+		}
+	}
+	schema, mt, ok := mediaSchema(op.body.content, contentType)
+	if !ok {
+		problems = append(problems, fmt.Sprintf("body: unsupported content type %q", contentType))
+		return asError(problems)
+	}
+	if isJSON(mt) {
+		problems = append(problems, validateJSON("body", schema, body)...)
+	}
+	return asError(problems)
+}
+
+// ValidateResponse checks a response's status, content type and body
+// against the operation.
+func (op *Operation) ValidateResponse(status int, header http.Header, body []byte) error {
+	code := strconv.Itoa(status)
+	resp, ok := op.responses[code]
+	if !ok {
+		resp, ok = op.responses[code[:1]+"XX"]
+	}
+	if !ok {
+		resp, ok = op.responses["DEFAULT"]
+	}
+	if !ok {
+		return asError([]string{fmt.Sprintf("status %d is not documented", status)})
+	}
+	contentType := header.Get("Content-Type")
+	if len(resp.content) == 0 {
+		if contentType != "" || len(body) > 0 {
+			return asError([]string{fmt.Sprintf("status %d documents no content, got %q", status, contentType)})
+		}
+		return nil
+	}
+	schema, mt, ok := mediaSchema(resp.content, contentType)
+	if !ok {
+		return asError([]string{fmt.Sprintf("status %d: undocumented content type %q", status, contentType)})
+	}
+	if !isJSON(mt) {
+		return nil
+	}
+	return asError(validateJSON("body", schema, body))
+}
+
+func validateJSON(path string, schema *Schema, body []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return []string{path + ": invalid JSON: " + err.Error()}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return []string{path + ": unexpected data after JSON value"}
+	}
+	return schema.Validate(path, v)
+}
+
+// mediaSchema finds the schema for contentType, allowing "type/*" and
+// "*/*" entries.
+func mediaSchema(content map[string]*Schema, contentType string) (*Schema, string, bool) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, "", false
+	}
+	if s, ok := content[mt]; ok {
+		return s, mt, true
+	}
+	if major, _, ok := strings.Cut(mt, "/"); ok {
+		if s, ok := content[major+"/*"]; ok {
+			return s, mt, true
+		}
+	}
+	if s, ok := content["*/*"]; ok {
+		return s, mt, true
+	}
+	return nil, "", false
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// coerce converts parameter strings to the JSON value the schema expects, so
+// they can be validated like body values. Values that do not convert are
+// left as strings and fail type validation.
+func coerce(s *Schema, values []string) any {
+	if s != nil && s.Type == "array" {
+		out := make([]any, 0, len(values))
+		for _, v := range values {
+			for _, part := range strings.Split(v, ",") {
+				out = append(out, coerce(s.Items, []string{part}))
+			}
+		}
+		return out
+	}
+	v := values[0]
+	if s == nil {
+		return v
+	}
+	switch s.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return json.Number(v)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
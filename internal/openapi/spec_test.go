@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	"testing"
+
+	customerservice "github.com/Archiit19/customer-service-go"
+)
+
+func TestLoadServiceDescription(t *testing.T) {
+	doc, err := decode(customerservice.OpenAPI)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	paths, _ := doc.(map[string]any)["paths"].(map[string]any)
+	spec, err := Load(customerservice.OpenAPI)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	templates := map[string]bool{}
+	for _, op := range spec.Operations() {
+		templates[op.Path] = true
+		if found, _ := spec.Find(op.Method, op.Path); found != op {
+			t.Errorf("Find(%s, %s) = %v", op.Method, op.Path, found)
+		}
+	}
+	if len(paths) == 0 || len(templates) != len(paths) {
+		t.Fatalf("%d paths with operations, %d paths parsed", len(templates), len(paths))
+	}
+}
+
+func TestDecodeWidensIntegers(t *testing.T) {
+	doc, err := decode([]byte("minLength: 1\nmaximum: 2.5\nenum: [3, x]\nnested: {minItems: 4}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := doc.(map[string]any)
+	if m["minLength"] != int64(1) || m["maximum"] != 2.5 || m["enum"].([]any)[0] != int64(3) || m["nested"].(map[string]any)["minItems"] != int64(4) {
+		t.Errorf("decoded %#v", m)
+	}
+	if _, err := decode([]byte("a: 1\na: 2\n")); err == nil {
+		t.Error("duplicate key accepted")
+	}
+}
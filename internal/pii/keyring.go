@@ -0,0 +1,240 @@
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	keySize = 32 // AES-256
+	prefix  = "pii:v1:"
+)
+
+var (
+	ErrUnknownKey       = errors.New("pii: unknown key id")
+	ErrMalformedSealed  = errors.New("pii: malformed ciphertext")
+	ErrDecryptionFailed = errors.New("pii: decryption failed")
+)
+
+// Domains bind ciphertexts and blind indexes to the column they belong to, so
+// a PAN ciphertext cannot be replayed into the email column and equal values
+// in different columns produce unrelated indexes.
+const (
+	DomainEmail = "email"
+	DomainPhone = "phone"
+	DomainPAN   = "pan"
+	// DomainJobInput seals uploaded import files until their job finishes.
+	DomainJobInput = "job_input"
+	// DomainContactCode keys the digests of one-time contact verification
+	// codes and of the contacts they were sent to.
+	DomainContactCode = "contact_code"
+	// DomainPhoneDigits indexes the last ten digits of phones, so encrypted
+	// phones can be compared for duplicate detection.
+	DomainPhoneDigits = "phone_digits"
+)
+
+// Keyring performs envelope encryption of PII values. Every value is sealed
+// with a fresh random data key, and the data key is wrapped with the active
+// key-encryption key. Older key-encryption keys stay in the ring so existing
+// rows remain readable until they are re-encrypted.
+//
+// Sealed values have the form
+//
+//	pii:v1:<key id>:<base64 wrapped data key>:<base64 ciphertext>
+type Keyring struct {
+	keys     map[string]cipher.AEAD
+	activeID string
+	indexKey []byte
+}
+
+// NewKeyring builds a keyring from raw 32-byte key-encryption keys keyed by
+// ID. activeID selects the key used for new ciphertexts, and indexKey is the
+// HMAC key for blind indexes. The index key cannot be rotated without
+// recomputing every index, so it is kept separate from the encryption keys.
+func NewKeyring(keys map[string][]byte, activeID string, indexKey []byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("pii: at least one key is required")
+	}
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("pii: active key %q is not in the keyring", activeID)
+	}
+	if len(indexKey) < keySize {
+		return nil, fmt.Errorf("pii: index key must be at least %d bytes", keySize)
+	}
+	ring := &Keyring{
+		keys:     make(map[string]cipher.AEAD, len(keys)),
+		activeID: activeID,
+		indexKey: append([]byte(nil), indexKey...),
+	}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("pii: invalid key id %q", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("pii: key %q: %w", id, err)
+		}
+		ring.keys[id] = aead
+	}
+	return ring, nil
+}
+
+// ParseKeys parses a ","-separated list of "<id>:<base64 key>" pairs.
+func ParseKeys(s string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for i, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			// Do not echo the entry: it is most likely a bare key.
+			return nil, fmt.Errorf("pii: key entry %d: expected <id>:<base64 key>", i+1)
+		}
+		key, err := DecodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("pii: key %q: %w", id, err)
+		}
+		keys[strings.TrimSpace(id)] = key
+	}
+	return keys, nil
+}
+
+// DecodeKey decodes a standard base64 key and checks its length.
+func DecodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("decode base64: %w", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("expected %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+// ActiveKeyID returns the ID of the key used for new ciphertexts.
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+// Encrypt seals plaintext for the given domain with the active key.
+func (k *Keyring) Encrypt(domain, plaintext string) (string, error) {
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", fmt.Errorf("pii: generate data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataAEAD, []byte(plaintext), []byte(domain))
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := seal(k.keys[k.activeID], dek, []byte(k.activeID))
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawStdEncoding
+	return prefix + k.activeID + ":" + enc.EncodeToString(wrapped) + ":" + enc.EncodeToString(ciphertext), nil
+}
+
+// Decrypt opens a value produced by Encrypt for the same domain.
+func (k *Keyring) Decrypt(domain, sealed string) (string, error) {
+	id, wrapped, ciphertext, err := split(sealed)
+	if err != nil {
+		return "", err
+	}
+	kek, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	dek, err := open(kek, wrapped, []byte(id))
+	if err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataAEAD, ciphertext, []byte(domain))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// KeyID reports which key-encryption key sealed a value.
+func (k *Keyring) KeyID(sealed string) (string, error) {
+	id, _, _, err := split(sealed)
+	return id, err
+}
+
+// BlindIndex returns a deterministic keyed hash of value for the given
+// domain. Callers normalise value first (e.g. lowercase emails) so that
+// equality of indexes matches the business notion of equality.
+func (k *Keyring) BlindIndex(domain, value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(domain))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("pii: generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, data, aad []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrMalformedSealed
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+func split(sealed string) (string, []byte, []byte, error) {
+	rest, ok := strings.CutPrefix(sealed, prefix)
+	if !ok {
+		return "", nil, nil, ErrMalformedSealed
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformedSealed
+	}
+	enc := base64.RawStdEncoding
+	wrapped, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformedSealed
+	}
+	ciphertext, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformedSealed
+	}
+	return parts[0], wrapped, ciphertext, nil
+}
@@ -0,0 +1,201 @@
+package pii
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, keySize)
+}
+
+func testKeyring(t *testing.T, keys map[string][]byte, activeID string) *Keyring {
+	t.Helper()
+	ring, err := NewKeyring(keys, activeID, testKey(0xff))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return ring
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	ring := testKeyring(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	for _, plaintext := range []string{"asha@example.com", "", "+91 98765 43210", "ஆஷா"} {
+		sealed, err := ring.Encrypt(DomainEmail, plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt(%q): %v", plaintext, err)
+		}
+		if plaintext != "" && strings.Contains(sealed, plaintext) {
+			t.Errorf("sealed value %q contains the plaintext", sealed)
+		}
+		if !strings.HasPrefix(sealed, prefix+"k1:") {
+			t.Errorf("sealed value %q lacks the %sk1: prefix", sealed, prefix)
+		}
+		got, err := ring.Decrypt(DomainEmail, sealed)
+		if err != nil {
+			t.Fatalf("Decrypt(%q): %v", sealed, err)
+		}
+		if got != plaintext {
+			t.Errorf("round trip of %q gave %q", plaintext, got)
+		}
+	}
+}
+
+func TestEncryptIsRandomized(t *testing.T) {
+	ring := testKeyring(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	a, _ := ring.Encrypt(DomainEmail, "asha@example.com")
+	b, _ := ring.Encrypt(DomainEmail, "asha@example.com")
+	if a == b {
+		t.Error("two encryptions of the same value are identical")
+	}
+}
+
+func TestDecryptRejects(t *testing.T) {
+	ring := testKeyring(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	sealed, err := ring.Encrypt(DomainEmail, "asha@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Same key ID, different key material.
+	wrongKey := testKeyring(t, map[string][]byte{"k1": testKey(2)}, "k1")
+	otherID := testKeyring(t, map[string][]byte{"k2": testKey(1)}, "k2")
+	id, wrapped, ciphertext, _ := split(sealed)
+	ciphertext[len(ciphertext)-1] ^= 1
+	enc := base64.RawStdEncoding
+	tampered := prefix + id + ":" + enc.EncodeToString(wrapped) + ":" + enc.EncodeToString(ciphertext)
+
+	tests := []struct {
+		name   string
+		ring   *Keyring
+		domain string
+		sealed string
+		want   error
+	}{
+		{"wrong key", wrongKey, DomainEmail, sealed, ErrDecryptionFailed},
+		{"unknown key id", otherID, DomainEmail, sealed, ErrUnknownKey},
+		{"wrong domain", ring, DomainPAN, sealed, ErrDecryptionFailed},
+		{"tampered ciphertext", ring, DomainEmail, tampered, ErrDecryptionFailed},
+		{"plaintext", ring, DomainEmail, "asha@example.com", ErrMalformedSealed},
+		{"missing part", ring, DomainEmail, prefix + "k1:abc", ErrMalformedSealed},
+		{"bad base64", ring, DomainEmail, prefix + "k1:!!:!!", ErrMalformedSealed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.ring.Decrypt(tt.domain, tt.sealed)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Decrypt = %q, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	old := testKeyring(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	sealedOld, err := old.Encrypt(DomainPhone, "+919876543210")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := testKeyring(t, map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k2")
+	if rotated.ActiveKeyID() != "k2" {
+		t.Fatalf("ActiveKeyID = %q, want k2", rotated.ActiveKeyID())
+	}
+	got, err := rotated.Decrypt(DomainPhone, sealedOld)
+	if err != nil || got != "+919876543210" {
+		t.Fatalf("rotated ring decrypting an old value = %q, %v", got, err)
+	}
+	if id, _ := rotated.KeyID(sealedOld); id != "k1" {
+		t.Errorf("KeyID of old value = %q, want k1", id)
+	}
+	sealedNew, err := rotated.Encrypt(DomainPhone, "+919876543210")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := rotated.KeyID(sealedNew); id != "k2" {
+		t.Errorf("KeyID of new value = %q, want k2", id)
+	}
+	// Once k1 is retired, only values re-encrypted under k2 stay readable.
+	retired := testKeyring(t, map[string][]byte{"k2": testKey(2)}, "k2")
+	if _, err := retired.Decrypt(DomainPhone, sealedOld); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("retired ring decrypting an old value: %v, want %v", err, ErrUnknownKey)
+	}
+	if got, err := retired.Decrypt(DomainPhone, sealedNew); err != nil || got != "+919876543210" {
+		t.Errorf("retired ring decrypting a new value = %q, %v", got, err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	ring := testKeyring(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	idx := ring.BlindIndex(DomainEmail, "asha@example.com")
+	if len(idx) != 64 {
+		t.Errorf("index %q is not a hex SHA-256", idx)
+	}
+	if again := ring.BlindIndex(DomainEmail, "asha@example.com"); again != idx {
+		t.Errorf("index changed between calls: %q, %q", idx, again)
+	}
+	// Rotating the encryption keys must not change indexes: they are keyed
+	// by the separate index key.
+	rotated := testKeyring(t, map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k2")
+	if got := rotated.BlindIndex(DomainEmail, "asha@example.com"); got != idx {
+		t.Errorf("index changed after key rotation: %q, want %q", got, idx)
+	}
+	if got := ring.BlindIndex(DomainPhone, "asha@example.com"); got == idx {
+		t.Error("equal values in different domains share an index")
+	}
+	if got := ring.BlindIndex(DomainEmail, "ravi@example.com"); got == idx {
+		t.Error("different values share an index")
+	}
+	otherIndexKey, err := NewKeyring(map[string][]byte{"k1": testKey(1)}, "k1", testKey(0xfe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := otherIndexKey.BlindIndex(DomainEmail, "asha@example.com"); got == idx {
+		t.Error("index does not depend on the index key")
+	}
+}
+
+func TestNewKeyringRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     map[string][]byte
+		activeID string
+		indexKey []byte
+	}{
+		{"no keys", nil, "k1", testKey(0xff)},
+		{"missing active key", map[string][]byte{"k1": testKey(1)}, "k2", testKey(0xff)},
+		{"short index key", map[string][]byte{"k1": testKey(1)}, "k1", testKey(0xff)[:16]},
+		{"colon in key id", map[string][]byte{"k:1": testKey(1)}, "k:1", testKey(0xff)},
+		{"short key", map[string][]byte{"k1": testKey(1)[:10]}, "k1", testKey(0xff)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeyring(tt.keys, tt.activeID, tt.indexKey); err == nil {
+				t.Error("NewKeyring succeeded")
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+	keys, err := ParseKeys(" k1:" + k1 + ", k2:" + k2 + ",")
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	if len(keys) != 2 || !bytes.Equal(keys["k1"], testKey(1)) || !bytes.Equal(keys["k2"], testKey(2)) {
+		t.Errorf("ParseKeys = %v", keys)
+	}
+	for _, bad := range []string{k1, "k1:not-base64", "k1:" + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		_, err := ParseKeys(bad)
+		if err == nil {
+			t.Errorf("ParseKeys(%q) succeeded", bad)
+			continue
+		}
+		if strings.Contains(err.Error(), k1) {
+			t.Errorf("ParseKeys error %q echoes the key", err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const memorySweepInterval = time.Minute
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	window  time.Duration
+}
+
+// MemoryStore keeps buckets in process memory. Limits are enforced per
+// replica, so it suits single-instance and local deployments.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, rule Rule) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(rule.Limit), updated: now}
+		s.buckets[key] = b
+	}
+	tokens, res := rule.take(b.tokens, now.Sub(b.updated))
+	b.tokens = tokens
+	b.updated = now
+	b.window = rule.Window
+	return res, nil
+}
+
+func (s *MemoryStore) Peek(_ context.Context, key string, rule Rule) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[key]
+	if !ok {
+		return rule.peek(float64(rule.Limit), 0), nil
+	}
+	return rule.peek(b.tokens, s.now().Sub(b.updated)), nil
+}
+
+// sweep drops buckets idle for longer than their window; they would have
+// refilled completely and are indistinguishable from a new bucket.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) > b.window {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	pgSweepEvery = 1000
+	pgStaleAfter = 24 * time.Hour
+)
+
+// PGStore keeps buckets in the rate_limit_buckets table so every replica
+// shares the same budget per client. Rows are locked for the duration of a
+// Take, and the database clock is used to avoid skew between replicas.
+type PGStore struct {
+	pool   *pgxpool.Pool
+	logger logger.Logger
+	calls  atomic.Uint64
+}
+
+func NewPGStore(pool *pgxpool.Pool, log logger.Logger) *PGStore {
+	return &PGStore{pool: pool, logger: log}
+}
+
+func (s *PGStore) Take(ctx context.Context, key string, rule Rule) (Result, error) {
+	var res Result
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+INSERT INTO rate_limit_buckets (bucket_key, tokens, updated_at)
+VALUES ($1, $2, now())
+ON CONFLICT (bucket_key) DO NOTHING;
+`, key, float64(rule.Limit))
+		if err != nil {
+			return fmt.Errorf("insert bucket: %w", err)
+		}
+
+		var (
+			tokens  float64
+			updated time.Time
+			now     time.Time
+		)
+		err = tx.QueryRow(ctx, `
+SELECT tokens, updated_at, now()
+FROM rate_limit_buckets
+WHERE bucket_key = $1
+FOR UPDATE;
+`, key).Scan(&tokens, &updated, &now)
+		if err != nil {
+			return fmt.Errorf("lock bucket: %w", err)
+		}
+
+		tokens, res = rule.take(tokens, now.Sub(updated))
+		_, err = tx.Exec(ctx, `
+UPDATE rate_limit_buckets
+SET tokens = $2, updated_at = $3
+WHERE bucket_key = $1;
+`, key, tokens, now)
+		if err != nil {
+			return fmt.Errorf("update bucket: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error(ctx, "rate limit bucket update failed", logger.Err(err))
+		return Result{}, err
+	}
+
+	if s.calls.Add(1)%pgSweepEvery == 0 {
+		s.sweep(ctx)
+	}
+	return res, nil
+}
+
+func (s *PGStore) Peek(ctx context.Context, key string, rule Rule) (Result, error) {
+	var (
+		tokens  float64
+		updated time.Time
+		now     time.Time
+	)
+	err := s.pool.QueryRow(ctx, `SELECT tokens, updated_at, now() FROM rate_limit_buckets WHERE bucket_key = $1;`, key).Scan(&tokens, &updated, &now)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return rule.peek(float64(rule.Limit), 0), nil
+	}
+	if err != nil {
+		s.logger.Error(ctx, "rate limit bucket read failed", logger.Err(err))
+		return Result{}, err
+	}
+	return rule.peek(tokens, now.Sub(updated)), nil
+}
+
+// sweep removes buckets nobody has touched for a day so the table does not
+// grow with every client IP ever seen.
+func (s *PGStore) sweep(ctx context.Context) {
+	ct, err := s.pool.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < now() - $1::interval;`, pgStaleAfter)
+	if err != nil {
+		s.logger.Warn(ctx, "rate limit bucket sweep failed", logger.Err(err))
+		return
+	}
+	s.logger.Debug(ctx, "rate limit buckets swept", logger.Int64("deleted", ct.RowsAffected()))
+}
@@ -0,0 +1,193 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rule describes a token bucket: Limit tokens refilled evenly over Window.
+// The bucket capacity equals Limit, so a quiet client may burst up to Limit
+// requests at once.
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Result reports the outcome of a single Take call.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // zero when allowed
+}
+
+// Store persists token buckets. Implementations must make Take atomic per key.
+type Store interface {
+	Take(ctx context.Context, key string, rule Rule) (Result, error)
+	// Peek reports whether Take would be allowed, without consuming a token.
+	Peek(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// AuthFailures is the route under which failed authentications are limited
+// per client, e.g. "auth-failures=10/1m". Without a rule of its own it uses
+// the default rule, in a bucket separate from the client's requests.
+const AuthFailures = "auth-failures"
+
+// ParseRule parses a "<limit>/<window>" expression such as "100/1m".
+func ParseRule(s string) (Rule, error) {
+	limitStr, windowStr, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("rate limit %q: expected <limit>/<window>", s)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil || limit <= 0 {
+		return Rule{}, fmt.Errorf("rate limit %q: invalid limit", s)
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(windowStr))
+	if err != nil || window <= 0 {
+		return Rule{}, fmt.Errorf("rate limit %q: invalid window", s)
+	}
+	return Rule{Limit: limit, Window: window}, nil
+}
+
+// ParseRoutes parses a ";"-separated list of "<METHOD> <pattern>=<rule>"
+// entries, e.g. "GET /v1/customers=30/1m;GET /v1/customers/{id}=60/1m".
+// The method may be omitted to apply the rule to every method of a pattern.
+func ParseRoutes(s string) (map[string]Rule, error) {
+	routes := make(map[string]Rule)
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, ruleStr, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit route %q: expected <route>=<rule>", entry)
+		}
+		rule, err := ParseRule(ruleStr)
+		if err != nil {
+			return nil, err
+		}
+		routes[normalizeRoute(route)] = rule
+	}
+	return routes, nil
+}
+
+func normalizeRoute(route string) string {
+	fields := strings.Fields(route)
+	if len(fields) == 2 {
+		return strings.ToUpper(fields[0]) + " " + fields[1]
+	}
+	return strings.Join(fields, " ")
+}
+
+// String renders the rule in the same form ParseRule accepts.
+func (r Rule) String() string {
+	return fmt.Sprintf("%d/%s", r.Limit, r.Window)
+}
+
+func (r Rule) perSecond() float64 {
+	return float64(r.Limit) / r.Window.Seconds()
+}
+
+// take refills a bucket holding tokens after elapsed time and tries to
+// consume one token. It is shared by every Store so the arithmetic is
+// identical regardless of where the bucket lives.
+func (r Rule) take(tokens float64, elapsed time.Duration) (float64, Result) {
+	rate := r.perSecond()
+	capacity := float64(r.Limit)
+	if elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed.Seconds()*rate)
+	}
+	res := Result{Limit: r.Limit}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = secondsToDuration((capacity - tokens) / rate)
+	return tokens, res
+}
+
+// peek returns the result take would have, leaving the bucket unchanged.
+func (r Rule) peek(tokens float64, elapsed time.Duration) Result {
+	_, res := r.take(tokens, elapsed)
+	return res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Limiter resolves the rule for a route and delegates bucket accounting to a
+// Store. Rules may be replaced while requests are being served.
+type Limiter struct {
+	store Store
+
+	mu     sync.RWMutex
+	def    Rule
+	routes map[string]Rule
+}
+
+// NewLimiter creates a Limiter applying def to routes without an explicit rule.
+func NewLimiter(store Store, def Rule, routes map[string]Rule) *Limiter {
+	if routes == nil {
+		routes = map[string]Rule{}
+	}
+	return &Limiter{store: store, def: def, routes: routes}
+}
+
+// SetRules replaces the default and per-route rules. Existing buckets are
+// kept and refill at the new rate from their current level.
+func (l *Limiter) SetRules(def Rule, routes map[string]Rule) {
+	if routes == nil {
+		routes = map[string]Rule{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.def, l.routes = def, routes
+}
+
+// Allow consumes a token for the client identified by key on the given route.
+// Routes with their own rule get their own bucket; every other route shares
+// the client's default bucket.
+func (l *Limiter) Allow(ctx context.Context, key, method, pattern string) (Result, error) {
+	rule, scope := l.ruleFor(method, pattern)
+	return l.store.Take(ctx, key+"|"+scope, rule)
+}
+
+// Check reports whether Allow would admit the client on the route, without
+// consuming a token.
+func (l *Limiter) Check(ctx context.Context, key, method, pattern string) (Result, error) {
+	rule, scope := l.ruleFor(method, pattern)
+	return l.store.Peek(ctx, key+"|"+scope, rule)
+}
+
+func (l *Limiter) ruleFor(method, pattern string) (Rule, string) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if pattern == AuthFailures {
+		if rule, ok := l.routes[AuthFailures]; ok {
+			return rule, AuthFailures
+		}
+		return l.def, AuthFailures
+	}
+	if pattern != "" {
+		route := strings.ToUpper(method) + " " + pattern
+		if rule, ok := l.routes[route]; ok {
+			return rule, route
+		}
+		if rule, ok := l.routes[pattern]; ok {
+			return rule, pattern
+		}
+	}
+	return l.def, "*"
+}
@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock is a MemoryStore clock advanced by hand.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newClockedStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.now = clock.now
+	return s, clock
+}
+
+func TestMemoryStoreRefill(t *testing.T) {
+	ctx := context.Background()
+	s, clock := newClockedStore()
+	rule := Rule{Limit: 10, Window: 10 * time.Second} // one token a second
+
+	for i := 0; i < 10; i++ {
+		res, _ := s.Take(ctx, "client", rule)
+		if !res.Allowed || res.Remaining != 9-i {
+			t.Fatalf("take %d: %+v, want allowed with %d remaining", i, res, 9-i)
+		}
+	}
+	res, _ := s.Take(ctx, "client", rule)
+	if res.Allowed {
+		t.Fatalf("take past the burst allowed: %+v", res)
+	}
+	if res.RetryAfter != time.Second || res.Reset != 10*time.Second {
+		t.Errorf("rejected take: retry after %v, reset %v; want 1s, 10s", res.RetryAfter, res.Reset)
+	}
+
+	clock.advance(500 * time.Millisecond)
+	if res, _ := s.Take(ctx, "client", rule); res.Allowed {
+		t.Errorf("half a token refilled but take allowed: %+v", res)
+	}
+	clock.advance(500 * time.Millisecond)
+	if res, _ := s.Take(ctx, "client", rule); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("one token refilled: %+v, want allowed with 0 remaining", res)
+	}
+
+	clock.advance(3 * time.Second)
+	if res, _ := s.Peek(ctx, "client", rule); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("peek after 3s: %+v, want allowed with 2 remaining after the take", res)
+	}
+	if res, _ := s.Peek(ctx, "client", rule); res.Remaining != 2 {
+		t.Errorf("peek consumed a token: %+v", res)
+	}
+
+	// Refill stops at the capacity however long the client was idle.
+	clock.advance(time.Hour)
+	if res, _ := s.Take(ctx, "client", rule); !res.Allowed || res.Remaining != 9 {
+		t.Errorf("take after an hour: %+v, want allowed with 9 remaining", res)
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newClockedStore()
+	rule := Rule{Limit: 1, Window: time.Minute}
+	if res, _ := s.Take(ctx, "a", rule); !res.Allowed {
+		t.Fatalf("first take for a: %+v", res)
+	}
+	if res, _ := s.Take(ctx, "a", rule); res.Allowed {
+		t.Fatalf("second take for a allowed: %+v", res)
+	}
+	if res, _ := s.Take(ctx, "b", rule); !res.Allowed {
+		t.Errorf("b limited by a's bucket: %+v", res)
+	}
+}
+
+func TestMemoryStoreSweepsIdleBuckets(t *testing.T) {
+	ctx := context.Background()
+	s, clock := newClockedStore()
+	rule := Rule{Limit: 5, Window: time.Second}
+	_, _ = s.Take(ctx, "idle", rule)
+	clock.advance(2 * memorySweepInterval)
+	_, _ = s.Take(ctx, "active", rule)
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("idle bucket survived the sweep")
+	}
+	if _, ok := s.buckets["active"]; !ok {
+		t.Error("active bucket was swept")
+	}
+}
+
+func TestLimiterRoutes(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newClockedStore()
+	routes, err := ParseRoutes("GET /v1/customers=1/1m; /v1/customers/{id}=2/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLimiter(s, Rule{Limit: 3, Window: time.Minute}, routes)
+
+	tests := []struct {
+		method, pattern string
+		limit           int
+	}{
+		{"get", "/v1/customers", 1},
+		{"POST", "/v1/customers", 3},
+		{"DELETE", "/v1/customers/{id}", 2},
+		{"GET", "", 3},
+		{"GET", AuthFailures, 3},
+	}
+	for _, tt := range tests {
+		res, err := l.Allow(ctx, "client", tt.method, tt.pattern)
+		if err != nil || res.Limit != tt.limit {
+			t.Errorf("Allow(%s %s) = %+v, %v; want limit %d", tt.method, tt.pattern, res, err, tt.limit)
+		}
+	}
+	// The POST and the pattern-less GET drew on the shared default bucket;
+	// auth failures have their own.
+	if res, _ := l.Check(ctx, "client", "PATCH", "/v1/other"); res.Remaining != 0 {
+		t.Errorf("default bucket %+v, want 0 remaining after the check's take", res)
+	}
+	if res, _ := l.Check(ctx, "client", "", AuthFailures); res.Remaining != 1 {
+		t.Errorf("auth failure bucket %+v, want 1 remaining after the check's take", res)
+	}
+
+	l.SetRules(Rule{Limit: 100, Window: time.Minute}, nil)
+	if res, _ := l.Allow(ctx, "client", "GET", "/v1/customers"); res.Limit != 100 {
+		t.Errorf("after SetRules: %+v, want the new default", res)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rule
+		wantErr bool
+	}{
+		{in: "100/1m", want: Rule{Limit: 100, Window: time.Minute}},
+		{in: " 5 / 10s ", want: Rule{Limit: 5, Window: 10 * time.Second}},
+		{in: "100", wantErr: true},
+		{in: "0/1m", wantErr: true},
+		{in: "-1/1m", wantErr: true},
+		{in: "10/0s", wantErr: true},
+		{in: "10/soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRule(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+	if s := (Rule{Limit: 100, Window: time.Minute}).String(); s != "100/1m0s" {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -0,0 +1,142 @@
+// Package settings applies configuration changes to a running process.
+//
+// Most configuration is read once at startup. The keys registered with a
+// Manager are re-read when the config file changes or the process receives
+// SIGHUP, and the new value is handed to the component that owns it.
+package settings
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/config"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// ApplyFunc makes value the current setting. An error leaves the previous
+// value in effect.
+type ApplyFunc func(ctx context.Context, value string) error
+
+// LoadFunc re-resolves configuration from the same layers used at startup.
+type LoadFunc func() (*config.Config, []string, error)
+
+type watched struct {
+	key   string
+	value string
+	apply ApplyFunc
+}
+
+// Manager re-applies registered settings when configuration changes.
+type Manager struct {
+	load LoadFunc
+	log  logger.Logger
+
+	mu      sync.Mutex
+	current *config.Config
+	watched []*watched
+}
+
+// NewManager returns a Manager whose settings start from initial.
+func NewManager(initial *config.Config, load LoadFunc, log logger.Logger) *Manager {
+	return &Manager{load: load, log: log, current: initial}
+}
+
+// Watch registers key as reloadable. apply is called with the new value
+// whenever a reload resolves key to something different from the value last
+// applied; it is not called for the initial value.
+func (m *Manager) Watch(key string, apply ApplyFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.watched = append(m.watched, &watched{key: key, value: m.current.Value(key), apply: apply})
+}
+
+// Reload re-reads configuration and applies the registered settings that
+// changed. A configuration that no longer loads is rejected as a whole, so a
+// bad edit leaves every setting as it was. Values changed at runtime, such as
+// through the admin API, are only replaced when the configured value itself
+// changes.
+func (m *Manager) Reload(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.log.Info(ctx, "settings reload invoked")
+	cfg, warnings, err := m.load()
+	if err != nil {
+		m.log.Error(ctx, "settings reload failed", logger.Err(err))
+		return err
+	}
+	for _, w := range warnings {
+		m.log.Warn(ctx, "configuration problem", logger.String("problem", w))
+	}
+
+	reloadable := map[string]bool{}
+	var errs []error
+	for _, w := range m.watched {
+		reloadable[w.key] = true
+		v := cfg.Value(w.key)
+		if v == w.value {
+			continue
+		}
+		if err := w.apply(ctx, v); err != nil {
+			m.log.Error(ctx, "setting rejected", logger.String("key", w.key), logger.Err(err))
+			errs = append(errs, err)
+			continue
+		}
+		w.value = v
+		m.log.Info(ctx, "setting applied", logger.String("key", w.key))
+	}
+	for _, s := range cfg.Settings() {
+		if !reloadable[s.Key] && s.Value != m.current.Value(s.Key) {
+			m.log.Warn(ctx, "setting changed but requires a restart", logger.String("key", s.Key))
+		}
+	}
+	m.current = cfg
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	m.log.Info(ctx, "settings reload succeeded")
+	return nil
+}
+
+// Run reloads on SIGHUP and, when file is set and interval is positive,
+// whenever file's modification time or size changes. It returns when ctx is
+// cancelled.
+func (m *Manager) Run(ctx context.Context, file string, interval time.Duration) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	var last os.FileInfo
+	if file != "" && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+		last, _ = os.Stat(file)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			m.log.Info(ctx, "SIGHUP received")
+			_ = m.Reload(ctx)
+		case <-tick:
+			fi, err := os.Stat(file)
+			if err != nil {
+				// Mounted ConfigMaps are swapped via a symlink, so the file may
+				// briefly be missing; try again on the next tick.
+				continue
+			}
+			if last != nil && fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size() {
+				continue
+			}
+			last = fi
+			_ = m.Reload(ctx)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Archiit19/customer-service-go/internal/config"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// loader returns a LoadFunc resolving configuration from *overrides as it is
+// at the time of each reload.
+func loader(overrides *map[string]string) LoadFunc {
+	return func() (*config.Config, []string, error) {
+		return config.Load(config.LoadOptions{Overrides: *overrides})
+	}
+}
+
+func TestReloadAppliesChangedSettings(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("RATE_LIMIT_DEFAULT", "")
+	overrides := map[string]string{"LOG_LEVEL": "INFO", "RATE_LIMIT_DEFAULT": "120/1m"}
+	initial, _, err := loader(&overrides)()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(initial, loader(&overrides), logger.NewNop())
+	var levels, limits []string
+	m.Watch("LOG_LEVEL", func(_ context.Context, v string) error {
+		levels = append(levels, v)
+		return nil
+	})
+	m.Watch("RATE_LIMIT_DEFAULT", func(_ context.Context, v string) error {
+		limits = append(limits, v)
+		return nil
+	})
+
+	if err := m.Reload(context.Background()); err != nil {
+		t.Fatalf("unchanged reload: %v", err)
+	}
+	if len(levels) != 0 || len(limits) != 0 {
+		t.Fatalf("unchanged reload applied %v, %v", levels, limits)
+	}
+
+	overrides["LOG_LEVEL"] = "DEBUG"
+	if err := m.Reload(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(levels) != 1 || levels[0] != "DEBUG" {
+		t.Errorf("LOG_LEVEL applied %v, want [DEBUG]", levels)
+	}
+	if len(limits) != 0 {
+		t.Errorf("unchanged RATE_LIMIT_DEFAULT applied %v", limits)
+	}
+
+	if err := m.Reload(context.Background()); err != nil {
+		t.Fatalf("second reload: %v", err)
+	}
+	if len(levels) != 1 {
+		t.Errorf("LOG_LEVEL re-applied without a change: %v", levels)
+	}
+}
+
+func TestReloadRetriesRejectedSettings(t *testing.T) {
+	t.Setenv("RATE_LIMIT_DEFAULT", "")
+	overrides := map[string]string{"RATE_LIMIT_DEFAULT": "120/1m"}
+	initial, _, err := loader(&overrides)()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(initial, loader(&overrides), logger.NewNop())
+	reject := errors.New("rejected")
+	var applied []string
+	m.Watch("RATE_LIMIT_DEFAULT", func(_ context.Context, v string) error {
+		if reject != nil {
+			return reject
+		}
+		applied = append(applied, v)
+		return nil
+	})
+
+	overrides["RATE_LIMIT_DEFAULT"] = "60/1m"
+	if err := m.Reload(context.Background()); !errors.Is(err, reject) {
+		t.Fatalf("reload with a rejected setting: %v, want %v", err, reject)
+	}
+	// A rejected value keeps the previous one in effect, so it is offered
+	// again on the next reload.
+	reject = nil
+	if err := m.Reload(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(applied) != 1 || applied[0] != "60/1m" {
+		t.Errorf("applied %v, want [60/1m]", applied)
+	}
+}
+
+func TestReloadKeepsSettingsWhenLoadFails(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	cfg, _, err := config.Load(config.LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loadErr := errors.New("config file unreadable")
+	m := NewManager(cfg, func() (*config.Config, []string, error) { return nil, nil, loadErr }, logger.NewNop())
+	m.Watch("LOG_LEVEL", func(context.Context, string) error {
+		t.Error("setting applied from a failed load")
+		return nil
+	})
+	if err := m.Reload(context.Background()); !errors.Is(err, loadErr) {
+		t.Errorf("Reload = %v, want %v", err, loadErr)
+	}
+}
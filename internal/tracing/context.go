@@ -0,0 +1,156 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader carries the W3C trace context between services.
+const TraceparentHeader = "traceparent"
+
+var errInvalidTraceparent = errors.New("invalid traceparent")
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+func newTraceID() TraceID {
+	var t TraceID
+	for !t.IsValid() {
+		putUint64(t[:8], rand.Uint64())
+		putUint64(t[8:], rand.Uint64())
+	}
+	return t
+}
+
+func newSpanID() SpanID {
+	var s SpanID
+	for !s.IsValid() {
+		putUint64(s[:], rand.Uint64())
+	}
+	return s
+}
+
+func putUint64(b []byte, v uint64) {
+	for i := range b {
+		b[i] = byte(v >> (56 - 8*i))
+	}
+}
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	// Remote is set for contexts extracted from an incoming request.
+	Remote bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats sc as a W3C traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a version 00 W3C traceparent header value. Later
+// versions are accepted as long as they start with the version 00 fields.
+func ParseTraceparent(v string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, errInvalidTraceparent
+	}
+	var sc SpanContext
+	if len(parts[1]) != 32 || !isLowerHex(parts[1]) || len(parts[2]) != 16 || !isLowerHex(parts[2]) || len(parts[3]) != 2 {
+		return SpanContext{}, errInvalidTraceparent
+	}
+	_, _ = hex.Decode(sc.TraceID[:], []byte(parts[1]))
+	_, _ = hex.Decode(sc.SpanID[:], []byte(parts[2]))
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || !sc.IsValid() {
+		return SpanContext{}, errInvalidTraceparent
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+	sc.Remote = true
+	return sc, nil
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Extract returns the span context carried by h, if any.
+func Extract(h http.Header) (SpanContext, bool) {
+	sc, err := ParseTraceparent(h.Get(TraceparentHeader))
+	return sc, err == nil
+}
+
+// Inject writes the span context of ctx into h for an outgoing request.
+func Inject(ctx context.Context, h http.Header) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		h.Set(TraceparentHeader, sc.Traceparent())
+	}
+}
+
+type contextKey struct{}
+
+// current is stored in a context: the span context always, and the span itself
+// when it was started in this process.
+type current struct {
+	sc   SpanContext
+	span *Span
+}
+
+// ContextWithSpan returns ctx carrying span as the current span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, contextKey{}, current{sc: span.SpanContext(), span: span})
+}
+
+// ContextWithRemote returns ctx carrying a span context received from
+// another service, to be used as the parent of the next span started.
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, current{sc: sc})
+}
+
+// SpanContextFromContext returns the current span context, or the zero value
+// when ctx carries none.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	c, _ := ctx.Value(contextKey{}).(current)
+	return c.sc
+}
+
+// SpanFromContext returns the span started in this process that ctx carries,
+// or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(contextKey{}).(current)
+	return c.span
+}
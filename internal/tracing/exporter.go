@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// NopExporter discards spans. Trace and span IDs are still generated, so logs
+// stay correlated with upstream callers when no collector is configured.
+type NopExporter struct{}
+
+func (NopExporter) Export(SpanData) {}
+
+func (NopExporter) Shutdown(context.Context) error { return nil }
+
+// InMemoryExporter keeps finished spans in memory for tests.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+func (e *InMemoryExporter) Export(span SpanData) {
+	e.mu.Lock()
+	e.spans = append(e.spans, span)
+	e.mu.Unlock()
+}
+
+func (e *InMemoryExporter) Shutdown(context.Context) error { return nil }
+
+// Spans returns the spans exported so far, in the order they ended.
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+// Reset discards all recorded spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	e.spans = nil
+	e.mu.Unlock()
+}
@@ -0,0 +1,262 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	otlpBatchSize     = 512
+	otlpQueueSize     = 4096
+	otlpFlushInterval = 5 * time.Second
+	otlpTimeout       = 10 * time.Second
+	instrumentation   = "github.com/Archiit19/customer-service-go"
+)
+
+// OTLPExporter sends spans to an OpenTelemetry collector using OTLP/HTTP
+// with protobuf encoding. Spans are batched in the background; when the queue is
+// full new spans are dropped rather than blocking requests.
+type OTLPExporter struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+	onError  func(error)
+
+	queue    chan SpanData
+	flushReq chan chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu      sync.Mutex
+	dropped int
+	lastErr error
+}
+
+// NewOTLPExporter starts an exporter posting to endpoint, the full traces URL
+// such as http://otel-collector:4318/v1/traces. onError, if non-nil, is
+// called with export failures.
+func NewOTLPExporter(endpoint string, headers map[string]string, serviceName string, onError func(error)) *OTLPExporter {
+	if onError == nil {
+		onError = func(error) {}
+	}
+	e := &OTLPExporter{
+		endpoint: endpoint,
+		headers:  headers,
+		service:  serviceName,
+		client:   &http.Client{Timeout: otlpTimeout},
+		onError:  onError,
+		queue:    make(chan SpanData, otlpQueueSize),
+		flushReq: make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// ParseHeaders parses OTEL_EXPORTER_OTLP_HEADERS style "k1=v1,k2=v2" pairs.
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			// Values are usually credentials; report only the key.
+			return nil, fmt.Errorf("otlp header %q: expected <name>=<value>", strings.TrimSpace(k))
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers, nil
+}
+
+func (e *OTLPExporter) Export(span SpanData) {
+	select {
+	case e.queue <- span:
+	default:
+		e.mu.Lock()
+		e.dropped++
+		e.mu.Unlock()
+	}
+}
+
+// Shutdown flushes queued spans and stops the background worker. Later calls
+// are no-ops.
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	var err error
+	e.stopOnce.Do(func() {
+		ack := make(chan struct{})
+		select {
+		case e.flushReq <- ack:
+		case <-ctx.Done():
+			close(e.done)
+			err = ctx.Err()
+			return
+		}
+		select {
+		case <-ack:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	})
+	return err
+}
+
+// Check reports the outcome of the most recent export, so a readiness probe
+// shows an unreachable collector without sending anything itself. It is nil
+// until the first export.
+func (e *OTLPExporter) Check(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastErr
+}
+
+func (e *OTLPExporter) run() {
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+	batch := make([]SpanData, 0, otlpBatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.send(batch)
+			batch = batch[:0]
+		}
+		e.reportDropped()
+	}
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) == otlpBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case ack := <-e.flushReq:
+			for drained := false; !drained; {
+				select {
+				case span := <-e.queue:
+					batch = append(batch, span)
+					if len(batch) == otlpBatchSize {
+						flush()
+					}
+				default:
+					drained = true
+				}
+			}
+			flush()
+			close(ack)
+			return
+		case <-e.done:
+			return
+		}
+	}
+}
+
+func (e *OTLPExporter) reportDropped() {
+	e.mu.Lock()
+	dropped := e.dropped
+	e.dropped = 0
+	e.mu.Unlock()
+	if dropped > 0 {
+		e.onError(fmt.Errorf("otlp export queue full: dropped %d spans", dropped))
+	}
+}
+
+func (e *OTLPExporter) send(batch []SpanData) {
+	err := e.post(batch)
+	e.mu.Lock()
+	e.lastErr = err
+	e.mu.Unlock()
+	if err != nil {
+		e.onError(err)
+	}
+}
+
+func (e *OTLPExporter) post(batch []SpanData) error {
+	body, err := proto.Marshal(e.payload(batch))
+	if err != nil {
+		return fmt.Errorf("marshal otlp payload: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build otlp request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlp export: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp export: collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// payload builds the export request for batch.
+func (e *OTLPExporter) payload(batch []SpanData) *coltracepb.ExportTraceServiceRequest {
+	spans := make([]*tracepb.Span, 0, len(batch))
+	for _, s := range batch {
+		span := &tracepb.Span{
+			TraceId:           s.SpanContext.TraceID[:],
+			SpanId:            s.SpanContext.SpanID[:],
+			Name:              s.Name,
+			Kind:              tracepb.Span_SpanKind(s.Kind),
+			StartTimeUnixNano: uint64(s.Start.UnixNano()),
+			EndTimeUnixNano:   uint64(s.End.UnixNano()),
+			Attributes:        otlpAttributes(s.Attributes),
+		}
+		if s.ParentSpanID.IsValid() {
+			span.ParentSpanId = s.ParentSpanID[:]
+		}
+		if s.Err != "" {
+			span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: s.Err}
+		}
+		spans = append(spans, span)
+	}
+	return &coltracepb.ExportTraceServiceRequest{ResourceSpans: []*tracepb.ResourceSpans{{
+		Resource:   &resourcepb.Resource{Attributes: otlpAttributes([]Attribute{String("service.name", e.service)})},
+		ScopeSpans: []*tracepb.ScopeSpans{{Scope: &commonpb.InstrumentationScope{Name: instrumentation}, Spans: spans}},
+	}}}
+}
+
+func otlpAttributes(attrs []Attribute) []*commonpb.KeyValue {
+	out := make([]*commonpb.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		v := &commonpb.AnyValue{}
+		switch val := a.Value.(type) {
+		case string:
+			v.Value = &commonpb.AnyValue_StringValue{StringValue: val}
+		case bool:
+			v.Value = &commonpb.AnyValue_BoolValue{BoolValue: val}
+		case int64:
+			v.Value = &commonpb.AnyValue_IntValue{IntValue: val}
+		case int:
+			v.Value = &commonpb.AnyValue_IntValue{IntValue: int64(val)}
+		case float64:
+			v.Value = &commonpb.AnyValue_DoubleValue{DoubleValue: val}
+		default:
+			v.Value = &commonpb.AnyValue_StringValue{StringValue: fmt.Sprint(val)}
+		}
+		out = append(out, &commonpb.KeyValue{Key: a.Key, Value: v})
+	}
+	return out
+}
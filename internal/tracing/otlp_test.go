@@ -0,0 +1,175 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTracerExportsSampledSpans(t *testing.T) {
+	exp := NewInMemoryExporter()
+	tracer := NewTracer(exp, 1)
+	ctx, root := tracer.Start(context.Background(), "GET /v1/customers/{id}", KindServer, String("http.method", "GET"))
+	_, child := tracer.Start(ctx, "customers.get", KindClient, Int("db.rows", 1))
+	child.RecordError(errors.New("no rows"))
+	child.End()
+	child.End()
+	root.End()
+
+	spans := exp.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	c, r := spans[0], spans[1]
+	if c.Name != "customers.get" || r.Name != "GET /v1/customers/{id}" {
+		t.Fatalf("spans exported as %q, %q; want the child first", c.Name, r.Name)
+	}
+	if c.SpanContext.TraceID != r.SpanContext.TraceID || c.ParentSpanID != r.SpanContext.SpanID {
+		t.Errorf("child %+v is not in the root's trace under the root %+v", c.SpanContext, r.SpanContext)
+	}
+	if r.ParentSpanID.IsValid() {
+		t.Errorf("root has parent %s", r.ParentSpanID)
+	}
+	if c.Err != "no rows" || r.Err != "" {
+		t.Errorf("errors %q, %q", c.Err, r.Err)
+	}
+	if c.End.Before(c.Start) {
+		t.Errorf("child ended at %v before it started at %v", c.End, c.Start)
+	}
+
+	exp.Reset()
+	_, s := NewTracer(exp, 0).Start(context.Background(), "unsampled", KindInternal)
+	s.End()
+	if got := exp.Spans(); len(got) != 0 {
+		t.Errorf("unsampled trace exported %d spans", len(got))
+	}
+}
+
+func TestOTLPExportRequest(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-protobuf" {
+			t.Errorf("Content-Type %q", ct)
+		}
+		if key := r.Header.Get("X-Api-Key"); key != "secret" {
+			t.Errorf("X-Api-Key %q", key)
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer collector.Close()
+
+	recorded := NewInMemoryExporter()
+	tracer := NewTracer(recorded, 1)
+	ctx, root := tracer.Start(context.Background(), "POST /v1/customers", KindServer,
+		String("http.method", "POST"), Int("http.status_code", 201), Bool("retry", false), Attribute{Key: "ratio", Value: 0.5})
+	_, child := tracer.Start(ctx, "customers.insert", KindClient)
+	child.RecordError(errors.New("duplicate key"))
+	child.End()
+	root.End()
+
+	var exportErr error
+	exp := NewOTLPExporter(collector.URL, map[string]string{"X-Api-Key": "secret"}, "customer-service", func(err error) { exportErr = err })
+	for _, s := range recorded.Spans() {
+		exp.Export(s)
+	}
+	if err := exp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if exportErr != nil {
+		t.Fatalf("export: %v", exportErr)
+	}
+
+	var req coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(<-bodies, &req); err != nil {
+		t.Fatalf("payload is not an OTLP export request: %v", err)
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("payload %v, want one resource with one scope", &req)
+	}
+	rs := req.ResourceSpans[0]
+	if attrs := rs.Resource.GetAttributes(); len(attrs) != 1 || attrs[0].Key != "service.name" || attrs[0].Value.GetStringValue() != "customer-service" {
+		t.Errorf("resource attributes %v", attrs)
+	}
+	if name := rs.ScopeSpans[0].GetScope().GetName(); name != instrumentation {
+		t.Errorf("scope %q", name)
+	}
+	got := rs.ScopeSpans[0].Spans
+	if len(got) != 2 {
+		t.Fatalf("payload has %d spans, want 2", len(got))
+	}
+	for i, want := range recorded.Spans() {
+		s := got[i]
+		if s.Name != want.Name || s.Kind != tracepb.Span_SpanKind(want.Kind) {
+			t.Errorf("span %d: %s kind %s, want %s kind %d", i, s.Name, s.Kind, want.Name, want.Kind)
+		}
+		if hex.EncodeToString(s.TraceId) != want.SpanContext.TraceID.String() || hex.EncodeToString(s.SpanId) != want.SpanContext.SpanID.String() {
+			t.Errorf("span %d: ids %x/%x, want %s/%s", i, s.TraceId, s.SpanId, want.SpanContext.TraceID, want.SpanContext.SpanID)
+		}
+		if s.StartTimeUnixNano != uint64(want.Start.UnixNano()) || s.EndTimeUnixNano != uint64(want.End.UnixNano()) {
+			t.Errorf("span %d: times %d-%d, want %v-%v", i, s.StartTimeUnixNano, s.EndTimeUnixNano, want.Start, want.End)
+		}
+	}
+	exportedChild, exportedRoot := got[0], got[1]
+	if len(exportedRoot.ParentSpanId) != 0 {
+		t.Errorf("root has parent %x", exportedRoot.ParentSpanId)
+	}
+	if hex.EncodeToString(exportedChild.ParentSpanId) != hex.EncodeToString(exportedRoot.SpanId) {
+		t.Errorf("child parent %x, want %x", exportedChild.ParentSpanId, exportedRoot.SpanId)
+	}
+	if st := exportedChild.GetStatus(); st.GetCode() != tracepb.Status_STATUS_CODE_ERROR || st.GetMessage() != "duplicate key" {
+		t.Errorf("child status %v", st)
+	}
+	if exportedRoot.Status != nil {
+		t.Errorf("root status %v, want unset", exportedRoot.Status)
+	}
+	attrs := map[string]any{}
+	for _, kv := range exportedRoot.Attributes {
+		switch v := kv.Value.Value.(type) {
+		case *commonpb.AnyValue_StringValue:
+			attrs[kv.Key] = v.StringValue
+		case *commonpb.AnyValue_IntValue:
+			attrs[kv.Key] = v.IntValue
+		case *commonpb.AnyValue_BoolValue:
+			attrs[kv.Key] = v.BoolValue
+		case *commonpb.AnyValue_DoubleValue:
+			attrs[kv.Key] = v.DoubleValue
+		}
+	}
+	want := map[string]any{"http.method": "POST", "http.status_code": int64(201), "retry": false, "ratio": 0.5}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("attribute %s = %#v, want %#v", k, attrs[k], v)
+		}
+	}
+}
+
+func TestOTLPExporterCheckReportsLastExport(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	exp := NewOTLPExporter(collector.URL, nil, "customer-service", nil)
+	if err := exp.Check(context.Background()); err != nil {
+		t.Fatalf("Check before any export: %v", err)
+	}
+	tracer := NewTracer(exp, 1)
+	_, span := tracer.Start(context.Background(), "GET /livez", KindServer)
+	span.End()
+	if err := exp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := exp.Check(context.Background()); err == nil {
+		t.Fatal("Check after a rejected export returned nil")
+	}
+}
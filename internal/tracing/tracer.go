@@ -0,0 +1,174 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// SpanKind follows the OpenTelemetry span kinds.
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// Attribute is a key/value pair attached to a span. Values are strings,
+// bools, ints, int64s or float64s.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// SpanData is a finished span as handed to an Exporter.
+type SpanData struct {
+	Name         string
+	Kind         SpanKind
+	SpanContext  SpanContext
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Attributes   []Attribute
+	// Err is the error message when the operation failed; empty otherwise.
+	Err string
+}
+
+// Exporter receives finished, sampled spans. Export must not block.
+type Exporter interface {
+	Export(span SpanData)
+	Shutdown(ctx context.Context) error
+}
+
+// Tracer starts spans and hands sampled ones to its exporter. A nil *Tracer
+// is valid and starts no spans, so components can be built without tracing.
+type Tracer struct {
+	exporter Exporter
+	// threshold is the sampling ratio scaled to the uint64 range.
+	threshold uint64
+	always    bool
+}
+
+// NewTracer returns a tracer that samples sampleRatio of new traces. Requests
+// arriving with a traceparent follow the caller's sampling decision.
+func NewTracer(exporter Exporter, sampleRatio float64) *Tracer {
+	t := &Tracer{exporter: exporter}
+	switch {
+	case sampleRatio >= 1:
+		t.always = true
+	case sampleRatio > 0:
+		t.threshold = uint64(sampleRatio * (1 << 63) * 2)
+	}
+	return t
+}
+
+func (t *Tracer) sample(id TraceID) bool {
+	return t.always || binary.BigEndian.Uint64(id[8:]) < t.threshold
+}
+
+// Start begins a span as a child of the span in ctx, or as the root of a new
+// trace. The returned context carries the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sample(sc.TraceID)
+	}
+	s := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:         name,
+			Kind:         kind,
+			SpanContext:  sc,
+			ParentSpanID: parent.SpanID,
+			Start:        time.Now(),
+			Attributes:   attrs,
+		},
+	}
+	return ContextWithSpan(ctx, s), s
+}
+
+// Shutdown flushes spans still buffered by the exporter.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.exporter.Shutdown(ctx)
+}
+
+// Span is an operation in progress. All methods are safe on a nil *Span.
+type Span struct {
+	tracer *Tracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Name = name
+	s.mu.Unlock()
+}
+
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+	s.mu.Unlock()
+}
+
+// RecordError marks the span as failed. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Err = err.Error()
+	s.mu.Unlock()
+}
+
+// End finishes the span. Only the first call has an effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	if data.SpanContext.Sampled {
+		s.tracer.exporter.Export(data)
+	}
+}
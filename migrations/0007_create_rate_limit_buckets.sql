@@ -0,0 +1,10 @@
+-- Token buckets shared by all replicas when RATE_LIMIT_STORE=postgres
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    bucket_key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
+
+-- Supports the periodic sweep of idle buckets
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at
+    ON rate_limit_buckets (updated_at);
@@ -0,0 +1,29 @@
+-- Application-level encryption of PII.
+-- *_enc columns hold envelope-encrypted values ("pii:v1:<key id>:..."),
+-- *_bidx columns hold HMAC blind indexes used for uniqueness and lookups.
+-- Legacy plaintext columns are kept nullable so rows can be migrated in place
+-- by `customer-service reencrypt`.
+
+ALTER TABLE customers
+    ADD COLUMN IF NOT EXISTS email_enc TEXT,
+    ADD COLUMN IF NOT EXISTS email_bidx VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS phone_enc TEXT,
+    ADD COLUMN IF NOT EXISTS phone_bidx VARCHAR(64);
+
+ALTER TABLE customers ALTER COLUMN email DROP NOT NULL;
+ALTER TABLE customers ALTER COLUMN phone DROP NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_customers_email_bidx
+    ON customers (email_bidx)
+    WHERE deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_customers_phone_bidx
+    ON customers (phone_bidx)
+    WHERE deleted_at IS NULL;
+
+ALTER TABLE verifications
+    ADD COLUMN IF NOT EXISTS pan_number_enc TEXT,
+    ADD COLUMN IF NOT EXISTS pan_number_bidx VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_verifications_pan_number_bidx
+    ON verifications (pan_number_bidx);
@@ -0,0 +1,18 @@
+-- Append-only record of every attempt to reveal a full PAN.
+-- No foreign key to customers: the log must outlive the customer record.
+CREATE TABLE IF NOT EXISTS pan_access_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id UUID NOT NULL,
+    principal VARCHAR(100) NOT NULL,
+    request_id VARCHAR(100) NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    outcome VARCHAR(20) NOT NULL,  -- allowed: GRANTED, DENIED, NOT_FOUND
+    accessed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_pan_access_log_customer_id
+    ON pan_access_log (customer_id, accessed_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_pan_access_log_principal
+    ON pan_access_log (principal, accessed_at DESC);
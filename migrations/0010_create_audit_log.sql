@@ -0,0 +1,25 @@
+-- Append-only access audit trail: who read or changed which customer, when.
+-- resource_id is text (not a foreign key) so rejected requests with malformed
+-- IDs are recorded too and entries outlive the records they describe.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    actor VARCHAR(100) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    resource_type VARCHAR(50) NOT NULL,
+    resource_id VARCHAR(100) NOT NULL DEFAULT '',
+    request_id VARCHAR(100) NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '',
+    outcome VARCHAR(20) NOT NULL,  -- allowed: SUCCESS, FAILURE, DENIED, NOT_FOUND, INVALID
+    changes JSONB,                 -- {"field": {"before": ..., "after": ...}} with PII masked
+    metadata JSONB
+    );
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_resource_id
+    ON audit_log (resource_id, occurred_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor
+    ON audit_log (actor, occurred_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_occurred_at
+    ON audit_log (occurred_at DESC);
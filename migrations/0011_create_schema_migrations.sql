@@ -0,0 +1,12 @@
+-- Records applied migrations so readiness can verify the schema matches the
+-- binary. Every migration from here on ends by inserting its own version, and
+-- db.SchemaVersion is bumped to match.
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
+
+-- Migrations 0001-0010 predate this table; running 0011 implies they ran.
+INSERT INTO schema_migrations (version)
+SELECT generate_series(1, 11)
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,13 @@
+-- Feature flag state shared by all replicas when FLAGS_PROVIDER=postgres.
+-- Flags without a row are off.
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key VARCHAR(100) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    rollout SMALLINT NOT NULL DEFAULT 0 CHECK (rollout BETWEEN 0 AND 100),
+    tenants TEXT[] NOT NULL DEFAULT '{}',
+    principals TEXT[] NOT NULL DEFAULT '{}',
+    updated_by VARCHAR(100) NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
+
+INSERT INTO schema_migrations (version) VALUES (12) ON CONFLICT DO NOTHING;
@@ -0,0 +1,65 @@
+-- Customers belong to a tenant (business unit). Existing rows move to the
+-- default tenant, and email, phone and PAN become unique per tenant instead
+-- of globally. Verifications, PAN access and audit entries carry the tenant of
+-- the customer they concern so every query can be scoped by it.
+ALTER TABLE customers
+    ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE verifications
+    ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE pan_access_log
+    ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE audit_log
+    ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+
+-- A verification always belongs to the same tenant as its customer.
+CREATE UNIQUE INDEX IF NOT EXISTS ux_customers_id_tenant
+    ON customers (id, tenant_id);
+DO $$
+BEGIN
+    ALTER TABLE verifications
+        ADD CONSTRAINT fk_verifications_customer_tenant
+            FOREIGN KEY (customer_id, tenant_id) REFERENCES customers (id, tenant_id) ON DELETE CASCADE;
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+-- Per-tenant uniqueness (ignoring soft-deleted rows)
+DROP INDEX IF EXISTS ux_customers_email;
+DROP INDEX IF EXISTS ux_customers_phone;
+DROP INDEX IF EXISTS ux_customers_email_bidx;
+DROP INDEX IF EXISTS ux_customers_phone_bidx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_customers_tenant_email
+    ON customers (tenant_id, lower(email))
+    WHERE deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_customers_tenant_phone
+    ON customers (tenant_id, phone)
+    WHERE deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_customers_tenant_email_bidx
+    ON customers (tenant_id, email_bidx)
+    WHERE deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_customers_tenant_phone_bidx
+    ON customers (tenant_id, phone_bidx)
+    WHERE deleted_at IS NULL;
+
+ALTER TABLE verifications DROP CONSTRAINT IF EXISTS unique_pan_number;
+ALTER TABLE verifications DROP CONSTRAINT IF EXISTS verifications_pan_number_key;
+DROP INDEX IF EXISTS ux_verifications_pan_number_bidx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_verifications_tenant_pan_number
+    ON verifications (tenant_id, pan_number);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_verifications_tenant_pan_number_bidx
+    ON verifications (tenant_id, pan_number_bidx);
+
+-- Listing is always within one tenant, newest first
+CREATE INDEX IF NOT EXISTS idx_customers_tenant_created_at
+    ON customers (tenant_id, created_at DESC)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_tenant_occurred_at
+    ON audit_log (tenant_id, occurred_at DESC);
+
+INSERT INTO schema_migrations (version) VALUES (13) ON CONFLICT DO NOTHING;
@@ -0,0 +1,8 @@
+-- Until the verification record created with each customer was written as
+-- StatusPending, it was written with the status 'Pending'. Every other path
+-- uses 'PENDING', so those customers reported a status outside the documented
+-- enum, were missed by ?status=PENDING filters and failed response
+-- validation. Rewrite them; the code no longer produces 'Pending'.
+UPDATE verifications SET status = 'PENDING' WHERE status = 'Pending';
+
+INSERT INTO schema_migrations (version) VALUES (14) ON CONFLICT DO NOTHING;
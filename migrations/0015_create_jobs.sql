@@ -0,0 +1,41 @@
+-- Background jobs, such as customer imports. A worker claims a queued job,
+-- processes its input in chunks and checkpoints progress after each one; a
+-- running job whose heartbeat has gone stale, e.g. because its process
+-- stopped, is claimed again and resumes from the last checkpoint.
+CREATE TABLE IF NOT EXISTS jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id VARCHAR(63) NOT NULL,
+    kind VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',  -- allowed: queued, running, succeeded, failed
+    format VARCHAR(20) NOT NULL,
+    input BYTEA,  -- cleared once the job finishes
+    input_encrypted BOOLEAN NOT NULL DEFAULT false,
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    created_rows INTEGER NOT NULL DEFAULT 0,
+    failed_rows INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_by VARCHAR(100) NOT NULL,
+    request_id VARCHAR(100) NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    heartbeat_at TIMESTAMPTZ
+    );
+
+CREATE INDEX IF NOT EXISTS idx_jobs_pending
+    ON jobs (created_at) WHERE status IN ('queued', 'running');
+
+-- Rows of a job's input that failed, keyed by their zero-based position.
+CREATE TABLE IF NOT EXISTS job_errors (
+    job_id UUID NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+    row_index INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,  -- allowed: invalid, conflict
+    error TEXT NOT NULL,
+    PRIMARY KEY (job_id, row_index)
+    );
+
+INSERT INTO schema_migrations (version) VALUES (15) ON CONFLICT DO NOTHING;
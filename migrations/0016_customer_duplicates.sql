@@ -0,0 +1,26 @@
+-- Duplicate detection and merging. Suspected duplicates are found by
+-- trigram similarity of names and by equal phone digits; a merged customer
+-- is soft-deleted and points at the customer it was merged into. pg_trgm
+-- ships with PostgreSQL but creating it needs the CREATE privilege on the
+-- database.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE customers
+    ADD COLUMN IF NOT EXISTS merged_into UUID REFERENCES customers(id);
+
+CREATE INDEX IF NOT EXISTS idx_customers_merged_into
+    ON customers (merged_into)
+    WHERE merged_into IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_customers_name_trgm
+    ON customers USING gin (name gin_trgm_ops)
+    WHERE deleted_at IS NULL;
+
+-- The last ten digits of a plaintext phone, so "+91 98765 43210" and
+-- "9876543210" meet. Encrypted phones are NULL here and are compared after
+-- decryption instead.
+CREATE INDEX IF NOT EXISTS idx_customers_tenant_phone_digits
+    ON customers (tenant_id, right(regexp_replace(phone, '[^0-9]', '', 'g'), 10))
+    WHERE deleted_at IS NULL AND phone IS NOT NULL;
+
+INSERT INTO schema_migrations (version) VALUES (16) ON CONFLICT DO NOTHING;
@@ -0,0 +1,29 @@
+-- Postal addresses of customers, for KYC. A customer has any number of
+-- addresses of each type, at most one of them primary.
+CREATE TABLE IF NOT EXISTS addresses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id VARCHAR(63) NOT NULL,
+    customer_id UUID NOT NULL,
+    type VARCHAR(20) NOT NULL,  -- allowed: residential, correspondence, permanent
+    line1 VARCHAR(200) NOT NULL,
+    line2 VARCHAR(200) NOT NULL DEFAULT '',
+    city VARCHAR(100) NOT NULL,
+    state VARCHAR(100) NOT NULL,
+    pin_code CHAR(6) NOT NULL,
+    is_primary BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT fk_addresses_customer_tenant
+        FOREIGN KEY (customer_id, tenant_id) REFERENCES customers (id, tenant_id) ON DELETE CASCADE,
+    CONSTRAINT ck_addresses_type CHECK (type IN ('residential', 'correspondence', 'permanent')),
+    CONSTRAINT ck_addresses_pin_code CHECK (pin_code ~ '^[1-9][0-9]{5}$')
+    );
+
+CREATE INDEX IF NOT EXISTS idx_addresses_customer
+    ON addresses (customer_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_addresses_primary
+    ON addresses (customer_id)
+    WHERE is_primary;
+
+INSERT INTO schema_migrations (version) VALUES (17) ON CONFLICT DO NOTHING;
@@ -0,0 +1,16 @@
+-- Optional onboarding details of a customer. NULL means not provided; the
+-- service validates values, the checks only keep the columns well formed.
+ALTER TABLE customers
+    ADD COLUMN IF NOT EXISTS date_of_birth DATE,
+    ADD COLUMN IF NOT EXISTS gender VARCHAR(20),  -- allowed: female, male, other, undisclosed
+    ADD COLUMN IF NOT EXISTS nationality CHAR(2),  -- ISO 3166-1 alpha-2
+    ADD COLUMN IF NOT EXISTS occupation VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS preferred_language VARCHAR(12);  -- e.g. en, hi-IN
+
+ALTER TABLE customers
+    DROP CONSTRAINT IF EXISTS ck_customers_gender,
+    ADD CONSTRAINT ck_customers_gender CHECK (gender IN ('female', 'male', 'other', 'undisclosed')),
+    DROP CONSTRAINT IF EXISTS ck_customers_nationality,
+    ADD CONSTRAINT ck_customers_nationality CHECK (nationality ~ '^[A-Z]{2}$');
+
+INSERT INTO schema_migrations (version) VALUES (18) ON CONFLICT DO NOTHING;
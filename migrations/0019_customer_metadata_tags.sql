@@ -0,0 +1,24 @@
+-- Attributes attached by API consumers without schema changes: a metadata
+-- object and a set of tags. The service enforces the key, size and tag
+-- format limits; the tag count is also checked here because tags are added
+-- in place.
+ALTER TABLE customers
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE customers
+    DROP CONSTRAINT IF EXISTS ck_customers_metadata,
+    ADD CONSTRAINT ck_customers_metadata CHECK (jsonb_typeof(metadata) = 'object'),
+    DROP CONSTRAINT IF EXISTS ck_customers_tags,
+    ADD CONSTRAINT ck_customers_tags CHECK (cardinality(tags) <= 50);
+
+-- Containment (@>) lookups for ?metadata.key=value and ?tag= filters.
+CREATE INDEX IF NOT EXISTS idx_customers_metadata
+    ON customers USING gin (metadata jsonb_path_ops)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_customers_tags
+    ON customers USING gin (tags)
+    WHERE deleted_at IS NULL;
+
+INSERT INTO schema_migrations (version) VALUES (19) ON CONFLICT DO NOTHING;
@@ -0,0 +1,32 @@
+-- Proof that a customer owns their email address and phone number. A
+-- verified_at column is set when the customer confirms a one-time code sent
+-- to the contact and cleared when the contact changes.
+ALTER TABLE customers
+    ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMPTZ;
+
+-- The pending code of each customer and channel; requesting a new code
+-- replaces it. Codes and the contact they were sent to are stored only as
+-- hex HMACs keyed by a server secret and salted with the row ID. The row
+-- outlives rejected and expired codes so the codes sent and the incorrect
+-- codes entered since window_start carry over to the next code.
+CREATE TABLE IF NOT EXISTS contact_verifications (
+    id UUID NOT NULL,
+    tenant_id VARCHAR(63) NOT NULL,
+    customer_id UUID NOT NULL,
+    channel VARCHAR(10) NOT NULL,  -- allowed: email, phone
+    code_hash TEXT NOT NULL,
+    destination_hash TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL,
+    sends INT NOT NULL DEFAULT 1,
+    window_start TIMESTAMPTZ NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (customer_id, channel),
+    CONSTRAINT fk_contact_verifications_customer_tenant
+        FOREIGN KEY (customer_id, tenant_id) REFERENCES customers (id, tenant_id) ON DELETE CASCADE,
+    CONSTRAINT ck_contact_verifications_channel CHECK (channel IN ('email', 'phone'))
+    );
+
+INSERT INTO schema_migrations (version) VALUES (20) ON CONFLICT DO NOTHING;
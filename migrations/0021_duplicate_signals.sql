@@ -0,0 +1,30 @@
+-- Duplicate signals that survive encryption. phone_digits_bidx is a blind
+-- index of the last ten digits of the phone, written whenever PII_KEYS is
+-- set and backfilled by `customer-service reencrypt`, so encrypted phones
+-- are paired like the plaintext ones in idx_customers_tenant_phone_digits.
+ALTER TABLE customers
+    ADD COLUMN IF NOT EXISTS phone_digits_bidx VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_customers_tenant_phone_digits_bidx
+    ON customers (tenant_id, phone_digits_bidx)
+    WHERE deleted_at IS NULL AND phone_digits_bidx IS NOT NULL;
+
+-- PANs are unique per tenant, so two customers never hold the same one.
+-- Instead, a customer refused a PAN because another customer holds it is
+-- recorded here and reported as a duplicate of that customer.
+CREATE TABLE IF NOT EXISTS pan_claims (
+    tenant_id VARCHAR(63) NOT NULL,
+    customer_id UUID NOT NULL,
+    holder_id UUID NOT NULL,
+    claimed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (customer_id, holder_id),
+    CONSTRAINT fk_pan_claims_customer_tenant
+        FOREIGN KEY (customer_id, tenant_id) REFERENCES customers (id, tenant_id) ON DELETE CASCADE,
+    CONSTRAINT fk_pan_claims_holder_tenant
+        FOREIGN KEY (holder_id, tenant_id) REFERENCES customers (id, tenant_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_pan_claims_tenant
+    ON pan_claims (tenant_id);
+
+INSERT INTO schema_migrations (version) VALUES (21) ON CONFLICT DO NOTHING;
@@ -0,0 +1,7 @@
+-- A job returned to the queue after a failed attempt waits until run_after
+-- before it is claimed again, so a failing job backs off instead of being
+-- retried at once. NULL means the job can run now.
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS run_after TIMESTAMPTZ;
+
+INSERT INTO schema_migrations (version) VALUES (22) ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+-- Listings and exports record the customers they returned under
+-- metadata.customer_ids, and audit queries by customer match them there.
+CREATE INDEX IF NOT EXISTS idx_audit_log_customer_ids
+    ON audit_log USING gin ((metadata->'customer_ids'));
+
+INSERT INTO schema_migrations (version) VALUES (23) ON CONFLICT DO NOTHING;
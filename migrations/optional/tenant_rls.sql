@@ -0,0 +1,50 @@
+-- Optional: enforce tenant isolation in PostgreSQL as well as in the service.
+-- Apply after 0021 and run the service with DB_TENANT_RLS=true, connected as a
+-- role that does not own these tables (owners and superusers bypass row-level
+-- security). The service sets app.tenant_id on each connection it uses; a
+-- connection without it sees no rows. Run `customer-service reencrypt` and
+-- migrations as the owning role, since they work across tenants. jobs and
+-- job_errors are left out because the job worker claims jobs across tenants;
+-- the service still scopes every request for them to its tenant.
+ALTER TABLE customers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE verifications ENABLE ROW LEVEL SECURITY;
+ALTER TABLE pan_access_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE addresses ENABLE ROW LEVEL SECURITY;
+ALTER TABLE contact_verifications ENABLE ROW LEVEL SECURITY;
+ALTER TABLE pan_claims ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON customers;
+CREATE POLICY tenant_isolation ON customers
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON verifications;
+CREATE POLICY tenant_isolation ON verifications
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON pan_access_log;
+CREATE POLICY tenant_isolation ON pan_access_log
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON audit_log;
+CREATE POLICY tenant_isolation ON audit_log
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON addresses;
+CREATE POLICY tenant_isolation ON addresses
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON contact_verifications;
+CREATE POLICY tenant_isolation ON contact_verifications
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+DROP POLICY IF EXISTS tenant_isolation ON pan_claims;
+CREATE POLICY tenant_isolation ON pan_claims
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
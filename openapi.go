@@ -0,0 +1,9 @@
+// Package customerservice holds assets shipped inside the service binary.
+package customerservice
+
+import _ "embed"
+
+// OpenAPI is the API description in openapi.yaml.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
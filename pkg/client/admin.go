@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ListAuditEntries returns one page of the tenant's audit trail. It needs an
+// API key with the audit:read scope.
+func (c *Client) ListAuditEntries(ctx context.Context, f AuditFilter) (*AuditList, error) {
+	q := f.values()
+	if f.CustomerID != "" {
+		q.Set("customer_id", f.CustomerID)
+	}
+	if f.Actor != "" {
+		q.Set("actor", f.Actor)
+	}
+	if !f.From.IsZero() {
+		q.Set("from", f.From.Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		q.Set("to", f.To.Format(time.RFC3339))
+	}
+	var out AuditList
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/audit", query: q}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+// LogLevel returns the service's current log level. It needs an API key with
+// the admin:write scope, as do the other admin calls.
+func (c *Client) LogLevel(ctx context.Context) (string, error) {
+	var out logLevel
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/log-level"}, &out); err != nil {
+		return "", err
+	}
+	return out.Level, nil
+}
+
+// SetLogLevel changes the log level until the process restarts or LOG_LEVEL
+// changes, and returns the level now in effect.
+func (c *Client) SetLogLevel(ctx context.Context, level string) (string, error) {
+	var out logLevel
+	if err := c.do(ctx, request{method: http.MethodPut, path: "/admin/log-level", body: logLevel{Level: level}}, &out); err != nil {
+		return "", err
+	}
+	return out.Level, nil
+}
+
+// ListFlags returns every feature flag the service consults.
+func (c *Client) ListFlags(ctx context.Context) ([]Flag, error) {
+	var out struct {
+		Data []Flag `json:"data"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/flags"}, &out); err != nil {
+		return nil, err
+	}
+	return out.Data, nil
+}
+
+// SetFlag replaces a flag's rollout state and returns the stored flag.
+func (c *Client) SetFlag(ctx context.Context, key string, update FlagUpdate) (*Flag, error) {
+	var out Flag
+	if err := c.do(ctx, request{method: http.MethodPut, path: "/admin/flags/" + url.PathEscape(key), body: update}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Live reports whether the process is up.
+func (c *Client) Live(ctx context.Context) error {
+	return c.do(ctx, request{method: http.MethodGet, path: "/livez", noRetry: true}, nil)
+}
+
+// Ready returns the readiness report. A service that is not ready answers
+// with a report whose Status is not "ok" rather than an error.
+func (c *Client) Ready(ctx context.Context) (*Readiness, error) {
+	var out Readiness
+	r := request{method: http.MethodGet, path: "/readyz", ok: []int{http.StatusOK, http.StatusServiceUnavailable}, noRetry: true}
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
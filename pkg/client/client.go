@@ -0,0 +1,265 @@
+// Package client is a typed Go client for the customer service HTTP API
+// described in openapi.yaml.
+//
+// GET, PUT and DELETE calls that fail with 429 or a 5xx status, or that do
+// not reach the server, are retried with exponential backoff. POST and PATCH
+// calls are not idempotent and are only retried on 429, which the service
+// returns before acting on a request. Every attempt of one call sends the
+// same X-Request-ID, taken from the context (see WithRequestID) or
+// generated.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyHeader       = "X-API-Key"
+	tenantHeader       = "X-Tenant-ID"
+	requestIDHeader    = "X-Request-ID"
+	accessReasonHeader = "X-Access-Reason"
+	userAgent          = "customer-service-go-client"
+)
+
+// Options configures a Client. Zero values select the defaults.
+type Options struct {
+	// HTTPClient sends requests; nil uses a client with a 30s timeout.
+	HTTPClient *http.Client
+	// APIKey is sent as X-API-Key; empty calls the API anonymously.
+	APIKey string
+	// Tenant is sent as X-Tenant-ID unless the context names one.
+	Tenant string
+	// Retry controls retries of failed calls.
+	Retry RetryPolicy
+}
+
+// RetryPolicy bounds retries. Each delay is drawn uniformly from zero to
+// BaseDelay doubled per attempt, capped at MaxDelay. A Retry-After longer
+// than MaxDelay ends retrying.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt; 1 disables retries. Default 3.
+	MaxAttempts int
+	// BaseDelay defaults to 200ms.
+	BaseDelay time.Duration
+	// MaxDelay defaults to 5s.
+	MaxDelay time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 3
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 200 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 5 * time.Second
+	}
+	return p
+}
+
+// backoff returns the delay before retry number attempt, starting at 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return rand.N(d) + 1
+}
+
+// Client calls the customer service. It is safe for concurrent use.
+type Client struct {
+	base   *url.URL
+	http   *http.Client
+	apiKey string
+	tenant string
+	retry  RetryPolicy
+}
+
+// New returns a client for the service at baseURL, e.g.
+// "http://customer-service".
+func New(baseURL string, opts Options) (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("client: invalid base URL %q", baseURL)
+	}
+	hc := opts.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{
+		base:   base,
+		http:   hc,
+		apiKey: opts.APIKey,
+		tenant: opts.Tenant,
+		retry:  opts.Retry.withDefaults(),
+	}, nil
+}
+
+// request describes one API call.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+	// ok lists the statuses decoded into the result; 2xx when empty.
+	ok []int
+	// noRetry disables retries, e.g. for probes whose failures are answers.
+	noRetry bool
+}
+
+func (r *request) success(status int) bool {
+	if len(r.ok) == 0 {
+		return status >= 200 && status < 300
+	}
+	for _, s := range r.ok {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// do performs r, retrying as the package documentation describes, and
+// decodes a successful response into out unless it is nil or the response
+// has no body. A *[]byte out receives the body as is.
+func (c *Client) do(ctx context.Context, r request, out any) error {
+	resp, err := c.open(ctx, r)
+	if err != nil {
+		return err
+	}
+	return decode(resp, out)
+}
+
+// open performs r like do but returns the successful response unread; the
+// caller closes its body.
+func (c *Client) open(ctx context.Context, r request) (*http.Response, error) {
+	var payload []byte
+	if r.body != nil {
+		var err error
+		if payload, err = json.Marshal(r.body); err != nil {
+			return nil, fmt.Errorf("client: encode request: %w", err)
+		}
+	}
+	requestID := RequestIDFromContext(ctx)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	idempotent := r.method == http.MethodGet || r.method == http.MethodPut || r.method == http.MethodDelete
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, r, payload, requestID)
+		var delay time.Duration
+		retryable := !r.noRetry
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			retryable = retryable && idempotent
+			delay = c.retry.backoff(attempt)
+		case r.success(resp.StatusCode):
+			return resp, nil
+		default:
+			apiErr := newError(resp, requestID)
+			if !retryableStatus(resp.StatusCode) {
+				return nil, apiErr
+			}
+			retryable = retryable && (idempotent || resp.StatusCode == http.StatusTooManyRequests)
+			err = apiErr
+			delay = max(apiErr.RetryAfter, c.retry.backoff(attempt))
+		}
+		if !retryable || attempt >= c.retry.MaxAttempts || delay > c.retry.MaxDelay {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, r request, payload []byte, requestID string) (*http.Response, error) {
+	u := c.base.JoinPath(r.path)
+	if len(r.query) > 0 {
+		u.RawQuery = r.query.Encode()
+	}
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("client: build request: %w", err)
+	}
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(requestIDHeader, requestID)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+	if tenant := tenantFromContext(ctx, c.tenant); tenant != "" {
+		req.Header.Set(tenantHeader, tenant)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("client: %s %s: %w", r.method, r.path, err)
+	}
+	return resp, nil
+}
+
+func decode(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if raw, ok := out.(*[]byte); ok {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("client: read response: %w", err)
+		}
+		*raw = data
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("client: decode response: %w", err)
+	}
+	return nil
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// retryAfter parses a Retry-After header given in seconds.
+func retryAfter(h http.Header) time.Duration {
+	s, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil || s < 0 {
+		return 0
+	}
+	return time.Duration(s) * time.Second
+}
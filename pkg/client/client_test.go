@@ -0,0 +1,384 @@
+package client
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Archiit19/customer-service-go/internal/customer"
+	"github.com/Archiit19/customer-service-go/internal/customer/customertest"
+	"github.com/Archiit19/customer-service-go/internal/flags"
+	httph "github.com/Archiit19/customer-service-go/internal/http"
+	"github.com/Archiit19/customer-service-go/internal/http/routertest"
+	"github.com/Archiit19/customer-service-go/internal/jobs"
+	"github.com/Archiit19/customer-service-go/internal/logger"
+)
+
+// newRouter returns the service's real router over in-memory stores, with a
+// job runner working in the background until the test ends.
+func newRouter(t *testing.T) (http.Handler, *customertest.Notifier) {
+	t.Helper()
+	r := routertest.New(t, httph.Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = jobs.NewRunner(r.Jobs, r.Service, logger.NewNop(), 100, time.Minute).Run(ctx, 10*time.Millisecond)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return r, r.Notifier
+}
+
+func newClient(t *testing.T, srv *httptest.Server, key string) *Client {
+	t.Helper()
+	c, err := New(srv.URL, Options{APIKey: key, Retry: RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func check(t *testing.T, what string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+}
+
+func TestClientCustomerLifecycle(t *testing.T) {
+	h, notifier := newRouter(t)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	c := newClient(t, srv, routertest.OpsKey)
+	ctx := context.Background()
+
+	asha, err := c.CreateCustomer(ctx, CreateCustomerRequest{
+		Name: "Asha Rao", Email: "asha@example.com", Phone: "+919876543210",
+		Gender: GenderFemale, Metadata: map[string]any{"segment": "retail"}, Tags: []string{"new"},
+	})
+	check(t, "CreateCustomer", err)
+	if asha.ID == "" || asha.Status != "PENDING" || asha.Gender != GenderFemale || asha.Links.Self != "/v1/customers/"+asha.ID {
+		t.Fatalf("created customer: %+v", asha)
+	}
+	if _, err := c.CreateCustomer(ctx, CreateCustomerRequest{Name: "Asha Rao", Email: "asha@example.com", Phone: "+919876543210"}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("duplicate create: got %v, want ErrConflict", err)
+	}
+	var apiErr *Error
+	if _, err := c.CreateCustomer(ctx, CreateCustomerRequest{Name: "Asha", Email: "not-an-email", Phone: "+919876543210"}); !errors.As(err, &apiErr) || apiErr.StatusCode/100 != 4 || apiErr.Message == "" {
+		t.Fatalf("invalid create: got %v, want a 4xx with a message", err)
+	}
+
+	got, err := c.GetCustomer(ctx, asha.ID)
+	check(t, "GetCustomer", err)
+	if got.Email != asha.Email || got.Metadata["segment"] != "retail" {
+		t.Fatalf("GetCustomer: %+v", got)
+	}
+	name := "Asha R."
+	updated, err := c.UpdateCustomer(ctx, asha.ID, UpdateCustomerRequest{Name: &name, Clear: []string{"gender"}})
+	check(t, "UpdateCustomer", err)
+	if updated.Name != name || updated.Gender != "" {
+		t.Fatalf("UpdateCustomer: %+v", updated)
+	}
+	tagged, err := c.AddTags(ctx, asha.ID, "vip", "priority")
+	check(t, "AddTags", err)
+	untagged, err := c.RemoveTag(ctx, asha.ID, "new")
+	check(t, "RemoveTag", err)
+	if len(tagged.Tags) != 3 || len(untagged.Tags) != 2 {
+		t.Fatalf("tags: after add %v, after remove %v", tagged.Tags, untagged.Tags)
+	}
+
+	v, err := c.SubmitPAN(ctx, asha.ID, "ABCDE1234F")
+	check(t, "SubmitPAN", err)
+	if v.PANNumber == "ABCDE1234F" || v.Status != "PENDING" {
+		t.Fatalf("SubmitPAN returned %+v, want a masked PAN", v)
+	}
+	v, err = c.UpdateVerificationStatus(ctx, asha.ID, "VERIFIED")
+	check(t, "UpdateVerificationStatus", err)
+	if v.Status != "VERIFIED" {
+		t.Fatalf("UpdateVerificationStatus: %+v", v)
+	}
+	v, err = c.GetVerification(ctx, asha.ID)
+	check(t, "GetVerification", err)
+	if v.Status != "VERIFIED" {
+		t.Fatalf("GetVerification: %+v", v)
+	}
+	v, err = c.RevealPAN(ctx, asha.ID, "KYC review")
+	check(t, "RevealPAN", err)
+	if v.PANNumber != "ABCDE1234F" {
+		t.Fatalf("RevealPAN: %+v", v)
+	}
+	if _, err := newClient(t, srv, routertest.ClerkKey).RevealPAN(ctx, asha.ID, "curious"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("RevealPAN without pan:reveal: got %v, want ErrForbidden", err)
+	}
+
+	addr, err := c.CreateAddress(ctx, asha.ID, CreateAddressRequest{Type: "residential", Line1: "12 MG Road", City: "Pune", State: "Maharashtra", PINCode: "411001", Primary: true})
+	check(t, "CreateAddress", err)
+	city := "Mumbai"
+	moved, err := c.UpdateAddress(ctx, asha.ID, addr.ID, UpdateAddressRequest{City: &city})
+	check(t, "UpdateAddress", err)
+	if moved.City != city || !moved.Primary {
+		t.Fatalf("UpdateAddress: %+v", moved)
+	}
+	fetched, err := c.GetAddress(ctx, asha.ID, addr.ID)
+	check(t, "GetAddress", err)
+	addresses, err := c.ListAddresses(ctx, asha.ID)
+	check(t, "ListAddresses", err)
+	withAddresses, err := c.GetCustomerWithAddresses(ctx, asha.ID)
+	check(t, "GetCustomerWithAddresses", err)
+	if fetched.City != city || len(addresses) != 1 || len(withAddresses.Addresses) != 1 || withAddresses.Addresses[0].ID != addr.ID {
+		t.Fatalf("addresses: get %+v, list %+v, embedded %+v", fetched, addresses, withAddresses.Addresses)
+	}
+	check(t, "DeleteAddress", c.DeleteAddress(ctx, asha.ID, addr.ID))
+	if _, err := c.GetAddress(ctx, asha.ID, addr.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetAddress after delete: got %v, want ErrNotFound", err)
+	}
+
+	pending, err := c.StartContactVerification(ctx, asha.ID, "email")
+	check(t, "StartContactVerification", err)
+	if pending.Channel != "email" || pending.Destination == asha.Email || pending.ExpiresAt.IsZero() {
+		t.Fatalf("StartContactVerification: %+v", pending)
+	}
+	confirmed, err := c.ConfirmContactVerification(ctx, asha.ID, "email", notifier.Code(t))
+	check(t, "ConfirmContactVerification", err)
+	if confirmed.EmailVerifiedAt == nil {
+		t.Fatalf("ConfirmContactVerification: %+v", confirmed)
+	}
+
+	list, err := c.ListCustomers(ctx, CustomerListOptions{CustomerFilter: CustomerFilter{Tag: "vip", Status: "VERIFIED"}, ListOptions: ListOptions{Limit: 10}})
+	check(t, "ListCustomers", err)
+	if list.Total != 1 || len(list.Data) != 1 || list.Data[0].ID != asha.ID || list.Limit != 10 {
+		t.Fatalf("ListCustomers: %+v", list)
+	}
+
+	check(t, "DeleteCustomer", c.DeleteCustomer(ctx, asha.ID))
+	_, err = c.GetCustomer(ctx, asha.ID)
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &apiErr) || apiErr.RequestID == "" {
+		t.Fatalf("GetCustomer after delete: got %v, want ErrNotFound with a request ID", err)
+	}
+}
+
+func TestClientBatchDuplicatesAndMerge(t *testing.T) {
+	h, _ := newRouter(t)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	c := newClient(t, srv, routertest.OpsKey)
+	ctx := context.Background()
+
+	rows := []CreateCustomerRequest{
+		{Name: "Ravi Kumar", Email: "ravi.kumar@example.com", Phone: "+919812345678"},
+		{Name: "Ravi Kumar", Email: "ravi.kumar@example.org", Phone: "+919812345678"},
+	}
+	atomic, err := c.BatchCreateCustomers(ctx, rows, BatchOptions{})
+	check(t, "atomic BatchCreateCustomers", err)
+	if atomic.Created != 0 || atomic.Failed == 0 {
+		t.Fatalf("atomic batch with a repeated phone: %+v", atomic)
+	}
+	rows[1].Phone = "+919812345679"
+	batch, err := c.BatchCreateCustomers(ctx, append(rows, CreateCustomerRequest{Name: "X", Email: "bad", Phone: "1"}), BatchOptions{Partial: true})
+	check(t, "BatchCreateCustomers", err)
+	if batch.Mode != "partial" || batch.Total != 3 || batch.Created != 2 || batch.Failed != 1 || batch.Results[2].Error == "" {
+		t.Fatalf("BatchCreateCustomers: %+v", batch)
+	}
+
+	dups, err := c.FindDuplicates(ctx, DuplicateOptions{MinScore: 0.3})
+	check(t, "FindDuplicates", err)
+	if len(dups.Data) != 1 {
+		t.Fatalf("FindDuplicates: %+v", dups)
+	}
+	pair := dups.Data[0]
+	duplicate := pair.Customers[0].ID
+	if duplicate == pair.SurvivorID {
+		duplicate = pair.Customers[1].ID
+	}
+	survivor, err := c.MergeCustomers(ctx, pair.SurvivorID, duplicate)
+	check(t, "MergeCustomers", err)
+	if survivor.ID != pair.SurvivorID {
+		t.Fatalf("MergeCustomers returned %s, want %s", survivor.ID, pair.SurvivorID)
+	}
+	if _, err := c.GetCustomer(ctx, duplicate); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("merged duplicate: got %v, want ErrNotFound", err)
+	}
+
+	trail, err := c.ListAuditEntries(ctx, AuditFilter{CustomerID: duplicate})
+	check(t, "ListAuditEntries", err)
+	actions := map[string]bool{}
+	for _, e := range trail.Data {
+		actions[e.Action] = true
+		if e.Tenant != "acme" {
+			t.Fatalf("audit entry of tenant %q", e.Tenant)
+		}
+	}
+	if !actions[customer.ActionCustomerMerge] || !actions[customer.ActionCustomerCreate] || trail.Total != len(trail.Data) {
+		t.Fatalf("audit trail of the duplicate: %+v", trail)
+	}
+	if _, err := newClient(t, srv, routertest.ClerkKey).ListAuditEntries(ctx, AuditFilter{}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("ListAuditEntries without audit:read: got %v, want ErrForbidden", err)
+	}
+}
+
+func TestClientExportAndImport(t *testing.T) {
+	h, _ := newRouter(t)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	c := newClient(t, srv, routertest.OpsKey)
+	ctx := context.Background()
+
+	job, err := c.ImportCustomers(ctx, []CreateCustomerRequest{
+		{Name: "Meera Iyer", Email: "meera@example.com", Phone: "+919811111111"},
+		{Name: "Meera Twin", Email: "meera@example.com", Phone: "+919822222222"},
+		{Name: "", Email: "nobody@example.com", Phone: "+919833333333"},
+	})
+	check(t, "ImportCustomers", err)
+	if job.ID == "" || job.TotalRows != 3 || job.Links.Self != "/v1/jobs/"+job.ID {
+		t.Fatalf("ImportCustomers: %+v", job)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !job.Finished() {
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		job, err = c.GetJob(ctx, job.ID)
+		check(t, "GetJob", err)
+	}
+	if job.Status != "succeeded" || job.CreatedRows != 1 || job.FailedRows != 2 {
+		t.Fatalf("finished job: %+v", job)
+	}
+	rowErrs, err := c.GetJobErrors(ctx, job.ID)
+	check(t, "GetJobErrors", err)
+	if len(rowErrs) != 2 || rowErrs[0].Index != 1 || rowErrs[0].Status != "conflict" || rowErrs[1].Index != 2 || rowErrs[1].Status != "invalid" {
+		t.Fatalf("GetJobErrors: %+v", rowErrs)
+	}
+	if _, err := c.GetJob(ctx, "9b2f4c1e-0000-4000-8000-000000000000"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetJob of an unknown job: got %v, want ErrNotFound", err)
+	}
+
+	export, err := c.ExportCustomers(ctx, ExportOptions{Format: "csv"})
+	check(t, "ExportCustomers", err)
+	records, err := csv.NewReader(export).ReadAll()
+	check(t, "read export", err)
+	_, _ = io.Copy(io.Discard, export)
+	check(t, "close export", export.Close())
+	if !export.Complete() || len(records) != 2 || export.ContentType == "" {
+		t.Fatalf("export: complete %t, content type %q, records %v", export.Complete(), export.ContentType, records)
+	}
+}
+
+func TestClientAdminAndProbes(t *testing.T) {
+	h, _ := newRouter(t)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	c := newClient(t, srv, routertest.OpsKey)
+	ctx := context.Background()
+
+	level, err := c.LogLevel(ctx)
+	check(t, "LogLevel", err)
+	if level != "INFO" {
+		t.Fatalf("LogLevel = %q", level)
+	}
+	level, err = c.SetLogLevel(ctx, "DEBUG")
+	check(t, "SetLogLevel", err)
+	if level != "DEBUG" {
+		t.Fatalf("SetLogLevel = %q", level)
+	}
+	fl, err := c.ListFlags(ctx)
+	check(t, "ListFlags", err)
+	if len(fl) == 0 {
+		t.Fatal("ListFlags returned no flags")
+	}
+	f, err := c.SetFlag(ctx, flags.PhoneIndiaOnly, FlagUpdate{Enabled: true, Rollout: 100, Tenants: []string{"acme"}})
+	check(t, "SetFlag", err)
+	if f.Key != flags.PhoneIndiaOnly || !f.Enabled || f.Rollout != 100 || f.UpdatedBy != "ops" {
+		t.Fatalf("SetFlag: %+v", f)
+	}
+	if _, err := c.SetFlag(ctx, "no.such.flag", FlagUpdate{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("SetFlag of an unknown flag: got %v, want ErrNotFound", err)
+	}
+	if _, err := newClient(t, srv, routertest.ClerkKey).SetLogLevel(ctx, "DEBUG"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("SetLogLevel without admin:write: got %v, want ErrForbidden", err)
+	}
+	if _, err := newClient(t, srv, "wrong-key").ListCustomers(ctx, CustomerListOptions{}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("unknown key: got %v, want ErrUnauthorized", err)
+	}
+
+	check(t, "Live", c.Live(ctx))
+	ready, err := c.Ready(ctx)
+	check(t, "Ready", err)
+	if ready.Status != "ok" {
+		t.Fatalf("Ready: %+v", ready)
+	}
+}
+
+// flaky answers the first failures requests with status, then passes
+// requests to next, recording the request ID of every attempt.
+type flaky struct {
+	next     http.Handler
+	status   int
+	failures int
+
+	mu  sync.Mutex
+	ids []string
+}
+
+func (f *flaky) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.ids = append(f.ids, r.Header.Get(requestIDHeader))
+	fail := len(f.ids) <= f.failures
+	f.mu.Unlock()
+	if fail {
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, `{"error":"try again"}`, f.status)
+		return
+	}
+	f.next.ServeHTTP(w, r)
+}
+
+func TestClientRetries(t *testing.T) {
+	h, _ := newRouter(t)
+	ctx := WithRequestID(context.Background(), "req-contract-1")
+	for _, tc := range []struct {
+		name     string
+		status   int
+		call     func(*Client) error
+		attempts int
+		ok       bool
+	}{
+		{"GET after 503", http.StatusServiceUnavailable, func(c *Client) error {
+			_, err := c.ListCustomers(ctx, CustomerListOptions{})
+			return err
+		}, 3, true},
+		{"POST after 429", http.StatusTooManyRequests, func(c *Client) error {
+			_, err := c.CreateCustomer(ctx, CreateCustomerRequest{Name: "Asha", Email: "asha@example.com", Phone: "+919876543210"})
+			return err
+		}, 3, true},
+		{"POST after 503", http.StatusServiceUnavailable, func(c *Client) error {
+			_, err := c.CreateCustomer(ctx, CreateCustomerRequest{Name: "Ravi", Email: "ravi@example.com", Phone: "+919812345678"})
+			return err
+		}, 1, false},
+	} {
+		f := &flaky{next: h, status: tc.status, failures: 2}
+		srv := httptest.NewServer(f)
+		err := tc.call(newClient(t, srv, routertest.OpsKey))
+		srv.Close()
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+		if len(f.ids) != tc.attempts {
+			t.Errorf("%s: %d attempts, want %d", tc.name, len(f.ids), tc.attempts)
+		}
+		for _, id := range f.ids {
+			if id != "req-contract-1" {
+				t.Errorf("%s: attempt sent request ID %q", tc.name, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package client
+
+import "context"
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	tenantKey    contextKey = "tenant"
+)
+
+// WithRequestID sends id as X-Request-ID on calls made with the returned
+// context, so they can be correlated with the caller's own logs.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext returns the request ID set by WithRequestID.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithTenant makes calls with the returned context act in tenant, overriding
+// Options.Tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+func tenantFromContext(ctx context.Context, fallback string) string {
+	if tenant, _ := ctx.Value(tenantKey).(string); tenant != "" {
+		return tenant
+	}
+	return fallback
+}
@@ -0,0 +1,286 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func customerPath(id string, rest ...string) string {
+	p := "/v1/customers/" + url.PathEscape(id)
+	for _, r := range rest {
+		p += "/" + r
+	}
+	return p
+}
+
+func (o ListOptions) values() url.Values {
+	q := url.Values{}
+	if o.Page > 0 {
+		q.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	return q
+}
+
+func (f CustomerFilter) values(q url.Values) url.Values {
+	if f.Status != "" {
+		q.Set("status", f.Status)
+	}
+	if !f.From.IsZero() {
+		q.Set("from", f.From.Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		q.Set("to", f.To.Format(time.RFC3339))
+	}
+	if f.Tag != "" {
+		q.Set("tag", f.Tag)
+	}
+	for k, v := range f.Metadata {
+		q.Set("metadata."+k, v)
+	}
+	return q
+}
+
+// CreateCustomer creates a customer, which starts with a pending
+// verification and no PAN.
+func (c *Client) CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*Customer, error) {
+	var out Customer
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/v1/customers", body: req}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// BatchCreateCustomers creates up to 5000 customers in one request. Rows that
+// fail are reported in the result rather than as an error, which is returned
+// only when the request as a whole is rejected.
+func (c *Client) BatchCreateCustomers(ctx context.Context, rows []CreateCustomerRequest, opts BatchOptions) (*BatchResult, error) {
+	q := url.Values{}
+	if opts.Partial {
+		q.Set("mode", "partial")
+	}
+	var out BatchResult
+	r := request{
+		method: http.MethodPost,
+		path:   "/v1/customers:batchCreate",
+		query:  q,
+		body:   rows,
+		ok:     []int{http.StatusCreated, http.StatusMultiStatus, http.StatusUnprocessableEntity},
+	}
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCustomer returns a customer with its masked PAN and verification status.
+func (c *Client) GetCustomer(ctx context.Context, id string) (*Customer, error) {
+	var out Customer
+	if err := c.do(ctx, request{method: http.MethodGet, path: customerPath(id)}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCustomerWithAddresses returns a customer like GetCustomer, with its
+// addresses, primary first.
+func (c *Client) GetCustomerWithAddresses(ctx context.Context, id string) (*Customer, error) {
+	q := url.Values{"expand": {"addresses"}}
+	var out Customer
+	if err := c.do(ctx, request{method: http.MethodGet, path: customerPath(id), query: q}, &out); err != nil {
+		return nil, err
+	}
+	if out.Addresses == nil {
+		out.Addresses = []Address{}
+	}
+	return &out, nil
+}
+
+// ListCustomers returns one page of the customers matching opts.
+func (c *Client) ListCustomers(ctx context.Context, opts CustomerListOptions) (*CustomerList, error) {
+	q := opts.CustomerFilter.values(opts.ListOptions.values())
+	var out CustomerList
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/customers", query: q}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateCustomer changes the fields set in req and returns the result.
+func (c *Client) UpdateCustomer(ctx context.Context, id string, req UpdateCustomerRequest) (*Customer, error) {
+	var out Customer
+	if err := c.do(ctx, request{method: http.MethodPatch, path: customerPath(id), body: req}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteCustomer soft-deletes a customer.
+func (c *Client) DeleteCustomer(ctx context.Context, id string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: customerPath(id)}, nil)
+}
+
+// GetVerification returns the customer's verification record.
+func (c *Client) GetVerification(ctx context.Context, customerID string) (*Verification, error) {
+	var out Verification
+	if err := c.do(ctx, request{method: http.MethodGet, path: customerPath(customerID, "status")}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SubmitPAN records the customer's PAN and resets verification to PENDING.
+func (c *Client) SubmitPAN(ctx context.Context, customerID, pan string) (*Verification, error) {
+	body := map[string]string{"pan_number": pan}
+	var out Verification
+	if err := c.do(ctx, request{method: http.MethodPatch, path: customerPath(customerID, "verification"), body: body}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateVerificationStatus moves verification to status, one of the Status
+// constants. A PAN must have been submitted first.
+func (c *Client) UpdateVerificationStatus(ctx context.Context, customerID, status string) (*Verification, error) {
+	body := map[string]string{"status": status}
+	var out Verification
+	if err := c.do(ctx, request{method: http.MethodPatch, path: customerPath(customerID, "verification"), body: body}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevealPAN returns the verification record with the unmasked PAN. It needs
+// an API key with the pan:reveal scope; reason is recorded with the access.
+func (c *Client) RevealPAN(ctx context.Context, customerID, reason string) (*Verification, error) {
+	h := http.Header{}
+	if reason != "" {
+		h.Set(accessReasonHeader, reason)
+	}
+	var out Verification
+	if err := c.do(ctx, request{method: http.MethodGet, path: customerPath(customerID, "verification", "pan"), header: h}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// FindDuplicates lists pairs of customers that are likely the same person.
+func (c *Client) FindDuplicates(ctx context.Context, opts DuplicateOptions) (*DuplicateList, error) {
+	q := url.Values{}
+	if opts.MinScore > 0 {
+		q.Set("min_score", strconv.FormatFloat(opts.MinScore, 'f', -1, 64))
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	var out DuplicateList
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/customers:duplicates", query: q}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MergeCustomers merges duplicateID into survivorID, soft-deleting the
+// duplicate, and returns the survivor. It fails with a conflict when both
+// customers have a PAN.
+func (c *Client) MergeCustomers(ctx context.Context, survivorID, duplicateID string) (*Customer, error) {
+	body := map[string]string{"duplicate_id": duplicateID}
+	var out Customer
+	if err := c.do(ctx, request{method: http.MethodPost, path: customerPath(survivorID, "merge"), body: body}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AddTags adds tags to a customer and returns it. Tags are stored trimmed
+// and lower-cased; those the customer already has are ignored.
+func (c *Client) AddTags(ctx context.Context, id string, tags ...string) (*Customer, error) {
+	body := map[string][]string{"tags": tags}
+	var out Customer
+	if err := c.do(ctx, request{method: http.MethodPost, path: customerPath(id, "tags"), body: body}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveTag removes a tag from a customer and returns it.
+func (c *Client) RemoveTag(ctx context.Context, id, tag string) (*Customer, error) {
+	var out Customer
+	if err := c.do(ctx, request{method: http.MethodDelete, path: customerPath(id, "tags", url.PathEscape(tag))}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartContactVerification sends a one-time code to the customer's email or
+// phone, channel being ContactEmail or ContactPhone. Sending another code
+// voids the previous one.
+func (c *Client) StartContactVerification(ctx context.Context, id, channel string) (*ContactVerification, error) {
+	body := map[string]string{"channel": channel}
+	var out ContactVerification
+	if err := c.do(ctx, request{method: http.MethodPost, path: customerPath(id, "contact-verification"), body: body}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmContactVerification marks the customer's email or phone verified
+// if code is the one last sent to it and returns the customer. Incorrect,
+// expired and exhausted codes fail with status 422.
+func (c *Client) ConfirmContactVerification(ctx context.Context, id, channel, code string) (*Customer, error) {
+	body := map[string]string{"channel": channel, "code": code}
+	var out Customer
+	if err := c.do(ctx, request{method: http.MethodPost, path: customerPath(id, "contact-verification", "confirm"), body: body}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAddresses returns the customer's addresses, primary first.
+func (c *Client) ListAddresses(ctx context.Context, customerID string) ([]Address, error) {
+	var out struct {
+		Data []Address `json:"data"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: customerPath(customerID, "addresses")}, &out); err != nil {
+		return nil, err
+	}
+	return out.Data, nil
+}
+
+// CreateAddress adds an address to a customer. A primary address replaces
+// the customer's current primary address as such.
+func (c *Client) CreateAddress(ctx context.Context, customerID string, req CreateAddressRequest) (*Address, error) {
+	var out Address
+	if err := c.do(ctx, request{method: http.MethodPost, path: customerPath(customerID, "addresses"), body: req}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAddress returns one address of a customer.
+func (c *Client) GetAddress(ctx context.Context, customerID, id string) (*Address, error) {
+	var out Address
+	if err := c.do(ctx, request{method: http.MethodGet, path: customerPath(customerID, "addresses", url.PathEscape(id))}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateAddress changes the fields set in req and returns the result.
+func (c *Client) UpdateAddress(ctx context.Context, customerID, id string, req UpdateAddressRequest) (*Address, error) {
+	var out Address
+	if err := c.do(ctx, request{method: http.MethodPatch, path: customerPath(customerID, "addresses", url.PathEscape(id)), body: req}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteAddress removes an address of a customer.
+func (c *Client) DeleteAddress(ctx context.Context, customerID, id string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: customerPath(customerID, "addresses", url.PathEscape(id))}, nil)
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Sentinel errors matched by *Error through errors.Is, e.g.
+// errors.Is(err, client.ErrNotFound).
+var (
+	ErrBadRequest    = errors.New("bad request")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrUnprocessable = errors.New("unprocessable entity")
+	ErrRateLimited   = errors.New("rate limited")
+)
+
+// Error is a response with a status the call did not expect.
+type Error struct {
+	StatusCode int
+	// Message is the "error" field of the response body, if any.
+	Message string
+	// RequestID is the X-Request-ID the call was sent with, for finding it in
+	// the service logs.
+	RequestID string
+	// RetryAfter is the delay the service asked for on 429 responses.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("customer service: %d: %s (request %s)", e.StatusCode, msg, e.RequestID)
+}
+
+// Is reports whether target is the sentinel for e's status code.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnprocessable:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	default:
+		return false
+	}
+}
+
+// newError reads the response, which it closes, into an *Error.
+func newError(resp *http.Response, requestID string) *Error {
+	defer resp.Body.Close()
+	e := &Error{StatusCode: resp.StatusCode, RequestID: requestID, RetryAfter: retryAfter(resp.Header)}
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err == nil {
+		e.Message = body.Error
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return e
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+const exportStatusTrailer = "X-Export-Status"
+
+// Export is a customer export being streamed from the service. Read it to
+// the end, check Complete, and Close it.
+type Export struct {
+	// ContentType is the media type of the export's format.
+	ContentType string
+	resp        *http.Response
+}
+
+// Read reads the export as the service sends it.
+func (e *Export) Read(p []byte) (int, error) {
+	return e.resp.Body.Read(p)
+}
+
+// Close releases the connection, cutting the export short if it has not
+// been read to the end.
+func (e *Export) Close() error {
+	return e.resp.Body.Close()
+}
+
+// Complete reports whether the service finished the export. It is only
+// meaningful once Read has returned io.EOF: an export that failed part-way
+// through ends early rather than with an error status.
+func (e *Export) Complete() bool {
+	return e.resp.Trailer.Get(exportStatusTrailer) == "complete"
+}
+
+// ExportCustomers starts streaming every customer matching opts. Email and
+// phone are masked unless the API key has the pii:export scope, and the PAN
+// unless it also has pan:reveal. The Options.HTTPClient timeout covers
+// reading the export, so large exports need a client without one.
+func (c *Client) ExportCustomers(ctx context.Context, opts ExportOptions) (*Export, error) {
+	q := opts.CustomerFilter.values(url.Values{})
+	if opts.Format != "" {
+		q.Set("format", opts.Format)
+	}
+	resp, err := c.open(ctx, request{
+		method: http.MethodGet,
+		path:   "/v1/customers:export",
+		query:  q,
+		header: http.Header{"Accept": {"*/*"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Export{ContentType: resp.Header.Get("Content-Type"), resp: resp}, nil
+}
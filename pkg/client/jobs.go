@@ -0,0 +1,68 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func jobPath(id string, rest ...string) string {
+	p := "/v1/jobs/" + url.PathEscape(id)
+	for _, r := range rest {
+		p += "/" + r
+	}
+	return p
+}
+
+// ImportCustomers queues rows for creation by a background job and returns
+// the job to poll with GetJob. Unlike BatchCreateCustomers it is not limited
+// to 5000 rows and does not report rows individually until the job runs.
+func (c *Client) ImportCustomers(ctx context.Context, rows []CreateCustomerRequest) (*Job, error) {
+	var out Job
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/v1/customers:import", body: rows}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJob returns a job and its progress.
+func (c *Client) GetJob(ctx context.Context, id string) (*Job, error) {
+	var out Job
+	if err := c.do(ctx, request{method: http.MethodGet, path: jobPath(id)}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJobErrors returns the rows a job could not process so far, in input
+// order.
+func (c *Client) GetJobErrors(ctx context.Context, id string) ([]JobRowError, error) {
+	var raw []byte
+	if err := c.do(ctx, request{method: http.MethodGet, path: jobPath(id, "errors")}, &raw); err != nil {
+		return nil, err
+	}
+	records, err := csv.NewReader(bytes.NewReader(raw)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("client: decode job errors: %w", err)
+	}
+	out := []JobRowError{}
+	for i, rec := range records {
+		if i == 0 {
+			// The first record is the header.
+			continue
+		}
+		if len(rec) != 3 {
+			return nil, fmt.Errorf("client: decode job errors: record %d has %d fields", i, len(rec))
+		}
+		index, err := strconv.Atoi(rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("client: decode job errors: record %d: %w", i, err)
+		}
+		out = append(out, JobRowError{Index: index, Status: rec[1], Error: rec[2]})
+	}
+	return out, nil
+}
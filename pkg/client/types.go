@@ -0,0 +1,425 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Verification statuses.
+const (
+	StatusPending  = "PENDING"
+	StatusVerified = "VERIFIED"
+	StatusRejected = "REJECTED"
+)
+
+// Customer is a customer profile. PANNumber is masked, and empty when no PAN
+// has been submitted.
+type Customer struct {
+	ID        string    `json:"customer_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	PANNumber string    `json:"pan_number,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Profile fields are empty when not provided. DateOfBirth is YYYY-MM-DD.
+	DateOfBirth       string `json:"date_of_birth,omitempty"`
+	Gender            string `json:"gender,omitempty"`
+	Nationality       string `json:"nationality,omitempty"`
+	Occupation        string `json:"occupation,omitempty"`
+	PreferredLanguage string `json:"preferred_language,omitempty"`
+	// Metadata numbers decode as float64.
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Tags     []string       `json:"tags,omitempty"`
+	// EmailVerifiedAt and PhoneVerifiedAt are nil until the customer
+	// confirms a code sent with StartContactVerification.
+	EmailVerifiedAt *time.Time    `json:"email_verified_at,omitempty"`
+	PhoneVerifiedAt *time.Time    `json:"phone_verified_at,omitempty"`
+	Links           CustomerLinks `json:"links"`
+	// Addresses is set only by GetCustomerWithAddresses.
+	Addresses []Address `json:"addresses,omitempty"`
+	// Deprecated: use Links.Status.
+	StatusURL string `json:"status_url,omitempty"`
+	// Deprecated: use Links.Verification.
+	VerificationURL string `json:"verification_url,omitempty"`
+}
+
+// Contact channels of StartContactVerification and ConfirmContactVerification.
+const (
+	ContactEmail = "email"
+	ContactPhone = "phone"
+)
+
+// ContactVerification describes a verification code that has been sent.
+type ContactVerification struct {
+	Channel string `json:"channel"`
+	// Destination is the masked email or phone the code was sent to.
+	Destination string    `json:"destination"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// CustomerLinks are the paths of a customer and its verification resources,
+// relative to the service's base URL.
+type CustomerLinks struct {
+	Self         string `json:"self"`
+	Status       string `json:"status"`
+	Verification string `json:"verification"`
+	Addresses    string `json:"addresses"`
+}
+
+// Address types.
+const (
+	AddressResidential    = "residential"
+	AddressCorrespondence = "correspondence"
+	AddressPermanent      = "permanent"
+)
+
+// Address is a customer's postal address in India.
+type Address struct {
+	ID         string       `json:"id"`
+	CustomerID string       `json:"customer_id"`
+	Type       string       `json:"type"`
+	Line1      string       `json:"line1"`
+	Line2      string       `json:"line2"`
+	City       string       `json:"city"`
+	State      string       `json:"state"`
+	PINCode    string       `json:"pin_code"`
+	Primary    bool         `json:"primary"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	Links      AddressLinks `json:"links"`
+}
+
+// AddressLinks are the paths of an address and its customer.
+type AddressLinks struct {
+	Self     string `json:"self"`
+	Customer string `json:"customer"`
+}
+
+// CreateAddressRequest is the body of CreateAddress. Type is one of the
+// Address constants and PINCode six digits.
+type CreateAddressRequest struct {
+	Type    string `json:"type"`
+	Line1   string `json:"line1"`
+	Line2   string `json:"line2,omitempty"`
+	City    string `json:"city"`
+	State   string `json:"state"`
+	PINCode string `json:"pin_code"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// UpdateAddressRequest changes only the fields that are non-nil.
+type UpdateAddressRequest struct {
+	Type    *string `json:"type,omitempty"`
+	Line1   *string `json:"line1,omitempty"`
+	Line2   *string `json:"line2,omitempty"`
+	City    *string `json:"city,omitempty"`
+	State   *string `json:"state,omitempty"`
+	PINCode *string `json:"pin_code,omitempty"`
+	Primary *bool   `json:"primary,omitempty"`
+}
+
+// CreateCustomerRequest is the body of CreateCustomer.
+type CreateCustomerRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+	// Optional profile fields. DateOfBirth is YYYY-MM-DD and the customer
+	// must be at least 18.
+	DateOfBirth       string `json:"date_of_birth,omitempty"`
+	Gender            string `json:"gender,omitempty"`
+	Nationality       string `json:"nationality,omitempty"`
+	Occupation        string `json:"occupation,omitempty"`
+	PreferredLanguage string `json:"preferred_language,omitempty"`
+	// Metadata is limited to 50 top-level keys and 16 KiB of JSON, and a
+	// customer to 50 tags.
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Tags     []string       `json:"tags,omitempty"`
+}
+
+// Genders accepted in CreateCustomerRequest and UpdateCustomerRequest.
+const (
+	GenderFemale      = "female"
+	GenderMale        = "male"
+	GenderOther       = "other"
+	GenderUndisclosed = "undisclosed"
+)
+
+// UpdateCustomerRequest changes only the fields that are non-nil. Metadata
+// is a JSON merge patch: nil values remove their keys. Clear names profile
+// fields to remove, by their JSON names, e.g. "occupation"; "metadata"
+// removes every metadata key.
+type UpdateCustomerRequest struct {
+	Name              *string        `json:"name,omitempty"`
+	Email             *string        `json:"email,omitempty"`
+	Phone             *string        `json:"phone,omitempty"`
+	DateOfBirth       *string        `json:"date_of_birth,omitempty"`
+	Gender            *string        `json:"gender,omitempty"`
+	Nationality       *string        `json:"nationality,omitempty"`
+	Occupation        *string        `json:"occupation,omitempty"`
+	PreferredLanguage *string        `json:"preferred_language,omitempty"`
+	Metadata          map[string]any `json:"metadata,omitempty"`
+	Clear             []string       `json:"-"`
+}
+
+// MarshalJSON sends the fields in Clear as null.
+func (r UpdateCustomerRequest) MarshalJSON() ([]byte, error) {
+	type fields UpdateCustomerRequest
+	b, err := json.Marshal(fields(r))
+	if err != nil || len(r.Clear) == 0 {
+		return b, err
+	}
+	m := map[string]any{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	for _, name := range r.Clear {
+		m[name] = nil
+	}
+	return json.Marshal(m)
+}
+
+// ListOptions pages through a listing; zero values use the server defaults.
+type ListOptions struct {
+	Page  int
+	Limit int
+}
+
+// CustomerFilter narrows ListCustomers and ExportCustomers; zero fields do
+// not filter.
+type CustomerFilter struct {
+	// Status is a verification status: PENDING, REJECTED or VERIFIED.
+	Status string
+	// From is inclusive and To exclusive, both on the creation time.
+	From, To time.Time
+	// Tag matches customers with the tag.
+	Tag string
+	// Metadata matches customers whose metadata has each key set to the
+	// given string.
+	Metadata map[string]string
+}
+
+// CustomerListOptions pages through the customers matching a filter.
+type CustomerListOptions struct {
+	CustomerFilter
+	ListOptions
+}
+
+// ExportOptions selects the format and rows of ExportCustomers.
+type ExportOptions struct {
+	// Format is csv (the default), ndjson or parquet.
+	Format string
+	CustomerFilter
+}
+
+// CustomerList is one page of customers.
+type CustomerList struct {
+	Page  int        `json:"page"`
+	Limit int        `json:"limit"`
+	Total int        `json:"total"`
+	Data  []Customer `json:"data"`
+	Links PageLinks  `json:"links"`
+}
+
+// PageLinks are the paths of neighbouring pages; Prev and Next are empty on
+// the first and last page.
+type PageLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last"`
+}
+
+// DuplicateOptions narrows FindDuplicates; zero values use the server
+// defaults of a 0.5 minimum score and 50 pairs.
+type DuplicateOptions struct {
+	MinScore float64
+	Limit    int
+}
+
+// Duplicate is a pair of customers that may be the same person.
+type Duplicate struct {
+	Score     float64          `json:"score"`
+	Signals   DuplicateSignals `json:"signals"`
+	Customers [2]Customer      `json:"customers"`
+	// SurvivorID is the customer suggested to keep.
+	SurvivorID string         `json:"survivor_id"`
+	Links      DuplicateLinks `json:"links"`
+}
+
+// DuplicateSignals are the similarities behind a duplicate's score.
+type DuplicateSignals struct {
+	NameSimilarity  float64 `json:"name_similarity"`
+	EmailSimilarity float64 `json:"email_similarity"`
+	SamePhone       bool    `json:"same_phone"`
+	SamePAN         bool    `json:"same_pan"`
+}
+
+// DuplicateLinks are the paths of the actions on a duplicate.
+type DuplicateLinks struct {
+	Merge string `json:"merge"`
+}
+
+// DuplicateList lists suspected duplicates, highest score first.
+type DuplicateList struct {
+	MinScore float64     `json:"min_score"`
+	Limit    int         `json:"limit"`
+	Data     []Duplicate `json:"data"`
+}
+
+// BatchOptions controls BatchCreateCustomers. By default a batch is atomic:
+// nothing is created unless every row can be. Partial creates every row it
+// can.
+type BatchOptions struct {
+	Partial bool
+}
+
+// BatchResult reports a batch create row by row, in input order.
+type BatchResult struct {
+	Mode    string           `json:"mode"`
+	Total   int              `json:"total"`
+	Created int              `json:"created"`
+	Failed  int              `json:"failed"`
+	Results []BatchRowResult `json:"results"`
+}
+
+// BatchRowResult is the outcome of one row: "created", "invalid",
+// "conflict", or "aborted" when the row was valid but another row of an
+// atomic batch failed. Customer is set only for created rows.
+type BatchRowResult struct {
+	Index    int       `json:"index"`
+	Status   string    `json:"status"`
+	Customer *Customer `json:"customer,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Job is a background job, such as a customer import, and its progress.
+type Job struct {
+	ID            string     `json:"id"`
+	Kind          string     `json:"kind"`
+	Status        string     `json:"status"`
+	Format        string     `json:"format"`
+	TotalRows     int        `json:"total_rows"`
+	ProcessedRows int        `json:"processed_rows"`
+	CreatedRows   int        `json:"created_rows"`
+	FailedRows    int        `json:"failed_rows"`
+	Error         string     `json:"error,omitempty"`
+	Attempts      int        `json:"attempts"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	Links         JobLinks   `json:"links"`
+}
+
+// Finished reports whether the job has stopped for good.
+func (j *Job) Finished() bool {
+	return j.Status == "succeeded" || j.Status == "failed"
+}
+
+// JobLinks are the paths of a job and its error report.
+type JobLinks struct {
+	Self   string `json:"self"`
+	Errors string `json:"errors"`
+}
+
+// JobRowError is a row a job could not process, identified by its
+// zero-based position in the input. Status is "invalid" or "conflict".
+type JobRowError struct {
+	Index  int
+	Status string
+	Error  string
+}
+
+// Verification is a customer's PAN verification record. PANNumber is masked
+// except in RevealPAN, and empty before a PAN is submitted.
+type Verification struct {
+	ID         string            `json:"id"`
+	CustomerID string            `json:"customer_id"`
+	PANNumber  string            `json:"pan_number"`
+	Status     string            `json:"status"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	Links      VerificationLinks `json:"links"`
+}
+
+// VerificationLinks are the paths related to a verification record.
+type VerificationLinks struct {
+	Self     string `json:"self"`
+	Customer string `json:"customer"`
+	Update   string `json:"update"`
+}
+
+// AuditFilter narrows ListAuditEntries; zero fields do not filter.
+type AuditFilter struct {
+	CustomerID string
+	Actor      string
+	// From is inclusive and To exclusive.
+	From, To time.Time
+	ListOptions
+}
+
+// AuditChange is the before and after value of one changed field.
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditEntry records one access to customer data.
+type AuditEntry struct {
+	ID           string                 `json:"id"`
+	OccurredAt   time.Time              `json:"occurred_at"`
+	Tenant       string                 `json:"tenant_id"`
+	Actor        string                 `json:"actor"`
+	Action       string                 `json:"action"`
+	ResourceType string                 `json:"resource_type"`
+	ResourceID   string                 `json:"resource_id,omitempty"`
+	RequestID    string                 `json:"request_id,omitempty"`
+	ClientIP     string                 `json:"client_ip,omitempty"`
+	Outcome      string                 `json:"outcome"`
+	Changes      map[string]AuditChange `json:"changes,omitempty"`
+	Metadata     map[string]any         `json:"metadata,omitempty"`
+}
+
+// AuditList is one page of audit entries, newest first.
+type AuditList struct {
+	Page  int          `json:"page"`
+	Limit int          `json:"limit"`
+	Total int          `json:"total"`
+	Data  []AuditEntry `json:"data"`
+}
+
+// Flag is the rollout state of a feature flag.
+type Flag struct {
+	Key         string    `json:"key"`
+	Description string    `json:"description"`
+	Enabled     bool      `json:"enabled"`
+	Rollout     int       `json:"rollout"`
+	Tenants     []string  `json:"tenants"`
+	Principals  []string  `json:"principals"`
+	UpdatedAt   time.Time `json:"updated_at,omitzero"`
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+}
+
+// FlagUpdate replaces a flag's rollout state.
+type FlagUpdate struct {
+	Enabled    bool     `json:"enabled"`
+	Rollout    int      `json:"rollout"`
+	Tenants    []string `json:"tenants"`
+	Principals []string `json:"principals"`
+}
+
+// Readiness is the dependency report served by /readyz.
+type Readiness struct {
+	// Status is "ok", "fail" or "shutting_down".
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of one readiness check.
+type CheckResult struct {
+	Status     string  `json:"status"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
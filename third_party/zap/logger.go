@@ -1,23 +1,21 @@
 package zap
 
 import (
-	"encoding/json"
-	"fmt"
 	"io"
 	"os"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap/zapcore"
 )
 
-type Field struct {
-	Key   string
-	Value any
-}
+// Field is a key-value pair attached to a log entry.
+type Field = zapcore.Field
 
+// AtomicLevel is a level that can be changed at runtime. Copies share the
+// same underlying value, as do loggers built from a Config and their children.
 type AtomicLevel struct {
-	level zapcore.Level
+	l *atomic.Int32
 }
 
 type Config struct {
@@ -31,23 +29,50 @@ type Config struct {
 	DisableStacktrace bool
 }
 
+// Logger hands the entries it is asked to log to its Core.
 type Logger struct {
-	mu      sync.Mutex
-	level   zapcore.Level
-	fields  []Field
-	encCfg  zapcore.EncoderConfig
-	writers []io.Writer
+	core zapcore.Core
+}
+
+// An Option configures a Logger.
+type Option interface {
+	apply(*Logger)
+}
+
+type optionFunc func(*Logger)
+
+func (f optionFunc) apply(l *Logger) { f(l) }
+
+// WrapCore wraps or replaces the Logger's Core.
+func WrapCore(f func(zapcore.Core) zapcore.Core) Option {
+	return optionFunc(func(l *Logger) {
+		l.core = f(l.core)
+	})
+}
+
+func NewAtomicLevel() AtomicLevel {
+	return NewAtomicLevelAt(zapcore.InfoLevel)
 }
 
 func NewAtomicLevelAt(level zapcore.Level) AtomicLevel {
-	return AtomicLevel{level: level}
+	a := AtomicLevel{l: new(atomic.Int32)}
+	a.l.Store(int32(level))
+	return a
+}
+
+func (a AtomicLevel) Level() zapcore.Level {
+	return zapcore.Level(a.l.Load())
+}
+
+func (a AtomicLevel) SetLevel(level zapcore.Level) {
+	a.l.Store(int32(level))
 }
 
 func (a AtomicLevel) Enabled(level zapcore.Level) bool {
-	return level >= a.level
+	return level >= a.Level()
 }
 
-func (c Config) Build() (*Logger, error) {
+func (c Config) Build(opts ...Option) (*Logger, error) {
 	writers := make([]io.Writer, 0, len(c.OutputPaths))
 	for _, path := range c.OutputPaths {
 		switch path {
@@ -66,22 +91,47 @@ func (c Config) Build() (*Logger, error) {
 	if len(writers) == 0 {
 		writers = append(writers, os.Stdout)
 	}
-	return &Logger{
-		level:   c.Level.level,
-		encCfg:  c.EncoderConfig,
-		writers: writers,
-	}, nil
+	level := c.Level
+	if level.l == nil {
+		level = NewAtomicLevel()
+	}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(c.EncoderConfig), io.MultiWriter(writers...), level)
+	return New(core, opts...), nil
+}
+
+// New returns a Logger that writes through core.
+func New(core zapcore.Core, opts ...Option) *Logger {
+	if core == nil {
+		core = zapcore.NewNopCore()
+	}
+	l := &Logger{core: core}
+	return l.WithOptions(opts...)
+}
+
+// NewNop returns a logger that writes nothing.
+func NewNop() *Logger {
+	return New(zapcore.NewNopCore())
+}
+
+// WithOptions returns a copy of the Logger with opts applied.
+func (l *Logger) WithOptions(opts ...Option) *Logger {
+	clone := *l
+	for _, opt := range opts {
+		opt.apply(&clone)
+	}
+	return &clone
+}
+
+// Core returns the Logger's Core.
+func (l *Logger) Core() zapcore.Core {
+	return l.core
 }
 
 func (l *Logger) With(fields ...Field) *Logger {
-	clone := &Logger{
-		level:   l.level,
-		encCfg:  l.encCfg,
-		writers: l.writers,
+	if len(fields) == 0 {
+		return l
 	}
-	clone.fields = append(clone.fields, l.fields...)
-	clone.fields = append(clone.fields, fields...)
-	return clone
+	return &Logger{core: l.core.With(fields)}
 }
 
 func (l *Logger) Debug(msg string, fields ...Field) {
@@ -101,57 +151,14 @@ func (l *Logger) Error(msg string, fields ...Field) {
 }
 
 func (l *Logger) Sync() error {
-	return nil
+	return l.core.Sync()
 }
 
 func (l *Logger) log(level zapcore.Level, msg string, fields []Field) {
-	if level < l.level {
+	if !l.core.Enabled(level) {
 		return
 	}
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	entry := make(map[string]any)
-	cfg := l.encCfg
-	if cfg.TimeKey != "" {
-		if cfg.EncodeTime != nil {
-			entry[cfg.TimeKey] = cfg.EncodeTime(time.Now())
-		} else {
-			entry[cfg.TimeKey] = zapcore.ISO8601TimeEncoder(time.Now())
-		}
-	}
-	if cfg.LevelKey != "" {
-		if cfg.EncodeLevel != nil {
-			entry[cfg.LevelKey] = cfg.EncodeLevel(level)
-		} else {
-			entry[cfg.LevelKey] = level.String()
-		}
-	}
-	if cfg.MessageKey != "" {
-		entry[cfg.MessageKey] = msg
-	}
-	for _, f := range l.fields {
-		entry[f.Key] = renderValue(f.Value)
-	}
-	for _, f := range fields {
-		entry[f.Key] = renderValue(f.Value)
-	}
-	data, err := json.Marshal(entry)
-	if err != nil {
-		data = []byte(fmt.Sprintf(`{"level":"error","message":"logging failure","error":"%v"}`, err))
-	}
-	for _, w := range l.writers {
-		_, _ = w.Write(data)
-		_, _ = w.Write([]byte(cfg.LineEnding))
-	}
-}
-
-func renderValue(v any) any {
-	switch val := v.(type) {
-	case error:
-		return val.Error()
-	default:
-		return val
-	}
+	_ = l.core.Write(zapcore.Entry{Level: level, Time: time.Now(), Message: msg}, fields)
 }
 
 func String(key, value string) Field {
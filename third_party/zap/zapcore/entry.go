@@ -0,0 +1,47 @@
+package zapcore
+
+import "time"
+
+// Field is a key-value pair attached to a log entry. The zap package aliases
+// it, so fields built there can be handed to a Core as they are.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Entry is the part of a log line that is not a field.
+type Entry struct {
+	Level   Level
+	Time    time.Time
+	Message string
+}
+
+// LevelEnabler decides whether a given level is logged.
+type LevelEnabler interface {
+	Enabled(Level) bool
+}
+
+// Core is the part of a logger that filters, encodes and writes entries.
+// Loggers hand every entry to their Core, so wrapping a Core sees everything a
+// logger writes, including the fields added with With. Unlike upstream zap
+// there is no Check step: Write is only called for enabled levels.
+type Core interface {
+	LevelEnabler
+
+	// With returns a Core that adds fields to every entry it writes.
+	With([]Field) Core
+	// Write encodes and writes an entry with its fields.
+	Write(Entry, []Field) error
+	// Sync flushes buffered entries, if any.
+	Sync() error
+}
+
+type nopCore struct{}
+
+// NewNopCore returns a Core that is never enabled and writes nothing.
+func NewNopCore() Core { return nopCore{} }
+
+func (nopCore) Enabled(Level) bool         { return false }
+func (n nopCore) With([]Field) Core        { return n }
+func (nopCore) Write(Entry, []Field) error { return nil }
+func (nopCore) Sync() error                { return nil }
@@ -0,0 +1,103 @@
+package zapcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Encoder renders an entry and its fields as one log line.
+type Encoder interface {
+	EncodeEntry(Entry, []Field) ([]byte, error)
+}
+
+type jsonEncoder struct {
+	cfg EncoderConfig
+}
+
+// NewJSONEncoder returns an Encoder that writes each entry as a JSON object
+// using the keys and encoders in cfg. Later fields replace earlier fields with
+// the same key.
+func NewJSONEncoder(cfg EncoderConfig) Encoder {
+	return jsonEncoder{cfg: cfg}
+}
+
+func (e jsonEncoder) EncodeEntry(ent Entry, fields []Field) ([]byte, error) {
+	entry := make(map[string]any, len(fields)+3)
+	cfg := e.cfg
+	if cfg.TimeKey != "" {
+		if cfg.EncodeTime != nil {
+			entry[cfg.TimeKey] = cfg.EncodeTime(ent.Time)
+		} else {
+			entry[cfg.TimeKey] = ISO8601TimeEncoder(ent.Time)
+		}
+	}
+	if cfg.LevelKey != "" {
+		if cfg.EncodeLevel != nil {
+			entry[cfg.LevelKey] = cfg.EncodeLevel(ent.Level)
+		} else {
+			entry[cfg.LevelKey] = ent.Level.String()
+		}
+	}
+	if cfg.MessageKey != "" {
+		entry[cfg.MessageKey] = ent.Message
+	}
+	for _, f := range fields {
+		entry[f.Key] = renderValue(f.Value)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, cfg.LineEnding...), nil
+}
+
+func renderValue(v any) any {
+	switch val := v.(type) {
+	case error:
+		return val.Error()
+	default:
+		return val
+	}
+}
+
+type ioCore struct {
+	LevelEnabler
+	enc    Encoder
+	mu     *sync.Mutex
+	out    io.Writer
+	fields []Field
+}
+
+// NewCore returns a Core that writes entries enabled by enab to out, encoded
+// with enc. Writes from the Core and every Core derived from it with With are
+// serialised.
+func NewCore(enc Encoder, out io.Writer, enab LevelEnabler) Core {
+	return &ioCore{LevelEnabler: enab, enc: enc, mu: new(sync.Mutex), out: out}
+}
+
+func (c *ioCore) With(fields []Field) Core {
+	clone := *c
+	clone.fields = append(append([]Field(nil), c.fields...), fields...)
+	return &clone
+}
+
+func (c *ioCore) Write(ent Entry, fields []Field) error {
+	all := fields
+	if len(c.fields) > 0 {
+		all = append(append(make([]Field, 0, len(c.fields)+len(fields)), c.fields...), fields...)
+	}
+	data, err := c.enc.EncodeEntry(ent, all)
+	if err != nil {
+		data = []byte(fmt.Sprintf(`{"level":"error","message":"logging failure","error":%q}`, err.Error()) + "\n")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.out.Write(data)
+	return err
+}
+
+func (c *ioCore) Sync() error {
+	return nil
+}